@@ -4,8 +4,11 @@ import (
 	"context"
 	"crypto/rand"
 	"html/template"
+	"log"
+	"net"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
@@ -21,21 +24,31 @@ import (
 
 // App represents the application
 type App struct {
-	config         *config.Config
-	repo           repository.Repository
-	userRepo       repository.UserRepository
-	bioPageRepo    repository.BioPageRepository
-	server         *http.Server
-	apiHandler     *handlers.API
-	webHandler     *handlers.Web
-	authHandler    *handlers.Auth
-	dashHandler    *handlers.Dashboard
-	qrCodeHandler  *handlers.QRCode
-	bioPageHandler *handlers.BioPage
-	dbManager      *database.Manager
-	authMiddleware *middleware.AuthMiddleware
-	sessionStore   *sessions.CookieStore
-	qrCodeService  *services.QRCodeService
+	config          *config.Config
+	repo            repository.Repository
+	userRepo        repository.UserRepository
+	bioPageRepo     repository.BioPageRepository
+	campaignRepo    repository.CampaignRepository
+	domainRepo      repository.DomainRepository
+	server          *http.Server
+	apiHandler      *handlers.API
+	webHandler      *handlers.Web
+	authHandler     *handlers.Auth
+	dashHandler     *handlers.Dashboard
+	qrCodeHandler   *handlers.QRCode
+	bioPageHandler  *handlers.BioPage
+	campaignHandler *handlers.Campaign
+	domainHandler   *handlers.Domain
+	adminHandler    *handlers.Admin
+	webhookHandler  *handlers.Webhook
+	streamHandler   *handlers.Stream
+	dbManager       *database.Manager
+	authMiddleware  *middleware.AuthMiddleware
+	sessionStore    *sessions.CookieStore
+	qrCodeService   *services.QRCodeService
+	scheduler       *services.Scheduler
+	metadataService *services.MetadataService
+	webhookService  *services.WebhookService
 }
 
 // New creates a new application
@@ -43,6 +56,16 @@ func New(cfg *config.Config) (*App, error) {
 	var repo repository.Repository
 	var userRepo repository.UserRepository
 	var bioPageRepo repository.BioPageRepository
+	var campaignRepo repository.CampaignRepository
+	var domainRepo repository.DomainRepository
+	var sequenceRepo repository.SequenceRepository
+	var reservedSlugRepo repository.ReservedSlugRepository
+	var slugRegistryRepo repository.SlugRegistryRepository
+	var aliasRepo repository.AliasRepository
+	var healthCheckRepo repository.HealthCheckRepository
+	var webhookRepo repository.WebhookRepository
+	var clickRepo repository.ClickRepository
+	var rollupRepo repository.RollupRepository
 	var dbManager *database.Manager
 	var err error
 
@@ -82,11 +105,82 @@ func New(cfg *config.Config) (*App, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		// Create PostgreSQL campaign repository
+		campaignRepo, err = repository.NewPostgresCampaignRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL domain repository
+		domainRepo, err = repository.NewPostgresDomainRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL sequence repository
+		sequenceRepo, err = repository.NewPostgresSequenceRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL reserved slug repository
+		reservedSlugRepo, err = repository.NewPostgresReservedSlugRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL slug registry repository
+		slugRegistryRepo, err = repository.NewPostgresSlugRegistryRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL alias repository
+		aliasRepo, err = repository.NewPostgresAliasRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL health check repository
+		healthCheckRepo, err = repository.NewPostgresHealthCheckRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL webhook repository
+		webhookRepo, err = repository.NewPostgresWebhookRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL click repository
+		clickRepo, err = repository.NewPostgresClickRepository(db)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create PostgreSQL rollup repository
+		rollupRepo, err = repository.NewPostgresRollupRepository(db)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		// Fall back to memory repository
-		repo = repository.NewMemoryRepository()
+		urlRepo := repository.NewMemoryRepository()
+		repo = urlRepo
 		userRepo = repository.NewMemoryUserRepository()
 		bioPageRepo = repository.NewMemoryBioPageRepository()
+		campaignRepo = repository.NewMemoryCampaignRepository()
+		domainRepo = repository.NewMemoryDomainRepository(urlRepo)
+		sequenceRepo = repository.NewMemorySequenceRepository()
+		reservedSlugRepo = repository.NewMemoryReservedSlugRepository()
+		slugRegistryRepo = repository.NewMemorySlugRegistryRepository()
+		aliasRepo = repository.NewMemoryAliasRepository()
+		healthCheckRepo = repository.NewMemoryHealthCheckRepository()
+		webhookRepo = repository.NewMemoryWebhookRepository()
+		clickRepo = repository.NewMemoryClickRepository()
+		rollupRepo = repository.NewMemoryRollupRepository()
 	}
 
 	// Create session store
@@ -110,6 +204,61 @@ func New(cfg *config.Config) (*App, error) {
 		cfg.Shortener.KeyLength,
 	)
 
+	shortenerService.SetAliasRepository(aliasRepo)
+
+	// Configure which custom slugs are accepted and how short codes are compared
+	slugPolicy := services.SlugPolicy{
+		CaseInsensitive: cfg.Shortener.CaseInsensitiveSlugs,
+		Unicode:         cfg.Shortener.UnicodeSlugs,
+	}
+	shortenerService.SetSlugPolicy(slugPolicy)
+
+	// Configure how destination URLs are canonicalized
+	shortenerService.SetURLNormalization(services.URLNormalization{
+		SortQuery:     cfg.Shortener.SortQueryParams,
+		StripTracking: cfg.Shortener.StripTrackingParams,
+	})
+
+	// Configure how IDs are generated, with counter IDs reserved in blocks
+	idAllocator, err := services.NewIDAllocator(sequenceRepo, services.URLSequence, cfg.Shortener.SlugBlockSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := shortenerService.SetSlugSettings(services.SlugSettings{
+		Strategy:    cfg.Shortener.SlugStrategy,
+		Length:      cfg.Shortener.KeyLength,
+		Alphabet:    slugPolicy.FoldAlphabet(cfg.Shortener.SlugAlphabet),
+		Words:       cfg.Shortener.SlugWords,
+		CounterSalt: cfg.Shortener.SlugCounterSalt,
+		Allocator:   idAllocator,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Create reserved slug service, loading the blocklist if one is configured.
+	// The route slugs are added once the routes are registered.
+	reservedSlugService := services.NewReservedSlugService(reservedSlugRepo)
+	if cfg.Shortener.SlugBlocklistPath != "" {
+		if err := reservedSlugService.LoadBlocklist(cfg.Shortener.SlugBlocklistPath); err != nil {
+			return nil, err
+		}
+	}
+	shortenerService.SetReservedSlugService(reservedSlugService)
+
+	// Create the slug registry, which keeps link and bio page short codes
+	// apart when the namespace is unified
+	slugRegistry := services.NewSlugRegistry(slugRegistryRepo, cfg.Shortener.UnifiedNamespace)
+	slugRegistry.SetSlugPolicy(slugPolicy)
+	shortenerService.SetSlugRegistry(slugRegistry)
+
+	// Create campaign service, used to tag new links with UTM parameters
+	campaignService := services.NewCampaignService(campaignRepo, repo)
+	shortenerService.SetCampaignService(campaignService)
+
+	// Create domain service, verifying domains with DNS TXT lookups
+	domainService := services.NewDomainService(domainRepo, net.DefaultResolver, cfg.Shortener.BaseURL)
+	shortenerService.SetDomainService(domainService)
+
 	authService := services.NewAuthService(userRepo, &cfg.Auth)
 
 	// Create QR code service
@@ -117,16 +266,132 @@ func New(cfg *config.Config) (*App, error) {
 
 	// Create Bio Page service
 	bioPageService := services.NewBioPageService(bioPageRepo, cfg.Shortener.BaseURL)
+	bioPageService.SetReservedSlugService(reservedSlugService)
+	bioPageService.SetSlugRegistry(slugRegistry)
+	bioPageService.SetSlugPolicy(slugPolicy)
+
+	// Create targeting service, loading the GeoIP database if one is configured
+	var geoIP services.GeoIPResolver
+	if cfg.Targeting.GeoIPDatabasePath != "" {
+		geoIPDatabase, err := services.LoadGeoIPDatabase(cfg.Targeting.GeoIPDatabasePath)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Loaded GeoIP database with %d ranges", geoIPDatabase.Len())
+		geoIP = geoIPDatabase
+	}
+	targetingService := services.NewTargetingService(geoIP)
+
+	// Check the default redirect status code and caching policy
+	redirectPolicy := services.RedirectPolicy{
+		Status:      cfg.Redirect.Status,
+		CachePolicy: cfg.Redirect.CachePolicy,
+		CacheMaxAge: cfg.Redirect.CacheMaxAge,
+	}
+	if err := redirectPolicy.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Create the background job scheduler
+	scheduler := services.NewScheduler()
+
+	// Create screening service, checking destinations when links are created
+	// and edited, and rescreening existing links as a background job
+	screeningPolicy := services.ScreeningPolicy{
+		Blocklist:      cfg.Screening.BlocklistAction,
+		PrivateNetwork: cfg.Screening.PrivateNetworkAction,
+		IPAddress:      cfg.Screening.IPAddressAction,
+		Shortener:      cfg.Screening.ShortenerAction,
+		Suspicious:     cfg.Screening.SuspiciousAction,
+	}
+	if err := screeningPolicy.Validate(); err != nil {
+		return nil, err
+	}
+	screeningService := services.NewScreeningService(repo, screeningPolicy, cfg.Shortener.BaseURL)
+	if cfg.Screening.BlocklistPath != "" {
+		if err := screeningService.LoadBlocklist(cfg.Screening.BlocklistPath); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Screening.LookupHosts {
+		screeningService.SetResolver(net.DefaultResolver)
+	}
+	if cfg.Screening.Enabled {
+		shortenerService.SetScreeningService(screeningService)
+		scheduler.Register(services.ScreeningJobName, cfg.Screening.Interval, screeningService.Run)
+	}
+
+	// Create metadata service, fetching the metadata of new destinations in
+	// the background and catching up with links missing it as a job
+	metadataService := services.NewMetadataService(repo, bioPageRepo, cfg.Metadata.Timeout, cfg.Metadata.BatchSize)
+	if cfg.Metadata.Enabled {
+		shortenerService.SetMetadataService(metadataService)
+		bioPageService.SetMetadataService(metadataService)
+		scheduler.Register(services.MetadataJobName, cfg.Metadata.Interval, metadataService.Run)
+	}
+
+	// Create health service, checking link destinations as a background job
+	// and telling owners about broken links by email if a mail server is set
+	healthService := services.NewHealthService(shortenerService, healthCheckRepo, &cfg.Health)
+	if cfg.Notify.SMTPHost != "" {
+		healthService.SetNotifier(services.NewEmailNotifier(userRepo, cfg.Notify.SMTPHost, cfg.Notify.SMTPPort,
+			cfg.Notify.SMTPUsername, cfg.Notify.SMTPPassword, cfg.Notify.From))
+	}
+	if cfg.Health.Enabled {
+		shortenerService.SetHealthService(healthService)
+		scheduler.Register(services.HealthJobName, cfg.Health.Interval, healthService.Run)
+	}
+
+	// Create webhook service, delivering link events in the background and
+	// retrying failed deliveries as a job
+	webhookService := services.NewWebhookService(webhookRepo, &cfg.Webhook)
+	shortenerService.SetWebhookService(webhookService)
+	bioPageService.SetWebhookService(webhookService)
+	scheduler.Register(services.WebhookJobName, cfg.Webhook.Interval, webhookService.Run)
+
+	// Create click stream, fanning visits out to the owners' live views
+	clickStream := services.NewClickStream()
+	shortenerService.SetClickStream(clickStream)
+	bioPageService.SetClickStream(clickStream)
+
+	// Create analytics service, recording visits, telling bots apart from
+	// people and counting unique visitors
+	botFilter := services.NewBotFilter(cfg.Analytics.FilterBots)
+	if cfg.Analytics.BotListPath != "" {
+		if err := botFilter.LoadBotList(cfg.Analytics.BotListPath); err != nil {
+			return nil, err
+		}
+	}
+	analyticsService := services.NewAnalyticsService(clickRepo, botFilter, cfg.Analytics.VisitorSketches)
+	shortenerService.SetAnalyticsService(analyticsService)
+	bioPageService.SetAnalyticsService(analyticsService)
+	analyticsService.SetTargetingService(targetingService)
+
+	// Create rollup service and register it as a background job
+	rollupService := services.NewRollupService(clickRepo, rollupRepo, &cfg.Analytics)
+	analyticsService.SetRollupService(rollupService)
+	if cfg.Analytics.Rollups {
+		scheduler.Register(services.RollupJobName, cfg.Analytics.RollupInterval, rollupService.Run)
+	}
+
+	// Create cleanup service and register it as a background job
+	cleanupService := services.NewCleanupService(shortenerService, &cfg.Cleanup)
+	if cfg.Cleanup.Enabled {
+		scheduler.Register(services.CleanupJobName, cfg.Cleanup.Interval, cleanupService.Run)
+	}
 
 	// Create auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService, sessionStore, cfg.Auth.SessionCookieName)
 
+	// Create domain middleware
+	domainMiddleware := middleware.NewDomainMiddleware(domainService)
+
 	// Create API handler
 	apiTemplates, err := template.New("").Funcs(handlers.GetTemplateFuncs()).ParseGlob(filepath.Join("templates", "*.html"))
 	if err != nil {
 		return nil, err
 	}
-	apiHandler := handlers.NewAPI(shortenerService, apiTemplates)
+	apiHandler := handlers.NewAPI(shortenerService, targetingService, apiTemplates, cfg.Server.TrustProxyHeaders, redirectPolicy)
 
 	// Create web handler
 	webHandler, err := handlers.NewWeb(shortenerService, "templates")
@@ -141,7 +406,7 @@ func New(cfg *config.Config) (*App, error) {
 	}
 
 	// Create dashboard handler
-	dashHandler, err := handlers.NewDashboard(shortenerService, "templates")
+	dashHandler, err := handlers.NewDashboard(shortenerService, campaignService, domainService, "templates")
 	if err != nil {
 		return nil, err
 	}
@@ -153,11 +418,38 @@ func New(cfg *config.Config) (*App, error) {
 	}
 
 	// Create Bio Page handler
-	bioPageHandler, err := handlers.NewBioPage(bioPageService, "templates")
+	bioPageHandler, err := handlers.NewBioPage(bioPageService, "templates", cfg.Server.TrustProxyHeaders, redirectPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create campaign handler
+	campaignHandler, err := handlers.NewCampaign(campaignService, "templates")
 	if err != nil {
 		return nil, err
 	}
 
+	// Create domain handler
+	domainHandler, err := handlers.NewDomain(domainService, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	// Create webhook handler
+	webhookHandler, err := handlers.NewWebhook(webhookService, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	// Create stream handler
+	streamHandler, err := handlers.NewStream(clickStream, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	// Create admin handler
+	adminHandler := handlers.NewAdmin(scheduler, cleanupService, reservedSlugService, screeningService, rollupService)
+
 	// Create router
 	router := mux.NewRouter()
 
@@ -177,10 +469,47 @@ func New(cfg *config.Config) (*App, error) {
 	)
 	router.Use(csrfMiddleware)
 
+	// Custom domains only serve their short links, so their routes come first
+	domainRouter := router.MatcherFunc(middleware.MatchCustomDomain).Subrouter()
+	domainRouter.HandleFunc("/{id}", apiHandler.RedirectURL).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+	domainRouter.HandleFunc("/{id}/{rest:.*}", apiHandler.RedirectURL).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+	domainRouter.PathPrefix("/").HandlerFunc(http.NotFound)
+
 	// API routes
 	apiRouter := router.PathPrefix("/api").Subrouter()
 	apiRouter.HandleFunc("/shorten", apiHandler.ShortenURL).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/urls", apiHandler.ListURLs).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/urls/{id}/expiry", apiHandler.UpdateExpirySettings).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/urls/{id}/forwarding", apiHandler.UpdateForwardingSettings).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/urls/{id}/redirect", apiHandler.UpdateRedirectSettings).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/urls/{id}/preview", apiHandler.UpdatePreviewSettings).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/urls/{id}/social", apiHandler.UpdateSocialCard).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/urls/{id}/health", apiHandler.GetHealth).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/urls/{id}/health/check", apiHandler.CheckHealth).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/urls/{id}/clicks", apiHandler.ListClicks).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/urls/{id}/visitors", apiHandler.GetVisitors).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/urls/{id}/analytics", apiHandler.GetAnalytics).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/urls/{id}/rules", apiHandler.UpdateTargetingRules).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/urls/{id}/destinations", apiHandler.UpdateDestinations).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/urls/{id}/destinations/{variant}/promote", apiHandler.PromoteDestination).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/urls/{id}/aliases", apiHandler.ListAliases).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/urls/{id}/aliases", apiHandler.AddAlias).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/urls/{id}/aliases/{alias}", apiHandler.RemoveAlias).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/urls/{id}/canonical-alias", apiHandler.SetCanonicalAlias).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/campaigns", campaignHandler.ListCampaignsAPI).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/campaigns", campaignHandler.CreateCampaignAPI).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/domains", domainHandler.ListDomainsAPI).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/domains", domainHandler.AddDomainAPI).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/domains/{id:[0-9]+}/verify", domainHandler.VerifyDomainAPI).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/webhooks", webhookHandler.ListWebhooksAPI).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/webhooks", webhookHandler.CreateWebhookAPI).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/webhooks/{id:[0-9]+}", webhookHandler.UpdateWebhookAPI).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/webhooks/{id:[0-9]+}", webhookHandler.DeleteWebhookAPI).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", webhookHandler.ListDeliveriesAPI).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/webhooks/{id:[0-9]+}/test", webhookHandler.SendTestEventAPI).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/bio/{id:[0-9]+}/visitors", bioPageHandler.GetVisitorsAPI).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/bio/links/{id:[0-9]+}/analytics", bioPageHandler.GetLinkAnalyticsAPI).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/clicks/stream", streamHandler.Clicks).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/auth/login", authHandler.LoginAPI).Methods(http.MethodPost)
 
 	// Auth routes
@@ -199,6 +528,45 @@ func New(cfg *config.Config) (*App, error) {
 	dashRouter.HandleFunc("", dashHandler.Home).Methods(http.MethodGet)
 	dashRouter.HandleFunc("/", dashHandler.Home).Methods(http.MethodGet)
 	dashRouter.HandleFunc("/shorten", dashHandler.ShortenURL).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}", dashHandler.LinkSettings).Methods(http.MethodGet)
+	dashRouter.HandleFunc("/links/{id}/expiry", dashHandler.UpdateExpirySettings).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/forwarding", dashHandler.UpdateForwardingSettings).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/redirect", dashHandler.UpdateRedirectSettings).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/preview", dashHandler.UpdatePreviewSettings).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/social", dashHandler.UpdateSocialCard).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/health/check", dashHandler.CheckHealth).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/rules", dashHandler.AddTargetingRule).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/rules/{index:[0-9]+}/delete", dashHandler.DeleteTargetingRule).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/rules/{index:[0-9]+}/up", dashHandler.MoveTargetingRuleUp).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/destinations", dashHandler.UpdateDestinations).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/destinations/{variant}/promote", dashHandler.PromoteDestination).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/aliases", dashHandler.AddAlias).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/aliases/{alias}/delete", dashHandler.RemoveAlias).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/links/{id}/aliases/{alias}/canonical", dashHandler.SetCanonicalAlias).Methods(http.MethodPost)
+
+	// Campaign routes
+	dashRouter.HandleFunc("/campaigns", campaignHandler.ListCampaigns).Methods(http.MethodGet)
+	dashRouter.HandleFunc("/campaigns", campaignHandler.CreateCampaign).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.ViewCampaign).Methods(http.MethodGet)
+	dashRouter.HandleFunc("/campaigns/{id:[0-9]+}", campaignHandler.UpdateCampaign).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/campaigns/{id:[0-9]+}/delete", campaignHandler.DeleteCampaign).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/campaigns/{id:[0-9]+}/channels", campaignHandler.AddChannel).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/campaigns/{id:[0-9]+}/channels/{channel}/delete", campaignHandler.RemoveChannel).Methods(http.MethodPost)
+
+	// Domain routes
+	dashRouter.HandleFunc("/domains", domainHandler.ListDomains).Methods(http.MethodGet)
+	dashRouter.HandleFunc("/domains", domainHandler.AddDomain).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/domains/{id:[0-9]+}/verify", domainHandler.VerifyDomain).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/domains/{id:[0-9]+}/delete", domainHandler.DeleteDomain).Methods(http.MethodPost)
+
+	// Webhook routes
+	dashRouter.HandleFunc("/webhooks", webhookHandler.ListWebhooks).Methods(http.MethodGet)
+	dashRouter.HandleFunc("/webhooks", webhookHandler.CreateWebhook).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/webhooks/{id:[0-9]+}", webhookHandler.ViewWebhook).Methods(http.MethodGet)
+	dashRouter.HandleFunc("/webhooks/{id:[0-9]+}", webhookHandler.UpdateWebhook).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/webhooks/{id:[0-9]+}/delete", webhookHandler.DeleteWebhook).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/webhooks/{id:[0-9]+}/test", webhookHandler.SendTestEvent).Methods(http.MethodPost)
+	dashRouter.HandleFunc("/live", streamHandler.LiveView).Methods(http.MethodGet)
 
 	// Bio Page routes
 	bioRouter := router.PathPrefix("/bio").Subrouter()
@@ -220,10 +588,21 @@ func New(cfg *config.Config) (*App, error) {
 	router.HandleFunc("/qrcode/generate", qrCodeHandler.Generate).Methods(http.MethodGet)
 	router.HandleFunc("/qrcode/preview/{id}", qrCodeHandler.Preview).Methods(http.MethodGet)
 
-	// Admin routes (example - not implemented yet)
+	// Admin routes
 	adminRouter := router.PathPrefix("/admin").Subrouter()
 	adminRouter.Use(authMiddleware.RequireAdmin)
-	// adminRouter.HandleFunc("", adminHandler.Home).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/jobs", adminHandler.ListJobs).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/jobs/{name}/run", adminHandler.RunJob).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/cleanup", adminHandler.CleanupStatus).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/rollups", adminHandler.RollupStatus).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/rollups/backfill", adminHandler.BackfillRollups).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/reserved-slugs", adminHandler.ListReservedSlugs).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/reserved-slugs", adminHandler.AddReservedSlug).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/reserved-slugs/{slug}", adminHandler.DeleteReservedSlug).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/screening", adminHandler.ListScreenedLinks).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/screening/check", adminHandler.ScreenDestination).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/screening/{id}/approve", adminHandler.ApproveLink).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/screening/{id}/block", adminHandler.BlockLink).Methods(http.MethodPost)
 
 	// Web routes
 	router.HandleFunc("/", webHandler.Home).Methods(http.MethodGet)
@@ -235,11 +614,18 @@ func New(cfg *config.Config) (*App, error) {
 	// This should be placed BEFORE the regular URL redirect route
 
 	// Special routes (bio pages and their links)
-	router.HandleFunc("/b/link/{id:[0-9]+}", bioPageHandler.RedirectBioLink).Methods(http.MethodGet)
+	router.HandleFunc("/b/link/{id:[0-9]+}", bioPageHandler.RedirectBioLink).Methods(http.MethodGet, http.MethodHead)
 	router.HandleFunc("/b/{shortCode}", bioPageHandler.ViewBioPage).Methods(http.MethodGet)
 
-	// Then place the regular URL redirect route after these specific routes
-	router.HandleFunc("/{id}", apiHandler.RedirectURL).Methods(http.MethodGet)
+	// Then place the regular URL redirect route after these specific routes.
+	// In a unified namespace the short code may also name a bio page.
+	// Posting to a short link continues past its preview page.
+	if cfg.Shortener.UnifiedNamespace {
+		shortCodeHandler := handlers.NewShortCode(slugRegistry, apiHandler, bioPageHandler)
+		router.HandleFunc("/{id}", shortCodeHandler.Resolve).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+	} else {
+		router.HandleFunc("/{id}", apiHandler.RedirectURL).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+	}
 		
 
 	// Password verification routes
@@ -249,33 +635,63 @@ func New(cfg *config.Config) (*App, error) {
 	// Static files
 	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
+	// Short links with trailing path segments, forwarded by links with path passthrough.
+	// This must be the last route so it doesn't shadow any other multi-segment route.
+	router.HandleFunc("/{id}/{rest:.*}", apiHandler.RedirectURL).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+
+	// Reserve the slugs the routes above would shadow
+	routeSlugs, err := routeSlugs(router)
+	if err != nil {
+		return nil, err
+	}
+	reservedSlugService.SetRouteSlugs(routeSlugs)
+
 	// Create server
 	server := &http.Server{
 		Addr:    cfg.Server.Address,
-		Handler: router,
+		Handler: domainMiddleware.Domain(router),
 	}
+	// End open click streams on shutdown, which otherwise waits for them
+	server.RegisterOnShutdown(clickStream.Close)
 
 	return &App{
-		config:         cfg,
-		repo:           repo,
-		userRepo:       userRepo,
-		bioPageRepo:    bioPageRepo,
-		server:         server,
-		apiHandler:     apiHandler,
-		webHandler:     webHandler,
-		authHandler:    authHandler,
-		dashHandler:    dashHandler,
-		qrCodeHandler:  qrCodeHandler,
-		bioPageHandler: bioPageHandler,
-		dbManager:      dbManager,
-		authMiddleware: authMiddleware,
-		sessionStore:   sessionStore,
-		qrCodeService:  qrCodeService,
+		config:          cfg,
+		repo:            repo,
+		userRepo:        userRepo,
+		bioPageRepo:     bioPageRepo,
+		campaignRepo:    campaignRepo,
+		domainRepo:      domainRepo,
+		server:          server,
+		apiHandler:      apiHandler,
+		webHandler:      webHandler,
+		authHandler:     authHandler,
+		dashHandler:     dashHandler,
+		qrCodeHandler:   qrCodeHandler,
+		bioPageHandler:  bioPageHandler,
+		campaignHandler: campaignHandler,
+		domainHandler:   domainHandler,
+		adminHandler:    adminHandler,
+		webhookHandler:  webhookHandler,
+		streamHandler:   streamHandler,
+		dbManager:       dbManager,
+		authMiddleware:  authMiddleware,
+		sessionStore:    sessionStore,
+		qrCodeService:   qrCodeService,
+		scheduler:       scheduler,
+		metadataService: metadataService,
+		webhookService:  webhookService,
 	}, nil
 }
 
 // Start starts the application
 func (a *App) Start() error {
+	// Start the background jobs, metadata fetching and webhook deliveries
+	a.scheduler.Start()
+	if a.config.Metadata.Enabled {
+		a.metadataService.Start()
+	}
+	a.webhookService.Start()
+
 	return a.server.ListenAndServe()
 }
 
@@ -290,6 +706,11 @@ func (a *App) Stop() error {
 		return err
 	}
 
+	// Stop the background jobs, metadata fetching and webhook deliveries
+	a.scheduler.Stop()
+	a.metadataService.Stop()
+	a.webhookService.Stop()
+
 	// Close the repository
 	if err := a.repo.Close(); err != nil {
 		return err
@@ -303,4 +724,26 @@ func (a *App) Stop() error {
 	}
 
 	return nil
+}
+
+// routeSlugs returns the first path segment of every route that starts with a
+// fixed segment, such as dashboard for /dashboard/links/{id}
+func routeSlugs(router *mux.Router) ([]string, error) {
+	seen := make(map[string]bool)
+	var slugs []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			// Routes matched without a path, such as subrouters on a matcher
+			return nil
+		}
+
+		segment, _, _ := strings.Cut(strings.TrimPrefix(template, "/"), "/")
+		if segment != "" && !strings.Contains(segment, "{") && !seen[segment] {
+			seen[segment] = true
+			slugs = append(slugs, segment)
+		}
+		return nil
+	})
+	return slugs, err
 }
\ No newline at end of file
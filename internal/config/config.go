@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,17 +15,183 @@ type Config struct {
 	Shortener ShortenerConfig
 	Database  DatabaseConfig
 	Auth      AuthConfig
+	Cleanup   CleanupConfig
+	Targeting TargetingConfig
+	Redirect  RedirectConfig
+	Screening ScreeningConfig
+	Metadata  MetadataConfig
+	Health    HealthConfig
+	Notify    NotifyConfig
+	Webhook   WebhookConfig
+	Analytics AnalyticsConfig
 }
 
 // ServerConfig holds the server configuration
 type ServerConfig struct {
 	Address string
+	// TrustProxyHeaders uses X-Forwarded-For and X-Real-IP to find the client IP
+	TrustProxyHeaders bool
 }
 
 // ShortenerConfig holds the shortener configuration
 type ShortenerConfig struct {
 	BaseURL   string
 	KeyLength int
+	// SlugStrategy is the default way of generating IDs (random, counter, words or unambiguous)
+	SlugStrategy string
+	// SlugAlphabet is the alphabet of random and counter IDs
+	SlugAlphabet string
+	// SlugWords is the number of words in word IDs
+	SlugWords int
+	// SlugCounterSalt gives the deployment its own sequence of counter IDs
+	SlugCounterSalt uint64
+	// SlugBlockSize is how many counter values an instance reserves at a time
+	SlugBlockSize int
+	// SlugBlocklistPath is the path to a file of words custom slugs can't contain, one per line
+	SlugBlocklistPath string
+	// UnifiedNamespace makes links and bio pages share short codes, serving bio pages at the root
+	UnifiedNamespace bool
+	// CaseInsensitiveSlugs makes short codes match regardless of case
+	CaseInsensitiveSlugs bool
+	// UnicodeSlugs accepts custom slugs with letters of any script and emoji
+	UnicodeSlugs bool
+	// SortQueryParams orders the query parameters of destination URLs by name
+	SortQueryParams bool
+	// StripTrackingParams removes utm_* parameters and click IDs from destination URLs
+	StripTrackingParams bool
+}
+
+// CleanupConfig holds the configuration for purging expired links
+type CleanupConfig struct {
+	// Enabled turns the background cleanup job on or off
+	Enabled bool
+	// Interval is how often the cleanup job runs
+	Interval time.Duration
+	// Retention is how long a link stays expired before it is purged
+	Retention time.Duration
+	// Archive moves purged links to the archive instead of deleting them
+	Archive bool
+	// BatchSize is the maximum number of links purged per database round trip
+	BatchSize int
+}
+
+// ScreeningConfig holds the configuration for screening link destinations
+type ScreeningConfig struct {
+	// Enabled turns screening on when links are created and edited
+	Enabled bool
+	// Interval is how often existing links are rescreened (zero to never rescreen)
+	Interval time.Duration
+	// BlocklistPath is the path to a file of blocked domains and URLs, one per line
+	BlocklistPath string
+	// LookupHosts resolves destination hostnames to catch those pointing at private networks
+	LookupHosts bool
+	// BlocklistAction is the action for destinations on the blocklist (allow, warn, flag or reject)
+	BlocklistAction string
+	// PrivateNetworkAction is the action for destinations on private networks
+	PrivateNetworkAction string
+	// IPAddressAction is the action for destinations using a public IP address instead of a hostname
+	IPAddressAction string
+	// ShortenerAction is the action for destinations on other URL shorteners
+	ShortenerAction string
+	// SuspiciousAction is the action for destinations with credentials, punycode or many subdomains
+	SuspiciousAction string
+}
+
+// MetadataConfig holds the configuration for fetching the metadata of link destinations
+type MetadataConfig struct {
+	// Enabled turns fetching destination titles, descriptions and images on or off
+	Enabled bool
+	// Interval is how often links missing their metadata are caught up with
+	Interval time.Duration
+	// Timeout bounds each fetch of a destination
+	Timeout time.Duration
+	// BatchSize is the maximum number of links and bio links fetched per run
+	BatchSize int
+}
+
+// HealthConfig holds the configuration for checking that link destinations still work
+type HealthConfig struct {
+	// Enabled turns the background health check job on or off
+	Enabled bool
+	// Interval is how often the health check job runs
+	Interval time.Duration
+	// RecheckAfter is how long a link goes between checks
+	RecheckAfter time.Duration
+	// Timeout bounds each request to a destination
+	Timeout time.Duration
+	// Concurrency is the most destinations checked at once
+	Concurrency int
+	// HostInterval is the least time between requests to the same host
+	HostInterval time.Duration
+	// FailureThreshold is how many checks in a row must fail before a link counts as broken
+	FailureThreshold int
+	// BatchSize is the maximum number of links checked per run
+	BatchSize int
+	// HistoryRetention is how long check results are kept
+	HistoryRetention time.Duration
+}
+
+// NotifyConfig holds the configuration for notifying users about their links
+type NotifyConfig struct {
+	// SMTPHost is the mail server notifications are sent through; notifications are only logged without one
+	SMTPHost string
+	// SMTPPort is the mail server port
+	SMTPPort int
+	// SMTPUsername and SMTPPassword authenticate with the mail server, if it needs them
+	SMTPUsername string
+	SMTPPassword string
+	// From is the sender address of notification emails
+	From string
+}
+
+// WebhookConfig holds the configuration for delivering events to users' webhooks
+type WebhookConfig struct {
+	// Interval is how often failed deliveries are retried
+	Interval time.Duration
+	// Timeout bounds each request to a webhook endpoint
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is attempted before it fails
+	MaxAttempts int
+	// RetryBackoff is the wait before the first retry, doubling with each retry after it
+	RetryBackoff time.Duration
+	// BatchSize is the maximum number of deliveries retried per run
+	BatchSize int
+	// Retention is how long finished deliveries are kept in the delivery logs
+	Retention time.Duration
+}
+
+// AnalyticsConfig holds the configuration for counting and recording visits
+type AnalyticsConfig struct {
+	// FilterBots counts visits from bots, crawlers and prefetchers apart, leaving them out of the visit counts
+	FilterBots bool
+	// BotListPath is the path to a file of extra user agent fragments identifying bots, one per line
+	BotListPath string
+	// VisitorSketches estimates total unique visitors with HyperLogLog sketches rather than counting them exactly
+	VisitorSketches bool
+	// Rollups turns on the background job counting clicks by hour and day for reports
+	Rollups bool
+	// RollupInterval is how often the rollup job runs
+	RollupInterval time.Duration
+	// RollupBatchSize is the maximum number of clicks rolled up per database round trip
+	RollupBatchSize int
+	// RawRetention is how long recorded clicks are kept once rolled up; zero keeps them forever
+	RawRetention time.Duration
+}
+
+// TargetingConfig holds the configuration for redirect targeting rules
+type TargetingConfig struct {
+	// GeoIPDatabasePath is the path to a CSV IP-to-country database; country rules never match without one
+	GeoIPDatabasePath string
+}
+
+// RedirectConfig holds the server-wide defaults for redirect responses
+type RedirectConfig struct {
+	// Status is the default redirect status code (301, 302, 307 or 308)
+	Status int
+	// CachePolicy is the default Cache-Control policy (auto, no-store, private or public)
+	CachePolicy string
+	// CacheMaxAge is how long browsers and CDNs may cache a cacheable redirect
+	CacheMaxAge time.Duration
 }
 
 // DatabaseConfig holds the database configuration
@@ -87,6 +254,7 @@ func Load(configPath string) (*Config, error) {
 
 	// Get server address from environment or use default
 	address := getEnv("SERVER_ADDRESS", ":8080")
+	trustProxyHeaders, _ := strconv.ParseBool(getEnv("TRUST_PROXY_HEADERS", "false"))
 
 	// Get base URL from environment or use default
 	baseURL := getEnv("BASE_URL", "http://localhost:8080")
@@ -94,7 +262,20 @@ func Load(configPath string) (*Config, error) {
 	baseURL = strings.TrimSuffix(baseURL, "/")
 
 	// Get key length from environment or use default
-	keyLength := 6
+	keyLength, _ := strconv.Atoi(getEnv("SLUG_LENGTH", "6"))
+
+	// Get slug generation settings from environment or use defaults
+	slugStrategy := getEnv("SLUG_STRATEGY", "random")
+	slugAlphabet := getEnv("SLUG_ALPHABET", "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	slugWords, _ := strconv.Atoi(getEnv("SLUG_WORDS", "3"))
+	slugCounterSalt, _ := strconv.ParseUint(getEnv("SLUG_COUNTER_SALT", "0"), 10, 64)
+	slugBlockSize, _ := strconv.Atoi(getEnv("SLUG_BLOCK_SIZE", "100"))
+	slugBlocklistPath := getEnv("SLUG_BLOCKLIST_PATH", "")
+	unifiedNamespace, _ := strconv.ParseBool(getEnv("UNIFIED_NAMESPACE", "false"))
+	caseInsensitiveSlugs, _ := strconv.ParseBool(getEnv("SLUG_CASE_INSENSITIVE", "false"))
+	unicodeSlugs, _ := strconv.ParseBool(getEnv("SLUG_UNICODE", "false"))
+	sortQueryParams, _ := strconv.ParseBool(getEnv("URL_SORT_QUERY", "false"))
+	stripTrackingParams, _ := strconv.ParseBool(getEnv("URL_STRIP_TRACKING", "false"))
 
 	// Get database type from environment or use default
 	dbType := getEnv("DB_TYPE", "memory")
@@ -116,6 +297,73 @@ func Load(configPath string) (*Config, error) {
 	sessionCookieMaxAge, _ := strconv.Atoi(getEnv("SESSION_COOKIE_MAX_AGE", "86400")) // 24 hours
 	csrfKey := getEnv("CSRF_KEY", "32-byte-long-auth-key")
 
+	// Cleanup config
+	cleanupEnabled, _ := strconv.ParseBool(getEnv("CLEANUP_ENABLED", "true"))
+	cleanupIntervalMinutes, _ := strconv.Atoi(getEnv("CLEANUP_INTERVAL_MINUTES", "60"))
+	cleanupRetentionHours, _ := strconv.Atoi(getEnv("CLEANUP_RETENTION_HOURS", "720")) // 30 days
+	cleanupMode := getEnv("CLEANUP_MODE", "archive")
+	cleanupBatchSize, _ := strconv.Atoi(getEnv("CLEANUP_BATCH_SIZE", "500"))
+
+	// Targeting config
+	geoIPDatabasePath := getEnv("GEOIP_DB_PATH", "")
+
+	// Screening config
+	screeningEnabled, _ := strconv.ParseBool(getEnv("SCREENING_ENABLED", "true"))
+	screeningIntervalMinutes, _ := strconv.Atoi(getEnv("SCREENING_INTERVAL_MINUTES", "1440")) // 24 hours
+	screeningBlocklistPath := getEnv("SCREENING_BLOCKLIST_PATH", "")
+	screeningLookupHosts, _ := strconv.ParseBool(getEnv("SCREENING_LOOKUP_HOSTS", "true"))
+	screeningBlocklistAction := getEnv("SCREENING_BLOCKLIST_ACTION", "reject")
+	screeningPrivateNetworkAction := getEnv("SCREENING_PRIVATE_NETWORK_ACTION", "reject")
+	screeningIPAddressAction := getEnv("SCREENING_IP_ADDRESS_ACTION", "warn")
+	screeningShortenerAction := getEnv("SCREENING_SHORTENER_ACTION", "warn")
+	screeningSuspiciousAction := getEnv("SCREENING_SUSPICIOUS_ACTION", "flag")
+
+	// Metadata config
+	metadataEnabled, _ := strconv.ParseBool(getEnv("METADATA_ENABLED", "true"))
+	metadataIntervalMinutes, _ := strconv.Atoi(getEnv("METADATA_INTERVAL_MINUTES", "15"))
+	metadataTimeoutSeconds, _ := strconv.Atoi(getEnv("METADATA_TIMEOUT_SECONDS", "5"))
+	metadataBatchSize, _ := strconv.Atoi(getEnv("METADATA_BATCH_SIZE", "50"))
+
+	// Health check config
+	healthEnabled, _ := strconv.ParseBool(getEnv("HEALTH_CHECK_ENABLED", "true"))
+	healthIntervalMinutes, _ := strconv.Atoi(getEnv("HEALTH_CHECK_INTERVAL_MINUTES", "10"))
+	healthRecheckHours, _ := strconv.Atoi(getEnv("HEALTH_CHECK_RECHECK_HOURS", "6"))
+	healthTimeoutSeconds, _ := strconv.Atoi(getEnv("HEALTH_CHECK_TIMEOUT_SECONDS", "10"))
+	healthConcurrency, _ := strconv.Atoi(getEnv("HEALTH_CHECK_CONCURRENCY", "8"))
+	healthHostIntervalMs, _ := strconv.Atoi(getEnv("HEALTH_CHECK_HOST_INTERVAL_MS", "1000"))
+	healthFailureThreshold, _ := strconv.Atoi(getEnv("HEALTH_CHECK_FAILURE_THRESHOLD", "3"))
+	healthBatchSize, _ := strconv.Atoi(getEnv("HEALTH_CHECK_BATCH_SIZE", "200"))
+	healthHistoryDays, _ := strconv.Atoi(getEnv("HEALTH_CHECK_HISTORY_DAYS", "30"))
+
+	// Notification config
+	notifySMTPHost := getEnv("NOTIFY_SMTP_HOST", "")
+	notifySMTPPort, _ := strconv.Atoi(getEnv("NOTIFY_SMTP_PORT", "587"))
+	notifySMTPUsername := getEnv("NOTIFY_SMTP_USERNAME", "")
+	notifySMTPPassword := getEnv("NOTIFY_SMTP_PASSWORD", "")
+	notifyFrom := getEnv("NOTIFY_FROM", "noreply@localhost")
+
+	// Webhook config
+	webhookIntervalSeconds, _ := strconv.Atoi(getEnv("WEBHOOK_INTERVAL_SECONDS", "30"))
+	webhookTimeoutSeconds, _ := strconv.Atoi(getEnv("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	webhookMaxAttempts, _ := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	webhookRetryBackoffSeconds, _ := strconv.Atoi(getEnv("WEBHOOK_RETRY_BACKOFF_SECONDS", "30"))
+	webhookBatchSize, _ := strconv.Atoi(getEnv("WEBHOOK_BATCH_SIZE", "100"))
+	webhookRetentionDays, _ := strconv.Atoi(getEnv("WEBHOOK_RETENTION_DAYS", "30"))
+
+	// Analytics config
+	analyticsFilterBots, _ := strconv.ParseBool(getEnv("ANALYTICS_FILTER_BOTS", "true"))
+	analyticsBotListPath := getEnv("ANALYTICS_BOT_LIST_PATH", "")
+	analyticsVisitorSketches, _ := strconv.ParseBool(getEnv("ANALYTICS_VISITOR_SKETCHES", "false"))
+	analyticsRollups, _ := strconv.ParseBool(getEnv("ANALYTICS_ROLLUP_ENABLED", "true"))
+	analyticsRollupIntervalMinutes, _ := strconv.Atoi(getEnv("ANALYTICS_ROLLUP_INTERVAL_MINUTES", "5"))
+	analyticsRollupBatchSize, _ := strconv.Atoi(getEnv("ANALYTICS_ROLLUP_BATCH_SIZE", "5000"))
+	analyticsRawRetentionDays, _ := strconv.Atoi(getEnv("ANALYTICS_RAW_RETENTION_DAYS", "90"))
+
+	// Redirect config
+	redirectStatus, _ := strconv.Atoi(getEnv("REDIRECT_STATUS", "302"))
+	redirectCachePolicy := getEnv("REDIRECT_CACHE_POLICY", "auto")
+	redirectCacheMaxAgeSeconds, _ := strconv.Atoi(getEnv("REDIRECT_CACHE_MAX_AGE_SECONDS", "86400")) // 24 hours
+
 	// OAuth config
 	googleClientID := getEnv("GOOGLE_CLIENT_ID", "")
 	googleClientSecret := getEnv("GOOGLE_CLIENT_SECRET", "")
@@ -127,11 +375,23 @@ func Load(configPath string) (*Config, error) {
 
 	return &Config{
 		Server: ServerConfig{
-			Address: address,
+			Address:           address,
+			TrustProxyHeaders: trustProxyHeaders,
 		},
 		Shortener: ShortenerConfig{
-			BaseURL:   baseURL,
-			KeyLength: keyLength,
+			BaseURL:              baseURL,
+			KeyLength:            keyLength,
+			SlugStrategy:         slugStrategy,
+			SlugAlphabet:         slugAlphabet,
+			SlugWords:            slugWords,
+			SlugCounterSalt:      slugCounterSalt,
+			SlugBlockSize:        slugBlockSize,
+			SlugBlocklistPath:    slugBlocklistPath,
+			UnifiedNamespace:     unifiedNamespace,
+			CaseInsensitiveSlugs: caseInsensitiveSlugs,
+			UnicodeSlugs:         unicodeSlugs,
+			SortQueryParams:      sortQueryParams,
+			StripTrackingParams:  stripTrackingParams,
 		},
 		Database: DatabaseConfig{
 			Type:            dbType,
@@ -157,6 +417,73 @@ func Load(configPath string) (*Config, error) {
 				GitHubRedirectURL:  githubRedirectURL,
 			},
 		},
+		Cleanup: CleanupConfig{
+			Enabled:   cleanupEnabled,
+			Interval:  time.Duration(cleanupIntervalMinutes) * time.Minute,
+			Retention: time.Duration(cleanupRetentionHours) * time.Hour,
+			Archive:   cleanupMode != "delete",
+			BatchSize: cleanupBatchSize,
+		},
+		Targeting: TargetingConfig{
+			GeoIPDatabasePath: geoIPDatabasePath,
+		},
+		Redirect: RedirectConfig{
+			Status:      redirectStatus,
+			CachePolicy: redirectCachePolicy,
+			CacheMaxAge: time.Duration(redirectCacheMaxAgeSeconds) * time.Second,
+		},
+		Screening: ScreeningConfig{
+			Enabled:              screeningEnabled,
+			Interval:             time.Duration(screeningIntervalMinutes) * time.Minute,
+			BlocklistPath:        screeningBlocklistPath,
+			LookupHosts:          screeningLookupHosts,
+			BlocklistAction:      screeningBlocklistAction,
+			PrivateNetworkAction: screeningPrivateNetworkAction,
+			IPAddressAction:      screeningIPAddressAction,
+			ShortenerAction:      screeningShortenerAction,
+			SuspiciousAction:     screeningSuspiciousAction,
+		},
+		Metadata: MetadataConfig{
+			Enabled:   metadataEnabled,
+			Interval:  time.Duration(metadataIntervalMinutes) * time.Minute,
+			Timeout:   time.Duration(metadataTimeoutSeconds) * time.Second,
+			BatchSize: metadataBatchSize,
+		},
+		Health: HealthConfig{
+			Enabled:          healthEnabled,
+			Interval:         time.Duration(healthIntervalMinutes) * time.Minute,
+			RecheckAfter:     time.Duration(healthRecheckHours) * time.Hour,
+			Timeout:          time.Duration(healthTimeoutSeconds) * time.Second,
+			Concurrency:      healthConcurrency,
+			HostInterval:     time.Duration(healthHostIntervalMs) * time.Millisecond,
+			FailureThreshold: healthFailureThreshold,
+			BatchSize:        healthBatchSize,
+			HistoryRetention: time.Duration(healthHistoryDays) * 24 * time.Hour,
+		},
+		Notify: NotifyConfig{
+			SMTPHost:     notifySMTPHost,
+			SMTPPort:     notifySMTPPort,
+			SMTPUsername: notifySMTPUsername,
+			SMTPPassword: notifySMTPPassword,
+			From:         notifyFrom,
+		},
+		Webhook: WebhookConfig{
+			Interval:     time.Duration(webhookIntervalSeconds) * time.Second,
+			Timeout:      time.Duration(webhookTimeoutSeconds) * time.Second,
+			MaxAttempts:  webhookMaxAttempts,
+			RetryBackoff: time.Duration(webhookRetryBackoffSeconds) * time.Second,
+			BatchSize:    webhookBatchSize,
+			Retention:    time.Duration(webhookRetentionDays) * 24 * time.Hour,
+		},
+		Analytics: AnalyticsConfig{
+			FilterBots:      analyticsFilterBots,
+			BotListPath:     analyticsBotListPath,
+			VisitorSketches: analyticsVisitorSketches,
+			Rollups:         analyticsRollups,
+			RollupInterval:  time.Duration(analyticsRollupIntervalMinutes) * time.Minute,
+			RollupBatchSize: analyticsRollupBatchSize,
+			RawRetention:    time.Duration(analyticsRawRetentionDays) * 24 * time.Hour,
+		},
 	}, nil
 }
 
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// Admin handles admin requests
+type Admin struct {
+	scheduler           *services.Scheduler
+	cleanupService      *services.CleanupService
+	reservedSlugService *services.ReservedSlugService
+	screeningService    *services.ScreeningService
+	rollupService       *services.RollupService
+}
+
+// NewAdmin creates a new admin handler
+func NewAdmin(scheduler *services.Scheduler, cleanupService *services.CleanupService, reservedSlugService *services.ReservedSlugService, screeningService *services.ScreeningService, rollupService *services.RollupService) *Admin {
+	return &Admin{
+		scheduler:           scheduler,
+		cleanupService:      cleanupService,
+		reservedSlugService: reservedSlugService,
+		screeningService:    screeningService,
+		rollupService:       rollupService,
+	}
+}
+
+// ListJobs returns the status of every background job
+func (h *Admin) ListJobs(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.scheduler.Statuses())
+}
+
+// RunJob runs a background job immediately
+func (h *Admin) RunJob(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	if err := h.scheduler.RunNow(r.Context(), name); err != nil {
+		if errors.Is(err, services.ErrUnknownJob) {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Job failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// CleanupStatus returns the status of the expired link cleanup job
+func (h *Admin) CleanupStatus(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.cleanupService.Status())
+}
+
+// RollupStatus returns the status of the analytics rollup job
+func (h *Admin) RollupStatus(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.rollupService.Status())
+}
+
+// BackfillRollups rebuilds the analytics rollups of the latest days from the
+// recorded clicks; zero days rebuilds every day whose clicks are still kept
+func (h *Admin) BackfillRollups(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Days int `json:"days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Days < 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var since time.Time
+	if req.Days > 0 {
+		since = time.Now().AddDate(0, 0, 1-req.Days)
+	}
+	rolledUp, err := h.rollupService.Backfill(r.Context(), since)
+	if err != nil {
+		http.Error(w, "Backfill failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]int{"rolled_up": rolledUp})
+}
+
+// ListReservedSlugs returns the slugs links and bio pages can't use
+func (h *Admin) ListReservedSlugs(w http.ResponseWriter, r *http.Request) {
+	reserved, err := h.reservedSlugService.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list reserved slugs", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, reserved)
+}
+
+// AddReservedSlug reserves a slug
+func (h *Admin) AddReservedSlug(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Slug string `json:"slug"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.reservedSlugService.Add(r.Context(), req.Slug); err != nil {
+		if errors.Is(err, services.ErrInvalidSlug) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to reserve slug", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+}
+
+// DeleteReservedSlug frees a slug reserved by an admin
+func (h *Admin) DeleteReservedSlug(w http.ResponseWriter, r *http.Request) {
+	if err := h.reservedSlugService.Remove(r.Context(), mux.Vars(r)["slug"]); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			http.Error(w, "Slug is not reserved", http.StatusNotFound)
+		case errors.Is(err, services.ErrRouteSlug):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to free slug", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// ListScreenedLinks returns the links with a screening status, by default
+// the flagged links waiting for review
+func (h *Admin) ListScreenedLinks(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ScreeningStatusFlagged
+	}
+
+	urls, err := h.screeningService.List(r.Context(), status)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScreeningStatus) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to list links", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, urls)
+}
+
+// ScreenDestination reports which screening checks a URL matches, without creating a link
+func (h *Admin) ScreenDestination(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.screeningService.Screen(r.Context(), req.URL))
+}
+
+// ApproveLink marks a flagged or warned link as reviewed, so it redirects without a warning
+func (h *Admin) ApproveLink(w http.ResponseWriter, r *http.Request) {
+	if err := h.screeningService.Approve(r.Context(), mux.Vars(r)["id"]); err != nil {
+		h.writeScreeningError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// BlockLink stops a link from redirecting
+func (h *Admin) BlockLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason string `json:"reason,omitempty"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.screeningService.Block(r.Context(), mux.Vars(r)["id"], req.Reason); err != nil {
+		h.writeScreeningError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+// writeScreeningError maps errors from reviewing a link to HTTP responses
+func (h *Admin) writeScreeningError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "URL not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Failed to review link", http.StatusInternalServerError)
+}
+
+// writeJSON writes a JSON response
+func (h *Admin) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
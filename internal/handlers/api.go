@@ -5,29 +5,44 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"net"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/middleware"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
 )
 
+// Sticky destination variants are remembered in a cookie per URL
+const (
+	variantCookiePrefix = "variant_"
+	variantCookieMaxAge = 30 * 24 * 60 * 60 // 30 days
+)
+
 // API handles API requests
 type API struct {
-	shortenerService *services.ShortenerService
-	templates        *template.Template
+	shortenerService  *services.ShortenerService
+	targetingService  *services.TargetingService
+	templates         *template.Template
+	trustProxyHeaders bool
+	redirectPolicy    services.RedirectPolicy
 }
 
 // NewAPI creates a new API handler
-func NewAPI(shortenerService *services.ShortenerService, templates *template.Template) *API {
+func NewAPI(shortenerService *services.ShortenerService, targetingService *services.TargetingService, templates *template.Template, trustProxyHeaders bool, redirectPolicy services.RedirectPolicy) *API {
 	return &API{
-		shortenerService: shortenerService,
-		templates:        templates,
+		shortenerService:  shortenerService,
+		targetingService:  targetingService,
+		templates:         templates,
+		trustProxyHeaders: trustProxyHeaders,
+		redirectPolicy:    redirectPolicy,
 	}
 }
 
@@ -39,6 +54,45 @@ func (h *API) ShortenURL(w http.ResponseWriter, r *http.Request) {
 		CustomSlug string `json:"custom_slug,omitempty"`
 		ExpiresIn  int64  `json:"expires_in,omitempty"` // Duration in seconds
 		Password   string `json:"password,omitempty"`   // Optional password
+
+		// What to do once the URL has expired
+		ExpiryAction      string `json:"expiry_action,omitempty"`
+		ExpiryRedirectURL string `json:"expiry_redirect_url,omitempty"`
+		ExpiryMessage     string `json:"expiry_message,omitempty"`
+
+		// Optional weighted destinations splitting the traffic
+		Destinations   []models.Destination `json:"destinations,omitempty"`
+		StickyRotation bool                 `json:"sticky_rotation,omitempty"`
+
+		// How query parameters and trailing path segments reach the destination
+		QueryMode       string `json:"query_mode,omitempty"`
+		PathPassthrough bool   `json:"path_passthrough,omitempty"`
+
+		// Optional campaign and channel whose UTM parameters tag the URL
+		CampaignID *int   `json:"campaign_id,omitempty"`
+		Channel    string `json:"channel,omitempty"`
+
+		// Redirect status code and Cache-Control policy, defaulting to the server's
+		RedirectStatus int    `json:"redirect_status,omitempty"`
+		CachePolicy    string `json:"cache_policy,omitempty"`
+
+		// Optional verified custom domain to create the link on
+		Domain string `json:"domain,omitempty"`
+
+		// How the ID is generated without a custom slug, defaulting to the server's
+		SlugStrategy string `json:"slug_strategy,omitempty"`
+
+		// Return the user's existing link to the destination instead of creating one
+		ReuseExisting bool `json:"reuse_existing,omitempty"`
+
+		// Title shown on the preview page, and whether visitors always see it
+		Title   string `json:"title,omitempty"`
+		Preview bool   `json:"preview,omitempty"`
+
+		// What social media sites and chat apps show when the link is shared
+		SocialTitle       string `json:"social_title,omitempty"`
+		SocialDescription string `json:"social_description,omitempty"`
+		SocialImageURL    string `json:"social_image_url,omitempty"`
 	}
 
 	// Check if this is a form submission or API request
@@ -57,6 +111,15 @@ func (h *API) ShortenURL(w http.ResponseWriter, r *http.Request) {
 		req.URL = r.FormValue("url")
 		req.CustomSlug = r.FormValue("custom_slug")
 		req.Password = r.FormValue("password")
+		req.ExpiryAction = r.FormValue("expiry_action")
+		req.ExpiryRedirectURL = r.FormValue("expiry_redirect_url")
+		req.ExpiryMessage = r.FormValue("expiry_message")
+		req.QueryMode = r.FormValue("query_mode")
+		req.PathPassthrough = r.FormValue("path_passthrough") == "on"
+		req.SlugStrategy = r.FormValue("slug_strategy")
+		req.ReuseExisting = r.FormValue("reuse_existing") == "on"
+		req.Title = r.FormValue("title")
+		req.Preview = r.FormValue("preview") == "on"
 
 		// Parse expiration time from form
 		expirationValue := r.FormValue("expiration_value")
@@ -103,15 +166,51 @@ func (h *API) ShortenURL(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Shorten the URL with optional password
-	response, err := h.shortenerService.Shorten(r.Context(), req.URL, userID, req.CustomSlug, expiresIn, req.Password)
+	response, err := h.shortenerService.ShortenWithOptions(r.Context(), req.URL, userID, &services.ShortenOptions{
+		CustomSlug:        req.CustomSlug,
+		ExpiresIn:         expiresIn,
+		Password:          req.Password,
+		ExpiryAction:      req.ExpiryAction,
+		ExpiryRedirectURL: req.ExpiryRedirectURL,
+		ExpiryMessage:     req.ExpiryMessage,
+		Destinations:      req.Destinations,
+		StickyRotation:    req.StickyRotation,
+		QueryMode:         req.QueryMode,
+		PathPassthrough:   req.PathPassthrough,
+		CampaignID:        req.CampaignID,
+		Channel:           req.Channel,
+		RedirectStatus:    req.RedirectStatus,
+		CachePolicy:       req.CachePolicy,
+		Domain:            req.Domain,
+		SlugStrategy:      req.SlugStrategy,
+		ReuseExisting:     req.ReuseExisting,
+		Title:             req.Title,
+		Preview:           req.Preview,
+		SocialTitle:       req.SocialTitle,
+		SocialDescription: req.SocialDescription,
+		SocialImageURL:    req.SocialImageURL,
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, services.ErrInvalidURL):
 			http.Error(w, "Invalid URL", http.StatusBadRequest)
-		case errors.Is(err, services.ErrInvalidSlug):
+		case errors.Is(err, services.ErrInvalidSlug), errors.Is(err, services.ErrSlugNotAllowed), errors.Is(err, services.ErrInvalidExpiry), errors.Is(err, services.ErrInvalidDestinations),
+			errors.Is(err, services.ErrInvalidQueryMode), errors.Is(err, services.ErrInvalidRedirectStatus), errors.Is(err, services.ErrInvalidCachePolicy),
+			errors.Is(err, services.ErrInvalidSlugStrategy), errors.Is(err, services.ErrConfusableSlug),
+			errors.Is(err, services.ErrDestinationBlocked), errors.Is(err, services.ErrTitleTooLong),
+			errors.Is(err, services.ErrSocialTitleTooLong), errors.Is(err, services.ErrSocialDescriptionTooLong), errors.Is(err, services.ErrInvalidSocialImage):
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		case errors.Is(err, services.ErrSlugUnavailable):
 			http.Error(w, "Custom slug is already in use", http.StatusConflict)
+		case errors.Is(err, repository.ErrNotFound):
+			http.Error(w, "Campaign not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrChannelNotFound), errors.Is(err, services.ErrCampaignUnavailable):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidDomain), errors.Is(err, services.ErrUnknownDomain),
+			errors.Is(err, services.ErrDomainNotVerified), errors.Is(err, services.ErrDomainUnavailable):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrNotCampaignOwner), errors.Is(err, services.ErrNotDomainOwner):
+			http.Error(w, err.Error(), http.StatusForbidden)
 		default:
 			http.Error(w, "Failed to shorten URL", http.StatusInternalServerError)
 		}
@@ -137,9 +236,13 @@ func (h *API) ShortenURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return the response
+	// Return the response, which is an existing link if one was reused
+	status := http.StatusCreated
+	if response.Existing {
+		status = http.StatusOK
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -149,10 +252,30 @@ func (h *API) RedirectURL(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	// Try to get the URL without checking password to see if it exists and if it's password protected
-	url, err := h.shortenerService.GetWithoutPassword(r.Context(), id)
+	// A + after the short code asks for the link's preview page
+	extraPath := vars["rest"]
+	previewRequested := extraPath == "" && strings.HasSuffix(id, "+")
+	if previewRequested {
+		id = strings.TrimSuffix(id, "+")
+	}
+
+	// Look the URL up even if it has expired, so its expiry action can be applied.
+	// On a custom domain the path is the slug on that domain rather than the ID.
+	// On the default domain the path may also be one of the link's aliases.
+	var url *models.URL
+	var alias string
+	var err error
+	if domain := middleware.GetDomainFromContext(r.Context()); domain != nil {
+		url, err = h.shortenerService.GetByDomainSlug(r.Context(), domain.Hostname, id)
+	} else {
+		url, err = h.shortenerService.GetIncludingExpired(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
+			url, err = h.shortenerService.GetByAlias(r.Context(), id)
+			alias = id
+		}
+	}
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) || errors.Is(err, services.ErrURLExpired) {
+		if errors.Is(err, repository.ErrNotFound) {
 			http.Error(w, "URL not found or has expired", http.StatusNotFound)
 			return
 		}
@@ -160,25 +283,795 @@ func (h *API) RedirectURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Trailing path segments are only accepted by links that forward them
+	if extraPath != "" && !url.PathPassthrough {
+		http.Error(w, "URL not found or has expired", http.StatusNotFound)
+		return
+	}
+
+	if url.HasExpired() {
+		h.handleExpiredURL(w, r, url)
+		return
+	}
+
+	// Links whose destination was blocked by screening or an admin don't redirect
+	if url.IsBlocked() {
+		http.Error(w, "This link has been disabled", http.StatusGone)
+		return
+	}
+
 	// Check if the URL is password protected
 	if url.IsPasswordProtected() {
+		// The password check must happen on every visit, so nothing may cache the redirect
+		w.Header().Set("Cache-Control", "no-store")
+
 		// Check if password is in session - simulating a checked password
-		session, _ := h.GetPasswordSession(r, id)
+		session, _ := h.GetPasswordSession(r, url.ID)
 		if !session {
-			// Redirect to password entry form
-			http.Redirect(w, r, "/password/"+id, http.StatusFound)
+			// Redirect to password entry form, which is only served on the default domain
+			http.Redirect(w, r, h.shortenerService.PasswordURL(url), http.StatusFound)
 			return
 		}
 	}
 
-	// Increment visit count
-	if err := h.shortenerService.IncrementVisitCount(r.Context(), url); err != nil {
-		// Log error but continue with redirect
-		// You might want to implement proper logging here
+	// Social media sites and chat apps fetching the link get its social card
+	// instead of following the redirect to the destination's own tags.
+	// Fetching the card doesn't count as a visit.
+	if url.HasSocialCard() {
+		w.Header().Add("Vary", "User-Agent")
+		if r.Method != http.MethodPost && services.IsSocialCrawler(r.UserAgent()) {
+			h.renderSocialCard(w, r, url, extraPath)
+			return
+		}
+	}
+
+	// Show the preview page without counting a visit. Its continue button
+	// posts back to the short link, which then redirects as usual.
+	if (previewRequested || url.Preview) && r.Method != http.MethodPost {
+		h.renderPreview(w, r, url, extraPath)
+		return
+	}
+
+	// Warn visitors about destinations that matched a screening check, unless
+	// they continued from the preview page, which showed the warning already.
+	// Like the preview, the warning is shown without counting a visit, and
+	// its continue button posts back to the short link.
+	if url.ShowsWarning() && r.Method != http.MethodPost {
+		h.renderWarning(w, r, url, extraPath)
+		return
+	}
+
+	// Tell bots apart from people, so bots don't inflate the visit counts
+	click := models.NewClick(r.Referer(), r.UserAgent())
+	click.Alias = alias
+	h.shortenerService.ClassifyClick(click, r.Header)
+	if err := h.shortenerService.IdentifyVisitor(r.Context(), click, clientIP(r, h.trustProxyHeaders)); err != nil {
+		// Count the click without a visitor, leaving it out of the unique visitors
+	}
+
+	// Pick the destination, counting a person's visit against its variant
+	destination := h.chooseDestination(w, r, url, r.Method != http.MethodHead && !click.Bot)
+
+	// Forward the trailing path and query parameters
+	destination, err = services.ForwardRequest(url, destination, extraPath, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Failed to build destination URL", http.StatusInternalServerError)
+		return
+	}
+
+	// Count the click, unless this is a HEAD request from a link checker
+	if r.Method != http.MethodHead {
+		click.Destination = destination
+		if err := h.shortenerService.CountClick(r.Context(), url, click); err != nil {
+			// Log error but continue with redirect
+			// You might want to implement proper logging here
+		}
+	}
+
+	// A redirect answering a post must be 303, so the destination is fetched with GET
+	if r.Method == http.MethodPost {
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, destination, http.StatusSeeOther)
+		return
+	}
+
+	// Redirect to the destination with the URL's status code and caching headers
+	policy := h.redirectPolicy.ForURL(url)
+	if w.Header().Get("Cache-Control") == "" {
+		shared := len(url.TargetingRules) == 0 && !url.IsRotating()
+		w.Header().Set("Cache-Control", policy.CacheControl(shared, url.ExpiresAt))
+	}
+	http.Redirect(w, r, destination, policy.Status)
+}
+
+// chooseDestination picks where a visit to an unexpired URL goes: the first
+// matching targeting rule, then a weighted destination variant, then the
+// original URL. If count is set, the visit is counted against the variant.
+func (h *API) chooseDestination(w http.ResponseWriter, r *http.Request, url *models.URL, count bool) string {
+	if len(url.TargetingRules) == 0 && !url.IsRotating() {
+		return url.OriginalURL
+	}
+
+	if len(url.TargetingRules) > 0 {
+		w.Header().Set("Vary", "User-Agent, Accept-Language")
+
+		info := h.targetingService.ClientInfo(r.UserAgent(), r.Header.Get("Accept-Language"), clientIP(r, h.trustProxyHeaders))
+		if rule := h.targetingService.MatchingRule(url, info); rule != nil {
+			return rule.Destination
+		}
+	}
+
+	if !url.IsRotating() {
+		return url.OriginalURL
+	}
+
+	// Keep returning visitors on their variant if the URL is sticky
+	cookieName := variantCookiePrefix + url.ID
+	var previousVariantID string
+	if cookie, err := r.Cookie(cookieName); err == nil {
+		previousVariantID = cookie.Value
+	}
+
+	variant := services.ChooseDestination(url, previousVariantID)
+	if count {
+		if err := h.shortenerService.CountDestinationClick(r.Context(), url, variant.ID); err != nil {
+			// Redirect anyway; only the variant's click count is lost
+		}
+	}
+
+	if url.StickyRotation && variant.ID != previousVariantID {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookieName,
+			Value:    variant.ID,
+			Path:     "/" + mux.Vars(r)["id"],
+			MaxAge:   variantCookieMaxAge,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	return variant.URL
+}
+
+// renderWarning shows the warning page for a link whose destinations matched
+// a screening check, with a button continuing to the destination
+func (h *API) renderWarning(w http.ResponseWriter, r *http.Request, url *models.URL, extraPath string) {
+	// Show where the visitor would go, without counting the visit yet
+	destination, err := services.ForwardRequest(url, h.chooseDestination(w, r, url, false), extraPath, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Failed to build destination URL", http.StatusInternalServerError)
+		return
+	}
+
+	// The continue button posts to the short link itself, keeping the
+	// trailing path and query forwarded to the destination
+	continueURL := r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		continueURL += "?" + r.URL.RawQuery
 	}
 
-	// Redirect to the original URL
-	http.Redirect(w, r, url.OriginalURL, http.StatusFound)
+	data := struct {
+		ID          string
+		Destination string
+		Reason      string
+		ContinueURL string
+		CSRFToken   string
+	}{
+		ID:          url.ID,
+		Destination: destination,
+		Reason:      url.ScreeningReason,
+		ContinueURL: continueURL,
+		CSRFToken:   csrf.Token(r),
+	}
+
+	// The warning must be shown on every visit, so nothing may cache it
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(w, "warning.html", data); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// renderPreview shows where a link goes, with a button continuing to the destination
+func (h *API) renderPreview(w http.ResponseWriter, r *http.Request, url *models.URL, extraPath string) {
+	preview, err := h.shortenerService.PreviewLink(url, extraPath, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Failed to build destination URL", http.StatusInternalServerError)
+		return
+	}
+
+	// The continue button posts to the short link itself, keeping the
+	// trailing path and query forwarded to the destination
+	continueURL := strings.TrimSuffix(r.URL.EscapedPath(), "+")
+	if r.URL.RawQuery != "" {
+		continueURL += "?" + r.URL.RawQuery
+	}
+
+	data := struct {
+		*services.LinkPreview
+		ContinueURL string
+		CSRFToken   string
+	}{
+		LinkPreview: preview,
+		ContinueURL: continueURL,
+		CSRFToken:   csrf.Token(r),
+	}
+
+	// The preview reflects the link's current settings, so nothing may cache it
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(w, "preview.html", data); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// renderSocialCard shows crawlers a page with the Open Graph and Twitter card tags of a link
+func (h *API) renderSocialCard(w http.ResponseWriter, r *http.Request, url *models.URL, extraPath string) {
+	card, err := h.shortenerService.SocialCard(url, extraPath, r.URL.Query())
+	if err != nil {
+		http.Error(w, "Failed to build destination URL", http.StatusInternalServerError)
+		return
+	}
+
+	// The card reflects the link's current settings, so nothing may cache it
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(w, "social_card.html", card); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleExpiredURL responds to a visit to an expired URL according to its expiry action
+func (h *API) handleExpiredURL(w http.ResponseWriter, r *http.Request, url *models.URL) {
+	// The owner may renew the link or change its expiry action, so nothing may
+	// cache the response
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch url.GetExpiryAction() {
+	case models.ExpiryActionRedirect:
+		http.Redirect(w, r, url.ExpiryRedirectURL, http.StatusFound)
+	case models.ExpiryActionPage:
+		message := url.ExpiryMessage
+		if message == "" {
+			message = "This link has expired."
+		}
+
+		data := struct {
+			ID        string
+			Message   string
+			ExpiredAt *time.Time
+		}{
+			ID:        url.ID,
+			Message:   message,
+			ExpiredAt: url.ExpiresAt,
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusGone)
+		if err := h.templates.ExecuteTemplate(w, "expired.html", data); err != nil {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+	case models.ExpiryActionGone:
+		http.Error(w, "This link has expired", http.StatusGone)
+	default:
+		http.Error(w, "URL not found or has expired", http.StatusNotFound)
+	}
+}
+
+// UpdateExpirySettings handles the request to change a URL's expiry action
+func (h *API) UpdateExpirySettings(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// Parse the request
+	var req struct {
+		ExpiryAction      string `json:"expiry_action"`
+		ExpiryRedirectURL string `json:"expiry_redirect_url,omitempty"`
+		ExpiryMessage     string `json:"expiry_message,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.UpdateExpirySettings(r.Context(), id, user.ID, req.ExpiryAction, req.ExpiryRedirectURL, req.ExpiryMessage)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateTargetingRules handles the request to replace a URL's targeting rules
+func (h *API) UpdateTargetingRules(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// Parse the request
+	var req struct {
+		Rules []models.TargetingRule `json:"rules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.SetTargetingRules(r.Context(), id, user.ID, req.Rules)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateDestinations handles the request to replace a URL's weighted destinations
+func (h *API) UpdateDestinations(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// Parse the request
+	var req struct {
+		Destinations   []models.Destination `json:"destinations"`
+		StickyRotation bool                 `json:"sticky_rotation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.SetDestinations(r.Context(), id, user.ID, req.Destinations, req.StickyRotation)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// PromoteDestination handles the request to end a URL's rotation with one variant as the sole destination
+func (h *API) PromoteDestination(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	response, err := h.shortenerService.PromoteDestination(r.Context(), vars["id"], user.ID, vars["variant"])
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateForwardingSettings handles the request to change how a URL forwards query parameters and paths
+func (h *API) UpdateForwardingSettings(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// Parse the request
+	var req struct {
+		QueryMode       string `json:"query_mode"`
+		PathPassthrough bool   `json:"path_passthrough"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.UpdateForwardingSettings(r.Context(), id, user.ID, req.QueryMode, req.PathPassthrough)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateRedirectSettings handles the request to change a URL's redirect status code and cache policy
+func (h *API) UpdateRedirectSettings(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// Parse the request
+	var req struct {
+		RedirectStatus int    `json:"redirect_status"`
+		CachePolicy    string `json:"cache_policy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.UpdateRedirectSettings(r.Context(), id, user.ID, req.RedirectStatus, req.CachePolicy)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdatePreviewSettings handles the request to change a URL's title and preview mode
+func (h *API) UpdatePreviewSettings(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// Parse the request
+	var req struct {
+		Title   string `json:"title"`
+		Preview bool   `json:"preview"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.UpdatePreviewSettings(r.Context(), id, user.ID, req.Title, req.Preview)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateSocialCard handles the request to change what social media sites and chat apps show for a URL
+func (h *API) UpdateSocialCard(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// Parse the request
+	var req struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		ImageURL    string `json:"image_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.UpdateSocialCard(r.Context(), id, user.ID, req.Title, req.Description, req.ImageURL)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetHealth handles the request to get a URL's health and its latest destination checks
+func (h *API) GetHealth(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	url, err := h.shortenerService.GetOwned(r.Context(), id, user.ID)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	checks, err := h.shortenerService.ListHealthChecks(r.Context(), id, user.ID)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	response := struct {
+		Status    string                `json:"status"`
+		Failures  int                   `json:"failures"`
+		CheckedAt *time.Time            `json:"checked_at,omitempty"`
+		Checks    []*models.HealthCheck `json:"checks"`
+	}{
+		Status:    url.HealthStatus,
+		Failures:  url.HealthFailures,
+		CheckedAt: url.HealthCheckedAt,
+		Checks:    checks,
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListClicks handles the request to get a URL's visit counts and its latest
+// clicks. Clicks from bots are left out unless include_bots is true.
+func (h *API) ListClicks(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	url, err := h.shortenerService.GetOwned(r.Context(), id, user.ID)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	includeBots, _ := strconv.ParseBool(r.URL.Query().Get("include_bots"))
+	clicks, err := h.shortenerService.ListClicks(r.Context(), id, user.ID, includeBots)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	response := struct {
+		Visits    int             `json:"visits"`
+		BotVisits int             `json:"bot_visits"`
+		Clicks    []*models.Click `json:"clicks"`
+	}{
+		Visits:    url.Visits,
+		BotVisits: url.BotVisits,
+		Clicks:    clicks,
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetVisitors handles the request to get a URL's visits by people and its
+// unique visitors, in total and on each of the latest days
+func (h *API) GetVisitors(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	stats, err := h.shortenerService.VisitorStats(r.Context(), id, user.ID, days)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// GetAnalytics handles the request for a URL's rolled up analytics by hour or day
+func (h *API) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	query := r.URL.Query()
+	days, _ := strconv.Atoi(query.Get("days"))
+	report, err := h.shortenerService.AnalyticsReport(r.Context(), id, user.ID, query.Get("granularity"), days)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// CheckHealth handles the request to check a URL's destinations now
+func (h *API) CheckHealth(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	response, err := h.shortenerService.CheckHealth(r.Context(), mux.Vars(r)["id"], user.ID)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListAliases handles the request to list a URL's aliases with their visit counts
+func (h *API) ListAliases(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	aliases, err := h.shortenerService.ListAliases(r.Context(), mux.Vars(r)["id"], user.ID)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aliases)
+}
+
+// AddAlias handles the request to add an alias to a URL
+func (h *API) AddAlias(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the request
+	var req struct {
+		Alias string `json:"alias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	alias, err := h.shortenerService.AddAlias(r.Context(), mux.Vars(r)["id"], user.ID, req.Alias)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(alias)
+}
+
+// RemoveAlias handles the request to remove an alias from a URL
+func (h *API) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := h.shortenerService.RemoveAlias(r.Context(), vars["id"], user.ID, vars["alias"]); err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetCanonicalAlias handles the request to choose the alias used in a URL's short URL
+func (h *API) SetCanonicalAlias(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the request
+	var req struct {
+		Alias string `json:"alias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.shortenerService.SetCanonicalAlias(r.Context(), mux.Vars(r)["id"], user.ID, req.Alias)
+	if err != nil {
+		h.writeURLError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeURLError writes the HTTP error matching an error from a URL update
+func (h *API) writeURLError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "URL not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrVariantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrNotURLOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidURL):
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+	case errors.Is(err, services.ErrSlugUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrHealthChecksDisabled), errors.Is(err, services.ErrRollupsDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrInvalidExpiry), errors.Is(err, services.ErrInvalidTargetingRule),
+		errors.Is(err, services.ErrInvalidDestinations), errors.Is(err, services.ErrInvalidQueryMode),
+		errors.Is(err, services.ErrInvalidRedirectStatus), errors.Is(err, services.ErrInvalidCachePolicy),
+		errors.Is(err, services.ErrInvalidSlug), errors.Is(err, services.ErrSlugNotAllowed),
+		errors.Is(err, services.ErrAliasIsID), errors.Is(err, services.ErrConfusableSlug),
+		errors.Is(err, services.ErrDestinationBlocked), errors.Is(err, services.ErrTitleTooLong),
+		errors.Is(err, services.ErrSocialTitleTooLong), errors.Is(err, services.ErrSocialDescriptionTooLong), errors.Is(err, services.ErrInvalidSocialImage),
+		errors.Is(err, services.ErrInvalidGranularity):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to update URL", http.StatusInternalServerError)
+	}
 }
 
 // GetPasswordSession checks if a password session exists for the URL
@@ -274,4 +1167,24 @@ func (h *API) ListURLs(w http.ResponseWriter, r *http.Request) {
 	// Return the response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(urls)
-}
\ No newline at end of file
+}
+
+// clientIP returns the IP address of the client. The X-Forwarded-For and
+// X-Real-IP headers are only used when the app runs behind a trusted proxy.
+func clientIP(r *http.Request, trustProxyHeaders bool) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			// The first address is the original client
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
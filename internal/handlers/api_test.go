@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// Browsers send languages, so the bot filter takes a request with this user
+// agent and header to come from a person
+const testBrowser = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+// testTemplates stand in for the pages RedirectURL renders, naming the page in its body
+var testTemplates = template.Must(template.New("").Parse(
+	`{{define "preview.html"}}preview{{end}}` +
+		`{{define "warning.html"}}warning{{end}}` +
+		`{{define "social_card.html"}}social card{{end}}` +
+		`{{define "expired.html"}}expired{{end}}`))
+
+// newTestRouter routes short links to an API handler backed by a memory
+// repository, which is returned for tests to store links in and inspect.
+// geoIP may be nil, in which case targeting rules by country never match.
+func newTestRouter(geoIP services.GeoIPResolver) (*mux.Router, *repository.MemoryRepository) {
+	repo := repository.NewMemoryRepository()
+	shortener := services.NewShortenerService(repo, "http://localhost:8080", 6)
+	shortener.SetAnalyticsService(services.NewAnalyticsService(repository.NewMemoryClickRepository(), services.NewBotFilter(true), false))
+	policy := services.RedirectPolicy{Status: http.StatusFound, CachePolicy: models.CachePolicyAuto, CacheMaxAge: time.Hour}
+	api := NewAPI(shortener, services.NewTargetingService(geoIP), testTemplates, false, policy)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{id}", api.RedirectURL).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+	router.HandleFunc("/{id}/{rest:.*}", api.RedirectURL).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+	return router, repo
+}
+
+func TestRedirectURL(t *testing.T) {
+	ctx := context.Background()
+	router, repo := newTestRouter(nil)
+
+	// A link with a social card whose destination matched a screening check
+	card := models.NewURL("card", "https://example.com/launch", nil, nil)
+	card.SocialTitle = "Launch day"
+	card.ScreeningStatus = models.ScreeningStatusWarn
+	card.ScreeningReason = "bit.ly is a URL shortener"
+	plain := models.NewURL("plain", "https://example.com", nil, nil)
+	for _, url := range []*models.URL{card, plain} {
+		if err := repo.Store(ctx, url); err != nil {
+			t.Fatalf("Failed to store URL: %v", err)
+		}
+	}
+
+	// Each request is checked against the page or redirect it gets and the
+	// visit counts of the link afterwards, so the order of the cases matters
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		userAgent string
+		status    int
+		body      string
+		location  string
+		visits    int
+		botVisits int
+	}{
+		{"preview", http.MethodGet, "/card+", testBrowser, http.StatusOK, "preview", "", 0, 0},
+		{"crawler asking for the preview", http.MethodGet, "/card+", "facebookexternalhit/1.1", http.StatusOK, "social card", "", 0, 0},
+		{"crawler", http.MethodGet, "/card", "Slackbot-LinkExpanding 1.0", http.StatusOK, "social card", "", 0, 0},
+		{"warning", http.MethodGet, "/card", testBrowser, http.StatusOK, "warning", "", 0, 0},
+		{"bot shown the warning", http.MethodGet, "/card", "curl/8.4.0", http.StatusOK, "warning", "", 0, 0},
+		{"continue from the warning or preview", http.MethodPost, "/card", testBrowser, http.StatusSeeOther, "", "https://example.com/launch", 1, 0},
+		{"bot continuing", http.MethodPost, "/card", "curl/8.4.0", http.StatusSeeOther, "", "https://example.com/launch", 1, 1},
+		{"link checker", http.MethodHead, "/card", "curl/8.4.0", http.StatusOK, "", "", 1, 1},
+		{"crawler without a social card", http.MethodGet, "/plain", "facebookexternalhit/1.1", http.StatusFound, "", "https://example.com", 0, 1},
+		{"person", http.MethodGet, "/plain", testBrowser, http.StatusFound, "", "https://example.com", 1, 1},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("User-Agent", tt.userAgent)
+		if tt.userAgent == testBrowser {
+			req.Header.Set("Accept-Language", "en-GB,en;q=0.9")
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, rec.Code)
+		}
+		if tt.body != "" && strings.TrimSpace(rec.Body.String()) != tt.body {
+			t.Errorf("%s: expected the %s page, got %q", tt.name, tt.body, rec.Body.String())
+		}
+		if location := rec.Header().Get("Location"); location != tt.location {
+			t.Errorf("%s: expected location %q, got %q", tt.name, tt.location, location)
+		}
+
+		id := strings.TrimSuffix(strings.TrimPrefix(tt.path, "/"), "+")
+		stored, _ := repo.GetByID(ctx, id)
+		if stored.Visits != tt.visits || stored.BotVisits != tt.botVisits {
+			t.Errorf("%s: expected %d visits and %d bot visits, got %d and %d", tt.name, tt.visits, tt.botVisits, stored.Visits, stored.BotVisits)
+		}
+	}
+
+	// Blocked links don't redirect, not even to show a crawler the social card
+	card.ScreeningStatus = models.ScreeningStatusBlocked
+	if err := repo.Update(ctx, card); err != nil {
+		t.Fatalf("Failed to update URL: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/card", nil)
+	req.Header.Set("User-Agent", "facebookexternalhit/1.1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected a blocked link to be gone, got %d", rec.Code)
+	}
+
+	// Expired links respond according to their expiry action, and nothing may
+	// cache the response, so renewing the link takes effect at once
+	expiredAt := time.Now().Add(-time.Hour)
+	expired := models.NewURL("expired", "https://example.com", nil, &expiredAt)
+	expired.ExpiryRedirectURL = "https://example.com/fallback"
+	if err := repo.Store(ctx, expired); err != nil {
+		t.Fatalf("Failed to store URL: %v", err)
+	}
+	for _, tt := range []struct {
+		action   string
+		status   int
+		location string
+	}{
+		{models.ExpiryActionRedirect, http.StatusFound, "https://example.com/fallback"},
+		{models.ExpiryActionPage, http.StatusGone, ""},
+		{models.ExpiryActionGone, http.StatusGone, ""},
+	} {
+		expired.ExpiryAction = tt.action
+		if err := repo.Update(ctx, expired); err != nil {
+			t.Fatalf("Failed to update URL: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/expired", nil)
+		req.Header.Set("User-Agent", testBrowser)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%s: expected status %d to %q, got %d to %q", tt.action, tt.status, tt.location, rec.Code, rec.Header().Get("Location"))
+		}
+		if cacheControl := rec.Header().Get("Cache-Control"); cacheControl != "no-store" {
+			t.Errorf("%s: expected the response not to be cached, got %q", tt.action, cacheControl)
+		}
+	}
+}
+
+func TestRedirectURLTargeting(t *testing.T) {
+	ctx := context.Background()
+	geoIP, err := services.ParseGeoIPDatabase(strings.NewReader("ip_start,ip_end,country\n10.0.0.0,10.0.0.255,DE\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse GeoIP database: %v", err)
+	}
+	router, repo := newTestRouter(geoIP)
+
+	// A link sending apps to their stores and Germany to its own site, and
+	// splitting everyone else between two sticky variants
+	app := models.NewURL("app", "https://example.com", nil, nil)
+	app.TargetingRules = []models.TargetingRule{
+		{OS: models.OSIOS, Destination: "https://apps.apple.com/app/example"},
+		{OS: models.OSAndroid, Destination: "https://play.google.com/store/apps/details?id=example"},
+		{Country: "DE", Destination: "https://example.de"},
+	}
+	app.Destinations = []models.Destination{
+		{ID: "a", URL: "https://example.com/a", Weight: 1},
+		{ID: "b", URL: "https://example.com/b", Weight: 1},
+	}
+	app.StickyRotation = true
+	if err := repo.Store(ctx, app); err != nil {
+		t.Fatalf("Failed to store URL: %v", err)
+	}
+
+	const iPhone = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148 Safari/604.1"
+	const android = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"
+	tests := []struct {
+		name      string
+		userAgent string
+		ip        string
+		variant   string
+		location  string
+	}{
+		{"iPhone in Germany", iPhone, "10.0.0.7", "", "https://apps.apple.com/app/example"},
+		{"Android", android, "192.0.2.1", "", "https://play.google.com/store/apps/details?id=example"},
+		{"desktop in Germany", testBrowser, "10.0.0.7", "", "https://example.de"},
+		{"returning visitor", testBrowser, "192.0.2.1", "b", "https://example.com/b"},
+		{"new visitor", testBrowser, "192.0.2.1", "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/app", nil)
+		req.RemoteAddr = tt.ip + ":1234"
+		req.Header.Set("User-Agent", tt.userAgent)
+		req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+		if tt.variant != "" {
+			req.AddCookie(&http.Cookie{Name: variantCookiePrefix + "app", Value: tt.variant})
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		location := rec.Header().Get("Location")
+		if tt.location != "" && location != tt.location {
+			t.Errorf("%s: expected location %q, got %q", tt.name, tt.location, location)
+		}
+		// The destination depends on headers, so caches must tell visitors apart
+		if vary := rec.Header().Get("Vary"); vary != "User-Agent, Accept-Language" {
+			t.Errorf("%s: expected to vary on the targeted headers, got %q", tt.name, vary)
+		}
+
+		// Only visitors newly assigned a variant get the sticky cookie
+		cookies := rec.Result().Cookies()
+		if tt.location != "" {
+			if len(cookies) != 0 {
+				t.Errorf("%s: expected no variant cookie, got %v", tt.name, cookies)
+			}
+			continue
+		}
+		if len(cookies) != 1 || cookies[0].Name != variantCookiePrefix+"app" || location != "https://example.com/"+cookies[0].Value {
+			t.Errorf("%s: expected a cookie with the variant redirected to, got %v to %q", tt.name, cookies, location)
+		}
+	}
+}
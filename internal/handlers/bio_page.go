@@ -19,12 +19,14 @@ import (
 
 // BioPage handles bio page requests
 type BioPage struct {
-	bioPageService *services.BioPageService
-	templates      *template.Template
+	bioPageService    *services.BioPageService
+	templates         *template.Template
+	trustProxyHeaders bool
+	redirectPolicy    services.RedirectPolicy
 }
 
 // NewBioPage creates a new bio page handler
-func NewBioPage(bioPageService *services.BioPageService, templatesDir string) (*BioPage, error) {
+func NewBioPage(bioPageService *services.BioPageService, templatesDir string, trustProxyHeaders bool, redirectPolicy services.RedirectPolicy) (*BioPage, error) {
 	// Create a new template with functions
 	tmpl := template.New("")
 
@@ -54,8 +56,10 @@ func NewBioPage(bioPageService *services.BioPageService, templatesDir string) (*
 	}
 
 	return &BioPage{
-		bioPageService: bioPageService,
-		templates:      templates,
+		bioPageService:    bioPageService,
+		templates:         templates,
+		trustProxyHeaders: trustProxyHeaders,
+		redirectPolicy:    redirectPolicy,
 	}, nil
 }
 
@@ -102,15 +106,17 @@ func (h *BioPage) CreateBioPageForm(w http.ResponseWriter, r *http.Request) {
 
 	// Render the template
 	data := struct {
-		User      *models.User
-		Error     string
-		CSRFToken string
-		Themes    []string
+		User         *models.User
+		Error        string
+		CSRFToken    string
+		Themes       []string
+		UnicodeSlugs bool
 	}{
-		User:      user,
-		Error:     r.URL.Query().Get("error"),
-		CSRFToken: csrf.Token(r),
-		Themes:    models.BioPageThemes,
+		User:         user,
+		Error:        r.URL.Query().Get("error"),
+		CSRFToken:    csrf.Token(r),
+		Themes:       models.BioPageThemes,
+		UnicodeSlugs: h.bioPageService.SlugPolicy().Unicode,
 	}
 
 	h.renderTemplate(w, "create_bio_page.html", data)
@@ -147,8 +153,12 @@ func (h *BioPage) CreateBioPage(w http.ResponseWriter, r *http.Request) {
 		switch err {
 		case services.ErrInvalidSlug:
 			http.Redirect(w, r, "/bio/create?error=Invalid short code format", http.StatusSeeOther)
+		case services.ErrConfusableSlug:
+			http.Redirect(w, r, "/bio/create?error=Short code mixes alphabets or uses look-alike characters", http.StatusSeeOther)
 		case services.ErrSlugUnavailable:
 			http.Redirect(w, r, "/bio/create?error=Short code is already in use", http.StatusSeeOther)
+		case services.ErrSlugNotAllowed:
+			http.Redirect(w, r, "/bio/create?error=Short code is reserved", http.StatusSeeOther)
 		default:
 			http.Redirect(w, r, "/bio/create?error=Failed to create bio page", http.StatusSeeOther)
 		}
@@ -189,10 +199,18 @@ func (h *BioPage) EditBioPageForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Count the page's unique visitors, by day for the last week
+	visitors, err := h.bioPageService.VisitorStats(r.Context(), bioPage, 7)
+	if err != nil {
+		h.renderError(w, "Failed to count visitors", http.StatusInternalServerError)
+		return
+	}
+
 	// Render the template
 	data := struct {
 		User      *models.User
 		BioPage   *models.BioPageResponse
+		Visitors  *models.VisitorStats
 		Error     string
 		Success   string
 		CSRFToken string
@@ -200,6 +218,7 @@ func (h *BioPage) EditBioPageForm(w http.ResponseWriter, r *http.Request) {
 	}{
 		User:      user,
 		BioPage:   bioPage,
+		Visitors:  visitors,
 		Error:     r.URL.Query().Get("error"),
 		Success:   r.URL.Query().Get("success"),
 		CSRFToken: csrf.Token(r),
@@ -549,6 +568,100 @@ func (h *BioPage) ReorderBioLinks(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
 }
 
+// GetVisitorsAPI returns a bio page's views by people and its unique
+// visitors, in total and on each of the latest days
+func (h *BioPage) GetVisitorsAPI(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the bio page ID from the URL
+	bioPageID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid bio page ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get the bio page to check ownership
+	bioPage, err := h.bioPageService.GetBioPage(r.Context(), bioPageID)
+	if err != nil {
+		http.Error(w, "Bio page not found", http.StatusNotFound)
+		return
+	}
+	if bioPage.UserID != user.ID {
+		http.Error(w, "You don't have permission to view this bio page", http.StatusForbidden)
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	stats, err := h.bioPageService.VisitorStats(r.Context(), bioPage, days)
+	if err != nil {
+		http.Error(w, "Failed to count visitors", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// GetLinkAnalyticsAPI handles the request for a bio link's rolled up analytics by hour or day
+func (h *BioPage) GetLinkAnalyticsAPI(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get the bio link ID from the URL
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid bio link ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get the bio page ID for this link
+	bioPageID, err := h.bioPageService.GetBioPageIDForLink(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Bio link not found", http.StatusNotFound)
+		return
+	}
+
+	// Get the bio page to check ownership
+	bioPage, err := h.bioPageService.GetBioPage(r.Context(), bioPageID)
+	if err != nil {
+		http.Error(w, "Bio page not found", http.StatusNotFound)
+		return
+	}
+	if bioPage.UserID != user.ID {
+		http.Error(w, "You don't have permission to view this bio link", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	days, _ := strconv.Atoi(query.Get("days"))
+	report, err := h.bioPageService.LinkAnalyticsReport(r.Context(), bioPageID, id, query.Get("granularity"), days)
+	switch {
+	case errors.Is(err, services.ErrInvalidGranularity):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrRollupsDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, "Failed to build the analytics report", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
 // ViewBioPage displays the public bio page
 func (h *BioPage) ViewBioPage(w http.ResponseWriter, r *http.Request) {
 	// Get the bio page short code from the URL
@@ -590,10 +703,15 @@ func (h *BioPage) ViewBioPage(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("Successfully retrieved bio page: ID=%d, Title=%s, Links=%d\n",
 		bioPage.ID, bioPage.Title, len(bioPage.Links))
 
-	// Increment the visit count
-	err = h.bioPageService.IncrementBioPageVisits(r.Context(), bioPage.ID)
+	// Count the view. Views from bots are recorded without being counted.
+	click := models.NewClick(r.Referer(), r.UserAgent())
+	h.bioPageService.ClassifyClick(click, r.Header)
+	if err := h.bioPageService.IdentifyVisitor(r.Context(), click, clientIP(r, h.trustProxyHeaders)); err != nil {
+		fmt.Printf("Warning: Failed to identify visitor: %v\n", err)
+	}
+	err = h.bioPageService.CountView(r.Context(), bioPage, click)
 	if err != nil {
-		fmt.Printf("Warning: Failed to increment visit count: %v\n", err)
+		fmt.Printf("Warning: Failed to count view: %v\n", err)
 		// Continue anyway - this is not critical
 	}
 
@@ -663,15 +781,25 @@ func (h *BioPage) RedirectBioLink(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("Redirecting to URL: %s\n", bioLink.URL)
 
-	// Increment the visit count
-	err = h.bioPageService.IncrementBioLinkVisits(r.Context(), id)
-	if err != nil {
-		fmt.Printf("Error incrementing bio link visit count: %v\n", err)
-		// Log the error but continue with the request
+	// Count the click, unless this is a HEAD request from a link checker.
+	// Clicks from bots are recorded without being counted.
+	if r.Method != http.MethodHead {
+		click := models.NewClick(r.Referer(), r.UserAgent())
+		h.bioPageService.ClassifyClick(click, r.Header)
+		if err := h.bioPageService.IdentifyVisitor(r.Context(), click, clientIP(r, h.trustProxyHeaders)); err != nil {
+			fmt.Printf("Error identifying visitor: %v\n", err)
+		}
+		err = h.bioPageService.CountLinkClick(r.Context(), id, click)
+		if err != nil {
+			fmt.Printf("Error counting bio link click: %v\n", err)
+			// Log the error but continue with the request
+		}
 	}
 
-	// Redirect to the URL
-	http.Redirect(w, r, bioLink.URL, http.StatusFound)
+	// Redirect to the URL with the default status code. Every click is counted
+	// and the owner may edit the link, so nothing may cache the redirect.
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, bioLink.URL, h.redirectPolicy.Status)
 }
 
 // renderTemplate renders a template
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/mux"
+)
+
+func TestRedirectBioLink(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryBioPageRepository()
+	page := models.NewBioPage(1, "me", "Me")
+	if err := repo.CreateBioPage(ctx, page); err != nil {
+		t.Fatalf("Failed to create bio page: %v", err)
+	}
+	link := models.NewBioLink(page.ID, "Site", "https://example.com", 0)
+	if err := repo.CreateBioLink(ctx, link); err != nil {
+		t.Fatalf("Failed to create bio link: %v", err)
+	}
+
+	// Even with a permanent default redirect cached publicly, bio link
+	// redirects are never cached, so every click is counted
+	policy := services.RedirectPolicy{Status: http.StatusMovedPermanently, CachePolicy: models.CachePolicyAuto, CacheMaxAge: time.Hour}
+	handler, err := NewBioPage(services.NewBioPageService(repo, "http://localhost:8080"), "../../templates", false, policy)
+	if err != nil {
+		t.Fatalf("Failed to create bio page handler: %v", err)
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/b/link/{id:[0-9]+}", handler.RedirectBioLink).Methods(http.MethodGet, http.MethodHead)
+
+	for i := 1; i <= 2; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/b/link/"+strconv.Itoa(link.ID), nil))
+
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://example.com" {
+			t.Errorf("Expected a permanent redirect to the link, got %d to %q", rec.Code, rec.Header().Get("Location"))
+		}
+		if cacheControl := rec.Header().Get("Cache-Control"); cacheControl != "no-store" {
+			t.Errorf("Expected the redirect not to be cached, got %q", cacheControl)
+		}
+		if stored, _ := repo.GetBioLinkByID(ctx, link.ID); stored.Visits != i {
+			t.Errorf("Expected %d visits, got %d", i, stored.Visits)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/middleware"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// Campaign handles campaign requests
+type Campaign struct {
+	campaignService *services.CampaignService
+	templates       *template.Template
+}
+
+// NewCampaign creates a new campaign handler
+func NewCampaign(campaignService *services.CampaignService, templatesDir string) (*Campaign, error) {
+	// Parse templates with the custom template functions
+	templates, err := template.New("").Funcs(GetTemplateFuncs()).ParseGlob(filepath.Join(templatesDir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Campaign{
+		campaignService: campaignService,
+		templates:       templates,
+	}, nil
+}
+
+// ListCampaigns displays the user's campaigns with their link and click counts
+func (h *Campaign) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	stats, err := h.campaignService.Stats(r.Context(), user.ID)
+	if err != nil {
+		h.renderError(w, "Failed to list campaigns", http.StatusInternalServerError)
+		return
+	}
+
+	// Render the template
+	data := struct {
+		User      *models.User
+		Campaigns []*services.CampaignStats
+		Error     string
+		Success   string
+		CSRFToken string
+	}{
+		User:      user,
+		Campaigns: stats,
+		Error:     r.URL.Query().Get("error"),
+		Success:   r.URL.Query().Get("success"),
+		CSRFToken: csrf.Token(r),
+	}
+
+	h.renderTemplate(w, "campaigns.html", data)
+}
+
+// CreateCampaign handles the creation of a new campaign
+func (h *Campaign) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, "/dashboard/campaigns?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	campaign, err := h.campaignService.CreateCampaign(r.Context(), user.ID, r.FormValue("name"), r.FormValue("utm_campaign"))
+	if err != nil {
+		h.redirectCampaignError(w, r, "/dashboard/campaigns", err)
+		return
+	}
+
+	// Redirect to the campaign page to add channels
+	http.Redirect(w, r, campaignPath(campaign.ID)+"?success=Campaign created", http.StatusSeeOther)
+}
+
+// ViewCampaign displays a campaign with its channels
+func (h *Campaign) ViewCampaign(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	campaign, err := h.campaignService.GetCampaign(r.Context(), id, user.ID)
+	if err != nil {
+		h.renderCampaignError(w, err)
+		return
+	}
+
+	// Find the campaign's stats
+	var campaignStats *services.CampaignStats
+	stats, err := h.campaignService.Stats(r.Context(), user.ID)
+	if err != nil {
+		h.renderError(w, "Failed to get campaign stats", http.StatusInternalServerError)
+		return
+	}
+	for _, s := range stats {
+		if s.Campaign.ID == campaign.ID {
+			campaignStats = s
+		}
+	}
+
+	// Render the template
+	data := struct {
+		User      *models.User
+		Campaign  *models.Campaign
+		Stats     *services.CampaignStats
+		Error     string
+		Success   string
+		CSRFToken string
+	}{
+		User:      user,
+		Campaign:  campaign,
+		Stats:     campaignStats,
+		Error:     r.URL.Query().Get("error"),
+		Success:   r.URL.Query().Get("success"),
+		CSRFToken: csrf.Token(r),
+	}
+
+	h.renderTemplate(w, "campaign.html", data)
+}
+
+// UpdateCampaign handles changing a campaign's name and utm_campaign value
+func (h *Campaign) UpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, campaignPath(id)+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	if _, err := h.campaignService.UpdateCampaign(r.Context(), id, user.ID, r.FormValue("name"), r.FormValue("utm_campaign")); err != nil {
+		h.redirectCampaignError(w, r, campaignPath(id), err)
+		return
+	}
+
+	// Redirect back to the campaign page with success message
+	http.Redirect(w, r, campaignPath(id)+"?success=Campaign updated", http.StatusSeeOther)
+}
+
+// DeleteCampaign handles deleting a campaign
+func (h *Campaign) DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := h.campaignService.DeleteCampaign(r.Context(), id, user.ID); err != nil {
+		h.redirectCampaignError(w, r, campaignPath(id), err)
+		return
+	}
+
+	// Redirect to the campaign list with success message
+	http.Redirect(w, r, "/dashboard/campaigns?success=Campaign deleted", http.StatusSeeOther)
+}
+
+// AddChannel handles adding a channel to a campaign
+func (h *Campaign) AddChannel(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, campaignPath(id)+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	channel := models.CampaignChannel{
+		Name:    r.FormValue("name"),
+		Source:  r.FormValue("source"),
+		Medium:  r.FormValue("medium"),
+		Content: r.FormValue("content"),
+		Term:    r.FormValue("term"),
+	}
+	if _, err := h.campaignService.AddChannel(r.Context(), id, user.ID, channel); err != nil {
+		h.redirectCampaignError(w, r, campaignPath(id), err)
+		return
+	}
+
+	// Redirect back to the campaign page with success message
+	http.Redirect(w, r, campaignPath(id)+"?success=Channel added", http.StatusSeeOther)
+}
+
+// RemoveChannel handles removing a channel from a campaign
+func (h *Campaign) RemoveChannel(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.Atoi(vars["id"])
+	if _, err := h.campaignService.RemoveChannel(r.Context(), id, user.ID, vars["channel"]); err != nil {
+		h.redirectCampaignError(w, r, campaignPath(id), err)
+		return
+	}
+
+	// Redirect back to the campaign page with success message
+	http.Redirect(w, r, campaignPath(id)+"?success=Channel removed", http.StatusSeeOther)
+}
+
+// ListCampaignsAPI returns the user's campaigns with their link and click counts as JSON
+func (h *Campaign) ListCampaignsAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	stats, err := h.campaignService.Stats(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to list campaigns", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// CreateCampaignAPI creates a campaign with its channels from JSON
+func (h *Campaign) CreateCampaignAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the request
+	var req struct {
+		Name        string                   `json:"name"`
+		UTMCampaign string                   `json:"utm_campaign,omitempty"`
+		Channels    []models.CampaignChannel `json:"channels,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := h.campaignService.CreateCampaign(r.Context(), user.ID, req.Name, req.UTMCampaign)
+	if err != nil {
+		h.writeCampaignError(w, err)
+		return
+	}
+
+	for _, channel := range req.Channels {
+		campaign, err = h.campaignService.AddChannel(r.Context(), campaign.ID, user.ID, channel)
+		if err != nil {
+			h.writeCampaignError(w, err)
+			return
+		}
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(campaign)
+}
+
+// writeCampaignError writes the HTTP error matching an error from a campaign operation
+func (h *Campaign) writeCampaignError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, services.ErrChannelNotFound):
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotCampaignOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidCampaign), errors.Is(err, services.ErrInvalidChannel), errors.Is(err, services.ErrChannelExists):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to save campaign", http.StatusInternalServerError)
+	}
+}
+
+// renderCampaignError renders the error page for a failed campaign lookup
+func (h *Campaign) renderCampaignError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.renderError(w, "Campaign not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotCampaignOwner):
+		h.renderError(w, "You don't have permission to view this campaign", http.StatusForbidden)
+	default:
+		h.renderError(w, "Failed to get campaign", http.StatusInternalServerError)
+	}
+}
+
+// redirectCampaignError redirects back to a page with a message for a failed campaign update
+func (h *Campaign) redirectCampaignError(w http.ResponseWriter, r *http.Request, path string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, services.ErrNotCampaignOwner):
+		h.renderCampaignError(w, err)
+	case errors.Is(err, services.ErrInvalidCampaign), errors.Is(err, services.ErrInvalidChannel),
+		errors.Is(err, services.ErrChannelExists), errors.Is(err, services.ErrChannelNotFound):
+		http.Redirect(w, r, path+"?error="+err.Error(), http.StatusSeeOther)
+	default:
+		http.Redirect(w, r, path+"?error=Failed to save campaign", http.StatusSeeOther)
+	}
+}
+
+// campaignPath returns the dashboard path of a campaign
+func campaignPath(id int) string {
+	return "/dashboard/campaigns/" + strconv.Itoa(id)
+}
+
+// renderTemplate renders a template
+func (h *Campaign) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// renderError renders an error page
+func (h *Campaign) renderError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	data := struct {
+		Message string
+		Status  int
+	}{
+		Message: message,
+		Status:  status,
+	}
+	if err := h.templates.ExecuteTemplate(w, "error.html", data); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -1,26 +1,32 @@
 package handlers
 
 import (
+	"errors"
 	"html/template"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/middleware"
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
 	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
 )
 
 // Dashboard handles dashboard requests
 type Dashboard struct {
 	shortenerService *services.ShortenerService
+	campaignService  *services.CampaignService
+	domainService    *services.DomainService
 	templates        *template.Template
 }
 
 // NewDashboard creates a new dashboard handler
-func NewDashboard(shortenerService *services.ShortenerService, templatesDir string) (*Dashboard, error) {
+func NewDashboard(shortenerService *services.ShortenerService, campaignService *services.CampaignService, domainService *services.DomainService, templatesDir string) (*Dashboard, error) {
 	// Create a new template with functions
 	tmpl := template.New("")
 	
@@ -35,6 +41,8 @@ func NewDashboard(shortenerService *services.ShortenerService, templatesDir stri
 
 	return &Dashboard{
 		shortenerService: shortenerService,
+		campaignService:  campaignService,
+		domainService:    domainService,
 		templates:        templates,
 	}, nil
 }
@@ -55,17 +63,70 @@ func (h *Dashboard) Home(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Count the broken links, showing only them if asked to
+	brokenLinks := 0
+	for _, url := range urls {
+		if url.HealthStatus == models.HealthStatusBroken {
+			brokenLinks++
+		}
+	}
+	showBroken := r.URL.Query().Get("health") == models.HealthStatusBroken
+	includeBots := r.URL.Query().Get("bots") == "include"
+	if showBroken {
+		broken := make([]*models.URLResponse, 0, brokenLinks)
+		for _, url := range urls {
+			if url.HealthStatus == models.HealthStatusBroken {
+				broken = append(broken, url)
+			}
+		}
+		urls = broken
+	}
+
+	// Get the user's campaigns for the shorten form
+	campaigns, err := h.campaignService.ListCampaigns(r.Context(), user.ID)
+	if err != nil {
+		h.renderError(w, "Failed to list campaigns", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the user's verified domains for the shorten form
+	allDomains, err := h.domainService.ListDomains(r.Context(), user.ID)
+	if err != nil {
+		h.renderError(w, "Failed to list domains", http.StatusInternalServerError)
+		return
+	}
+	domains := make([]*models.Domain, 0, len(allDomains))
+	for _, domain := range allDomains {
+		if domain.IsVerified() {
+			domains = append(domains, domain)
+		}
+	}
+
 	// Render the template
 	data := struct {
-		User      *models.User
-		URLs      []*models.URLResponse
-		Error     string
-		CSRFToken string
+		User           *models.User
+		URLs           []*models.URLResponse
+		BrokenLinks    int
+		ShowBroken     bool
+		IncludeBots    bool
+		Campaigns      []*models.Campaign
+		Domains        []*models.Domain
+		SlugStrategies []string
+		UnicodeSlugs   bool
+		Error          string
+		CSRFToken      string
 	}{
-		User:      user,
-		URLs:      urls,
-		Error:     r.URL.Query().Get("error"),
-		CSRFToken: csrf.Token(r),
+		User:           user,
+		URLs:           urls,
+		BrokenLinks:    brokenLinks,
+		ShowBroken:     showBroken,
+		IncludeBots:    includeBots,
+		Campaigns:      campaigns,
+		Domains:        domains,
+		SlugStrategies: services.SlugStrategies,
+		UnicodeSlugs:   h.shortenerService.SlugPolicy().Unicode,
+		Error:          r.URL.Query().Get("error"),
+		CSRFToken:      csrf.Token(r),
 	}
 
 	h.renderTemplate(w, "dashboard.html", data)
@@ -125,13 +186,47 @@ func (h *Dashboard) ShortenURL(w http.ResponseWriter, r *http.Request) {
 		expiresIn = &duration
 	}
 
+	// Parse the campaign and channel, submitted together as "campaignID:channel"
+	var campaignID *int
+	var channel string
+	if campaignChannel := r.FormValue("campaign_channel"); campaignChannel != "" {
+		idPart, channelPart, _ := strings.Cut(campaignChannel, ":")
+		id, err := strconv.Atoi(idPart)
+		if err != nil || channelPart == "" {
+			http.Redirect(w, r, "/dashboard?error=Invalid campaign", http.StatusSeeOther)
+			return
+		}
+		campaignID = &id
+		channel = channelPart
+	}
+
 	// Shorten the URL
-	_, err := h.shortenerService.Shorten(r.Context(), url, &user.ID, customSlug, expiresIn, password)
+	response, err := h.shortenerService.ShortenWithOptions(r.Context(), url, &user.ID, &services.ShortenOptions{
+		CustomSlug:        customSlug,
+		ExpiresIn:         expiresIn,
+		Password:          password,
+		ExpiryAction:      r.FormValue("expiry_action"),
+		ExpiryRedirectURL: r.FormValue("expiry_redirect_url"),
+		ExpiryMessage:     r.FormValue("expiry_message"),
+		CampaignID:        campaignID,
+		Channel:           channel,
+		Domain:            r.FormValue("domain"),
+		SlugStrategy:      r.FormValue("slug_strategy"),
+		ReuseExisting:     r.FormValue("reuse_existing") == "on",
+	})
 	if err != nil {
 		switch {
 		case err == services.ErrInvalidURL:
 			http.Redirect(w, r, "/dashboard?error=Invalid URL", http.StatusSeeOther)
-		case err == services.ErrInvalidSlug:
+		case err == services.ErrInvalidSlug, err == services.ErrConfusableSlug, err == services.ErrSlugNotAllowed, err == services.ErrInvalidExpiry,
+			err == services.ErrChannelNotFound, err == services.ErrNotCampaignOwner,
+			err == services.ErrInvalidDomain, err == services.ErrUnknownDomain,
+			err == services.ErrNotDomainOwner, err == services.ErrDomainNotVerified,
+			err == services.ErrInvalidSlugStrategy:
+			http.Redirect(w, r, "/dashboard?error="+err.Error(), http.StatusSeeOther)
+		case err == repository.ErrNotFound:
+			http.Redirect(w, r, "/dashboard?error=Campaign not found", http.StatusSeeOther)
+		case errors.Is(err, services.ErrDestinationBlocked):
 			http.Redirect(w, r, "/dashboard?error="+err.Error(), http.StatusSeeOther)
 		case err == services.ErrSlugUnavailable:
 			http.Redirect(w, r, "/dashboard?error=Custom slug is already in use", http.StatusSeeOther)
@@ -141,10 +236,586 @@ func (h *Dashboard) ShortenURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Show the existing link instead if one was reused
+	if response.Existing {
+		http.Redirect(w, r, "/dashboard/links/"+response.ID+"?success=You already have a link to this URL", http.StatusSeeOther)
+		return
+	}
+
 	// Redirect to the dashboard
 	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
 }
 
+// LinkSettings displays the settings page for one of the user's links
+func (h *Dashboard) LinkSettings(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	// Get the link, checking ownership
+	id := mux.Vars(r)["id"]
+	url, err := h.shortenerService.GetOwned(r.Context(), id, user.ID)
+	if err != nil {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	// Get the link's aliases with their visit counts
+	aliases, err := h.shortenerService.ListAliases(r.Context(), id, user.ID)
+	if err != nil {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	// Get the latest checks of the link's destinations
+	healthChecks, err := h.shortenerService.ListHealthChecks(r.Context(), id, user.ID)
+	if err != nil {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	// Get the latest clicks, showing clicks from bots only if asked to
+	includeBots := r.URL.Query().Get("bots") == "include"
+	clicks, err := h.shortenerService.ListClicks(r.Context(), id, user.ID, includeBots)
+	if err != nil {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	// Count the link's unique visitors, by day for the last week
+	visitors, err := h.shortenerService.VisitorStats(r.Context(), id, user.ID, 7)
+	if err != nil {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	// Sum the last month's rolled up clicks by source, unless rollups are off
+	report, err := h.shortenerService.AnalyticsReport(r.Context(), id, user.ID, models.RollupDay, 30)
+	if err != nil && !errors.Is(err, services.ErrRollupsDisabled) {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	// Offer two empty destination rows to start a rotation, or one to add to it
+	blankDestinations := []string{"a", "b"}
+	if url.IsRotating() {
+		blankDestinations = blankDestinations[:1]
+	}
+
+	// Render the template
+	data := struct {
+		User              *models.User
+		ID                string
+		URL               *models.URLResponse
+		Aliases           []*models.Alias
+		HealthChecks      []*models.HealthCheck
+		Clicks            []*models.Click
+		IncludeBots       bool
+		Visitors          *models.VisitorStats
+		Report            *models.AnalyticsReport
+		UnicodeSlugs      bool
+		ExpiryActions     []string
+		QueryModes        []string
+		RedirectStatuses  []int
+		CachePolicies     []string
+		Devices           []string
+		OSes              []string
+		Browsers          []string
+		BlankDestinations []string
+		Error             string
+		Success           string
+		CSRFToken         string
+	}{
+		User:              user,
+		ID:                id,
+		URL:               h.shortenerService.ToResponse(url),
+		Aliases:           aliases,
+		HealthChecks:      healthChecks,
+		Clicks:            clicks,
+		IncludeBots:       includeBots,
+		Visitors:          visitors,
+		Report:            report,
+		UnicodeSlugs:      h.shortenerService.SlugPolicy().Unicode,
+		ExpiryActions:     models.ExpiryActions,
+		QueryModes:        models.QueryModes,
+		RedirectStatuses:  models.RedirectStatuses,
+		CachePolicies:     models.CachePolicies,
+		Devices:           models.TargetingDevices,
+		OSes:              models.TargetingOSes,
+		Browsers:          models.TargetingBrowsers,
+		BlankDestinations: blankDestinations,
+		Error:             r.URL.Query().Get("error"),
+		Success:           r.URL.Query().Get("success"),
+		CSRFToken:         csrf.Token(r),
+	}
+
+	h.renderTemplate(w, "link_settings.html", data)
+}
+
+// UpdateExpirySettings handles changing what happens when an expired link is visited
+func (h *Dashboard) UpdateExpirySettings(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	_, err := h.shortenerService.UpdateExpirySettings(
+		r.Context(),
+		id,
+		user.ID,
+		r.FormValue("expiry_action"),
+		r.FormValue("expiry_redirect_url"),
+		r.FormValue("expiry_message"),
+	)
+	if err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Expiry settings updated", http.StatusSeeOther)
+}
+
+// UpdateForwardingSettings handles changing how a link forwards query parameters and paths
+func (h *Dashboard) UpdateForwardingSettings(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	_, err := h.shortenerService.UpdateForwardingSettings(
+		r.Context(),
+		id,
+		user.ID,
+		r.FormValue("query_mode"),
+		r.FormValue("path_passthrough") == "on",
+	)
+	if err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Forwarding settings updated", http.StatusSeeOther)
+}
+
+// UpdatePreviewSettings handles changing a link's title and preview mode
+func (h *Dashboard) UpdatePreviewSettings(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	_, err := h.shortenerService.UpdatePreviewSettings(r.Context(), id, user.ID, r.FormValue("title"), r.FormValue("preview") == "on")
+	if err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Preview settings updated", http.StatusSeeOther)
+}
+
+// UpdateSocialCard handles changing what social media sites and chat apps show for a link
+func (h *Dashboard) UpdateSocialCard(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	_, err := h.shortenerService.UpdateSocialCard(r.Context(), id, user.ID, r.FormValue("social_title"), r.FormValue("social_description"), r.FormValue("social_image_url"))
+	if err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Social preview updated", http.StatusSeeOther)
+}
+
+// CheckHealth handles checking a link's destinations now
+func (h *Dashboard) CheckHealth(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	response, err := h.shortenerService.CheckHealth(r.Context(), id, user.ID)
+	if err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with the outcome
+	message := "?success=The destinations responded"
+	if response.HealthFailures > 0 {
+		message = "?error=The destinations failed the check"
+	}
+	http.Redirect(w, r, settingsURL+message, http.StatusSeeOther)
+}
+
+// UpdateRedirectSettings handles changing a link's redirect status code and cache policy
+func (h *Dashboard) UpdateRedirectSettings(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	// An empty status selects the server default
+	var status int
+	if value := r.FormValue("redirect_status"); value != "" {
+		var err error
+		status, err = strconv.Atoi(value)
+		if err != nil {
+			h.redirectLinkError(w, r, settingsURL, services.ErrInvalidRedirectStatus)
+			return
+		}
+	}
+
+	_, err := h.shortenerService.UpdateRedirectSettings(r.Context(), id, user.ID, status, r.FormValue("cache_policy"))
+	if err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Redirect settings updated", http.StatusSeeOther)
+}
+
+// AddTargetingRule handles adding a targeting rule to the end of a link's rules
+func (h *Dashboard) AddTargetingRule(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	// Get the link, checking ownership
+	url, err := h.shortenerService.GetOwned(r.Context(), id, user.ID)
+	if err != nil {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	rule := models.TargetingRule{
+		Device:      r.FormValue("device"),
+		OS:          r.FormValue("os"),
+		Browser:     r.FormValue("browser"),
+		Language:    r.FormValue("language"),
+		Country:     r.FormValue("country"),
+		Destination: r.FormValue("destination"),
+	}
+	rules := append(url.TargetingRules, rule)
+
+	if _, err := h.shortenerService.SetTargetingRules(r.Context(), id, user.ID, rules); err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Targeting rule added", http.StatusSeeOther)
+}
+
+// DeleteTargetingRule handles removing one of a link's targeting rules
+func (h *Dashboard) DeleteTargetingRule(w http.ResponseWriter, r *http.Request) {
+	h.changeTargetingRules(w, r, "Targeting rule deleted", func(rules []models.TargetingRule, index int) []models.TargetingRule {
+		return append(rules[:index:index], rules[index+1:]...)
+	})
+}
+
+// MoveTargetingRuleUp handles moving one of a link's targeting rules before the previous one
+func (h *Dashboard) MoveTargetingRuleUp(w http.ResponseWriter, r *http.Request) {
+	h.changeTargetingRules(w, r, "Targeting rule moved", func(rules []models.TargetingRule, index int) []models.TargetingRule {
+		if index > 0 {
+			rules[index-1], rules[index] = rules[index], rules[index-1]
+		}
+		return rules
+	})
+}
+
+// changeTargetingRules applies change to the rule at the {index} path variable and saves the result
+func (h *Dashboard) changeTargetingRules(w http.ResponseWriter, r *http.Request, success string, change func([]models.TargetingRule, int) []models.TargetingRule) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Get the link, checking ownership
+	url, err := h.shortenerService.GetOwned(r.Context(), id, user.ID)
+	if err != nil {
+		h.renderLinkError(w, err)
+		return
+	}
+
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil || index < 0 || index >= len(url.TargetingRules) {
+		http.Redirect(w, r, settingsURL+"?error=Targeting rule not found", http.StatusSeeOther)
+		return
+	}
+
+	rules := change(url.TargetingRules, index)
+	if _, err := h.shortenerService.SetTargetingRules(r.Context(), id, user.ID, rules); err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success="+success, http.StatusSeeOther)
+}
+
+// UpdateDestinations handles replacing the weighted destinations of a link
+func (h *Dashboard) UpdateDestinations(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	// Each destination is a pair of destination_url and destination_weight fields; blank rows are skipped
+	urls := r.Form["destination_url"]
+	weights := r.Form["destination_weight"]
+	destinations := make([]models.Destination, 0, len(urls))
+	for i, destinationURL := range urls {
+		if strings.TrimSpace(destinationURL) == "" {
+			continue
+		}
+
+		weight := 1
+		if i < len(weights) && weights[i] != "" {
+			parsed, err := strconv.Atoi(weights[i])
+			if err != nil {
+				http.Redirect(w, r, settingsURL+"?error=Invalid weight", http.StatusSeeOther)
+				return
+			}
+			weight = parsed
+		}
+
+		destinations = append(destinations, models.Destination{URL: destinationURL, Weight: weight})
+	}
+
+	sticky := r.FormValue("sticky_rotation") == "on"
+	if _, err := h.shortenerService.SetDestinations(r.Context(), id, user.ID, destinations, sticky); err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Destinations updated", http.StatusSeeOther)
+}
+
+// PromoteDestination handles ending a link's rotation with one variant as the sole destination
+func (h *Dashboard) PromoteDestination(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	vars := mux.Vars(r)
+	settingsURL := "/dashboard/links/" + vars["id"]
+
+	if _, err := h.shortenerService.PromoteDestination(r.Context(), vars["id"], user.ID, vars["variant"]); err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Destination promoted", http.StatusSeeOther)
+}
+
+// AddAlias handles adding an alias to a link
+func (h *Dashboard) AddAlias(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	settingsURL := "/dashboard/links/" + id
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, settingsURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	if _, err := h.shortenerService.AddAlias(r.Context(), id, user.ID, r.FormValue("alias")); err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Alias added", http.StatusSeeOther)
+}
+
+// RemoveAlias handles removing an alias from a link
+func (h *Dashboard) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	vars := mux.Vars(r)
+	settingsURL := "/dashboard/links/" + vars["id"]
+
+	if err := h.shortenerService.RemoveAlias(r.Context(), vars["id"], user.ID, vars["alias"]); err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Alias removed", http.StatusSeeOther)
+}
+
+// SetCanonicalAlias handles choosing the alias used in a link's short URL
+func (h *Dashboard) SetCanonicalAlias(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	vars := mux.Vars(r)
+	settingsURL := "/dashboard/links/" + vars["id"]
+
+	if _, err := h.shortenerService.SetCanonicalAlias(r.Context(), vars["id"], user.ID, vars["alias"]); err != nil {
+		h.redirectLinkError(w, r, settingsURL, err)
+		return
+	}
+
+	// Redirect back to the settings page with success message
+	http.Redirect(w, r, settingsURL+"?success=Short URL updated", http.StatusSeeOther)
+}
+
+// renderLinkError renders the error page for a failed link lookup
+func (h *Dashboard) renderLinkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.renderError(w, "Link not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotURLOwner):
+		h.renderError(w, "You don't have permission to edit this link", http.StatusForbidden)
+	default:
+		h.renderError(w, "Failed to get link", http.StatusInternalServerError)
+	}
+}
+
+// redirectLinkError redirects back to a link's settings page with a message for a failed update
+func (h *Dashboard) redirectLinkError(w http.ResponseWriter, r *http.Request, settingsURL string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, services.ErrNotURLOwner):
+		h.renderLinkError(w, err)
+	case errors.Is(err, services.ErrInvalidURL):
+		http.Redirect(w, r, settingsURL+"?error=Invalid URL", http.StatusSeeOther)
+	case errors.Is(err, services.ErrInvalidExpiry), errors.Is(err, services.ErrInvalidTargetingRule),
+		errors.Is(err, services.ErrInvalidDestinations), errors.Is(err, services.ErrVariantNotFound),
+		errors.Is(err, services.ErrInvalidQueryMode), errors.Is(err, services.ErrInvalidRedirectStatus),
+		errors.Is(err, services.ErrInvalidCachePolicy), errors.Is(err, services.ErrInvalidSlug),
+		errors.Is(err, services.ErrSlugUnavailable), errors.Is(err, services.ErrSlugNotAllowed),
+		errors.Is(err, services.ErrAliasIsID), errors.Is(err, services.ErrConfusableSlug),
+		errors.Is(err, services.ErrDestinationBlocked), errors.Is(err, services.ErrTitleTooLong),
+		errors.Is(err, services.ErrSocialTitleTooLong), errors.Is(err, services.ErrSocialDescriptionTooLong), errors.Is(err, services.ErrInvalidSocialImage),
+		errors.Is(err, services.ErrHealthChecksDisabled):
+		http.Redirect(w, r, settingsURL+"?error="+err.Error(), http.StatusSeeOther)
+	default:
+		http.Redirect(w, r, settingsURL+"?error=Failed to update link", http.StatusSeeOther)
+	}
+}
+
 // renderTemplate renders a template
 func (h *Dashboard) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
 	w.Header().Set("Content-Type", "text/html")
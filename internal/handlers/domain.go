@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/middleware"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// Domain handles custom domain requests
+type Domain struct {
+	domainService *services.DomainService
+	templates     *template.Template
+}
+
+// NewDomain creates a new domain handler
+func NewDomain(domainService *services.DomainService, templatesDir string) (*Domain, error) {
+	// Parse templates with the custom template functions
+	templates, err := template.New("").Funcs(GetTemplateFuncs()).ParseGlob(filepath.Join(templatesDir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Domain{
+		domainService: domainService,
+		templates:     templates,
+	}, nil
+}
+
+// ListDomains displays the user's domains with their verification records
+func (h *Domain) ListDomains(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	domains, err := h.domainService.ListDomains(r.Context(), user.ID)
+	if err != nil {
+		h.renderError(w, "Failed to list domains", http.StatusInternalServerError)
+		return
+	}
+
+	// Render the template
+	data := struct {
+		User      *models.User
+		Domains   []*models.Domain
+		Error     string
+		Success   string
+		CSRFToken string
+	}{
+		User:      user,
+		Domains:   domains,
+		Error:     r.URL.Query().Get("error"),
+		Success:   r.URL.Query().Get("success"),
+		CSRFToken: csrf.Token(r),
+	}
+
+	h.renderTemplate(w, "domains.html", data)
+}
+
+// AddDomain handles registering a new custom domain
+func (h *Domain) AddDomain(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, "/dashboard/domains?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	if _, err := h.domainService.AddDomain(r.Context(), user.ID, r.FormValue("hostname")); err != nil {
+		h.redirectDomainError(w, r, err)
+		return
+	}
+
+	// Redirect back to the domains page, which shows the TXT record to add
+	http.Redirect(w, r, "/dashboard/domains?success=Domain added. Add the TXT record below, then verify the domain", http.StatusSeeOther)
+}
+
+// VerifyDomain handles checking a domain's verification TXT record
+func (h *Domain) VerifyDomain(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if _, err := h.domainService.VerifyDomain(r.Context(), id, user.ID); err != nil {
+		h.redirectDomainError(w, r, err)
+		return
+	}
+
+	// Redirect back to the domains page with success message
+	http.Redirect(w, r, "/dashboard/domains?success=Domain verified", http.StatusSeeOther)
+}
+
+// DeleteDomain handles removing a custom domain
+func (h *Domain) DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := h.domainService.DeleteDomain(r.Context(), id, user.ID); err != nil {
+		h.redirectDomainError(w, r, err)
+		return
+	}
+
+	// Redirect back to the domains page with success message
+	http.Redirect(w, r, "/dashboard/domains?success=Domain removed", http.StatusSeeOther)
+}
+
+// ListDomainsAPI returns the user's domains as JSON
+func (h *Domain) ListDomainsAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	domains, err := h.domainService.ListDomains(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to list domains", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domains)
+}
+
+// AddDomainAPI registers a new custom domain from JSON
+func (h *Domain) AddDomainAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the request
+	var req struct {
+		Hostname string `json:"hostname"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	domain, err := h.domainService.AddDomain(r.Context(), user.ID, req.Hostname)
+	if err != nil {
+		h.writeDomainError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(domain)
+}
+
+// VerifyDomainAPI checks a domain's verification TXT record
+func (h *Domain) VerifyDomainAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	domain, err := h.domainService.VerifyDomain(r.Context(), id, user.ID)
+	if err != nil {
+		h.writeDomainError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain)
+}
+
+// writeDomainError writes the HTTP error matching an error from a domain operation
+func (h *Domain) writeDomainError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Domain not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotDomainOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repository.ErrDomainTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidDomain), errors.Is(err, services.ErrDomainVerificationFailed):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to save domain", http.StatusInternalServerError)
+	}
+}
+
+// redirectDomainError redirects back to the domains page with a message for a failed domain operation
+func (h *Domain) redirectDomainError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.renderError(w, "Domain not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotDomainOwner):
+		h.renderError(w, "You don't have permission to manage this domain", http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidDomain), errors.Is(err, repository.ErrDomainTaken),
+		errors.Is(err, services.ErrDomainVerificationFailed):
+		http.Redirect(w, r, "/dashboard/domains?error="+err.Error(), http.StatusSeeOther)
+	default:
+		http.Redirect(w, r, "/dashboard/domains?error=Failed to save domain", http.StatusSeeOther)
+	}
+}
+
+// renderTemplate renders a template
+func (h *Domain) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// renderError renders an error page
+func (h *Domain) renderError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	data := struct {
+		Message string
+		Status  int
+	}{
+		Message: message,
+		Status:  status,
+	}
+	if err := h.templates.ExecuteTemplate(w, "error.html", data); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
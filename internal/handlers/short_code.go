@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// ShortCode serves root-level short codes in a unified namespace, where a
+// short code names either a link or a bio page
+type ShortCode struct {
+	registry       *services.SlugRegistry
+	apiHandler     *API
+	bioPageHandler *BioPage
+}
+
+// NewShortCode creates a new short code handler
+func NewShortCode(registry *services.SlugRegistry, apiHandler *API, bioPageHandler *BioPage) *ShortCode {
+	return &ShortCode{
+		registry:       registry,
+		apiHandler:     apiHandler,
+		bioPageHandler: bioPageHandler,
+	}
+}
+
+// Resolve shows the bio page a short code names, or redirects to its link
+func (h *ShortCode) Resolve(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	// Codes missing from the registry are left to the link redirect, which
+	// renders the not found page
+	kind, err := h.registry.Resolve(r.Context(), id)
+	if err == nil && kind == models.SlugKindBioPage {
+		h.bioPageHandler.ViewBioPage(w, mux.SetURLVars(r, map[string]string{"shortCode": id}))
+		return
+	}
+
+	h.apiHandler.RedirectURL(w, r)
+}
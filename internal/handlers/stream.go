@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/middleware"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+)
+
+// Timings of the click stream
+const (
+	// streamKeepAlive is how often an idle stream sends a comment, so proxies
+	// don't close the connection
+	streamKeepAlive = 25 * time.Second
+	// streamRetry is how long browsers wait before reconnecting a dropped stream
+	streamRetry = 3 * time.Second
+)
+
+// Stream handles the live click stream
+type Stream struct {
+	clickStream *services.ClickStream
+	templates   *template.Template
+}
+
+// NewStream creates a new stream handler
+func NewStream(clickStream *services.ClickStream, templatesDir string) (*Stream, error) {
+	// Parse templates with the custom template functions
+	templates, err := template.New("").Funcs(GetTemplateFuncs()).ParseGlob(filepath.Join(templatesDir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stream{
+		clickStream: clickStream,
+		templates:   templates,
+	}, nil
+}
+
+// LiveView displays the user's clicks as they happen
+func (h *Stream) LiveView(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	// Render the template
+	data := struct {
+		User *models.User
+	}{
+		User: user,
+	}
+
+	h.renderTemplate(w, "live.html", data)
+}
+
+// Clicks streams the user's click events as Server-Sent Events until the
+// client disconnects or the server shuts down
+func (h *Stream) Clicks(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe, err := h.clickStream.Subscribe(user.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyStreams) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, "Click stream is unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	// Stop nginx buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// The server is shutting down; clients reconnect after the retry delay
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// renderTemplate renders a template
+func (h *Stream) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"fmt"
 	"html/template"
+	"net/http"
 	"strings"
 	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
 )
 
 // GetTemplateFuncs returns a FuncMap of custom template functions
@@ -45,6 +50,101 @@ func GetTemplateFuncs() template.FuncMap {
 			}
 			return time.Now().After(*t)
 		},
+		"expiryActionLabel": func(action string) string {
+			switch action {
+			case models.ExpiryActionRedirect:
+				return "Redirect to a fallback URL"
+			case models.ExpiryActionPage:
+				return "Show the expired page with a custom message"
+			case models.ExpiryActionGone:
+				return "Respond with 410 Gone"
+			default:
+				return "Respond with 404 Not Found"
+			}
+		},
+		"queryModeLabel": func(mode string) string {
+			switch mode {
+			case models.QueryModeMergeKeep:
+				return "Add them, keeping the destination's value on conflict"
+			case models.QueryModeMergeOverride:
+				return "Add them, replacing the destination's value on conflict"
+			case models.QueryModeMergeAppend:
+				return "Add them, keeping both values on conflict"
+			default:
+				return "Drop them"
+			}
+		},
+		"redirectStatusLabel": func(status int) string {
+			switch status {
+			case http.StatusMovedPermanently:
+				return "301 Moved Permanently"
+			case http.StatusFound:
+				return "302 Found"
+			case http.StatusTemporaryRedirect:
+				return "307 Temporary Redirect"
+			case http.StatusPermanentRedirect:
+				return "308 Permanent Redirect"
+			default:
+				return "Server default"
+			}
+		},
+		"cachePolicyLabel": func(policy string) string {
+			switch policy {
+			case models.CachePolicyAuto:
+				return "Cache permanent redirects only"
+			case models.CachePolicyNoStore:
+				return "Never cache, so every visit is counted"
+			case models.CachePolicyPrivate:
+				return "Let browsers cache the redirect"
+			case models.CachePolicyPublic:
+				return "Let browsers and CDNs cache the redirect"
+			default:
+				return "Server default"
+			}
+		},
+		"slugStrategyLabel": func(strategy string) string {
+			switch strategy {
+			case services.SlugStrategyRandom:
+				return "Random characters"
+			case services.SlugStrategyCounter:
+				return "Short sequential code"
+			case services.SlugStrategyWords:
+				return "Readable words, like brave-green-otter"
+			case services.SlugStrategyUnambiguous:
+				return "Lowercase, easy to read aloud"
+			default:
+				return "Server default"
+			}
+		},
+		"botReasonLabel": func(reason string) string {
+			switch reason {
+			case models.BotReasonNoUserAgent:
+				return "no user agent"
+			case models.BotReasonCrawler:
+				return "crawler"
+			case models.BotReasonAutomation:
+				return "script or tool"
+			case models.BotReasonPrefetch:
+				return "prefetch"
+			case models.BotReasonHeaders:
+				return "not a browser"
+			default:
+				return "bot"
+			}
+		},
+		"percent": func(part, total int) string {
+			if total <= 0 {
+				return "0%"
+			}
+			return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+		},
+		"destinationClicks": func(destinations []models.Destination) int {
+			total := 0
+			for _, d := range destinations {
+				total += d.Clicks
+			}
+			return total
+		},
 		"title": func(s string) string {
 			words := strings.Fields(s)
 			for i, word := range words {
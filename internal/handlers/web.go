@@ -56,15 +56,17 @@ func (h *Web) Home(w http.ResponseWriter, r *http.Request) {
 
 	// Render the template
 	data := struct {
-		URLs      []*models.URLResponse
-		Error     string
-		User      *models.User
-		CSRFToken string
+		URLs         []*models.URLResponse
+		Error        string
+		User         *models.User
+		CSRFToken    string
+		UnicodeSlugs bool
 	}{
-		URLs:      urls,
-		Error:     r.URL.Query().Get("error"),
-		User:      user,
-		CSRFToken: csrf.Token(r),
+		URLs:         urls,
+		Error:        r.URL.Query().Get("error"),
+		User:         user,
+		CSRFToken:    csrf.Token(r),
+		UnicodeSlugs: h.shortenerService.SlugPolicy().Unicode,
 	}
 
 	h.renderTemplate(w, "home.html", data)
@@ -130,7 +132,9 @@ func (h *Web) ShortenURL(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case err == services.ErrInvalidURL:
 			http.Redirect(w, r, "/?error=Invalid URL", http.StatusSeeOther)
-		case err == services.ErrInvalidSlug:
+		case err == services.ErrInvalidSlug, err == services.ErrConfusableSlug, err == services.ErrSlugNotAllowed:
+			http.Redirect(w, r, "/?error="+err.Error(), http.StatusSeeOther)
+		case errors.Is(err, services.ErrDestinationBlocked):
 			http.Redirect(w, r, "/?error="+err.Error(), http.StatusSeeOther)
 		case err == services.ErrSlugUnavailable:
 			http.Redirect(w, r, "/?error=Custom slug is already in use", http.StatusSeeOther)
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/middleware"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// Webhook handles webhook requests
+type Webhook struct {
+	webhookService *services.WebhookService
+	templates      *template.Template
+}
+
+// NewWebhook creates a new webhook handler
+func NewWebhook(webhookService *services.WebhookService, templatesDir string) (*Webhook, error) {
+	// Parse templates with the custom template functions
+	templates, err := template.New("").Funcs(GetTemplateFuncs()).ParseGlob(filepath.Join(templatesDir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Webhook{
+		webhookService: webhookService,
+		templates:      templates,
+	}, nil
+}
+
+// ListWebhooks displays the user's webhooks with a form to add one
+func (h *Webhook) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(r.Context(), user.ID)
+	if err != nil {
+		h.renderError(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	// Render the template
+	data := struct {
+		User      *models.User
+		Webhooks  []*models.Webhook
+		Events    []string
+		Error     string
+		Success   string
+		CSRFToken string
+	}{
+		User:      user,
+		Webhooks:  webhooks,
+		Events:    models.WebhookEvents,
+		Error:     r.URL.Query().Get("error"),
+		Success:   r.URL.Query().Get("success"),
+		CSRFToken: csrf.Token(r),
+	}
+
+	h.renderTemplate(w, "webhooks.html", data)
+}
+
+// CreateWebhook handles registering a new webhook
+func (h *Webhook) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, "/dashboard/webhooks?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(r.Context(), user.ID, r.FormValue("url"), r.Form["events"])
+	if err != nil {
+		h.redirectWebhookError(w, r, "/dashboard/webhooks", err)
+		return
+	}
+
+	// Redirect to the webhook, which shows its signing secret
+	http.Redirect(w, r, "/dashboard/webhooks/"+strconv.Itoa(webhook.ID)+"?success=Webhook added. Use the signing secret below to verify its deliveries", http.StatusSeeOther)
+}
+
+// ViewWebhook displays a webhook's settings and delivery log
+func (h *Webhook) ViewWebhook(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	webhook, err := h.webhookService.GetWebhook(r.Context(), id, user.ID)
+	if err != nil {
+		h.redirectWebhookError(w, r, "/dashboard/webhooks", err)
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), id, user.ID)
+	if err != nil {
+		h.renderError(w, "Failed to list deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	// Render the template
+	data := struct {
+		User       *models.User
+		Webhook    *models.Webhook
+		Deliveries []*models.WebhookDelivery
+		Events     []string
+		Error      string
+		Success    string
+		CSRFToken  string
+	}{
+		User:       user,
+		Webhook:    webhook,
+		Deliveries: deliveries,
+		Events:     models.WebhookEvents,
+		Error:      r.URL.Query().Get("error"),
+		Success:    r.URL.Query().Get("success"),
+		CSRFToken:  csrf.Token(r),
+	}
+
+	h.renderTemplate(w, "webhook.html", data)
+}
+
+// UpdateWebhook handles changing a webhook's URL and events, and pausing or resuming it
+func (h *Webhook) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	webhookURL := "/dashboard/webhooks/" + strconv.Itoa(id)
+
+	// Parse the form
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, webhookURL+"?error=Invalid form", http.StatusSeeOther)
+		return
+	}
+
+	_, err := h.webhookService.UpdateWebhook(r.Context(), id, user.ID, r.FormValue("url"), r.Form["events"], r.FormValue("active") != "")
+	if err != nil {
+		h.redirectWebhookError(w, r, webhookURL, err)
+		return
+	}
+
+	// Redirect back to the webhook with success message
+	http.Redirect(w, r, webhookURL+"?success=Webhook saved", http.StatusSeeOther)
+}
+
+// DeleteWebhook handles removing a webhook
+func (h *Webhook) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := h.webhookService.DeleteWebhook(r.Context(), id, user.ID); err != nil {
+		h.redirectWebhookError(w, r, "/dashboard/webhooks", err)
+		return
+	}
+
+	// Redirect back to the webhooks page with success message
+	http.Redirect(w, r, "/dashboard/webhooks?success=Webhook removed", http.StatusSeeOther)
+}
+
+// SendTestEvent handles sending a test event to a webhook, reporting how it answered
+func (h *Webhook) SendTestEvent(w http.ResponseWriter, r *http.Request) {
+	// Get the user from the context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Redirect(w, r, "/auth/login", http.StatusSeeOther)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	webhookURL := "/dashboard/webhooks/" + strconv.Itoa(id)
+
+	delivery, err := h.webhookService.SendTestEvent(r.Context(), id, user.ID)
+	if err != nil {
+		h.redirectWebhookError(w, r, webhookURL, err)
+		return
+	}
+
+	// Redirect back to the webhook with the outcome, which is also in the delivery log
+	if delivery.Status != models.WebhookDeliveryDelivered {
+		http.Redirect(w, r, webhookURL+"?error=Test event failed. See the delivery log below for how the endpoint answered", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, webhookURL+"?success=Test event delivered", http.StatusSeeOther)
+}
+
+// ListWebhooksAPI returns the user's webhooks as JSON
+func (h *Webhook) ListWebhooksAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// CreateWebhookAPI registers a new webhook from JSON
+func (h *Webhook) CreateWebhookAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the request
+	var req struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(r.Context(), user.ID, req.URL, req.Events)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// UpdateWebhookAPI changes a webhook from JSON. Leaving out active keeps the webhook paused or running.
+func (h *Webhook) UpdateWebhookAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse the request
+	var req struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Active *bool    `json:"active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	webhook, err := h.webhookService.GetWebhook(r.Context(), id, user.ID)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	active := webhook.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	webhook, err = h.webhookService.UpdateWebhook(r.Context(), id, user.ID, req.URL, req.Events, active)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// DeleteWebhookAPI removes a webhook
+func (h *Webhook) DeleteWebhookAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := h.webhookService.DeleteWebhook(r.Context(), id, user.ID); err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveriesAPI returns a webhook's latest deliveries as JSON
+func (h *Webhook) ListDeliveriesAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), id, user.ID)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// SendTestEventAPI sends a test event to a webhook and returns the delivery
+func (h *Webhook) SendTestEventAPI(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	delivery, err := h.webhookService.SendTestEvent(r.Context(), id, user.ID)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+
+	// Return the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// writeWebhookError writes the HTTP error matching an error from a webhook operation
+func (h *Webhook) writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotWebhookOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrTooManyWebhooks):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEvent),
+		errors.Is(err, services.ErrNoWebhookEvents):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to save webhook", http.StatusInternalServerError)
+	}
+}
+
+// redirectWebhookError redirects back to a webhooks page with a message for a failed webhook operation
+func (h *Webhook) redirectWebhookError(w http.ResponseWriter, r *http.Request, pageURL string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.renderError(w, "Webhook not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotWebhookOwner):
+		h.renderError(w, "You don't have permission to manage this webhook", http.StatusForbidden)
+	case errors.Is(err, services.ErrTooManyWebhooks), errors.Is(err, services.ErrInvalidWebhookURL),
+		errors.Is(err, services.ErrInvalidWebhookEvent), errors.Is(err, services.ErrNoWebhookEvents):
+		http.Redirect(w, r, pageURL+"?error="+err.Error(), http.StatusSeeOther)
+	default:
+		http.Redirect(w, r, pageURL+"?error=Failed to save webhook", http.StatusSeeOther)
+	}
+}
+
+// renderTemplate renders a template
+func (h *Webhook) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// renderError renders an error page
+func (h *Webhook) renderError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	data := struct {
+		Message string
+		Status  int
+	}{
+		Message: message,
+		Status:  status,
+	}
+	if err := h.templates.ExecuteTemplate(w, "error.html", data); err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// DomainContextKey is the key for the custom domain in the context
+const DomainContextKey contextKey = "domain"
+
+// DomainMiddleware recognizes requests for verified custom domains
+type DomainMiddleware struct {
+	domainService *services.DomainService
+}
+
+// NewDomainMiddleware creates a new domain middleware
+func NewDomainMiddleware(domainService *services.DomainService) *DomainMiddleware {
+	return &DomainMiddleware{
+		domainService: domainService,
+	}
+}
+
+// Domain adds the custom domain named by the Host header to the context. It
+// wraps the whole router, because route matchers run before router middleware.
+// Requests for the default domain, static assets included, need no lookup, and
+// custom domains are resolved from a short-lived cache.
+func (m *DomainMiddleware) Domain(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domain, err := m.domainService.Resolve(r.Context(), r.Host)
+		if err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), DomainContextKey, domain))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetDomainFromContext gets the custom domain from the context, or nil for the default domain
+func GetDomainFromContext(ctx context.Context) *models.Domain {
+	domain, ok := ctx.Value(DomainContextKey).(*models.Domain)
+	if !ok {
+		return nil
+	}
+	return domain
+}
+
+// MatchCustomDomain is a route matcher for requests on a custom domain
+func MatchCustomDomain(r *http.Request, _ *mux.RouteMatch) bool {
+	return GetDomainFromContext(r.Context()) != nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Alias is an extra short code pointing at a link, such as /spring-sale next
+// to the link's own ID. Visits through an alias are counted on the alias as
+// well as on the link.
+type Alias struct {
+	Alias       string    `json:"alias"`
+	URLID       string    `json:"url_id"`
+	Visits      int       `json:"visits"`
+	LastVisitAt time.Time `json:"last_visit_at,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Canonical   bool      `json:"canonical"` // Whether the link's short URL uses this alias
+}
+
+// NewAlias creates a new alias for a link
+func NewAlias(alias, urlID string) *Alias {
+	return &Alias{
+		Alias:     alias,
+		URLID:     urlID,
+		CreatedAt: time.Now(),
+	}
+}
@@ -34,6 +34,7 @@ type BioLink struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 	Visits       int       `json:"visits"`
 	IsEnabled    bool      `json:"is_enabled"`
+	Metadata     *LinkMetadata `json:"metadata,omitempty"`
 }
 
 // BioPageThemes defines the available themes
@@ -117,6 +118,7 @@ type BioLinkResponse struct {
 	Icon         string    `json:"icon,omitempty"`
 	Visits       int       `json:"visits"`
 	IsEnabled    bool      `json:"is_enabled"`
+	Metadata     *LinkMetadata `json:"metadata,omitempty"`
 }
 
 // ToBioPageResponse converts a bio page to a response
@@ -156,5 +158,6 @@ func (b *BioLink) ToBioLinkResponse() *BioLinkResponse {
 		Icon:         b.Icon,
 		Visits:       b.Visits,
 		IsEnabled:    b.IsEnabled,
+		Metadata:     b.Metadata,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"net/url"
+	"time"
+)
+
+// Campaign is a named UTM preset. Links created for a campaign are tagged with
+// its utm_campaign value plus the source and medium of one of its channels.
+type Campaign struct {
+	ID          int               `json:"id"`
+	UserID      int               `json:"user_id"`
+	Name        string            `json:"name"`
+	UTMCampaign string            `json:"utm_campaign"`
+	Channels    []CampaignChannel `json:"channels"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// CampaignChannel is a place a campaign's links are shared, such as a newsletter or a social network
+type CampaignChannel struct {
+	Name    string `json:"name"`              // Channel name used when shortening, e.g. "newsletter"
+	Source  string `json:"source"`            // utm_source value
+	Medium  string `json:"medium"`            // utm_medium value
+	Content string `json:"content,omitempty"` // Optional utm_content value
+	Term    string `json:"term,omitempty"`    // Optional utm_term value
+}
+
+// NewCampaign creates a new campaign
+func NewCampaign(userID int, name, utmCampaign string) *Campaign {
+	now := time.Now()
+	return &Campaign{
+		UserID:      userID,
+		Name:        name,
+		UTMCampaign: utmCampaign,
+		Channels:    make([]CampaignChannel, 0),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// GetChannel returns the channel with the given name, or nil
+func (c *Campaign) GetChannel(name string) *CampaignChannel {
+	for i := range c.Channels {
+		if c.Channels[i].Name == name {
+			return &c.Channels[i]
+		}
+	}
+	return nil
+}
+
+// TagURL adds the campaign's UTM parameters for a channel to a URL, replacing
+// any UTM parameters the URL already has
+func (c *Campaign) TagURL(rawURL string, channel *CampaignChannel) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsed.Query()
+	query.Set("utm_campaign", c.UTMCampaign)
+	query.Set("utm_source", channel.Source)
+	query.Set("utm_medium", channel.Medium)
+	if channel.Content != "" {
+		query.Set("utm_content", channel.Content)
+	}
+	if channel.Term != "" {
+		query.Set("utm_term", channel.Term)
+	}
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String(), nil
+}
@@ -0,0 +1,104 @@
+package models
+
+import "time"
+
+// Click event types
+const (
+	// ClickEventLink is streamed when a short link is visited
+	ClickEventLink = "link.clicked"
+	// ClickEventBioView is streamed when a bio page is viewed
+	ClickEventBioView = "bio.viewed"
+	// ClickEventBioLink is streamed when a link on a bio page is followed
+	ClickEventBioLink = "bio_link.clicked"
+)
+
+// ClickEvent is a visit to one of a user's links or bio pages, streamed to them as it happens
+type ClickEvent struct {
+	// ID numbers the events streamed since the server started
+	ID          int64     `json:"id"`
+	Type        string    `json:"type"`
+	LinkID      string    `json:"link_id,omitempty"`
+	ShortURL    string    `json:"short_url,omitempty"`
+	Alias       string    `json:"alias,omitempty"`
+	BioPageID   int       `json:"bio_page_id,omitempty"`
+	BioLinkID   int       `json:"bio_link_id,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	Referrer    string    `json:"referrer,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	Visits      int       `json:"visits,omitempty"`
+	Bot         bool      `json:"bot"`
+	BotReason   string    `json:"bot_reason,omitempty"`
+	Time        time.Time `json:"time"`
+	// UserID is the user the event is streamed to
+	UserID int `json:"-"`
+}
+
+// Reasons a click was taken to come from a bot
+const (
+	// BotReasonNoUserAgent is a request without a user agent
+	BotReasonNoUserAgent = "no_user_agent"
+	// BotReasonCrawler is a known crawler, link previewer or uptime monitor
+	BotReasonCrawler = "crawler"
+	// BotReasonAutomation is an HTTP library, command line tool or headless browser
+	BotReasonAutomation = "automation"
+	// BotReasonPrefetch is a browser prefetching the link before anyone clicked it
+	BotReasonPrefetch = "prefetch"
+	// BotReasonHeaders is a user agent claiming to be a browser without sending a browser's headers
+	BotReasonHeaders = "headers"
+)
+
+// Click is a recorded visit to a link, bio page or bio link, tagged as coming from a person or a bot
+type Click struct {
+	ID int64 `json:"id"`
+	// URLID is the visited link, empty for bio page visits
+	URLID string `json:"url_id,omitempty"`
+	// Alias is the alias the link was visited through, if any
+	Alias string `json:"alias,omitempty"`
+	// BioPageID is the visited bio page, or the page of the visited bio link
+	BioPageID int `json:"bio_page_id,omitempty"`
+	// BioLinkID is the visited bio link, zero for page views
+	BioLinkID   int    `json:"bio_link_id,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Referrer    string `json:"referrer,omitempty"`
+	UserAgent   string `json:"user_agent,omitempty"`
+	// Bot is set for clicks from bots, crawlers and prefetchers, which the visit counts leave out
+	Bot       bool   `json:"bot"`
+	BotReason string `json:"bot_reason,omitempty"`
+	// VisitorHash identifies the visitor for the day without revealing their IP address
+	VisitorHash string `json:"-"`
+	// Country is the visitor's ISO country code, empty without a GeoIP database
+	Country   string    `json:"country,omitempty"`
+	Device    string    `json:"device,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewClick creates a click made now. Its time is in UTC, so visits are
+// counted by the same days everywhere.
+func NewClick(referrer, userAgent string) *Click {
+	return &Click{
+		Referrer:  referrer,
+		UserAgent: userAgent,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// DailyVisits counts the visits by people to a link or bio page on a day
+type DailyVisits struct {
+	// Date is the day in UTC, as YYYY-MM-DD
+	Date     string `json:"date"`
+	Visits   int    `json:"visits"`
+	Visitors int    `json:"visitors"`
+}
+
+// VisitorStats counts the visits by people to a link or bio page and the
+// unique visitors who made them
+type VisitorStats struct {
+	Visits int `json:"visits"`
+	// Visitors counts unique visitors, each counted once a day
+	Visitors int `json:"visitors"`
+	// Estimated is set when Visitors is estimated from a HyperLogLog sketch
+	Estimated bool `json:"estimated"`
+	// Days counts the visits and visitors of the latest days, newest first
+	Days []*DailyVisits `json:"days"`
+}
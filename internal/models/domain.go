@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// DomainVerificationPrefix is prepended to a domain's hostname to get the name of its verification TXT record
+const DomainVerificationPrefix = "_url-shortener-verification."
+
+// Domain is a custom hostname a user serves their short links from, such as go.acme.com.
+// Links on a domain have their own slugs, so the same slug can exist on several domains.
+type Domain struct {
+	ID                int        `json:"id"`
+	UserID            int        `json:"user_id"`
+	Hostname          string     `json:"hostname"`
+	VerificationToken string     `json:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// NewDomain creates a new, unverified domain
+func NewDomain(userID int, hostname, verificationToken string) *Domain {
+	return &Domain{
+		UserID:            userID,
+		Hostname:          hostname,
+		VerificationToken: verificationToken,
+		CreatedAt:         time.Now(),
+	}
+}
+
+// IsVerified checks if the domain's DNS verification has succeeded
+func (d *Domain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// VerificationRecord returns the name of the TXT record that must contain the verification token
+func (d *Domain) VerificationRecord() string {
+	return DomainVerificationPrefix + d.Hostname
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Link health statuses
+const (
+	// HealthStatusHealthy means the link's destinations responded to the last check
+	HealthStatusHealthy = "healthy"
+	// HealthStatusBroken means the link's destinations failed several checks in a row
+	HealthStatusBroken = "broken"
+)
+
+// HealthCheck records one check of a link destination
+type HealthCheck struct {
+	ID          int       `json:"id"`
+	URLID       string    `json:"url_id"`
+	Destination string    `json:"destination"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int       `json:"duration_ms"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// Healthy reports whether the destination responded successfully
+func (c *HealthCheck) Healthy() bool {
+	return c.Error == ""
+}
+
+// IsBroken reports whether the link's destinations failed enough checks in a row to count as broken
+func (u *URL) IsBroken() bool {
+	return u.HealthStatus == HealthStatusBroken
+}
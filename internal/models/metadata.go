@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// LinkMetadata describes the page a link's destination points to, as fetched
+// in the background after the link is created
+type LinkMetadata struct {
+	// URL is the destination the metadata was fetched from
+	URL string `json:"url"`
+	// Title is the page title
+	Title string `json:"title,omitempty"`
+	// Description is the page description
+	Description string `json:"description,omitempty"`
+	// FaviconURL is the page's icon
+	FaviconURL string `json:"favicon_url,omitempty"`
+	// ImageURL is the page's Open Graph image
+	ImageURL string `json:"image_url,omitempty"`
+	// FetchedAt is when the metadata was fetched
+	FetchedAt time.Time `json:"fetched_at"`
+	// Error describes why the last fetch failed
+	Error string `json:"error,omitempty"`
+}
+
+// NeedsFetch reports whether metadata must be fetched for a destination: it
+// is missing, was fetched for another destination, or failed before retryBefore
+func (m *LinkMetadata) NeedsFetch(destination string, retryBefore time.Time) bool {
+	if m == nil || m.URL != destination {
+		return true
+	}
+	return m.Error != "" && m.FetchedAt.Before(retryBefore)
+}
+
+// Usable reports whether the metadata was fetched for the destination and describes it
+func (m *LinkMetadata) Usable(destination string) bool {
+	return m != nil && m.URL == destination && m.Error == ""
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// Rollup granularities
+const (
+	RollupHour = "hour"
+	RollupDay  = "day"
+)
+
+// Rollup breakdown dimensions
+const (
+	// BreakdownReferrer breaks clicks down by the referrer's host, empty for direct visits
+	BreakdownReferrer = "referrer"
+	// BreakdownCountry breaks clicks down by the visitor's country
+	BreakdownCountry = "country"
+	// BreakdownDevice breaks clicks down by the visitor's device
+	BreakdownDevice = "device"
+)
+
+// RollupTarget is what a rollup counts the clicks on: a link, the views of a
+// bio page, or a link on a bio page, which sets both BioPageID and BioLinkID
+type RollupTarget struct {
+	URLID     string `json:"url_id,omitempty"`
+	BioPageID int    `json:"bio_page_id,omitempty"`
+	BioLinkID int    `json:"bio_link_id,omitempty"`
+}
+
+// Target returns the rollup target of a click
+func (c *Click) Target() RollupTarget {
+	return RollupTarget{
+		URLID:     c.URLID,
+		BioPageID: c.BioPageID,
+		BioLinkID: c.BioLinkID,
+	}
+}
+
+// ClickRollup counts the clicks on a target in an hour or a day
+type ClickRollup struct {
+	RollupTarget
+	Granularity string `json:"granularity"`
+	// Bucket is the start of the hour or day, in UTC
+	Bucket time.Time `json:"bucket"`
+	// Clicks counts the clicks by people
+	Clicks    int `json:"clicks"`
+	BotClicks int `json:"bot_clicks"`
+	// Visitors counts the unique visitors in the bucket
+	Visitors int `json:"visitors"`
+}
+
+// ClickBreakdown counts the clicks by people on a target in an hour or a day
+// with one value of a dimension, such as the clicks from one country
+type ClickBreakdown struct {
+	RollupTarget
+	Granularity string    `json:"granularity"`
+	Bucket      time.Time `json:"bucket"`
+	Dimension   string    `json:"dimension"`
+	Value       string    `json:"value"`
+	Clicks      int       `json:"clicks"`
+}
+
+// AnalyticsBucket counts the clicks on a link or bio link in an hour or a day
+type AnalyticsBucket struct {
+	Start     time.Time `json:"start"`
+	Clicks    int       `json:"clicks"`
+	BotClicks int       `json:"bot_clicks"`
+	Visitors  int       `json:"visitors"`
+}
+
+// BreakdownEntry counts the clicks with one value of a breakdown dimension
+type BreakdownEntry struct {
+	Value  string `json:"value"`
+	Clicks int    `json:"clicks"`
+}
+
+// AnalyticsReport sums the rolled up clicks on a link or bio link over the
+// latest hours or days
+type AnalyticsReport struct {
+	Granularity string    `json:"granularity"`
+	Since       time.Time `json:"since"`
+	Clicks      int       `json:"clicks"`
+	BotClicks   int       `json:"bot_clicks"`
+	// Visitors sums the buckets' unique visitors, counting each visitor once a bucket
+	Visitors int `json:"visitors"`
+	// Buckets count the clicks in each hour or day, newest first
+	Buckets   []*AnalyticsBucket `json:"buckets"`
+	Referrers []*BreakdownEntry  `json:"referrers"`
+	Countries []*BreakdownEntry  `json:"countries"`
+	Devices   []*BreakdownEntry  `json:"devices"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// Destination is one variant of a link that splits its traffic across
+// several destinations by weight
+type Destination struct {
+	ID     string `json:"id"`     // Stable variant ID, used for sticky assignment
+	URL    string `json:"url"`    // Destination URL
+	Weight int    `json:"weight"` // Relative share of the traffic
+	Clicks int    `json:"clicks"` // Number of visits sent to this variant
+}
+
+// IsRotating checks if the URL splits its traffic across weighted destinations
+func (u *URL) IsRotating() bool {
+	return len(u.Destinations) > 0
+}
+
+// GetDestination returns the destination with the given variant ID, or nil
+func (u *URL) GetDestination(variantID string) *Destination {
+	for i := range u.Destinations {
+		if u.Destinations[i].ID == variantID {
+			return &u.Destinations[i]
+		}
+	}
+	return nil
+}
+
+// TotalWeight returns the sum of the weights of the URL's destinations
+func (u *URL) TotalWeight() int {
+	total := 0
+	for _, d := range u.Destinations {
+		total += d.Weight
+	}
+	return total
+}
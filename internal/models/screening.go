@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Screening actions control what happens to a link whose destination matches a screening check
+const (
+	// ScreeningActionAllow ignores the match
+	ScreeningActionAllow = "allow"
+	// ScreeningActionWarn shows visitors a warning before they continue to the destination
+	ScreeningActionWarn = "warn"
+	// ScreeningActionFlag shows the warning and queues the link for admin review
+	ScreeningActionFlag = "flag"
+	// ScreeningActionReject refuses to create the link, or blocks an existing one
+	ScreeningActionReject = "reject"
+)
+
+// ScreeningActions lists the valid screening actions, least severe first
+var ScreeningActions = []string{
+	ScreeningActionAllow,
+	ScreeningActionWarn,
+	ScreeningActionFlag,
+	ScreeningActionReject,
+}
+
+// Screening statuses record the outcome of screening a link's destinations
+const (
+	// ScreeningStatusClean means no check matched (the default)
+	ScreeningStatusClean = ""
+	// ScreeningStatusWarn shows visitors a warning before the redirect
+	ScreeningStatusWarn = "warn"
+	// ScreeningStatusFlagged shows the warning until an admin reviews the link
+	ScreeningStatusFlagged = "flagged"
+	// ScreeningStatusBlocked stops the link from redirecting
+	ScreeningStatusBlocked = "blocked"
+	// ScreeningStatusApproved means an admin reviewed the link and it redirects without a warning
+	ScreeningStatusApproved = "approved"
+)
+
+// ScreeningStatuses lists the screening statuses
+var ScreeningStatuses = []string{
+	ScreeningStatusClean,
+	ScreeningStatusWarn,
+	ScreeningStatusFlagged,
+	ScreeningStatusBlocked,
+	ScreeningStatusApproved,
+}
+
+// ScreeningResult is the outcome of screening a link's destinations
+type ScreeningResult struct {
+	// Action is the most severe action of the checks that matched
+	Action string `json:"action"`
+	// Reasons describes each match
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// SetScreening records the outcome of screening on the URL
+func (u *URL) SetScreening(status, reason string) {
+	now := time.Now()
+	u.ScreeningStatus = status
+	u.ScreeningReason = reason
+	u.ScreenedAt = &now
+}
+
+// ShowsWarning reports whether visitors see a warning before the redirect
+func (u *URL) ShowsWarning() bool {
+	return u.ScreeningStatus == ScreeningStatusWarn || u.ScreeningStatus == ScreeningStatusFlagged
+}
+
+// IsBlocked reports whether the link was blocked by screening or an admin
+func (u *URL) IsBlocked() bool {
+	return u.ScreeningStatus == ScreeningStatusBlocked
+}
+
+// DestinationURLs returns every URL a visitor could be sent to: the original
+// URL, the weighted destinations, the targeting rule destinations and the
+// expiry fallback
+func (u *URL) DestinationURLs() []string {
+	urls := []string{u.OriginalURL}
+	for _, d := range u.Destinations {
+		urls = append(urls, d.URL)
+	}
+	for _, rule := range u.TargetingRules {
+		urls = append(urls, rule.Destination)
+	}
+	if u.ExpiryRedirectURL != "" {
+		urls = append(urls, u.ExpiryRedirectURL)
+	}
+	return urls
+}
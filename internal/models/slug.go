@@ -0,0 +1,21 @@
+package models
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Kinds of resources a short code can name in the slug registry
+const (
+	SlugKindLink    = "link"
+	SlugKindBioPage = "bio_page"
+)
+
+// SlugKey returns the key a short code is stored under alongside its
+// original form: lowercased and NFC-normalized, so two short codes that only
+// differ in case or normalization have the same key. Keys are unique, and
+// case-insensitive short codes are looked up by their key.
+func SlugKey(slug string) string {
+	return strings.ToLower(norm.NFC.String(slug))
+}
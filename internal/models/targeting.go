@@ -0,0 +1,76 @@
+package models
+
+import "strings"
+
+// Device types matched by targeting rules
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// Operating systems matched by targeting rules
+const (
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+)
+
+// Browsers matched by targeting rules
+const (
+	BrowserChrome  = "chrome"
+	BrowserSafari  = "safari"
+	BrowserFirefox = "firefox"
+	BrowserEdge    = "edge"
+	BrowserOpera   = "opera"
+	BrowserSamsung = "samsung"
+)
+
+// TargetingDevices lists the devices a rule can match
+var TargetingDevices = []string{DeviceMobile, DeviceTablet, DeviceDesktop}
+
+// TargetingOSes lists the operating systems a rule can match
+var TargetingOSes = []string{OSIOS, OSAndroid, OSWindows, OSMacOS, OSLinux, OSChromeOS}
+
+// TargetingBrowsers lists the browsers a rule can match
+var TargetingBrowsers = []string{BrowserChrome, BrowserSafari, BrowserFirefox, BrowserEdge, BrowserOpera, BrowserSamsung}
+
+// TargetingRule sends visitors matching every non-empty condition to its destination.
+// Rules are evaluated in order and the first match wins.
+type TargetingRule struct {
+	Device      string `json:"device,omitempty"`   // One of TargetingDevices
+	OS          string `json:"os,omitempty"`       // One of TargetingOSes
+	Browser     string `json:"browser,omitempty"`  // One of TargetingBrowsers
+	Language    string `json:"language,omitempty"` // Primary language subtag, e.g. "en"
+	Country     string `json:"country,omitempty"`  // ISO 3166-1 alpha-2 country code, e.g. "US"
+	Destination string `json:"destination"`
+}
+
+// HasConditions checks if the rule has at least one condition
+func (t TargetingRule) HasConditions() bool {
+	return t.Device != "" || t.OS != "" || t.Browser != "" || t.Language != "" || t.Country != ""
+}
+
+// Describe returns a short human-readable description of the rule's conditions
+func (t TargetingRule) Describe() string {
+	parts := make([]string, 0, 5)
+	if t.Device != "" {
+		parts = append(parts, "device "+t.Device)
+	}
+	if t.OS != "" {
+		parts = append(parts, "OS "+t.OS)
+	}
+	if t.Browser != "" {
+		parts = append(parts, "browser "+t.Browser)
+	}
+	if t.Language != "" {
+		parts = append(parts, "language "+t.Language)
+	}
+	if t.Country != "" {
+		parts = append(parts, "country "+t.Country)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,41 +1,172 @@
 package models
 
 import (
+	"net/http"
 	"time"
 )
 
+// Expiry actions control what happens when an expired link is visited
+const (
+	// ExpiryActionNotFound responds with 404 Not Found (the default)
+	ExpiryActionNotFound = "not_found"
+	// ExpiryActionRedirect redirects to the link's fallback URL
+	ExpiryActionRedirect = "redirect"
+	// ExpiryActionPage renders the branded expired page with a custom message
+	ExpiryActionPage = "page"
+	// ExpiryActionGone responds with 410 Gone
+	ExpiryActionGone = "gone"
+)
+
+// ExpiryActions lists the valid expiry actions
+var ExpiryActions = []string{
+	ExpiryActionNotFound,
+	ExpiryActionRedirect,
+	ExpiryActionPage,
+	ExpiryActionGone,
+}
+
+// Query modes control how query parameters on a short link are passed to the destination
+const (
+	// QueryModeNone drops the incoming query parameters (the default)
+	QueryModeNone = "none"
+	// QueryModeMergeKeep adds incoming parameters, keeping the destination's value on conflict
+	QueryModeMergeKeep = "merge_keep"
+	// QueryModeMergeOverride adds incoming parameters, replacing the destination's value on conflict
+	QueryModeMergeOverride = "merge_override"
+	// QueryModeMergeAppend adds incoming parameters, keeping both values on conflict
+	QueryModeMergeAppend = "merge_append"
+)
+
+// QueryModes lists the valid query modes
+var QueryModes = []string{
+	QueryModeNone,
+	QueryModeMergeKeep,
+	QueryModeMergeOverride,
+	QueryModeMergeAppend,
+}
+
+// RedirectStatuses lists the HTTP status codes a link can redirect with
+var RedirectStatuses = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// Cache policies control the Cache-Control header sent with a redirect
+const (
+	// CachePolicyDefault uses the server-wide default policy
+	CachePolicyDefault = ""
+	// CachePolicyAuto caches permanent redirects publicly and never caches temporary ones
+	CachePolicyAuto = "auto"
+	// CachePolicyNoStore never caches the redirect, so every visit is counted
+	CachePolicyNoStore = "no-store"
+	// CachePolicyPrivate lets browsers, but not shared caches, reuse the redirect
+	CachePolicyPrivate = "private"
+	// CachePolicyPublic lets browsers and CDNs reuse the redirect
+	CachePolicyPublic = "public"
+)
+
+// CachePolicies lists the valid cache policies
+var CachePolicies = []string{
+	CachePolicyDefault,
+	CachePolicyAuto,
+	CachePolicyNoStore,
+	CachePolicyPrivate,
+	CachePolicyPublic,
+}
+
 // URL represents a shortened URL
 type URL struct {
-	ID           string     `json:"id"`           // Shortened ID
-	OriginalURL  string     `json:"original_url"` // Original URL
-	CreatedAt    time.Time  `json:"created_at"`   // Creation time
-	Visits       int        `json:"visits"`       // Number of visits
-	LastVisitAt  time.Time  `json:"last_visit_at,omitempty"` // Last visit time
-	UserID       *int       `json:"user_id,omitempty"`       // ID of the user who created the URL
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`    // Expiration time (nil for never)
-	PasswordHash string     `json:"password_hash,omitempty"` // Hash of the password (empty for no password)
+	ID                string          `json:"id"`                            // Shortened ID
+	OriginalURL       string          `json:"original_url"`                  // Original URL
+	CreatedAt         time.Time       `json:"created_at"`                    // Creation time
+	Visits            int             `json:"visits"`                        // Number of visits
+	BotVisits         int             `json:"bot_visits"`                    // Number of visits from bots, left out of Visits
+	LastVisitAt       time.Time       `json:"last_visit_at,omitempty"`       // Last visit time
+	UserID            *int            `json:"user_id,omitempty"`             // ID of the user who created the URL
+	ExpiresAt         *time.Time      `json:"expires_at,omitempty"`          // Expiration time (nil for never)
+	PasswordHash      string          `json:"password_hash,omitempty"`       // Hash of the password (empty for no password)
+	ExpiryAction      string          `json:"expiry_action,omitempty"`       // What to do once the URL has expired
+	ExpiryRedirectURL string          `json:"expiry_redirect_url,omitempty"` // Fallback destination for the redirect action
+	ExpiryMessage     string          `json:"expiry_message,omitempty"`      // Custom message for the page action
+	TargetingRules    []TargetingRule `json:"targeting_rules,omitempty"`     // Ordered rules picking a destination per visitor
+	Destinations      []Destination   `json:"destinations,omitempty"`        // Weighted destinations replacing OriginalURL when set
+	StickyRotation    bool            `json:"sticky_rotation,omitempty"`     // Keep returning visitors on the same destination
+	QueryMode         string          `json:"query_mode,omitempty"`          // How incoming query parameters reach the destination
+	PathPassthrough   bool            `json:"path_passthrough,omitempty"`    // Forward trailing path segments to the destination
+	CampaignID        *int            `json:"campaign_id,omitempty"`         // Campaign whose UTM parameters tagged the destination
+	Channel           string          `json:"channel,omitempty"`             // Campaign channel the link was created for
+	RedirectStatus    int             `json:"redirect_status,omitempty"`     // Redirect status code (0 for the server default)
+	CachePolicy       string          `json:"cache_policy,omitempty"`        // Cache-Control policy for the redirect (empty for the server default)
+	Domain            string          `json:"domain,omitempty"`              // Custom domain serving the URL (empty for the default domain)
+	Slug              string          `json:"slug,omitempty"`                // Path of the URL on its custom domain
+	CanonicalAlias    string          `json:"canonical_alias,omitempty"`     // Alias used in the short URL (empty for the ID)
+	ScreeningStatus   string          `json:"screening_status,omitempty"`    // Outcome of destination screening (empty when nothing matched)
+	ScreeningReason   string          `json:"screening_reason,omitempty"`    // Why the destination was flagged, warned about or blocked
+	ScreenedAt        *time.Time      `json:"screened_at,omitempty"`         // When the destinations were last screened
+	Title             string          `json:"title,omitempty"`               // Title shown on the preview page
+	Preview           bool            `json:"preview,omitempty"`             // Show a preview page instead of redirecting
+	Metadata          *LinkMetadata   `json:"metadata,omitempty"`            // Title, description and images of the destination page
+	SocialTitle       string          `json:"social_title,omitempty"`        // Title shown when the link is shared on social media and chat apps
+	SocialDescription string          `json:"social_description,omitempty"`  // Description shown when the link is shared
+	SocialImageURL    string          `json:"social_image_url,omitempty"`    // Image shown when the link is shared
+	HealthStatus      string          `json:"health_status,omitempty"`       // Outcome of the latest destination health checks (empty until checked)
+	HealthFailures    int             `json:"health_failures,omitempty"`     // Failed health checks in a row
+	HealthCheckedAt   *time.Time      `json:"health_checked_at,omitempty"`   // When the destinations were last checked
 }
 
 // URLResponse represents the response to be sent to the client
 type URLResponse struct {
-	ShortURL       string     `json:"short_url"`
-	OriginalURL    string     `json:"original_url"`
-	CreatedAt      time.Time  `json:"created_at"`
-	Visits         int        `json:"visits"`
-	UserID         *int       `json:"user_id,omitempty"`
-	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
-	IsPasswordProtected bool   `json:"is_password_protected"`
+	ID                  string          `json:"id"`
+	ShortURL            string          `json:"short_url"`
+	OriginalURL         string          `json:"original_url"`
+	CreatedAt           time.Time       `json:"created_at"`
+	Visits              int             `json:"visits"`
+	BotVisits           int             `json:"bot_visits"`
+	UserID              *int            `json:"user_id,omitempty"`
+	ExpiresAt           *time.Time      `json:"expires_at,omitempty"`
+	IsPasswordProtected bool            `json:"is_password_protected"`
+	ExpiryAction        string          `json:"expiry_action"`
+	ExpiryRedirectURL   string          `json:"expiry_redirect_url,omitempty"`
+	ExpiryMessage       string          `json:"expiry_message,omitempty"`
+	TargetingRules      []TargetingRule `json:"targeting_rules,omitempty"`
+	Destinations        []Destination   `json:"destinations,omitempty"`
+	StickyRotation      bool            `json:"sticky_rotation,omitempty"`
+	QueryMode           string          `json:"query_mode"`
+	PathPassthrough     bool            `json:"path_passthrough"`
+	CampaignID          *int            `json:"campaign_id,omitempty"`
+	Channel             string          `json:"channel,omitempty"`
+	RedirectStatus      int             `json:"redirect_status,omitempty"`
+	CachePolicy         string          `json:"cache_policy,omitempty"`
+	Domain              string          `json:"domain,omitempty"`
+	Slug                string          `json:"slug,omitempty"`
+	CanonicalAlias      string          `json:"canonical_alias,omitempty"`
+	Existing            bool            `json:"existing,omitempty"`
+	ScreeningStatus     string          `json:"screening_status,omitempty"`
+	ScreeningReason     string          `json:"screening_reason,omitempty"`
+	Title               string          `json:"title,omitempty"`
+	Preview             bool            `json:"preview"`
+	Metadata            *LinkMetadata   `json:"metadata,omitempty"`
+	SocialTitle         string          `json:"social_title,omitempty"`
+	SocialDescription   string          `json:"social_description,omitempty"`
+	SocialImageURL      string          `json:"social_image_url,omitempty"`
+	HealthStatus        string          `json:"health_status,omitempty"`
+	HealthFailures      int             `json:"health_failures,omitempty"`
+	HealthCheckedAt     *time.Time      `json:"health_checked_at,omitempty"`
 }
 
 // NewURL creates a new URL
 func NewURL(id, originalURL string, userID *int, expiresAt *time.Time) *URL {
 	return &URL{
-		ID:          id,
-		OriginalURL: originalURL,
-		CreatedAt:   time.Now(),
-		Visits:      0,
-		UserID:      userID,
-		ExpiresAt:   expiresAt,
+		ID:           id,
+		OriginalURL:  originalURL,
+		CreatedAt:    time.Now(),
+		Visits:       0,
+		UserID:       userID,
+		ExpiresAt:    expiresAt,
+		ExpiryAction: ExpiryActionNotFound,
+		QueryMode:    QueryModeNone,
 	}
 }
 
@@ -45,6 +176,12 @@ func (u *URL) IncrementVisits() {
 	u.LastVisitAt = time.Now()
 }
 
+// IncrementBotVisits counts a visit from a bot. Bot visits don't change
+// when the link was last visited.
+func (u *URL) IncrementBotVisits() {
+	u.BotVisits++
+}
+
 // HasExpired checks if the URL has expired
 func (u *URL) HasExpired() bool {
 	if u.ExpiresAt == nil {
@@ -56,4 +193,42 @@ func (u *URL) HasExpired() bool {
 // IsPasswordProtected checks if the URL is password protected
 func (u *URL) IsPasswordProtected() bool {
 	return u.PasswordHash != ""
-}
\ No newline at end of file
+}
+
+// IsOwnedBy checks if the URL was created by the given user
+func (u *URL) IsOwnedBy(userID int) bool {
+	return u.UserID != nil && *u.UserID == userID
+}
+
+// GetExpiryAction returns the expiry action, falling back to not found
+func (u *URL) GetExpiryAction() string {
+	if u.ExpiryAction == "" {
+		return ExpiryActionNotFound
+	}
+	return u.ExpiryAction
+}
+
+// ShortPath returns the path of the short link: the slug on a custom domain,
+// or the canonical alias, or the ID
+func (u *URL) ShortPath() string {
+	if u.Domain != "" && u.Slug != "" {
+		return u.Slug
+	}
+	if u.CanonicalAlias != "" {
+		return u.CanonicalAlias
+	}
+	return u.ID
+}
+
+// GetQueryMode returns the query mode, falling back to dropping the parameters
+func (u *URL) GetQueryMode() string {
+	if u.QueryMode == "" {
+		return QueryModeNone
+	}
+	return u.QueryMode
+}
+
+// HasSocialCard checks if the URL overrides what social media and chat apps show when it's shared
+func (u *URL) HasSocialCard() bool {
+	return u.SocialTitle != "" || u.SocialDescription != "" || u.SocialImageURL != ""
+}
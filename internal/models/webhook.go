@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Webhook event types
+const (
+	// WebhookEventLinkCreated is sent when a link is created
+	WebhookEventLinkCreated = "link.created"
+	// WebhookEventLinkUpdated is sent when a link's settings are changed
+	WebhookEventLinkUpdated = "link.updated"
+	// WebhookEventLinkDeleted is sent when an expired link is purged
+	WebhookEventLinkDeleted = "link.deleted"
+	// WebhookEventLinkClicked is sent when a link is visited
+	WebhookEventLinkClicked = "link.clicked"
+	// WebhookEventBioViewed is sent when a bio page is viewed
+	WebhookEventBioViewed = "bio.viewed"
+	// WebhookEventTest is sent by the send test event button, whatever events a webhook subscribes to
+	WebhookEventTest = "webhook.test"
+)
+
+// WebhookEvents lists the events webhooks can subscribe to
+var WebhookEvents = []string{
+	WebhookEventLinkCreated,
+	WebhookEventLinkUpdated,
+	WebhookEventLinkDeleted,
+	WebhookEventLinkClicked,
+	WebhookEventBioViewed,
+}
+
+// Webhook delivery statuses
+const (
+	// WebhookDeliveryPending means the delivery is waiting for its first attempt or a retry
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliveryDelivered means the endpoint accepted the delivery
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryFailed means the delivery ran out of attempts
+	WebhookDeliveryFailed = "failed"
+)
+
+// Webhook is an endpoint a user has registered to be sent their link events
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewWebhook creates a new, active webhook
+func NewWebhook(userID int, url, secret string, events []string) *Webhook {
+	return &Webhook{
+		UserID:    userID,
+		URL:       url,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Subscribes checks if the webhook is sent events of the given type
+func (w *Webhook) Subscribes(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// WebhookEvent is the payload sent to webhooks
+type WebhookEvent struct {
+	// ID identifies the event, staying the same across retries so receivers can ignore duplicates
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+	// UserID is the user whose webhooks are sent the event
+	UserID int `json:"-"`
+}
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its latest attempt
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	ResponseBody   string          `json:"response_body,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	DurationMs     int             `json:"duration_ms"`
+	CreatedAt      time.Time       `json:"created_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+}
+
+// NewWebhookDelivery creates a pending delivery of an event's payload
+func NewWebhookDelivery(webhookID int, eventID, event string, payload []byte) *WebhookDelivery {
+	return &WebhookDelivery{
+		WebhookID: webhookID,
+		EventID:   eventID,
+		Event:     event,
+		Payload:   payload,
+		Status:    WebhookDeliveryPending,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// AliasRepository defines the interface for link alias storage
+type AliasRepository interface {
+	// CreateAlias creates a new alias, failing with ErrSlugUnavailable if it
+	// or its models.SlugKey is already in use
+	CreateAlias(ctx context.Context, alias *models.Alias) error
+
+	// GetAlias retrieves an alias
+	GetAlias(ctx context.Context, alias string) (*models.Alias, error)
+
+	// GetAliasByKey retrieves an alias by its models.SlugKey
+	GetAliasByKey(ctx context.Context, key string) (*models.Alias, error)
+
+	// ListAliasesByURLID lists all aliases of a link, oldest first
+	ListAliasesByURLID(ctx context.Context, urlID string) ([]*models.Alias, error)
+
+	// IncrementAliasVisits counts a visit through an alias
+	IncrementAliasVisits(ctx context.Context, alias string) error
+
+	// DeleteAlias deletes an alias
+	DeleteAlias(ctx context.Context, alias string) error
+
+	// DeleteAliasesByURLID deletes all aliases of a link and returns them
+	DeleteAliasesByURLID(ctx context.Context, urlID string) ([]*models.Alias, error)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestAliasRepository(t *testing.T) {
+	forEachRepository(t,
+		func() AliasRepository { return NewMemoryAliasRepository() },
+		func(db *sql.DB) (AliasRepository, error) { return NewPostgresAliasRepository(db) },
+		[]string{"url_aliases"},
+		func(t *testing.T, repo AliasRepository) {
+			ctx := context.Background()
+			created := time.Now().Add(-time.Hour)
+			for i, name := range []string{"spring-sale", "spring", "other"} {
+				alias := models.NewAlias(name, "abc123")
+				if name == "other" {
+					alias.URLID = "xyz789"
+				}
+				alias.CreatedAt = created.Add(time.Duration(i) * time.Minute)
+				if err := repo.CreateAlias(ctx, alias); err != nil {
+					t.Fatalf("Failed to create alias: %v", err)
+				}
+			}
+			if err := repo.CreateAlias(ctx, models.NewAlias("spring", "xyz789")); err != ErrSlugUnavailable {
+				t.Errorf("Expected ErrSlugUnavailable for an alias in use, got %v", err)
+			}
+			if err := repo.CreateAlias(ctx, models.NewAlias("Spring", "xyz789")); err != ErrSlugUnavailable {
+				t.Errorf("Expected ErrSlugUnavailable for an alias differing only in case, got %v", err)
+			}
+			if alias, err := repo.GetAliasByKey(ctx, "spring-sale"); err != nil || alias.Alias != "spring-sale" {
+				t.Errorf("Expected the alias by its key, got %+v (%v)", alias, err)
+			}
+
+			aliases, err := repo.ListAliasesByURLID(ctx, "abc123")
+			if err != nil || len(aliases) != 2 || aliases[0].Alias != "spring-sale" || aliases[1].Alias != "spring" {
+				t.Fatalf("Expected the link's 2 aliases oldest first, got %+v (%v)", aliases, err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := repo.IncrementAliasVisits(ctx, "spring"); err != nil {
+						t.Errorf("Failed to count alias visit: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+			if alias, err := repo.GetAlias(ctx, "spring"); err != nil || alias.Visits != 10 || alias.LastVisitAt.IsZero() {
+				t.Errorf("Expected 10 visits through the alias, got %+v (%v)", alias, err)
+			}
+			if err := repo.IncrementAliasVisits(ctx, "missing"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound for a missing alias, got %v", err)
+			}
+
+			if err := repo.DeleteAlias(ctx, "spring-sale"); err != nil {
+				t.Fatalf("Failed to delete alias: %v", err)
+			}
+			if err := repo.DeleteAlias(ctx, "spring-sale"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound deleting a deleted alias, got %v", err)
+			}
+
+			deleted, err := repo.DeleteAliasesByURLID(ctx, "abc123")
+			if err != nil || len(deleted) != 1 || deleted[0].Alias != "spring" {
+				t.Errorf("Expected the link's remaining alias to be deleted, got %+v (%v)", deleted, err)
+			}
+			if _, err := repo.GetAlias(ctx, "other"); err != nil {
+				t.Errorf("Expected other links' aliases to be kept, got %v", err)
+			}
+		})
+}
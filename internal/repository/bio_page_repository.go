@@ -2,13 +2,15 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
 )
 
 // BioPageRepository defines the interface for bio page storage
 type BioPageRepository interface {
-	// CreateBioPage creates a new bio page
+	// CreateBioPage creates a new bio page, failing with ErrSlugUnavailable
+	// if its short code or the short code's models.SlugKey is already in use
 	CreateBioPage(ctx context.Context, bioPage *models.BioPage) error
 
 	// GetBioPageByID retrieves a bio page by ID
@@ -17,6 +19,9 @@ type BioPageRepository interface {
 	// GetBioPageByShortCode retrieves a bio page by short code
 	GetBioPageByShortCode(ctx context.Context, shortCode string) (*models.BioPage, error)
 
+	// GetBioPageByShortCodeKey retrieves a bio page by the models.SlugKey of its short code
+	GetBioPageByShortCodeKey(ctx context.Context, key string) (*models.BioPage, error)
+
 	// ListBioPagesByUserID lists all bio pages for a user
 	ListBioPagesByUserID(ctx context.Context, userID int) ([]*models.BioPage, error)
 
@@ -43,4 +48,12 @@ type BioPageRepository interface {
 
 	// ReorderBioLinks updates the display order of bio links
 	ReorderBioLinks(ctx context.Context, bioPageID int, linkIDs []int) error
+
+	// ListBioLinksWithStaleMetadata lists up to limit bio links, or all of them if
+	// limit is zero or less, whose destination metadata is missing, was fetched
+	// for another URL, or failed before retryBefore
+	ListBioLinksWithStaleMetadata(ctx context.Context, retryBefore time.Time, limit int) ([]*models.BioLink, error)
+
+	// UpdateBioLinkMetadata records the metadata fetched for a bio link's URL
+	UpdateBioLinkMetadata(ctx context.Context, id int, metadata *models.LinkMetadata) error
 }
\ No newline at end of file
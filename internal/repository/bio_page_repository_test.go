@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestListBioLinksWithStaleMetadata(t *testing.T) {
+	forEachRepository(t,
+		func() BioPageRepository { return NewMemoryBioPageRepository() },
+		func(db *sql.DB) (BioPageRepository, error) {
+			if err := createTestUsers(db, 1); err != nil {
+				return nil, err
+			}
+			return NewPostgresBioPageRepository(db)
+		},
+		[]string{"users", "bio_pages", "bio_links"},
+		func(t *testing.T, repo BioPageRepository) {
+			ctx := context.Background()
+			page := models.NewBioPage(1, "links", "Links")
+			if err := repo.CreateBioPage(ctx, page); err != nil {
+				t.Fatalf("Failed to create bio page: %v", err)
+			}
+			var links []*models.BioLink
+			for i, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+				link := models.NewBioLink(page.ID, "Link", url, i)
+				if err := repo.CreateBioLink(ctx, link); err != nil {
+					t.Fatalf("Failed to create bio link: %v", err)
+				}
+				links = append(links, link)
+			}
+			fetched := &models.LinkMetadata{URL: "https://example.com/a", Title: "A", FetchedAt: time.Now()}
+			if err := repo.UpdateBioLinkMetadata(ctx, links[0].ID, fetched); err != nil {
+				t.Fatalf("Failed to update bio link metadata: %v", err)
+			}
+
+			stale, err := repo.ListBioLinksWithStaleMetadata(ctx, time.Now(), 0)
+			if err != nil || len(stale) != 2 || stale[0].ID != links[1].ID || stale[1].ID != links[2].ID {
+				t.Errorf("Expected the 2 bio links without metadata in order, got %+v (%v)", stale, err)
+			}
+			if batch, _ := repo.ListBioLinksWithStaleMetadata(ctx, time.Now(), 1); len(batch) != 1 {
+				t.Errorf("Expected a batch of 1 bio link, got %d", len(batch))
+			}
+		})
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// CampaignRepository defines the interface for campaign storage
+type CampaignRepository interface {
+	// CreateCampaign creates a new campaign
+	CreateCampaign(ctx context.Context, campaign *models.Campaign) error
+
+	// GetCampaignByID retrieves a campaign by ID
+	GetCampaignByID(ctx context.Context, id int) (*models.Campaign, error)
+
+	// ListCampaignsByUserID lists all campaigns for a user
+	ListCampaignsByUserID(ctx context.Context, userID int) ([]*models.Campaign, error)
+
+	// UpdateCampaign updates a campaign
+	UpdateCampaign(ctx context.Context, campaign *models.Campaign) error
+
+	// DeleteCampaign deletes a campaign
+	DeleteCampaign(ctx context.Context, id int) error
+}
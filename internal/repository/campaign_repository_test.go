@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestCampaignRepository(t *testing.T) {
+	forEachRepository(t,
+		func() CampaignRepository { return NewMemoryCampaignRepository() },
+		func(db *sql.DB) (CampaignRepository, error) {
+			if err := createTestUsers(db, 2); err != nil {
+				return nil, err
+			}
+			return NewPostgresCampaignRepository(db)
+		},
+		[]string{"users", "campaigns"},
+		func(t *testing.T, repo CampaignRepository) {
+			ctx := context.Background()
+			spring := models.NewCampaign(1, "Spring sale", "spring_sale")
+			spring.Channels = []models.CampaignChannel{{Name: "newsletter", Source: "newsletter", Medium: "email"}}
+			if err := repo.CreateCampaign(ctx, spring); err != nil || spring.ID == 0 {
+				t.Fatalf("Failed to create campaign: %v", err)
+			}
+			if err := repo.CreateCampaign(ctx, models.NewCampaign(2, "Other", "other")); err != nil {
+				t.Fatalf("Failed to create campaign: %v", err)
+			}
+
+			stored, err := repo.GetCampaignByID(ctx, spring.ID)
+			if err != nil {
+				t.Fatalf("Failed to get campaign: %v", err)
+			}
+			if stored.UTMCampaign != "spring_sale" || len(stored.Channels) != 1 || stored.Channels[0].Medium != "email" {
+				t.Errorf("Expected the campaign with its channel, got %+v", stored)
+			}
+
+			campaigns, err := repo.ListCampaignsByUserID(ctx, 1)
+			if err != nil || len(campaigns) != 1 || campaigns[0].ID != spring.ID {
+				t.Errorf("Expected only the user's campaign, got %+v (%v)", campaigns, err)
+			}
+
+			stored.Name = "Spring sale 2"
+			stored.Channels = nil
+			if err := repo.UpdateCampaign(ctx, stored); err != nil {
+				t.Fatalf("Failed to update campaign: %v", err)
+			}
+			if updated, _ := repo.GetCampaignByID(ctx, spring.ID); updated.Name != "Spring sale 2" || len(updated.Channels) != 0 {
+				t.Errorf("Expected the updated campaign, got %+v", updated)
+			}
+
+			if err := repo.DeleteCampaign(ctx, spring.ID); err != nil {
+				t.Fatalf("Failed to delete campaign: %v", err)
+			}
+			if _, err := repo.GetCampaignByID(ctx, spring.ID); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound after deleting, got %v", err)
+			}
+			if err := repo.UpdateCampaign(ctx, stored); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound updating a deleted campaign, got %v", err)
+			}
+			if err := repo.DeleteCampaign(ctx, spring.ID); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound deleting a deleted campaign, got %v", err)
+			}
+		})
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// ClickRepository defines the interface for recorded click storage
+type ClickRepository interface {
+	// RecordClick records a click, setting its ID
+	RecordClick(ctx context.Context, click *models.Click) error
+
+	// ListClicksByURL lists up to limit clicks on a link, or all of them if
+	// limit is zero or less, newest first, leaving out clicks from bots unless
+	// includeBots is set
+	ListClicksByURL(ctx context.Context, urlID string, includeBots bool, limit int) ([]*models.Click, error)
+
+	// CountDailyVisitsByURL counts the visits by people to a link and their
+	// unique visitors on each day since the given time. Days without visits
+	// are left out.
+	CountDailyVisitsByURL(ctx context.Context, urlID string, since time.Time) ([]*models.DailyVisits, error)
+
+	// CountDailyVisitsByBioPage counts the views by people of a bio page and
+	// their unique visitors on each day since the given time
+	CountDailyVisitsByBioPage(ctx context.Context, bioPageID int, since time.Time) ([]*models.DailyVisits, error)
+
+	// CountVisitorsByURL counts the distinct visitor hashes of a link's visits by people
+	CountVisitorsByURL(ctx context.Context, urlID string) (int, error)
+
+	// CountVisitorsByBioPage counts the distinct visitor hashes of a bio page's views by people
+	CountVisitorsByBioPage(ctx context.Context, bioPageID int) (int, error)
+
+	// ListClicksAfter lists up to limit clicks, or all of them if limit is zero
+	// or less, with IDs above afterID made since the given time, in ID order
+	ListClicksAfter(ctx context.Context, afterID int64, since time.Time, limit int) ([]*models.Click, error)
+
+	// ListVisitorClicks lists the clicks by people with any of the visitor
+	// hashes made since the given time, with IDs below beforeID
+	ListVisitorClicks(ctx context.Context, visitorHashes []string, since time.Time, beforeID int64) ([]*models.Click, error)
+
+	// DeleteClicksBefore deletes the clicks made before the given time with
+	// IDs up to throughID, returning how many were deleted
+	DeleteClicksBefore(ctx context.Context, before time.Time, throughID int64) (int, error)
+
+	// DeleteClicksByURL deletes every click on a link
+	DeleteClicksByURL(ctx context.Context, urlID string) error
+
+	// GetVisitorSalt returns the salt visitors are hashed with on a day,
+	// storing the given salt if the day has none yet
+	GetVisitorSalt(ctx context.Context, day time.Time, salt []byte) ([]byte, error)
+
+	// DeleteVisitorSaltsBefore deletes the salts of the days before the given day
+	DeleteVisitorSaltsBefore(ctx context.Context, day time.Time) error
+
+	// UpdateVisitorSketch raises a register of a visitor sketch to the given
+	// rank, leaving it alone if it is already higher
+	UpdateVisitorSketch(ctx context.Context, target string, register, rank int) error
+
+	// GetVisitorSketch returns the ranks of a visitor sketch's registers by
+	// register, leaving out registers that are still zero
+	GetVisitorSketch(ctx context.Context, target string) (map[int]int, error)
+
+	// DeleteVisitorSketch deletes a visitor sketch
+	DeleteVisitorSketch(ctx context.Context, target string) error
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// forEachClickRepository runs a test against the memory and Postgres click repositories
+func forEachClickRepository(t *testing.T, test func(t *testing.T, repo ClickRepository)) {
+	forEachRepository(t,
+		func() ClickRepository { return NewMemoryClickRepository() },
+		func(db *sql.DB) (ClickRepository, error) { return NewPostgresClickRepository(db) },
+		[]string{"clicks", "visitor_salts", "visitor_sketches"}, test)
+}
+
+// recordTestClick records a click on a link made at the given time
+func recordTestClick(t *testing.T, repo ClickRepository, urlID string, bot bool, createdAt time.Time) *models.Click {
+	t.Helper()
+
+	click := models.NewClick("https://referrer.example", "Mozilla/5.0")
+	click.URLID = urlID
+	click.Destination = "https://example.com"
+	click.Bot = bot
+	if bot {
+		click.BotReason = models.BotReasonCrawler
+	}
+	click.CreatedAt = createdAt.UTC()
+	if err := repo.RecordClick(context.Background(), click); err != nil {
+		t.Fatalf("Failed to record click: %v", err)
+	}
+	return click
+}
+
+func TestListClicksByURL(t *testing.T) {
+	forEachClickRepository(t, func(t *testing.T, repo ClickRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		first := recordTestClick(t, repo, "abc123", false, now.Add(-2*time.Hour))
+		bot := recordTestClick(t, repo, "abc123", true, now.Add(-time.Hour))
+		last := recordTestClick(t, repo, "abc123", false, now)
+		recordTestClick(t, repo, "xyz789", false, now)
+		if first.ID == 0 || bot.ID <= first.ID {
+			t.Fatalf("Expected increasing click IDs, got %d and %d", first.ID, bot.ID)
+		}
+
+		people, err := repo.ListClicksByURL(ctx, "abc123", false, 0)
+		if err != nil || len(people) != 2 || people[0].ID != last.ID || people[1].ID != first.ID {
+			t.Fatalf("Expected the 2 clicks by people newest first, got %+v (%v)", people, err)
+		}
+		if people[0].Referrer != "https://referrer.example" || people[0].Destination != "https://example.com" {
+			t.Errorf("Expected the stored click, got %+v", people[0])
+		}
+
+		all, _ := repo.ListClicksByURL(ctx, "abc123", true, 0)
+		if len(all) != 3 || !all[1].Bot || all[1].BotReason != models.BotReasonCrawler {
+			t.Errorf("Expected all 3 clicks including the bot's, got %+v", all)
+		}
+		if latest, _ := repo.ListClicksByURL(ctx, "abc123", true, 1); len(latest) != 1 || latest[0].ID != last.ID {
+			t.Errorf("Expected the latest click, got %+v", latest)
+		}
+	})
+}
+
+func TestDeleteClicksByURL(t *testing.T) {
+	forEachClickRepository(t, func(t *testing.T, repo ClickRepository) {
+		ctx := context.Background()
+		recordTestClick(t, repo, "abc123", false, time.Now())
+		recordTestClick(t, repo, "abc123", true, time.Now())
+		recordTestClick(t, repo, "xyz789", false, time.Now())
+		for _, target := range []string{"url:abc123", "url:xyz789"} {
+			if err := repo.UpdateVisitorSketch(ctx, target, 7, 3); err != nil {
+				t.Fatalf("Failed to update visitor sketch: %v", err)
+			}
+		}
+
+		if err := repo.DeleteClicksByURL(ctx, "abc123"); err != nil {
+			t.Fatalf("Failed to delete clicks: %v", err)
+		}
+		if err := repo.DeleteVisitorSketch(ctx, "url:abc123"); err != nil {
+			t.Fatalf("Failed to delete visitor sketch: %v", err)
+		}
+
+		if clicks, _ := repo.ListClicksByURL(ctx, "abc123", true, 0); len(clicks) != 0 {
+			t.Errorf("Expected the link's clicks to be deleted, got %d", len(clicks))
+		}
+		if sketch, _ := repo.GetVisitorSketch(ctx, "url:abc123"); len(sketch) != 0 {
+			t.Errorf("Expected the link's visitor sketch to be deleted, got %v", sketch)
+		}
+		if clicks, _ := repo.ListClicksByURL(ctx, "xyz789", true, 0); len(clicks) != 1 {
+			t.Errorf("Expected other links' clicks to be kept, got %d", len(clicks))
+		}
+		if sketch, _ := repo.GetVisitorSketch(ctx, "url:xyz789"); sketch[7] != 3 {
+			t.Errorf("Expected other links' visitor sketches to be kept, got %v", sketch)
+		}
+	})
+}
+
+func TestCountVisitors(t *testing.T) {
+	forEachClickRepository(t, func(t *testing.T, repo ClickRepository) {
+		ctx := context.Background()
+		day := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		clicks := []struct {
+			urlID     string
+			bioPageID int
+			bioLinkID int
+			visitor   string
+			bot       bool
+			createdAt time.Time
+		}{
+			{"abc123", 0, 0, "alice", false, day},
+			{"abc123", 0, 0, "alice", false, day.Add(time.Hour)},
+			{"abc123", 0, 0, "bob", false, day.Add(2 * time.Hour)},
+			{"abc123", 0, 0, "", false, day.Add(3 * time.Hour)},
+			{"abc123", 0, 0, "crawler", true, day},
+			{"abc123", 0, 0, "alice", false, day.Add(24 * time.Hour)},
+			{"abc123", 0, 0, "carol", false, day.Add(-48 * time.Hour)},
+			{"", 5, 0, "alice", false, day},
+			{"", 5, 0, "bob", false, day},
+			{"", 5, 3, "carol", false, day},
+		}
+		for _, c := range clicks {
+			click := models.NewClick("", "Mozilla/5.0")
+			click.URLID, click.BioPageID, click.BioLinkID = c.urlID, c.bioPageID, c.bioLinkID
+			click.VisitorHash, click.Bot, click.CreatedAt = c.visitor, c.bot, c.createdAt
+			if err := repo.RecordClick(ctx, click); err != nil {
+				t.Fatalf("Failed to record click: %v", err)
+			}
+		}
+
+		// Visits by people since the given time are counted by day, each
+		// visitor once a day
+		days, err := repo.CountDailyVisitsByURL(ctx, "abc123", day.Add(-24*time.Hour))
+		if err != nil {
+			t.Fatalf("Failed to count daily visits: %v", err)
+		}
+		counts := make(map[string]models.DailyVisits)
+		for _, d := range days {
+			counts[d.Date] = *d
+		}
+		want := map[string]models.DailyVisits{
+			"2026-03-01": {Date: "2026-03-01", Visits: 4, Visitors: 2},
+			"2026-03-02": {Date: "2026-03-02", Visits: 1, Visitors: 1},
+		}
+		if len(counts) != len(want) || counts["2026-03-01"] != want["2026-03-01"] || counts["2026-03-02"] != want["2026-03-02"] {
+			t.Errorf("Expected %v, got %v", want, counts)
+		}
+
+		if visitors, err := repo.CountVisitorsByURL(ctx, "abc123"); err != nil || visitors != 3 {
+			t.Errorf("Expected 3 visitors of the link, got %d (%v)", visitors, err)
+		}
+
+		// Bio page views leave out the clicks on the page's links
+		if visitors, _ := repo.CountVisitorsByBioPage(ctx, 5); visitors != 2 {
+			t.Errorf("Expected 2 visitors of the bio page, got %d", visitors)
+		}
+		views, err := repo.CountDailyVisitsByBioPage(ctx, 5, day.Add(-time.Hour))
+		if err != nil || len(views) != 1 || views[0].Visits != 2 || views[0].Visitors != 2 {
+			t.Errorf("Expected 2 views by 2 visitors of the bio page, got %+v (%v)", views, err)
+		}
+	})
+}
+
+func TestVisitorSalts(t *testing.T) {
+	forEachClickRepository(t, func(t *testing.T, repo ClickRepository) {
+		ctx := context.Background()
+		day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		if salt, err := repo.GetVisitorSalt(ctx, day, []byte("first")); err != nil || string(salt) != "first" {
+			t.Fatalf("Expected the salt to be stored, got %q (%v)", salt, err)
+		}
+		if salt, _ := repo.GetVisitorSalt(ctx, day, []byte("second")); string(salt) != "first" {
+			t.Errorf("Expected the day's stored salt, got %q", salt)
+		}
+		if salt, _ := repo.GetVisitorSalt(ctx, day.AddDate(0, 0, 1), []byte("next")); string(salt) != "next" {
+			t.Errorf("Expected a salt for the next day, got %q", salt)
+		}
+
+		if err := repo.DeleteVisitorSaltsBefore(ctx, day.AddDate(0, 0, 1)); err != nil {
+			t.Fatalf("Failed to delete salts: %v", err)
+		}
+		if salt, _ := repo.GetVisitorSalt(ctx, day, []byte("new")); string(salt) != "new" {
+			t.Errorf("Expected the past salt to be deleted, got %q", salt)
+		}
+		if salt, _ := repo.GetVisitorSalt(ctx, day.AddDate(0, 0, 1), []byte("other")); string(salt) != "next" {
+			t.Errorf("Expected the next day's salt to be kept, got %q", salt)
+		}
+	})
+}
+
+func TestVisitorSketch(t *testing.T) {
+	forEachClickRepository(t, func(t *testing.T, repo ClickRepository) {
+		ctx := context.Background()
+		updates := []struct{ register, rank int }{{3, 2}, {3, 5}, {3, 4}, {9, 1}}
+		for _, u := range updates {
+			if err := repo.UpdateVisitorSketch(ctx, "url:abc123", u.register, u.rank); err != nil {
+				t.Fatalf("Failed to update visitor sketch: %v", err)
+			}
+		}
+
+		// Registers only ever rise
+		sketch, err := repo.GetVisitorSketch(ctx, "url:abc123")
+		if err != nil || len(sketch) != 2 || sketch[3] != 5 || sketch[9] != 1 {
+			t.Errorf("Expected registers 3 and 9 at ranks 5 and 1, got %v (%v)", sketch, err)
+		}
+		if empty, err := repo.GetVisitorSketch(ctx, "bio:1"); err != nil || len(empty) != 0 {
+			t.Errorf("Expected an empty sketch for another target, got %v (%v)", empty, err)
+		}
+	})
+}
+
+func TestListClicksAfter(t *testing.T) {
+	forEachClickRepository(t, func(t *testing.T, repo ClickRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		old := recordTestClick(t, repo, "abc123", false, now.Add(-48*time.Hour))
+		var recent []*models.Click
+		for i := 0; i < 3; i++ {
+			recent = append(recent, recordTestClick(t, repo, "abc123", i == 1, now.Add(time.Duration(i)*time.Minute)))
+		}
+
+		clicks, err := repo.ListClicksAfter(ctx, 0, now.Add(-time.Hour), 0)
+		if err != nil || len(clicks) != 3 || clicks[0].ID != recent[0].ID || clicks[2].ID != recent[2].ID {
+			t.Fatalf("Expected the 3 recent clicks in ID order, got %+v (%v)", clicks, err)
+		}
+		if batch, _ := repo.ListClicksAfter(ctx, recent[0].ID, now.Add(-time.Hour), 1); len(batch) != 1 || batch[0].ID != recent[1].ID {
+			t.Errorf("Expected a batch of the click after the first, got %+v", batch)
+		}
+
+		// Old clicks up to the given ID are deleted
+		deleted, err := repo.DeleteClicksBefore(ctx, now.Add(-time.Hour), recent[2].ID)
+		if err != nil || deleted != 1 {
+			t.Errorf("Expected the old click to be deleted, got %d (%v)", deleted, err)
+		}
+		if clicks, _ := repo.ListClicksAfter(ctx, 0, time.Time{}, 0); len(clicks) != 3 || clicks[0].ID == old.ID {
+			t.Errorf("Expected the recent clicks to be kept, got %+v", clicks)
+		}
+		if deleted, _ := repo.DeleteClicksBefore(ctx, now.Add(time.Hour), recent[0].ID); deleted != 1 {
+			t.Errorf("Expected only the clicks up to the given ID to be deleted, got %d", deleted)
+		}
+	})
+}
+
+func TestListVisitorClicks(t *testing.T) {
+	forEachClickRepository(t, func(t *testing.T, repo ClickRepository) {
+		ctx := context.Background()
+		now := time.Now()
+		var clicks []*models.Click
+		for _, c := range []struct {
+			visitor   string
+			bot       bool
+			createdAt time.Time
+		}{
+			{"alice", false, now},
+			{"bob", false, now},
+			{"alice", true, now},
+			{"alice", false, now.Add(-48 * time.Hour)},
+			{"carol", false, now},
+			{"alice", false, now},
+		} {
+			click := models.NewClick("", "Mozilla/5.0")
+			click.URLID = "abc123"
+			click.Bot = c.bot
+			click.VisitorHash = c.visitor
+			click.CreatedAt = c.createdAt.UTC()
+			if err := repo.RecordClick(ctx, click); err != nil {
+				t.Fatalf("Failed to record click: %v", err)
+			}
+			clicks = append(clicks, click)
+		}
+
+		// Only clicks by people with the hashes since the given time and below
+		// the given ID are listed
+		listed, err := repo.ListVisitorClicks(ctx, []string{"alice", "bob"}, now.Add(-time.Hour), clicks[5].ID)
+		if err != nil {
+			t.Fatalf("Failed to list visitor clicks: %v", err)
+		}
+		ids := make(map[int64]bool)
+		for _, click := range listed {
+			ids[click.ID] = true
+		}
+		if len(listed) != 2 || !ids[clicks[0].ID] || !ids[clicks[1].ID] {
+			t.Errorf("Expected the recent clicks by alice and bob, got %+v", listed)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// DomainRepository defines the interface for custom domain storage
+type DomainRepository interface {
+	// CreateDomain creates a new, unverified domain, failing with ErrDomainTaken
+	// if someone has verified the hostname or the user has already added it.
+	// Until one of them verifies it, several users may add a hostname.
+	CreateDomain(ctx context.Context, domain *models.Domain) error
+
+	// GetDomainByID retrieves a domain by ID
+	GetDomainByID(ctx context.Context, id int) (*models.Domain, error)
+
+	// GetVerifiedDomain retrieves the verified domain with a hostname
+	GetVerifiedDomain(ctx context.Context, hostname string) (*models.Domain, error)
+
+	// ListDomainsByUserID lists all domains for a user
+	ListDomainsByUserID(ctx context.Context, userID int) ([]*models.Domain, error)
+
+	// UpdateDomain updates a domain, failing with ErrDomainTaken when verifying
+	// a hostname someone else has verified
+	UpdateDomain(ctx context.Context, domain *models.Domain) error
+
+	// DeleteDomain deletes a domain, moving its owner's links on it back to
+	// the default domain, so they never resolve on the hostname again
+	DeleteDomain(ctx context.Context, id int) error
+}
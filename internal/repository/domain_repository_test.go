@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestDomainRepository(t *testing.T) {
+	forEachRepository(t,
+		func() DomainRepository { return NewMemoryDomainRepository(nil) },
+		func(db *sql.DB) (DomainRepository, error) {
+			if err := createTestUsers(db, 2); err != nil {
+				return nil, err
+			}
+			return NewPostgresDomainRepository(db)
+		},
+		[]string{"users", "domains"},
+		func(t *testing.T, repo DomainRepository) {
+			ctx := context.Background()
+			for _, hostname := range []string{"links.acme.com", "go.acme.com"} {
+				if err := repo.CreateDomain(ctx, models.NewDomain(1, hostname, "token")); err != nil {
+					t.Fatalf("Failed to create domain: %v", err)
+				}
+			}
+			if err := repo.CreateDomain(ctx, models.NewDomain(1, "go.acme.com", "token")); err != ErrDomainTaken {
+				t.Errorf("Expected ErrDomainTaken for a hostname the user added, got %v", err)
+			}
+
+			// Another user may claim the hostname until it is verified
+			claim := models.NewDomain(2, "go.acme.com", "other-token")
+			if err := repo.CreateDomain(ctx, claim); err != nil {
+				t.Fatalf("Failed to create domain: %v", err)
+			}
+
+			domains, err := repo.ListDomainsByUserID(ctx, 1)
+			if err != nil || len(domains) != 2 || domains[0].Hostname != "go.acme.com" {
+				t.Fatalf("Expected the user's 2 domains by hostname, got %+v (%v)", domains, err)
+			}
+			if others, _ := repo.ListDomainsByUserID(ctx, 2); len(others) != 1 || others[0].ID != claim.ID {
+				t.Errorf("Expected only the other user's claim, got %+v", others)
+			}
+			if _, err := repo.GetVerifiedDomain(ctx, "go.acme.com"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound for an unverified hostname, got %v", err)
+			}
+
+			// Verifying keeps the hostname and owner
+			verified := *claim
+			verifiedAt := time.Now()
+			verified.VerifiedAt = &verifiedAt
+			verified.Hostname = "evil.example"
+			verified.UserID = 1
+			if err := repo.UpdateDomain(ctx, &verified); err != nil {
+				t.Fatalf("Failed to update domain: %v", err)
+			}
+			domain, err := repo.GetVerifiedDomain(ctx, "go.acme.com")
+			if err != nil || domain.ID != claim.ID || domain.UserID != 2 {
+				t.Errorf("Expected the verified domain with its hostname and owner, got %+v (%v)", domain, err)
+			}
+
+			// Once verified, the hostname can't be verified or added by anyone else
+			first := *domains[0]
+			first.VerifiedAt = &verifiedAt
+			if err := repo.UpdateDomain(ctx, &first); err != ErrDomainTaken {
+				t.Errorf("Expected ErrDomainTaken verifying a verified hostname, got %v", err)
+			}
+			if stored, _ := repo.GetDomainByID(ctx, first.ID); stored.IsVerified() {
+				t.Errorf("Expected the earlier claim to stay unverified")
+			}
+			if err := repo.CreateDomain(ctx, models.NewDomain(1, "go.acme.com", "token")); err != ErrDomainTaken {
+				t.Errorf("Expected ErrDomainTaken for a verified hostname, got %v", err)
+			}
+
+			if err := repo.DeleteDomain(ctx, claim.ID); err != nil {
+				t.Fatalf("Failed to delete domain: %v", err)
+			}
+			if _, err := repo.GetVerifiedDomain(ctx, "go.acme.com"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound after deleting, got %v", err)
+			}
+			if err := repo.DeleteDomain(ctx, claim.ID); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound deleting a deleted domain, got %v", err)
+			}
+		})
+}
+
+// domainRepositories are a domain repository and the URL repository its
+// links are stored in
+type domainRepositories struct {
+	domains DomainRepository
+	urls    Repository
+}
+
+func TestDeleteDomainMovesLinks(t *testing.T) {
+	forEachRepository(t,
+		func() domainRepositories {
+			urls := NewMemoryRepository()
+			return domainRepositories{NewMemoryDomainRepository(urls), urls}
+		},
+		func(db *sql.DB) (domainRepositories, error) {
+			if err := createTestUsers(db, 2); err != nil {
+				return domainRepositories{}, err
+			}
+			domains, _ := NewPostgresDomainRepository(db)
+			urls, err := NewPostgresRepository(db)
+			return domainRepositories{domains, urls}, err
+		},
+		[]string{"users", "urls", "archived_urls", "domains"},
+		func(t *testing.T, repos domainRepositories) {
+			ctx := context.Background()
+			domain := models.NewDomain(1, "go.acme.com", "token")
+			claim := models.NewDomain(2, "go.acme.com", "other-token")
+			for _, d := range []*models.Domain{domain, claim} {
+				if err := repos.domains.CreateDomain(ctx, d); err != nil {
+					t.Fatalf("Failed to create domain: %v", err)
+				}
+			}
+			verifiedAt := time.Now()
+			domain.VerifiedAt = &verifiedAt
+			if err := repos.domains.UpdateDomain(ctx, domain); err != nil {
+				t.Fatalf("Failed to update domain: %v", err)
+			}
+
+			userID := 1
+			url := models.NewURL("abc123", "https://example.com", &userID, nil)
+			url.Domain = "go.acme.com"
+			url.Slug = "launch"
+			if err := repos.urls.Store(ctx, url); err != nil {
+				t.Fatalf("Failed to store URL: %v", err)
+			}
+
+			// Deleting another user's unverified claim keeps the owner's links
+			if err := repos.domains.DeleteDomain(ctx, claim.ID); err != nil {
+				t.Fatalf("Failed to delete domain: %v", err)
+			}
+			if _, err := repos.urls.GetByDomainSlug(ctx, "go.acme.com", "launch"); err != nil {
+				t.Errorf("Expected the link to stay on the domain, got %v", err)
+			}
+
+			// Deleting the domain moves its links back to the default domain, so
+			// whoever adds the hostname next doesn't serve them
+			if err := repos.domains.DeleteDomain(ctx, domain.ID); err != nil {
+				t.Fatalf("Failed to delete domain: %v", err)
+			}
+			if _, err := repos.urls.GetByDomainSlug(ctx, "go.acme.com", "launch"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound for the slug on the deleted domain, got %v", err)
+			}
+			stored, err := repos.urls.GetByID(ctx, "abc123")
+			if err != nil || stored.Domain != "" || stored.Slug != "" {
+				t.Errorf("Expected the link on the default domain, got %+v (%v)", stored, err)
+			}
+		})
+}
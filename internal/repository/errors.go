@@ -6,4 +6,7 @@ import "errors"
 var (
 	// ErrSlugUnavailable is returned when a slug is already in use
 	ErrSlugUnavailable = errors.New("slug is already in use")
+
+	// ErrDomainTaken is returned when a hostname is already verified or added
+	ErrDomainTaken = errors.New("domain is already registered")
 )
\ No newline at end of file
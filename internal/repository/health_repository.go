@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// HealthCheckRepository defines the interface for link health check history storage
+type HealthCheckRepository interface {
+	// AddHealthCheck records a check of a link destination, setting its ID
+	AddHealthCheck(ctx context.Context, check *models.HealthCheck) error
+
+	// ListHealthChecks lists up to limit checks of a link's destinations, or all
+	// of them if limit is zero or less, newest first
+	ListHealthChecks(ctx context.Context, urlID string, limit int) ([]*models.HealthCheck, error)
+
+	// DeleteHealthChecksBefore deletes the checks made before the given time
+	// and returns how many were deleted
+	DeleteHealthChecksBefore(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestHealthCheckRepository(t *testing.T) {
+	forEachRepository(t,
+		func() HealthCheckRepository { return NewMemoryHealthCheckRepository() },
+		func(db *sql.DB) (HealthCheckRepository, error) { return NewPostgresHealthCheckRepository(db) },
+		[]string{"link_health_checks"},
+		func(t *testing.T, repo HealthCheckRepository) {
+			ctx := context.Background()
+			now := time.Now()
+			for i, statusCode := range []int{200, 500, 404} {
+				check := &models.HealthCheck{
+					URLID:       "abc123",
+					Destination: "https://example.com",
+					StatusCode:  statusCode,
+					DurationMs:  20,
+					CheckedAt:   now.Add(time.Duration(i-2) * 24 * time.Hour),
+				}
+				if err := repo.AddHealthCheck(ctx, check); err != nil || check.ID == 0 {
+					t.Fatalf("Failed to add health check: %v", err)
+				}
+			}
+			other := &models.HealthCheck{URLID: "xyz789", Destination: "https://example.org", Error: "timeout", CheckedAt: now}
+			if err := repo.AddHealthCheck(ctx, other); err != nil {
+				t.Fatalf("Failed to add health check: %v", err)
+			}
+
+			checks, err := repo.ListHealthChecks(ctx, "abc123", 0)
+			if err != nil || len(checks) != 3 || checks[0].StatusCode != 404 || checks[2].StatusCode != 200 {
+				t.Fatalf("Expected the link's 3 checks newest first, got %+v (%v)", checks, err)
+			}
+			if latest, _ := repo.ListHealthChecks(ctx, "abc123", 1); len(latest) != 1 || latest[0].StatusCode != 404 {
+				t.Errorf("Expected the latest check, got %+v", latest)
+			}
+
+			deleted, err := repo.DeleteHealthChecksBefore(ctx, now.Add(-time.Hour))
+			if err != nil || deleted != 2 {
+				t.Errorf("Expected the 2 old checks to be deleted, got %d (%v)", deleted, err)
+			}
+			if checks, _ := repo.ListHealthChecks(ctx, "abc123", 0); len(checks) != 1 {
+				t.Errorf("Expected 1 check to be kept, got %d", len(checks))
+			}
+		})
+}
@@ -2,18 +2,30 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
 )
 
 // Repository defines the interface for URL storage
 type Repository interface {
-	// Store stores a URL in the repository
+	// Store stores a URL in the repository, failing with ErrSlugUnavailable if
+	// its ID, or its slug on its custom domain, or their models.SlugKey is
+	// already in use
 	Store(ctx context.Context, url *models.URL) error
 
 	// GetByID retrieves a URL by its ID
 	GetByID(ctx context.Context, id string) (*models.URL, error)
 
+	// GetByIDKey retrieves a URL by the models.SlugKey of its ID
+	GetByIDKey(ctx context.Context, key string) (*models.URL, error)
+
+	// GetByDomainSlug retrieves a URL by its slug on a custom domain
+	GetByDomainSlug(ctx context.Context, domain, slug string) (*models.URL, error)
+
+	// GetByDomainSlugKey retrieves a URL by the models.SlugKey of its slug on a custom domain
+	GetByDomainSlugKey(ctx context.Context, domain, key string) (*models.URL, error)
+
 	// Update updates a URL in the repository
 	Update(ctx context.Context, url *models.URL) error
 
@@ -23,6 +35,47 @@ type Repository interface {
 	// ListByUserID lists all URLs for a user
 	ListByUserID(ctx context.Context, userID int) ([]*models.URL, error)
 
+	// ListByUserDestination lists a user's URLs to a destination, newest first
+	ListByUserDestination(ctx context.Context, userID int, destination string) ([]*models.URL, error)
+
+	// ListByScreeningStatus lists the URLs with a screening status, newest first
+	ListByScreeningStatus(ctx context.Context, status string) ([]*models.URL, error)
+
+	// UpdateScreening records the outcome of screening a URL's destinations
+	UpdateScreening(ctx context.Context, id, status, reason string, screenedAt time.Time) error
+
+	// ListStaleMetadata lists up to limit URLs, or all of them if limit is zero
+	// or less, whose destination metadata is missing, was fetched for another
+	// destination, or failed before retryBefore
+	ListStaleMetadata(ctx context.Context, retryBefore time.Time, limit int) ([]*models.URL, error)
+
+	// UpdateMetadata records the metadata fetched for a URL's destination
+	UpdateMetadata(ctx context.Context, id string, metadata *models.LinkMetadata) error
+
+	// ListDueHealthChecks lists up to limit unexpired, unblocked URLs, or all
+	// of them if limit is zero or less, whose destinations were never checked
+	// or last checked before checkedBefore, least recently checked first
+	ListDueHealthChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]*models.URL, error)
+
+	// UpdateHealth records the outcome of checking a URL's destinations
+	UpdateHealth(ctx context.Context, id, status string, failures int, checkedAt time.Time) error
+
+	// IncrementDestinationClicks atomically counts a visit sent to one of a
+	// URL's destination variants, failing with ErrNotFound if the URL or the
+	// variant doesn't exist. Update keeps the variants' stored click counts.
+	IncrementDestinationClicks(ctx context.Context, id, variantID string) error
+
+	// PurgeExpired removes up to limit URLs that expired before the given time,
+	// or all of them if limit is zero or less, archiving them first if archive
+	// is set, and returns the removed URLs
+	PurgeExpired(ctx context.Context, before time.Time, archive bool, limit int) ([]*models.URL, error)
+
 	// Close closes the repository
 	Close() error
+}
+
+// ArchivedURL is a purged URL kept in the archive after its slug was freed
+type ArchivedURL struct {
+	URL        *models.URL
+	ArchivedAt time.Time
 }
\ No newline at end of file
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// MemoryAliasRepository is an in-memory implementation of the AliasRepository interface
+type MemoryAliasRepository struct {
+	aliases map[string]*models.Alias
+	mutex   sync.RWMutex
+}
+
+// NewMemoryAliasRepository creates a new in-memory alias repository
+func NewMemoryAliasRepository() *MemoryAliasRepository {
+	return &MemoryAliasRepository{
+		aliases: make(map[string]*models.Alias),
+	}
+}
+
+// CreateAlias creates a new alias
+func (r *MemoryAliasRepository) CreateAlias(ctx context.Context, alias *models.Alias) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.aliases {
+		if models.SlugKey(existing.Alias) == models.SlugKey(alias.Alias) {
+			return ErrSlugUnavailable
+		}
+	}
+
+	r.aliases[alias.Alias] = alias
+	return nil
+}
+
+// GetAlias retrieves an alias
+func (r *MemoryAliasRepository) GetAlias(ctx context.Context, alias string) (*models.Alias, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, ok := r.aliases[alias]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	// Return a copy, so callers can't change the stored counts
+	copied := *a
+	return &copied, nil
+}
+
+// GetAliasByKey retrieves an alias by its models.SlugKey
+func (r *MemoryAliasRepository) GetAliasByKey(ctx context.Context, key string) (*models.Alias, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, a := range r.aliases {
+		if models.SlugKey(a.Alias) == key {
+			copied := *a
+			return &copied, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// ListAliasesByURLID lists all aliases of a link, oldest first
+func (r *MemoryAliasRepository) ListAliasesByURLID(ctx context.Context, urlID string) ([]*models.Alias, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	aliases := []*models.Alias{}
+	for _, a := range r.aliases {
+		if a.URLID == urlID {
+			copied := *a
+			aliases = append(aliases, &copied)
+		}
+	}
+
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].CreatedAt.Before(aliases[j].CreatedAt)
+	})
+
+	return aliases, nil
+}
+
+// IncrementAliasVisits counts a visit through an alias
+func (r *MemoryAliasRepository) IncrementAliasVisits(ctx context.Context, alias string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a, ok := r.aliases[alias]
+	if !ok {
+		return ErrNotFound
+	}
+
+	a.Visits++
+	a.LastVisitAt = time.Now()
+	return nil
+}
+
+// DeleteAlias deletes an alias
+func (r *MemoryAliasRepository) DeleteAlias(ctx context.Context, alias string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.aliases[alias]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.aliases, alias)
+	return nil
+}
+
+// DeleteAliasesByURLID deletes all aliases of a link and returns them
+func (r *MemoryAliasRepository) DeleteAliasesByURLID(ctx context.Context, urlID string) ([]*models.Alias, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleted := []*models.Alias{}
+	for key, a := range r.aliases {
+		if a.URLID == urlID {
+			deleted = append(deleted, a)
+			delete(r.aliases, key)
+		}
+	}
+
+	return deleted, nil
+}
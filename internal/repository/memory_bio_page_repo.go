@@ -34,9 +34,9 @@ func (r *MemoryBioPageRepository) CreateBioPage(ctx context.Context, bioPage *mo
 	r.bioPagesMux.Lock()
 	defer r.bioPagesMux.Unlock()
 
-	// Check if short code is already in use
+	// Check if short code, or one differing only in case, is already in use
 	for _, existingPage := range r.bioPages {
-		if existingPage.ShortCode == bioPage.ShortCode {
+		if models.SlugKey(existingPage.ShortCode) == models.SlugKey(bioPage.ShortCode) {
 			return ErrSlugUnavailable
 		}
 	}
@@ -83,6 +83,22 @@ func (r *MemoryBioPageRepository) GetBioPageByShortCode(ctx context.Context, sho
 	return nil, ErrNotFound
 }
 
+// GetBioPageByShortCodeKey retrieves a bio page by the models.SlugKey of its short code
+func (r *MemoryBioPageRepository) GetBioPageByShortCodeKey(ctx context.Context, key string) (*models.BioPage, error) {
+	r.bioPagesMux.RLock()
+	defer r.bioPagesMux.RUnlock()
+
+	for _, bioPage := range r.bioPages {
+		if models.SlugKey(bioPage.ShortCode) == key {
+			// Get the links for this bio page
+			bioPage.Links, _ = r.ListBioLinksByBioPageID(ctx, bioPage.ID)
+			return bioPage, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
 // ListBioPagesByUserID lists all bio pages for a user
 func (r *MemoryBioPageRepository) ListBioPagesByUserID(ctx context.Context, userID int) ([]*models.BioPage, error) {
 	r.bioPagesMux.RLock()
@@ -259,5 +275,42 @@ func (r *MemoryBioPageRepository) ReorderBioLinks(ctx context.Context, bioPageID
 		r.bioLinks[linkID] = bioLink
 	}
 
+	return nil
+}
+
+// ListBioLinksWithStaleMetadata lists up to limit bio links whose destination metadata must be fetched
+func (r *MemoryBioPageRepository) ListBioLinksWithStaleMetadata(ctx context.Context, retryBefore time.Time, limit int) ([]*models.BioLink, error) {
+	r.bioLinksMux.RLock()
+	defer r.bioLinksMux.RUnlock()
+
+	bioLinks := []*models.BioLink{}
+	for _, bioLink := range r.bioLinks {
+		if bioLink.Metadata.NeedsFetch(bioLink.URL, retryBefore) {
+			bioLinks = append(bioLinks, bioLink)
+		}
+	}
+
+	// Sort by ID, oldest first, matching the PostgreSQL ordering
+	sort.Slice(bioLinks, func(i, j int) bool {
+		return bioLinks[i].ID < bioLinks[j].ID
+	})
+
+	if limit > 0 && len(bioLinks) > limit {
+		bioLinks = bioLinks[:limit]
+	}
+	return bioLinks, nil
+}
+
+// UpdateBioLinkMetadata records the metadata fetched for a bio link's URL
+func (r *MemoryBioPageRepository) UpdateBioLinkMetadata(ctx context.Context, id int, metadata *models.LinkMetadata) error {
+	r.bioLinksMux.Lock()
+	defer r.bioLinksMux.Unlock()
+
+	bioLink, ok := r.bioLinks[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	bioLink.Metadata = metadata
 	return nil
 }
\ No newline at end of file
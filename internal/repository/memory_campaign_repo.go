@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// MemoryCampaignRepository is an in-memory implementation of the CampaignRepository interface
+type MemoryCampaignRepository struct {
+	campaigns      map[int]*models.Campaign
+	mutex          sync.RWMutex
+	nextCampaignID int
+}
+
+// NewMemoryCampaignRepository creates a new in-memory campaign repository
+func NewMemoryCampaignRepository() *MemoryCampaignRepository {
+	return &MemoryCampaignRepository{
+		campaigns:      make(map[int]*models.Campaign),
+		nextCampaignID: 1,
+	}
+}
+
+// CreateCampaign creates a new campaign
+func (r *MemoryCampaignRepository) CreateCampaign(ctx context.Context, campaign *models.Campaign) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Assign an ID
+	campaign.ID = r.nextCampaignID
+	r.nextCampaignID++
+
+	// Store the campaign
+	r.campaigns[campaign.ID] = campaign
+
+	return nil
+}
+
+// GetCampaignByID retrieves a campaign by ID
+func (r *MemoryCampaignRepository) GetCampaignByID(ctx context.Context, id int) (*models.Campaign, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	campaign, ok := r.campaigns[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return campaign, nil
+}
+
+// ListCampaignsByUserID lists all campaigns for a user
+func (r *MemoryCampaignRepository) ListCampaignsByUserID(ctx context.Context, userID int) ([]*models.Campaign, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	campaigns := []*models.Campaign{}
+	for _, campaign := range r.campaigns {
+		if campaign.UserID == userID {
+			campaigns = append(campaigns, campaign)
+		}
+	}
+
+	// Sort by creation date (newest first)
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].CreatedAt.After(campaigns[j].CreatedAt)
+	})
+
+	return campaigns, nil
+}
+
+// UpdateCampaign updates a campaign
+func (r *MemoryCampaignRepository) UpdateCampaign(ctx context.Context, campaign *models.Campaign) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existingCampaign, ok := r.campaigns[campaign.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// Update the timestamp, preserving the created date and user ID
+	campaign.UpdatedAt = time.Now()
+	campaign.CreatedAt = existingCampaign.CreatedAt
+	campaign.UserID = existingCampaign.UserID
+
+	// Update the campaign
+	r.campaigns[campaign.ID] = campaign
+
+	return nil
+}
+
+// DeleteCampaign deletes a campaign
+func (r *MemoryCampaignRepository) DeleteCampaign(ctx context.Context, id int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.campaigns[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.campaigns, id)
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// MemoryClickRepository is an in-memory implementation of the ClickRepository interface
+type MemoryClickRepository struct {
+	clicks []*models.Click
+	nextID int64
+	// salts are the visitor salts by day, as YYYY-MM-DD
+	salts    map[string][]byte
+	sketches map[string]map[int]int
+	mutex    sync.RWMutex
+}
+
+// NewMemoryClickRepository creates a new in-memory click repository
+func NewMemoryClickRepository() *MemoryClickRepository {
+	return &MemoryClickRepository{
+		nextID:   1,
+		salts:    make(map[string][]byte),
+		sketches: make(map[string]map[int]int),
+	}
+}
+
+// RecordClick records a click
+func (r *MemoryClickRepository) RecordClick(ctx context.Context, click *models.Click) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	click.ID = r.nextID
+	r.nextID++
+	r.clicks = append(r.clicks, click)
+	return nil
+}
+
+// ListClicksByURL lists up to limit clicks on a link, newest first
+func (r *MemoryClickRepository) ListClicksByURL(ctx context.Context, urlID string, includeBots bool, limit int) ([]*models.Click, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Clicks are recorded in order, so walk them backwards
+	clicks := []*models.Click{}
+	for i := len(r.clicks) - 1; i >= 0; i-- {
+		click := r.clicks[i]
+		if click.URLID != urlID || (click.Bot && !includeBots) {
+			continue
+		}
+		clicks = append(clicks, click)
+		if limit > 0 && len(clicks) == limit {
+			break
+		}
+	}
+	return clicks, nil
+}
+
+// CountDailyVisitsByURL counts the visits by people to a link and their unique visitors by day
+func (r *MemoryClickRepository) CountDailyVisitsByURL(ctx context.Context, urlID string, since time.Time) ([]*models.DailyVisits, error) {
+	return r.countDailyVisits(since, func(click *models.Click) bool {
+		return click.URLID == urlID
+	}), nil
+}
+
+// CountDailyVisitsByBioPage counts the views by people of a bio page and their unique visitors by day
+func (r *MemoryClickRepository) CountDailyVisitsByBioPage(ctx context.Context, bioPageID int, since time.Time) ([]*models.DailyVisits, error) {
+	return r.countDailyVisits(since, func(click *models.Click) bool {
+		return click.BioPageID == bioPageID && click.BioLinkID == 0
+	}), nil
+}
+
+// countDailyVisits counts the matching clicks by people and their distinct
+// visitor hashes by day
+func (r *MemoryClickRepository) countDailyVisits(since time.Time, match func(*models.Click) bool) []*models.DailyVisits {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	days := make(map[string]*models.DailyVisits)
+	visitors := make(map[string]map[string]bool)
+	for _, click := range r.clicks {
+		if click.Bot || click.CreatedAt.Before(since) || !match(click) {
+			continue
+		}
+		date := click.CreatedAt.UTC().Format("2006-01-02")
+		if days[date] == nil {
+			days[date] = &models.DailyVisits{Date: date}
+			visitors[date] = make(map[string]bool)
+		}
+		days[date].Visits++
+		if click.VisitorHash != "" && !visitors[date][click.VisitorHash] {
+			visitors[date][click.VisitorHash] = true
+			days[date].Visitors++
+		}
+	}
+
+	counts := make([]*models.DailyVisits, 0, len(days))
+	for _, day := range days {
+		counts = append(counts, day)
+	}
+	return counts
+}
+
+// CountVisitorsByURL counts the distinct visitor hashes of a link's visits by people
+func (r *MemoryClickRepository) CountVisitorsByURL(ctx context.Context, urlID string) (int, error) {
+	return r.countVisitors(func(click *models.Click) bool {
+		return click.URLID == urlID
+	}), nil
+}
+
+// CountVisitorsByBioPage counts the distinct visitor hashes of a bio page's views by people
+func (r *MemoryClickRepository) CountVisitorsByBioPage(ctx context.Context, bioPageID int) (int, error) {
+	return r.countVisitors(func(click *models.Click) bool {
+		return click.BioPageID == bioPageID && click.BioLinkID == 0
+	}), nil
+}
+
+// countVisitors counts the distinct visitor hashes of the matching clicks by people
+func (r *MemoryClickRepository) countVisitors(match func(*models.Click) bool) int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	visitors := make(map[string]bool)
+	for _, click := range r.clicks {
+		if !click.Bot && click.VisitorHash != "" && match(click) {
+			visitors[click.VisitorHash] = true
+		}
+	}
+	return len(visitors)
+}
+
+// GetVisitorSalt returns the salt of a day, storing the given salt if the day has none yet
+func (r *MemoryClickRepository) GetVisitorSalt(ctx context.Context, day time.Time, salt []byte) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	date := day.Format("2006-01-02")
+	if stored, ok := r.salts[date]; ok {
+		return stored, nil
+	}
+	r.salts[date] = salt
+	return salt, nil
+}
+
+// DeleteVisitorSaltsBefore deletes the salts of the days before the given day
+func (r *MemoryClickRepository) DeleteVisitorSaltsBefore(ctx context.Context, day time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Dates sort as strings
+	before := day.Format("2006-01-02")
+	for date := range r.salts {
+		if date < before {
+			delete(r.salts, date)
+		}
+	}
+	return nil
+}
+
+// UpdateVisitorSketch raises a register of a visitor sketch to the given rank
+func (r *MemoryClickRepository) UpdateVisitorSketch(ctx context.Context, target string, register, rank int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.sketches[target] == nil {
+		r.sketches[target] = make(map[int]int)
+	}
+	if rank > r.sketches[target][register] {
+		r.sketches[target][register] = rank
+	}
+	return nil
+}
+
+// GetVisitorSketch returns the ranks of a visitor sketch's registers
+func (r *MemoryClickRepository) GetVisitorSketch(ctx context.Context, target string) (map[int]int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Return a copy so callers can't change the stored sketch
+	ranks := make(map[int]int, len(r.sketches[target]))
+	for register, rank := range r.sketches[target] {
+		ranks[register] = rank
+	}
+	return ranks, nil
+}
+
+// DeleteVisitorSketch deletes a visitor sketch
+func (r *MemoryClickRepository) DeleteVisitorSketch(ctx context.Context, target string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.sketches, target)
+	return nil
+}
+
+// ListClicksAfter lists up to limit clicks with IDs above afterID made since the given time
+func (r *MemoryClickRepository) ListClicksAfter(ctx context.Context, afterID int64, since time.Time, limit int) ([]*models.Click, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Clicks are recorded in ID order
+	clicks := []*models.Click{}
+	for _, click := range r.clicks {
+		if click.ID <= afterID || click.CreatedAt.Before(since) {
+			continue
+		}
+		clicks = append(clicks, click)
+		if limit > 0 && len(clicks) == limit {
+			break
+		}
+	}
+	return clicks, nil
+}
+
+// ListVisitorClicks lists the clicks by people with any of the visitor hashes
+// made since the given time, with IDs below beforeID
+func (r *MemoryClickRepository) ListVisitorClicks(ctx context.Context, visitorHashes []string, since time.Time, beforeID int64) ([]*models.Click, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	wanted := make(map[string]bool, len(visitorHashes))
+	for _, hash := range visitorHashes {
+		wanted[hash] = true
+	}
+
+	clicks := []*models.Click{}
+	for _, click := range r.clicks {
+		if click.ID >= beforeID {
+			break
+		}
+		if !click.Bot && wanted[click.VisitorHash] && !click.CreatedAt.Before(since) {
+			clicks = append(clicks, click)
+		}
+	}
+	return clicks, nil
+}
+
+// DeleteClicksBefore deletes the clicks made before the given time with IDs up to throughID
+func (r *MemoryClickRepository) DeleteClicksBefore(ctx context.Context, before time.Time, throughID int64) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	kept := r.clicks[:0]
+	deleted := 0
+	for _, click := range r.clicks {
+		if click.CreatedAt.Before(before) && click.ID <= throughID {
+			deleted++
+			continue
+		}
+		kept = append(kept, click)
+	}
+	r.clicks = kept
+	return deleted, nil
+}
+
+// DeleteClicksByURL deletes every click on a link
+func (r *MemoryClickRepository) DeleteClicksByURL(ctx context.Context, urlID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	kept := r.clicks[:0]
+	for _, click := range r.clicks {
+		if click.URLID != urlID {
+			kept = append(kept, click)
+		}
+	}
+	r.clicks = kept
+	return nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// MemoryDomainRepository is an in-memory implementation of the DomainRepository interface
+type MemoryDomainRepository struct {
+	domains      map[int]*models.Domain
+	urls         *MemoryRepository
+	mutex        sync.RWMutex
+	nextDomainID int
+}
+
+// NewMemoryDomainRepository creates a new in-memory domain repository. The
+// links of deleted domains are moved back to the default domain in urls,
+// which may be nil if no links are stored.
+func NewMemoryDomainRepository(urls *MemoryRepository) *MemoryDomainRepository {
+	return &MemoryDomainRepository{
+		domains:      make(map[int]*models.Domain),
+		urls:         urls,
+		nextDomainID: 1,
+	}
+}
+
+// CreateDomain creates a new domain
+func (r *MemoryDomainRepository) CreateDomain(ctx context.Context, domain *models.Domain) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Verified hostnames are unique across all users, the others for each user
+	for _, existing := range r.domains {
+		if existing.Hostname == domain.Hostname && (existing.IsVerified() || existing.UserID == domain.UserID) {
+			return ErrDomainTaken
+		}
+	}
+
+	// Assign an ID
+	domain.ID = r.nextDomainID
+	r.nextDomainID++
+
+	// Store the domain
+	r.domains[domain.ID] = domain
+
+	return nil
+}
+
+// GetDomainByID retrieves a domain by ID
+func (r *MemoryDomainRepository) GetDomainByID(ctx context.Context, id int) (*models.Domain, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	domain, ok := r.domains[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return domain, nil
+}
+
+// GetVerifiedDomain retrieves the verified domain with a hostname
+func (r *MemoryDomainRepository) GetVerifiedDomain(ctx context.Context, hostname string) (*models.Domain, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, domain := range r.domains {
+		if domain.Hostname == hostname && domain.IsVerified() {
+			return domain, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// ListDomainsByUserID lists all domains for a user
+func (r *MemoryDomainRepository) ListDomainsByUserID(ctx context.Context, userID int) ([]*models.Domain, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	domains := []*models.Domain{}
+	for _, domain := range r.domains {
+		if domain.UserID == userID {
+			domains = append(domains, domain)
+		}
+	}
+
+	// Sort by hostname
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Hostname < domains[j].Hostname
+	})
+
+	return domains, nil
+}
+
+// UpdateDomain updates a domain
+func (r *MemoryDomainRepository) UpdateDomain(ctx context.Context, domain *models.Domain) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existingDomain, ok := r.domains[domain.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// The hostname and owner can't change
+	domain.Hostname = existingDomain.Hostname
+	domain.UserID = existingDomain.UserID
+	domain.CreatedAt = existingDomain.CreatedAt
+
+	// Only one user can verify a hostname
+	if domain.IsVerified() {
+		for _, other := range r.domains {
+			if other.ID != domain.ID && other.Hostname == domain.Hostname && other.IsVerified() {
+				return ErrDomainTaken
+			}
+		}
+	}
+
+	// Update the domain
+	r.domains[domain.ID] = domain
+
+	return nil
+}
+
+// DeleteDomain deletes a domain
+func (r *MemoryDomainRepository) DeleteDomain(ctx context.Context, id int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	domain, ok := r.domains[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// Only the user who verified the domain can have links on it
+	if domain.IsVerified() && r.urls != nil {
+		r.urls.clearDomain(domain.Hostname, domain.UserID)
+	}
+
+	delete(r.domains, id)
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// MemoryHealthCheckRepository is an in-memory implementation of the HealthCheckRepository interface
+type MemoryHealthCheckRepository struct {
+	checks []*models.HealthCheck
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMemoryHealthCheckRepository creates a new in-memory health check repository
+func NewMemoryHealthCheckRepository() *MemoryHealthCheckRepository {
+	return &MemoryHealthCheckRepository{
+		nextID: 1,
+	}
+}
+
+// AddHealthCheck records a check of a link destination
+func (r *MemoryHealthCheckRepository) AddHealthCheck(ctx context.Context, check *models.HealthCheck) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	check.ID = r.nextID
+	r.nextID++
+	r.checks = append(r.checks, check)
+	return nil
+}
+
+// ListHealthChecks lists up to limit checks of a link's destinations, newest first
+func (r *MemoryHealthCheckRepository) ListHealthChecks(ctx context.Context, urlID string, limit int) ([]*models.HealthCheck, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	checks := []*models.HealthCheck{}
+	for _, check := range r.checks {
+		if check.URLID == urlID {
+			checks = append(checks, check)
+		}
+	}
+
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checks[i].CheckedAt.Equal(checks[j].CheckedAt) {
+			return checks[i].ID > checks[j].ID
+		}
+		return checks[i].CheckedAt.After(checks[j].CheckedAt)
+	})
+
+	if limit > 0 && len(checks) > limit {
+		checks = checks[:limit]
+	}
+	return checks, nil
+}
+
+// DeleteHealthChecksBefore deletes the checks made before the given time
+func (r *MemoryHealthCheckRepository) DeleteHealthChecksBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	kept := r.checks[:0]
+	for _, check := range r.checks {
+		if !check.CheckedAt.Before(before) {
+			kept = append(kept, check)
+		}
+	}
+	deleted := len(r.checks) - len(kept)
+	r.checks = kept
+	return deleted, nil
+}
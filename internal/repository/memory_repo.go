@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,8 +15,9 @@ var ErrNotFound = errors.New("URL not found")
 
 // MemoryRepository is an in-memory repository
 type MemoryRepository struct {
-	urls  map[string]*models.URL
-	mutex sync.RWMutex
+	urls     map[string]*models.URL
+	archived []*ArchivedURL
+	mutex    sync.RWMutex
 }
 
 // NewMemoryRepository creates a new in-memory repository
@@ -25,11 +27,23 @@ func NewMemoryRepository() *MemoryRepository {
 	}
 }
 
-// Store stores a URL in the repository
+// Store stores a URL in the repository, failing with ErrSlugUnavailable if
+// its ID, or its slug on its custom domain, is already in use
 func (r *MemoryRepository) Store(ctx context.Context, url *models.URL) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
+	// Short codes differing only in case or normalization can't both be used
+	for _, existing := range r.urls {
+		if models.SlugKey(existing.ID) == models.SlugKey(url.ID) {
+			return ErrSlugUnavailable
+		}
+		if url.Domain != "" && existing.Domain == url.Domain &&
+			models.SlugKey(existing.Slug) == models.SlugKey(url.Slug) {
+			return ErrSlugUnavailable
+		}
+	}
+
 	r.urls[url.ID] = url
 	return nil
 }
@@ -44,66 +58,293 @@ func (r *MemoryRepository) GetByID(ctx context.Context, id string) (*models.URL,
 		return nil, ErrNotFound
 	}
 	
-	// Check if URL has expired
-	if url.HasExpired() {
-		return nil, ErrNotFound
-	}
-	
+	// Expired URLs are still returned so their expiry action can be applied
 	return url, nil
 }
 
+// GetByDomainSlug retrieves a URL by its slug on a custom domain
+func (r *MemoryRepository) GetByDomainSlug(ctx context.Context, domain, slug string) (*models.URL, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, url := range r.urls {
+		if url.Domain == domain && url.Slug == slug {
+			return url, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// GetByIDKey retrieves a URL by the models.SlugKey of its ID
+func (r *MemoryRepository) GetByIDKey(ctx context.Context, key string) (*models.URL, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, url := range r.urls {
+		if models.SlugKey(url.ID) == key {
+			return url, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// GetByDomainSlugKey retrieves a URL by the models.SlugKey of its slug on a custom domain
+func (r *MemoryRepository) GetByDomainSlugKey(ctx context.Context, domain, key string) (*models.URL, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, url := range r.urls {
+		if url.Domain == domain && models.SlugKey(url.Slug) == key {
+			return url, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// clearDomain moves a user's links on a deleted custom domain back to the
+// default domain, where they stay reachable by ID
+func (r *MemoryRepository) clearDomain(domain string, userID int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, url := range r.urls {
+		if url.Domain == domain && url.UserID != nil && *url.UserID == userID {
+			url.Domain = ""
+			url.Slug = ""
+		}
+	}
+}
+
 // Update updates a URL in the repository
 func (r *MemoryRepository) Update(ctx context.Context, url *models.URL) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
-	_, ok := r.urls[url.ID]
+	existing, ok := r.urls[url.ID]
 	if !ok {
 		return ErrNotFound
 	}
+
+	// Destinations keep their stored click counts, as in PostgreSQL
+	for i := range url.Destinations {
+		if stored := existing.GetDestination(url.Destinations[i].ID); stored != nil {
+			url.Destinations[i].Clicks = stored.Clicks
+		}
+	}
 	
 	r.urls[url.ID] = url
 	return nil
 }
 
-// List lists all URLs in the repository that are not expired
+// List lists all URLs in the repository, including expired ones
 func (r *MemoryRepository) List(ctx context.Context) ([]*models.URL, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	now := time.Now()
 	urls := make([]*models.URL, 0, len(r.urls))
 	for _, url := range r.urls {
-		// Skip expired URLs
-		if url.ExpiresAt != nil && now.After(*url.ExpiresAt) {
-			continue
-		}
 		urls = append(urls, url)
 	}
+	sortURLsByCreatedAt(urls)
 	
 	return urls, nil
 }
 
-// ListByUserID lists all URLs for a user that are not expired
+// ListByUserID lists all URLs for a user, including expired ones
 func (r *MemoryRepository) ListByUserID(ctx context.Context, userID int) ([]*models.URL, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	now := time.Now()
 	urls := make([]*models.URL, 0)
 	for _, url := range r.urls {
 		if url.UserID != nil && *url.UserID == userID {
-			// Skip expired URLs
-			if url.ExpiresAt != nil && now.After(*url.ExpiresAt) {
-				continue
-			}
 			urls = append(urls, url)
 		}
 	}
+	sortURLsByCreatedAt(urls)
 	
 	return urls, nil
 }
 
+// ListByUserDestination lists a user's URLs to a destination, newest first
+func (r *MemoryRepository) ListByUserDestination(ctx context.Context, userID int, destination string) ([]*models.URL, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	urls := make([]*models.URL, 0)
+	for _, url := range r.urls {
+		if url.UserID != nil && *url.UserID == userID && url.OriginalURL == destination {
+			urls = append(urls, url)
+		}
+	}
+	sortURLsByCreatedAt(urls)
+
+	return urls, nil
+}
+
+// ListByScreeningStatus lists the URLs with a screening status, newest first
+func (r *MemoryRepository) ListByScreeningStatus(ctx context.Context, status string) ([]*models.URL, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	urls := make([]*models.URL, 0)
+	for _, url := range r.urls {
+		if url.ScreeningStatus == status {
+			urls = append(urls, url)
+		}
+	}
+	sortURLsByCreatedAt(urls)
+
+	return urls, nil
+}
+
+// UpdateScreening records the outcome of screening a URL's destinations
+func (r *MemoryRepository) UpdateScreening(ctx context.Context, id, status, reason string, screenedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	url, ok := r.urls[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	url.ScreeningStatus = status
+	url.ScreeningReason = reason
+	url.ScreenedAt = &screenedAt
+	return nil
+}
+
+// ListStaleMetadata lists up to limit URLs whose destination metadata must be fetched, newest first
+func (r *MemoryRepository) ListStaleMetadata(ctx context.Context, retryBefore time.Time, limit int) ([]*models.URL, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	urls := make([]*models.URL, 0)
+	for _, url := range r.urls {
+		if url.Metadata.NeedsFetch(url.OriginalURL, retryBefore) {
+			urls = append(urls, url)
+		}
+	}
+	sortURLsByCreatedAt(urls)
+
+	if limit > 0 && len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
+}
+
+// UpdateMetadata records the metadata fetched for a URL's destination
+func (r *MemoryRepository) UpdateMetadata(ctx context.Context, id string, metadata *models.LinkMetadata) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	url, ok := r.urls[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	url.Metadata = metadata
+	return nil
+}
+
+// ListDueHealthChecks lists up to limit unexpired, unblocked URLs due a
+// health check, never checked first and then least recently checked
+func (r *MemoryRepository) ListDueHealthChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]*models.URL, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	urls := make([]*models.URL, 0)
+	for _, url := range r.urls {
+		if url.HasExpired() || url.IsBlocked() {
+			continue
+		}
+		if url.HealthCheckedAt == nil || url.HealthCheckedAt.Before(checkedBefore) {
+			urls = append(urls, url)
+		}
+	}
+	sortURLsByCreatedAt(urls)
+	sort.SliceStable(urls, func(i, j int) bool {
+		a, b := urls[i].HealthCheckedAt, urls[j].HealthCheckedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+
+	if limit > 0 && len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
+}
+
+// UpdateHealth records the outcome of checking a URL's destinations
+func (r *MemoryRepository) UpdateHealth(ctx context.Context, id, status string, failures int, checkedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	url, ok := r.urls[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	url.HealthStatus = status
+	url.HealthFailures = failures
+	url.HealthCheckedAt = &checkedAt
+	return nil
+}
+
+// IncrementDestinationClicks counts a visit sent to one of a URL's destination variants
+func (r *MemoryRepository) IncrementDestinationClicks(ctx context.Context, id, variantID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	url, ok := r.urls[id]
+	if !ok {
+		return ErrNotFound
+	}
+	destination := url.GetDestination(variantID)
+	if destination == nil {
+		return ErrNotFound
+	}
+
+	destination.Clicks++
+	return nil
+}
+
+// sortURLsByCreatedAt sorts URLs newest first, matching the PostgreSQL ordering
+func sortURLsByCreatedAt(urls []*models.URL) {
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].CreatedAt.After(urls[j].CreatedAt)
+	})
+}
+
+// PurgeExpired removes up to limit URLs that expired before the given time
+func (r *MemoryRepository) PurgeExpired(ctx context.Context, before time.Time, archive bool, limit int) ([]*models.URL, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := make([]*models.URL, 0)
+	for id, url := range r.urls {
+		if limit > 0 && len(purged) >= limit {
+			break
+		}
+		if url.ExpiresAt == nil || !url.ExpiresAt.Before(before) {
+			continue
+		}
+
+		if archive {
+			r.archived = append(r.archived, &ArchivedURL{URL: url, ArchivedAt: time.Now()})
+		}
+		delete(r.urls, id)
+		purged = append(purged, url)
+	}
+
+	return purged, nil
+}
+
 // Close closes the repository
 func (r *MemoryRepository) Close() error {
 	return nil
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryReservedSlugRepository is an in-memory implementation of the ReservedSlugRepository interface
+type MemoryReservedSlugRepository struct {
+	slugs map[string]bool
+	mutex sync.RWMutex
+}
+
+// NewMemoryReservedSlugRepository creates a new in-memory reserved slug repository
+func NewMemoryReservedSlugRepository() *MemoryReservedSlugRepository {
+	return &MemoryReservedSlugRepository{
+		slugs: make(map[string]bool),
+	}
+}
+
+// AddReservedSlug reserves a slug
+func (r *MemoryReservedSlugRepository) AddReservedSlug(ctx context.Context, slug string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.slugs[slug] = true
+	return nil
+}
+
+// DeleteReservedSlug frees a reserved slug
+func (r *MemoryReservedSlugRepository) DeleteReservedSlug(ctx context.Context, slug string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.slugs[slug] {
+		return ErrNotFound
+	}
+	delete(r.slugs, slug)
+	return nil
+}
+
+// ListReservedSlugs lists all reserved slugs
+func (r *MemoryReservedSlugRepository) ListReservedSlugs(ctx context.Context) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	slugs := make([]string, 0, len(r.slugs))
+	for slug := range r.slugs {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	return slugs, nil
+}
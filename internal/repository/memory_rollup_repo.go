@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// rollupKey identifies a stored rollup
+type rollupKey struct {
+	target      models.RollupTarget
+	granularity string
+	bucket      int64
+}
+
+// breakdownKey identifies a stored breakdown
+type breakdownKey struct {
+	rollupKey
+	dimension string
+	value     string
+}
+
+// MemoryRollupRepository is an in-memory implementation of the RollupRepository interface
+type MemoryRollupRepository struct {
+	rollups    map[rollupKey]*models.ClickRollup
+	breakdowns map[breakdownKey]*models.ClickBreakdown
+	watermark  int64
+	mutex      sync.RWMutex
+}
+
+// NewMemoryRollupRepository creates a new in-memory rollup repository
+func NewMemoryRollupRepository() *MemoryRollupRepository {
+	return &MemoryRollupRepository{
+		rollups:    make(map[rollupKey]*models.ClickRollup),
+		breakdowns: make(map[breakdownKey]*models.ClickBreakdown),
+	}
+}
+
+// GetRollupWatermark returns the ID of the last click rolled up
+func (r *MemoryRollupRepository) GetRollupWatermark(ctx context.Context) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.watermark, nil
+}
+
+// AddRollups adds the counts of rollups and breakdowns and raises the watermark
+func (r *MemoryRollupRepository) AddRollups(ctx context.Context, rollups []*models.ClickRollup, breakdowns []*models.ClickBreakdown, lastClickID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, rollup := range rollups {
+		key := rollupKey{rollup.RollupTarget, rollup.Granularity, rollup.Bucket.Unix()}
+		stored, ok := r.rollups[key]
+		if !ok {
+			stored = &models.ClickRollup{
+				RollupTarget: rollup.RollupTarget,
+				Granularity:  rollup.Granularity,
+				Bucket:       rollup.Bucket,
+			}
+			r.rollups[key] = stored
+		}
+		stored.Clicks += rollup.Clicks
+		stored.BotClicks += rollup.BotClicks
+		stored.Visitors += rollup.Visitors
+	}
+
+	for _, breakdown := range breakdowns {
+		key := breakdownKey{
+			rollupKey{breakdown.RollupTarget, breakdown.Granularity, breakdown.Bucket.Unix()},
+			breakdown.Dimension,
+			breakdown.Value,
+		}
+		stored, ok := r.breakdowns[key]
+		if !ok {
+			stored = &models.ClickBreakdown{
+				RollupTarget: breakdown.RollupTarget,
+				Granularity:  breakdown.Granularity,
+				Bucket:       breakdown.Bucket,
+				Dimension:    breakdown.Dimension,
+				Value:        breakdown.Value,
+			}
+			r.breakdowns[key] = stored
+		}
+		stored.Clicks += breakdown.Clicks
+	}
+
+	if lastClickID > r.watermark {
+		r.watermark = lastClickID
+	}
+	return nil
+}
+
+// DeleteRollupsSince deletes the rollups and breakdowns of the buckets starting at or after the given time
+func (r *MemoryRollupRepository) DeleteRollupsSince(ctx context.Context, since time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for key, rollup := range r.rollups {
+		if !rollup.Bucket.Before(since) {
+			delete(r.rollups, key)
+		}
+	}
+	for key, breakdown := range r.breakdowns {
+		if !breakdown.Bucket.Before(since) {
+			delete(r.breakdowns, key)
+		}
+	}
+	return nil
+}
+
+// DeleteRollupsByURL deletes every rollup and breakdown of a link
+func (r *MemoryRollupRepository) DeleteRollupsByURL(ctx context.Context, urlID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for key, rollup := range r.rollups {
+		if rollup.URLID == urlID {
+			delete(r.rollups, key)
+		}
+	}
+	for key, breakdown := range r.breakdowns {
+		if breakdown.URLID == urlID {
+			delete(r.breakdowns, key)
+		}
+	}
+	return nil
+}
+
+// ListRollups lists a target's rollups of a granularity since the given time, oldest first
+func (r *MemoryRollupRepository) ListRollups(ctx context.Context, target models.RollupTarget, granularity string, since time.Time) ([]*models.ClickRollup, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	rollups := []*models.ClickRollup{}
+	for _, rollup := range r.rollups {
+		if rollup.RollupTarget == target && rollup.Granularity == granularity && !rollup.Bucket.Before(since) {
+			// Return a copy so callers can't change the stored rollup
+			copied := *rollup
+			rollups = append(rollups, &copied)
+		}
+	}
+
+	sort.Slice(rollups, func(i, j int) bool {
+		return rollups[i].Bucket.Before(rollups[j].Bucket)
+	})
+	return rollups, nil
+}
+
+// SumBreakdowns sums a target's breakdowns of a granularity since the given time by dimension and value
+func (r *MemoryRollupRepository) SumBreakdowns(ctx context.Context, target models.RollupTarget, granularity string, since time.Time) ([]*models.ClickBreakdown, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	type sumKey struct {
+		dimension string
+		value     string
+	}
+	sums := make(map[sumKey]*models.ClickBreakdown)
+	for _, breakdown := range r.breakdowns {
+		if breakdown.RollupTarget != target || breakdown.Granularity != granularity || breakdown.Bucket.Before(since) {
+			continue
+		}
+		key := sumKey{breakdown.Dimension, breakdown.Value}
+		if sums[key] == nil {
+			sums[key] = &models.ClickBreakdown{
+				RollupTarget: target,
+				Granularity:  granularity,
+				Dimension:    breakdown.Dimension,
+				Value:        breakdown.Value,
+			}
+		}
+		sums[key].Clicks += breakdown.Clicks
+	}
+
+	breakdowns := make([]*models.ClickBreakdown, 0, len(sums))
+	for _, sum := range sums {
+		breakdowns = append(breakdowns, sum)
+	}
+	sort.Slice(breakdowns, func(i, j int) bool {
+		if breakdowns[i].Clicks != breakdowns[j].Clicks {
+			return breakdowns[i].Clicks > breakdowns[j].Clicks
+		}
+		return breakdowns[i].Value < breakdowns[j].Value
+	})
+	return breakdowns, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySequenceRepository is an in-memory implementation of the SequenceRepository interface
+type MemorySequenceRepository struct {
+	next  map[string]uint64
+	mutex sync.Mutex
+}
+
+// NewMemorySequenceRepository creates a new in-memory sequence repository
+func NewMemorySequenceRepository() *MemorySequenceRepository {
+	return &MemorySequenceRepository{
+		next: make(map[string]uint64),
+	}
+}
+
+// ReserveBlock reserves the next size numbers of a sequence
+func (r *MemorySequenceRepository) ReserveBlock(ctx context.Context, name string, size int) (uint64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	start, ok := r.next[name]
+	if !ok {
+		start = 1
+	}
+	r.next[name] = start + uint64(size)
+
+	return start, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// MemorySlugRegistryRepository is an in-memory implementation of the SlugRegistryRepository interface
+type MemorySlugRegistryRepository struct {
+	kinds map[string]string
+	mutex sync.RWMutex
+}
+
+// NewMemorySlugRegistryRepository creates a new in-memory slug registry repository
+func NewMemorySlugRegistryRepository() *MemorySlugRegistryRepository {
+	return &MemorySlugRegistryRepository{
+		kinds: make(map[string]string),
+	}
+}
+
+// ClaimSlug records a slug as used
+func (r *MemorySlugRegistryRepository) ClaimSlug(ctx context.Context, slug, kind string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for claimed := range r.kinds {
+		if models.SlugKey(claimed) == models.SlugKey(slug) {
+			return ErrSlugUnavailable
+		}
+	}
+	r.kinds[slug] = kind
+	return nil
+}
+
+// ReleaseSlug frees a slug
+func (r *MemorySlugRegistryRepository) ReleaseSlug(ctx context.Context, slug, kind string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.kinds[slug] == kind {
+		delete(r.kinds, slug)
+	}
+	return nil
+}
+
+// GetSlugKind returns the kind of resource that claimed a slug
+func (r *MemorySlugRegistryRepository) GetSlugKind(ctx context.Context, slug string) (string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	kind, ok := r.kinds[slug]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return kind, nil
+}
+
+// GetSlugKindByKey returns the kind of resource that claimed the slug with a models.SlugKey
+func (r *MemorySlugRegistryRepository) GetSlugKindByKey(ctx context.Context, key string) (string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for slug, kind := range r.kinds {
+		if models.SlugKey(slug) == key {
+			return kind, nil
+		}
+	}
+	return "", ErrNotFound
+}
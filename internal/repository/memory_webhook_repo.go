@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// MemoryWebhookRepository is an in-memory implementation of the WebhookRepository interface
+type MemoryWebhookRepository struct {
+	webhooks       map[int]*models.Webhook
+	deliveries     map[int]*models.WebhookDelivery
+	mutex          sync.RWMutex
+	nextWebhookID  int
+	nextDeliveryID int
+}
+
+// NewMemoryWebhookRepository creates a new in-memory webhook repository
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		webhooks:       make(map[int]*models.Webhook),
+		deliveries:     make(map[int]*models.WebhookDelivery),
+		nextWebhookID:  1,
+		nextDeliveryID: 1,
+	}
+}
+
+// CreateWebhook creates a new webhook
+func (r *MemoryWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	webhook.ID = r.nextWebhookID
+	r.nextWebhookID++
+	r.webhooks[webhook.ID] = webhook
+
+	return nil
+}
+
+// GetWebhookByID retrieves a webhook by ID
+func (r *MemoryWebhookRepository) GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooksByUserID lists all webhooks for a user, oldest first
+func (r *MemoryWebhookRepository) ListWebhooksByUserID(ctx context.Context, userID int) ([]*models.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhooks := []*models.Webhook{}
+	for _, webhook := range r.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].ID < webhooks[j].ID
+	})
+
+	return webhooks, nil
+}
+
+// UpdateWebhook updates a webhook's URL, events and whether it is active
+func (r *MemoryWebhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, ok := r.webhooks[webhook.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// The owner and secret can't change
+	webhook.UserID = existing.UserID
+	webhook.Secret = existing.Secret
+	webhook.CreatedAt = existing.CreatedAt
+	r.webhooks[webhook.ID] = webhook
+
+	return nil
+}
+
+// DeleteWebhook deletes a webhook and its deliveries
+func (r *MemoryWebhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.webhooks, id)
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+// CreateDelivery records a delivery
+func (r *MemoryWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[delivery.WebhookID]; !ok {
+		return ErrNotFound
+	}
+
+	delivery.ID = r.nextDeliveryID
+	r.nextDeliveryID++
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+
+	return nil
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *MemoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.deliveries[delivery.ID]; !ok {
+		return ErrNotFound
+	}
+
+	// Store a copy, so deliveries being attempted aren't changed under readers
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+
+	return nil
+}
+
+// ListDueDeliveries lists up to limit pending deliveries due at the given time, the longest due first
+func (r *MemoryWebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	deliveries := []*models.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if deliveries[i].NextAttemptAt.Equal(*deliveries[j].NextAttemptAt) {
+			return deliveries[i].ID < deliveries[j].ID
+		}
+		return deliveries[i].NextAttemptAt.Before(*deliveries[j].NextAttemptAt)
+	})
+
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// ListDeliveries lists up to limit deliveries to a webhook, newest first
+func (r *MemoryWebhookRepository) ListDeliveries(ctx context.Context, webhookID int, limit int) ([]*models.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	deliveries := []*models.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// DeleteDeliveriesBefore deletes the finished deliveries created before the given time
+func (r *MemoryWebhookRepository) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleted := 0
+	for id, delivery := range r.deliveries {
+		if delivery.Status != models.WebhookDeliveryPending && delivery.CreatedAt.Before(before) {
+			delete(r.deliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/lib/pq"
+)
+
+// aliasColumns is the column list used when selecting aliases, in scanAlias order
+const aliasColumns = `alias, url_id, visits, last_visit_at, created_at`
+
+// PostgresAliasRepository is a PostgreSQL implementation of the AliasRepository interface
+type PostgresAliasRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAliasRepository creates a new PostgreSQL alias repository
+func NewPostgresAliasRepository(db *sql.DB) (*PostgresAliasRepository, error) {
+	return &PostgresAliasRepository{
+		db: db,
+	}, nil
+}
+
+// CreateAlias creates a new alias
+func (r *PostgresAliasRepository) CreateAlias(ctx context.Context, alias *models.Alias) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO url_aliases (alias, url_id, visits, created_at, alias_key) VALUES ($1, $2, $3, $4, $5)",
+		alias.Alias,
+		alias.URLID,
+		alias.Visits,
+		alias.CreatedAt,
+		models.SlugKey(alias.Alias),
+	)
+	if err != nil {
+		// Check for unique violation
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrSlugUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetAlias retrieves an alias
+func (r *PostgresAliasRepository) GetAlias(ctx context.Context, alias string) (*models.Alias, error) {
+	a, err := scanAlias(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+aliasColumns+" FROM url_aliases WHERE alias = $1",
+		alias,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// GetAliasByKey retrieves an alias by its models.SlugKey
+func (r *PostgresAliasRepository) GetAliasByKey(ctx context.Context, key string) (*models.Alias, error) {
+	a, err := scanAlias(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+aliasColumns+" FROM url_aliases WHERE alias_key = $1",
+		key,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// ListAliasesByURLID lists all aliases of a link, oldest first
+func (r *PostgresAliasRepository) ListAliasesByURLID(ctx context.Context, urlID string) ([]*models.Alias, error) {
+	return r.queryAliases(
+		ctx,
+		"SELECT "+aliasColumns+" FROM url_aliases WHERE url_id = $1 ORDER BY created_at",
+		urlID,
+	)
+}
+
+// IncrementAliasVisits counts a visit through an alias
+func (r *PostgresAliasRepository) IncrementAliasVisits(ctx context.Context, alias string) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE url_aliases SET visits = visits + 1, last_visit_at = NOW() WHERE alias = $1",
+		alias,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Check if the alias exists
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteAlias deletes an alias
+func (r *PostgresAliasRepository) DeleteAlias(ctx context.Context, alias string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM url_aliases WHERE alias = $1", alias)
+	if err != nil {
+		return err
+	}
+
+	// Check if the alias existed
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteAliasesByURLID deletes all aliases of a link and returns them
+func (r *PostgresAliasRepository) DeleteAliasesByURLID(ctx context.Context, urlID string) ([]*models.Alias, error) {
+	return r.queryAliases(
+		ctx,
+		"DELETE FROM url_aliases WHERE url_id = $1 RETURNING "+aliasColumns,
+		urlID,
+	)
+}
+
+// queryAliases runs a query returning aliasColumns and scans every row
+func (r *PostgresAliasRepository) queryAliases(ctx context.Context, query string, args ...interface{}) ([]*models.Alias, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []*models.Alias{}
+	for rows.Next() {
+		a, err := scanAlias(rows)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// scanAlias scans a row selected with aliasColumns into an alias
+func scanAlias(row rowScanner) (*models.Alias, error) {
+	var a models.Alias
+	var lastVisitAt sql.NullTime
+
+	if err := row.Scan(&a.Alias, &a.URLID, &a.Visits, &lastVisitAt, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if lastVisitAt.Valid {
+		a.LastVisitAt = lastVisitAt.Time
+	}
+
+	return &a, nil
+}
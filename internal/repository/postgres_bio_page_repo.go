@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -35,8 +36,8 @@ func (r *PostgresBioPageRepository) CreateBioPage(ctx context.Context, bioPage *
 	err = tx.QueryRowContext(
 		ctx,
 		`INSERT INTO bio_pages (user_id, short_code, title, description, theme, profile_image_url, 
-                              created_at, updated_at, visits, last_visit_at, is_published, custom_css) 
-         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
+                              created_at, updated_at, visits, last_visit_at, is_published, custom_css, short_code_key) 
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) 
          RETURNING id`,
 		bioPage.UserID,
 		bioPage.ShortCode,
@@ -50,6 +51,7 @@ func (r *PostgresBioPageRepository) CreateBioPage(ctx context.Context, bioPage *
 		nil, // last_visit_at starts as NULL
 		bioPage.IsPublished,
 		bioPage.CustomCSS,
+		models.SlugKey(bioPage.ShortCode),
 	).Scan(&bioPage.ID)
 
 	if err != nil {
@@ -128,6 +130,16 @@ func (r *PostgresBioPageRepository) GetBioPageByID(ctx context.Context, id int)
 
 // GetBioPageByShortCode retrieves a bio page by short code
 func (r *PostgresBioPageRepository) GetBioPageByShortCode(ctx context.Context, shortCode string) (*models.BioPage, error) {
+	return r.getBioPageBy(ctx, "short_code", shortCode)
+}
+
+// GetBioPageByShortCodeKey retrieves a bio page by the models.SlugKey of its short code
+func (r *PostgresBioPageRepository) GetBioPageByShortCodeKey(ctx context.Context, key string) (*models.BioPage, error) {
+	return r.getBioPageBy(ctx, "short_code_key", key)
+}
+
+// getBioPageBy retrieves a bio page by the value of a short code column
+func (r *PostgresBioPageRepository) getBioPageBy(ctx context.Context, column, value string) (*models.BioPage, error) {
 	var bioPage models.BioPage
 	var lastVisitAt sql.NullTime
 	var description sql.NullString
@@ -139,8 +151,8 @@ func (r *PostgresBioPageRepository) GetBioPageByShortCode(ctx context.Context, s
 		`SELECT id, user_id, short_code, title, description, theme, profile_image_url, 
                 created_at, updated_at, visits, last_visit_at, is_published, custom_css 
          FROM bio_pages 
-         WHERE short_code = $1`,
-		shortCode,
+         WHERE `+column+` = $1`,
+		value,
 	).Scan(
 		&bioPage.ID,
 		&bioPage.UserID,
@@ -391,10 +403,11 @@ func (r *PostgresBioPageRepository) CreateBioLink(ctx context.Context, bioLink *
 func (r *PostgresBioPageRepository) GetBioLinkByID(ctx context.Context, id int) (*models.BioLink, error) {
 	var bioLink models.BioLink
 	var icon sql.NullString
+	var metadata []byte
 
 	err := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, bio_page_id, title, url, display_order, icon, created_at, updated_at, visits, is_enabled 
+		`SELECT id, bio_page_id, title, url, display_order, icon, created_at, updated_at, visits, is_enabled, metadata 
          FROM bio_links 
          WHERE id = $1`,
 		id,
@@ -409,6 +422,7 @@ func (r *PostgresBioPageRepository) GetBioLinkByID(ctx context.Context, id int)
 		&bioLink.UpdatedAt,
 		&bioLink.Visits,
 		&bioLink.IsEnabled,
+		&metadata,
 	)
 
 	if err != nil {
@@ -422,6 +436,9 @@ func (r *PostgresBioPageRepository) GetBioLinkByID(ctx context.Context, id int)
 	if icon.Valid {
 		bioLink.Icon = icon.String
 	}
+	if err := decodeMetadata(metadata, &bioLink.Metadata); err != nil {
+		return nil, err
+	}
 
 	return &bioLink, nil
 }
@@ -430,7 +447,7 @@ func (r *PostgresBioPageRepository) GetBioLinkByID(ctx context.Context, id int)
 func (r *PostgresBioPageRepository) ListBioLinksByBioPageID(ctx context.Context, bioPageID int) ([]*models.BioLink, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT id, bio_page_id, title, url, display_order, icon, created_at, updated_at, visits, is_enabled 
+		`SELECT id, bio_page_id, title, url, display_order, icon, created_at, updated_at, visits, is_enabled, metadata 
          FROM bio_links 
          WHERE bio_page_id = $1
          ORDER BY display_order ASC`,
@@ -445,6 +462,7 @@ func (r *PostgresBioPageRepository) ListBioLinksByBioPageID(ctx context.Context,
 	for rows.Next() {
 		var bioLink models.BioLink
 		var icon sql.NullString
+		var metadata []byte
 
 		err := rows.Scan(
 			&bioLink.ID,
@@ -457,6 +475,7 @@ func (r *PostgresBioPageRepository) ListBioLinksByBioPageID(ctx context.Context,
 			&bioLink.UpdatedAt,
 			&bioLink.Visits,
 			&bioLink.IsEnabled,
+			&metadata,
 		)
 		if err != nil {
 			return nil, err
@@ -466,6 +485,9 @@ func (r *PostgresBioPageRepository) ListBioLinksByBioPageID(ctx context.Context,
 		if icon.Valid {
 			bioLink.Icon = icon.String
 		}
+		if err := decodeMetadata(metadata, &bioLink.Metadata); err != nil {
+			return nil, err
+		}
 
 		bioLinks = append(bioLinks, &bioLink)
 	}
@@ -584,4 +606,96 @@ func (r *PostgresBioPageRepository) ReorderBioLinks(ctx context.Context, bioPage
 
 	// Commit the transaction
 	return tx.Commit()
+}
+
+// ListBioLinksWithStaleMetadata lists up to limit bio links whose destination
+// metadata is missing, was fetched for another URL, or failed before retryBefore
+func (r *PostgresBioPageRepository) ListBioLinksWithStaleMetadata(ctx context.Context, retryBefore time.Time, limit int) ([]*models.BioLink, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, bio_page_id, title, url, display_order, icon, created_at, updated_at, visits, is_enabled, metadata 
+         FROM bio_links 
+         WHERE metadata IS NULL OR metadata->>'url' IS DISTINCT FROM url
+            OR (metadata->>'error' <> '' AND (metadata->>'fetched_at')::timestamptz < $1)
+         ORDER BY id ASC
+         LIMIT $2`,
+		retryBefore,
+		limitArg(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bioLinks := []*models.BioLink{}
+	for rows.Next() {
+		var bioLink models.BioLink
+		var icon sql.NullString
+		var metadata []byte
+
+		err := rows.Scan(
+			&bioLink.ID,
+			&bioLink.BioPageID,
+			&bioLink.Title,
+			&bioLink.URL,
+			&bioLink.DisplayOrder,
+			&icon,
+			&bioLink.CreatedAt,
+			&bioLink.UpdatedAt,
+			&bioLink.Visits,
+			&bioLink.IsEnabled,
+			&metadata,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Handle nullable fields
+		if icon.Valid {
+			bioLink.Icon = icon.String
+		}
+		if err := decodeMetadata(metadata, &bioLink.Metadata); err != nil {
+			return nil, err
+		}
+
+		bioLinks = append(bioLinks, &bioLink)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bioLinks, nil
+}
+
+// UpdateBioLinkMetadata records the metadata fetched for a bio link's URL
+// without touching its other fields, which may have changed meanwhile
+func (r *PostgresBioPageRepository) UpdateBioLinkMetadata(ctx context.Context, id int, metadata *models.LinkMetadata) error {
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, "UPDATE bio_links SET metadata = $1 WHERE id = $2", encoded, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// decodeMetadata decodes a metadata column, which is NULL until metadata is fetched
+func decodeMetadata(data []byte, metadata **models.LinkMetadata) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, metadata)
 }
\ No newline at end of file
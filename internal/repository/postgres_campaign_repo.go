@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// campaignColumns is the column list used when selecting campaigns, in scanCampaign order
+const campaignColumns = `id, user_id, name, utm_campaign, channels, created_at, updated_at`
+
+// PostgresCampaignRepository is a PostgreSQL implementation of the CampaignRepository interface
+type PostgresCampaignRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCampaignRepository creates a new PostgreSQL campaign repository
+func NewPostgresCampaignRepository(db *sql.DB) (*PostgresCampaignRepository, error) {
+	return &PostgresCampaignRepository{
+		db: db,
+	}, nil
+}
+
+// CreateCampaign creates a new campaign
+func (r *PostgresCampaignRepository) CreateCampaign(ctx context.Context, campaign *models.Campaign) error {
+	channels, err := json.Marshal(campaign.Channels)
+	if err != nil {
+		return err
+	}
+
+	// Insert the campaign
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO campaigns (user_id, name, utm_campaign, channels, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id`,
+		campaign.UserID,
+		campaign.Name,
+		campaign.UTMCampaign,
+		channels,
+		campaign.CreatedAt,
+		campaign.UpdatedAt,
+	).Scan(&campaign.ID)
+}
+
+// GetCampaignByID retrieves a campaign by ID
+func (r *PostgresCampaignRepository) GetCampaignByID(ctx context.Context, id int) (*models.Campaign, error) {
+	campaign, err := scanCampaign(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+campaignColumns+" FROM campaigns WHERE id = $1",
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// ListCampaignsByUserID lists all campaigns for a user
+func (r *PostgresCampaignRepository) ListCampaignsByUserID(ctx context.Context, userID int) ([]*models.Campaign, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+campaignColumns+" FROM campaigns WHERE user_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := []*models.Campaign{}
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// UpdateCampaign updates a campaign
+func (r *PostgresCampaignRepository) UpdateCampaign(ctx context.Context, campaign *models.Campaign) error {
+	channels, err := json.Marshal(campaign.Channels)
+	if err != nil {
+		return err
+	}
+
+	// Update the timestamp
+	campaign.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE campaigns SET name = $1, utm_campaign = $2, channels = $3, updated_at = $4
+		 WHERE id = $5`,
+		campaign.Name,
+		campaign.UTMCampaign,
+		channels,
+		campaign.UpdatedAt,
+		campaign.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Check if the campaign was updated
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteCampaign deletes a campaign. Its links are kept, with their campaign cleared.
+func (r *PostgresCampaignRepository) DeleteCampaign(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM campaigns WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if the campaign was deleted
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// scanCampaign scans a row selected with campaignColumns into a campaign
+func scanCampaign(row rowScanner) (*models.Campaign, error) {
+	var campaign models.Campaign
+	var channels []byte
+
+	err := row.Scan(
+		&campaign.ID,
+		&campaign.UserID,
+		&campaign.Name,
+		&campaign.UTMCampaign,
+		&channels,
+		&campaign.CreatedAt,
+		&campaign.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the channels
+	campaign.Channels = make([]models.CampaignChannel, 0)
+	if len(channels) > 0 {
+		if err := json.Unmarshal(channels, &campaign.Channels); err != nil {
+			return nil, err
+		}
+	}
+
+	return &campaign, nil
+}
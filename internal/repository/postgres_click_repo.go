@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// clickColumns is the column list used when selecting clicks, in scan order
+const clickColumns = `id, url_id, alias, bio_page_id, bio_link_id, destination, referrer, user_agent, bot, bot_reason, visitor_hash, country, device, created_at`
+
+// PostgresClickRepository is a PostgreSQL implementation of the ClickRepository interface
+type PostgresClickRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresClickRepository creates a new PostgreSQL click repository
+func NewPostgresClickRepository(db *sql.DB) (*PostgresClickRepository, error) {
+	return &PostgresClickRepository{
+		db: db,
+	}, nil
+}
+
+// RecordClick records a click
+func (r *PostgresClickRepository) RecordClick(ctx context.Context, click *models.Click) error {
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO clicks (url_id, alias, bio_page_id, bio_link_id, destination, referrer, user_agent, bot, bot_reason, visitor_hash, country, device, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`,
+		click.URLID,
+		click.Alias,
+		click.BioPageID,
+		click.BioLinkID,
+		click.Destination,
+		click.Referrer,
+		click.UserAgent,
+		click.Bot,
+		click.BotReason,
+		click.VisitorHash,
+		click.Country,
+		click.Device,
+		click.CreatedAt,
+	).Scan(&click.ID)
+}
+
+// ListClicksByURL lists up to limit clicks on a link, newest first
+func (r *PostgresClickRepository) ListClicksByURL(ctx context.Context, urlID string, includeBots bool, limit int) ([]*models.Click, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+clickColumns+` FROM clicks
+		 WHERE url_id = $1 AND ($2 OR NOT bot) ORDER BY created_at DESC, id DESC LIMIT $3`,
+		urlID,
+		includeBots,
+		limitArg(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanClicks(rows)
+}
+
+// ListClicksAfter lists up to limit clicks with IDs above afterID made since the given time
+func (r *PostgresClickRepository) ListClicksAfter(ctx context.Context, afterID int64, since time.Time, limit int) ([]*models.Click, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+clickColumns+" FROM clicks WHERE id > $1 AND created_at >= $2 ORDER BY id LIMIT $3",
+		afterID,
+		since,
+		limitArg(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanClicks(rows)
+}
+
+// ListVisitorClicks lists the clicks by people with any of the visitor hashes
+// made since the given time, with IDs below beforeID
+func (r *PostgresClickRepository) ListVisitorClicks(ctx context.Context, visitorHashes []string, since time.Time, beforeID int64) ([]*models.Click, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+clickColumns+` FROM clicks
+		 WHERE visitor_hash = ANY($1) AND visitor_hash <> '' AND NOT bot AND created_at >= $2 AND id < $3`,
+		pq.Array(visitorHashes),
+		since,
+		beforeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanClicks(rows)
+}
+
+// DeleteClicksBefore deletes the clicks made before the given time with IDs up to throughID
+func (r *PostgresClickRepository) DeleteClicksBefore(ctx context.Context, before time.Time, throughID int64) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM clicks WHERE created_at < $1 AND id <= $2", before, throughID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
+
+// DeleteClicksByURL deletes every click on a link
+func (r *PostgresClickRepository) DeleteClicksByURL(ctx context.Context, urlID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM clicks WHERE url_id = $1", urlID)
+	return err
+}
+
+// scanClicks scans rows of clickColumns into clicks
+func scanClicks(rows *sql.Rows) ([]*models.Click, error) {
+	clicks := []*models.Click{}
+	for rows.Next() {
+		var click models.Click
+		err := rows.Scan(
+			&click.ID,
+			&click.URLID,
+			&click.Alias,
+			&click.BioPageID,
+			&click.BioLinkID,
+			&click.Destination,
+			&click.Referrer,
+			&click.UserAgent,
+			&click.Bot,
+			&click.BotReason,
+			&click.VisitorHash,
+			&click.Country,
+			&click.Device,
+			&click.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		clicks = append(clicks, &click)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clicks, nil
+}
+
+// CountDailyVisitsByURL counts the visits by people to a link and their unique visitors by day
+func (r *PostgresClickRepository) CountDailyVisitsByURL(ctx context.Context, urlID string, since time.Time) ([]*models.DailyVisits, error) {
+	return r.countDailyVisits(ctx, "url_id = $1", urlID, since)
+}
+
+// CountDailyVisitsByBioPage counts the views by people of a bio page and their unique visitors by day
+func (r *PostgresClickRepository) CountDailyVisitsByBioPage(ctx context.Context, bioPageID int, since time.Time) ([]*models.DailyVisits, error) {
+	return r.countDailyVisits(ctx, "bio_page_id = $1 AND bio_link_id = 0", bioPageID, since)
+}
+
+// countDailyVisits counts the clicks by people matching the condition on $1
+// and their distinct visitor hashes by day
+func (r *PostgresClickRepository) countDailyVisits(ctx context.Context, condition string, target interface{}, since time.Time) ([]*models.DailyVisits, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT TO_CHAR(created_at::date, 'YYYY-MM-DD'), COUNT(*), COUNT(DISTINCT NULLIF(visitor_hash, ''))
+		 FROM clicks WHERE `+condition+` AND NOT bot AND created_at >= $2
+		 GROUP BY created_at::date`,
+		target,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*models.DailyVisits{}
+	for rows.Next() {
+		var day models.DailyVisits
+		if err := rows.Scan(&day.Date, &day.Visits, &day.Visitors); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// CountVisitorsByURL counts the distinct visitor hashes of a link's visits by people
+func (r *PostgresClickRepository) CountVisitorsByURL(ctx context.Context, urlID string) (int, error) {
+	var visitors int
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(DISTINCT visitor_hash) FROM clicks WHERE url_id = $1 AND NOT bot AND visitor_hash <> ''",
+		urlID,
+	).Scan(&visitors)
+	return visitors, err
+}
+
+// CountVisitorsByBioPage counts the distinct visitor hashes of a bio page's views by people
+func (r *PostgresClickRepository) CountVisitorsByBioPage(ctx context.Context, bioPageID int) (int, error) {
+	var visitors int
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(DISTINCT visitor_hash) FROM clicks WHERE bio_page_id = $1 AND bio_link_id = 0 AND NOT bot AND visitor_hash <> ''",
+		bioPageID,
+	).Scan(&visitors)
+	return visitors, err
+}
+
+// GetVisitorSalt returns the salt of a day, storing the given salt if the day
+// has none yet. Instances racing to store a salt all get the first one stored.
+func (r *PostgresClickRepository) GetVisitorSalt(ctx context.Context, day time.Time, salt []byte) ([]byte, error) {
+	date := day.Format("2006-01-02")
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO visitor_salts (day, salt) VALUES ($1, $2) ON CONFLICT (day) DO NOTHING",
+		date,
+		salt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []byte
+	err = r.db.QueryRowContext(ctx, "SELECT salt FROM visitor_salts WHERE day = $1", date).Scan(&stored)
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// DeleteVisitorSaltsBefore deletes the salts of the days before the given day
+func (r *PostgresClickRepository) DeleteVisitorSaltsBefore(ctx context.Context, day time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM visitor_salts WHERE day < $1", day.Format("2006-01-02"))
+	return err
+}
+
+// UpdateVisitorSketch raises a register of a visitor sketch to the given rank
+func (r *PostgresClickRepository) UpdateVisitorSketch(ctx context.Context, target string, register, rank int) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO visitor_sketches (target, register, rank) VALUES ($1, $2, $3)
+		 ON CONFLICT (target, register) DO UPDATE SET rank = GREATEST(visitor_sketches.rank, EXCLUDED.rank)`,
+		target,
+		register,
+		rank,
+	)
+	return err
+}
+
+// GetVisitorSketch returns the ranks of a visitor sketch's registers
+func (r *PostgresClickRepository) GetVisitorSketch(ctx context.Context, target string) (map[int]int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT register, rank FROM visitor_sketches WHERE target = $1", target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranks := make(map[int]int)
+	for rows.Next() {
+		var register, rank int
+		if err := rows.Scan(&register, &rank); err != nil {
+			return nil, err
+		}
+		ranks[register] = rank
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranks, nil
+}
+
+// DeleteVisitorSketch deletes a visitor sketch
+func (r *PostgresClickRepository) DeleteVisitorSketch(ctx context.Context, target string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM visitor_sketches WHERE target = $1", target)
+	return err
+}
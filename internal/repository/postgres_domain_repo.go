@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/lib/pq"
+)
+
+// domainColumns is the column list used when selecting domains, in scanDomain order
+const domainColumns = `id, user_id, hostname, verification_token, verified_at, created_at`
+
+// PostgresDomainRepository is a PostgreSQL implementation of the DomainRepository interface
+type PostgresDomainRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresDomainRepository creates a new PostgreSQL domain repository
+func NewPostgresDomainRepository(db *sql.DB) (*PostgresDomainRepository, error) {
+	return &PostgresDomainRepository{
+		db: db,
+	}, nil
+}
+
+// CreateDomain creates a new domain, unless someone has verified its hostname
+func (r *PostgresDomainRepository) CreateDomain(ctx context.Context, domain *models.Domain) error {
+	err := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO domains (user_id, hostname, verification_token, verified_at, created_at)
+		 SELECT $1::int, $2::varchar, $3::varchar, $4::timestamp, $5::timestamp
+		 WHERE NOT EXISTS (SELECT 1 FROM domains WHERE hostname = $2::varchar AND verified_at IS NOT NULL)
+		 RETURNING id`,
+		domain.UserID,
+		domain.Hostname,
+		domain.VerificationToken,
+		domain.VerifiedAt,
+		domain.CreatedAt,
+	).Scan(&domain.ID)
+	if err != nil {
+		// No row is inserted for a verified hostname, and the user's own
+		// hostnames are unique
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDomainTaken
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDomainTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetDomainByID retrieves a domain by ID
+func (r *PostgresDomainRepository) GetDomainByID(ctx context.Context, id int) (*models.Domain, error) {
+	return r.getDomain(ctx, "id = $1", id)
+}
+
+// GetVerifiedDomain retrieves the verified domain with a hostname
+func (r *PostgresDomainRepository) GetVerifiedDomain(ctx context.Context, hostname string) (*models.Domain, error) {
+	return r.getDomain(ctx, "hostname = $1 AND verified_at IS NOT NULL", hostname)
+}
+
+// getDomain retrieves the domain matching a condition
+func (r *PostgresDomainRepository) getDomain(ctx context.Context, condition string, arg interface{}) (*models.Domain, error) {
+	domain, err := scanDomain(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+domainColumns+" FROM domains WHERE "+condition,
+		arg,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return domain, nil
+}
+
+// ListDomainsByUserID lists all domains for a user
+func (r *PostgresDomainRepository) ListDomainsByUserID(ctx context.Context, userID int) ([]*models.Domain, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+domainColumns+" FROM domains WHERE user_id = $1 ORDER BY hostname",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := []*models.Domain{}
+	for rows.Next() {
+		domain, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+// UpdateDomain updates a domain's verification state
+func (r *PostgresDomainRepository) UpdateDomain(ctx context.Context, domain *models.Domain) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE domains SET verification_token = $1, verified_at = $2 WHERE id = $3`,
+		domain.VerificationToken,
+		domain.VerifiedAt,
+		domain.ID,
+	)
+	if err != nil {
+		// Someone else verified the hostname first
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDomainTaken
+		}
+		return err
+	}
+
+	// Check if the domain was updated
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteDomain deletes a domain and moves its links back to the default domain
+func (r *PostgresDomainRepository) DeleteDomain(ctx context.Context, id int) error {
+	// Begin a transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hostname string
+	var userID int
+	var verified bool
+	err = tx.QueryRowContext(
+		ctx,
+		`DELETE FROM domains WHERE id = $1 RETURNING hostname, user_id, verified_at IS NOT NULL`,
+		id,
+	).Scan(&hostname, &userID, &verified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	// Only the user who verified the domain can have links on it
+	if verified {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE urls SET domain = '', slug = '', slug_key = '' WHERE domain = $1 AND user_id = $2`,
+			hostname,
+			userID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// scanDomain scans a row selected with domainColumns into a domain
+func scanDomain(row rowScanner) (*models.Domain, error) {
+	var domain models.Domain
+	var verifiedAt sql.NullTime
+
+	err := row.Scan(
+		&domain.ID,
+		&domain.UserID,
+		&domain.Hostname,
+		&domain.VerificationToken,
+		&verifiedAt,
+		&domain.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set VerifiedAt if not NULL
+	if verifiedAt.Valid {
+		domain.VerifiedAt = &verifiedAt.Time
+	}
+
+	return &domain, nil
+}
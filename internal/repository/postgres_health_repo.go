@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// PostgresHealthCheckRepository is a PostgreSQL implementation of the HealthCheckRepository interface
+type PostgresHealthCheckRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresHealthCheckRepository creates a new PostgreSQL health check repository
+func NewPostgresHealthCheckRepository(db *sql.DB) (*PostgresHealthCheckRepository, error) {
+	return &PostgresHealthCheckRepository{
+		db: db,
+	}, nil
+}
+
+// AddHealthCheck records a check of a link destination
+func (r *PostgresHealthCheckRepository) AddHealthCheck(ctx context.Context, check *models.HealthCheck) error {
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO link_health_checks (url_id, destination, status_code, error, duration_ms, checked_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		check.URLID,
+		check.Destination,
+		check.StatusCode,
+		check.Error,
+		check.DurationMs,
+		check.CheckedAt,
+	).Scan(&check.ID)
+}
+
+// ListHealthChecks lists up to limit checks of a link's destinations, newest first
+func (r *PostgresHealthCheckRepository) ListHealthChecks(ctx context.Context, urlID string, limit int) ([]*models.HealthCheck, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, url_id, destination, status_code, error, duration_ms, checked_at
+		 FROM link_health_checks WHERE url_id = $1 ORDER BY checked_at DESC, id DESC LIMIT $2`,
+		urlID,
+		limitArg(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []*models.HealthCheck{}
+	for rows.Next() {
+		var check models.HealthCheck
+		if err := rows.Scan(&check.ID, &check.URLID, &check.Destination, &check.StatusCode, &check.Error, &check.DurationMs, &check.CheckedAt); err != nil {
+			return nil, err
+		}
+		checks = append(checks, &check)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return checks, nil
+}
+
+// DeleteHealthChecksBefore deletes the checks made before the given time
+func (r *PostgresHealthCheckRepository) DeleteHealthChecksBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM link_health_checks WHERE checked_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
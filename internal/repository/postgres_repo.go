@@ -3,13 +3,33 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq"
 )
 
+// urlColumns is the column list used when selecting URLs, in scanURL order
+const urlColumns = `id, original_url, created_at, visits, last_visit_at, user_id, expires_at, password_hash,
+	expiry_action, expiry_redirect_url, expiry_message, targeting_rules,
+	destinations, sticky_rotation, query_mode, path_passthrough, campaign_id, channel,
+	redirect_status, cache_policy, domain, slug, canonical_alias,
+	screening_status, screening_reason, screened_at, title, preview, metadata,
+	social_title, social_description, social_image_url, health_status, health_failures, health_checked_at, bot_visits`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// limitArg is the value of a LIMIT parameter. A limit of zero or less becomes
+// NULL, which limits nothing, as in the memory repositories.
+func limitArg(limit int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+}
+
 // PostgresRepository is a PostgreSQL implementation of the Repository interface
 type PostgresRepository struct {
 	db *sql.DB
@@ -39,10 +59,32 @@ func (r *PostgresRepository) Store(ctx context.Context, url *models.URL) error {
 		lastVisitAt = url.LastVisitAt
 	}
 
+	// Encode the targeting rules and destinations as JSON
+	targetingRules, err := json.Marshal(url.TargetingRules)
+	if err != nil {
+		return err
+	}
+	destinations, err := json.Marshal(url.Destinations)
+	if err != nil {
+		return err
+	}
+
+	// Only links on a custom domain have a slug
+	slugKey := ""
+	if url.Domain != "" {
+		slugKey = models.SlugKey(url.Slug)
+	}
+
 	// Insert the URL - using time values for created_at and last_visit_at
 	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO urls (id, original_url, created_at, visits, last_visit_at, user_id, expires_at, password_hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		`INSERT INTO urls (id, original_url, created_at, visits, last_visit_at, user_id, expires_at, password_hash,
+		                   expiry_action, expiry_redirect_url, expiry_message, targeting_rules, destinations, sticky_rotation,
+		                   query_mode, path_passthrough, campaign_id, channel, redirect_status, cache_policy, domain, slug,
+		                   screening_status, screening_reason, screened_at, title, preview,
+		                   social_title, social_description, social_image_url, bot_visits, id_key, slug_key)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+		         $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33)`,
 		url.ID,
 		url.OriginalURL,
 		url.CreatedAt,
@@ -51,8 +93,37 @@ func (r *PostgresRepository) Store(ctx context.Context, url *models.URL) error {
 		url.UserID,
 		url.ExpiresAt,
 		url.PasswordHash,
+		url.GetExpiryAction(),
+		url.ExpiryRedirectURL,
+		url.ExpiryMessage,
+		targetingRules,
+		destinations,
+		url.StickyRotation,
+		url.GetQueryMode(),
+		url.PathPassthrough,
+		url.CampaignID,
+		url.Channel,
+		url.RedirectStatus,
+		url.CachePolicy,
+		url.Domain,
+		url.Slug,
+		url.ScreeningStatus,
+		url.ScreeningReason,
+		url.ScreenedAt,
+		url.Title,
+		url.Preview,
+		url.SocialTitle,
+		url.SocialDescription,
+		url.SocialImageURL,
+		url.BotVisits,
+		models.SlugKey(url.ID),
+		slugKey,
 	)
 	if err != nil {
+		// Check for unique violation of the ID or the domain slug, or their keys
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrSlugUnavailable
+		}
 		return err
 	}
 
@@ -60,29 +131,14 @@ func (r *PostgresRepository) Store(ctx context.Context, url *models.URL) error {
 	return tx.Commit()
 }
 
-// GetByID retrieves a URL by its ID
+// GetByID retrieves a URL by its ID. Expired URLs are returned as well so
+// that callers can apply the URL's expiry action.
 func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*models.URL, error) {
-	// Query the URL
-	var url models.URL
-	var lastVisitAt sql.NullTime
-	var userID sql.NullInt64
-	var expiresAt sql.NullTime
-	var passwordHash sql.NullString
-
-	err := r.db.QueryRowContext(
+	url, err := scanURL(r.db.QueryRowContext(
 		ctx,
-		"SELECT id, original_url, created_at, visits, last_visit_at, user_id, expires_at, password_hash FROM urls WHERE id = $1",
+		"SELECT "+urlColumns+" FROM urls WHERE id = $1",
 		id,
-	).Scan(
-		&url.ID,
-		&url.OriginalURL,
-		&url.CreatedAt,
-		&url.Visits,
-		&lastVisitAt,
-		&userID,
-		&expiresAt,
-		&passwordHash,
-	)
+	))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
@@ -90,41 +146,60 @@ func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*models.UR
 		return nil, err
 	}
 
-	// Set LastVisitAt if not NULL
-	if lastVisitAt.Valid {
-		url.LastVisitAt = lastVisitAt.Time
-	} else {
-		url.LastVisitAt = time.Time{}
-	}
+	return url, nil
+}
 
-	// Set UserID if not NULL
-	if userID.Valid {
-		userId := int(userID.Int64)
-		url.UserID = &userId
-	} else {
-		url.UserID = nil
+// GetByIDKey retrieves a URL by the models.SlugKey of its ID, even if it has expired
+func (r *PostgresRepository) GetByIDKey(ctx context.Context, key string) (*models.URL, error) {
+	url, err := scanURL(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE id_key = $1",
+		key,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
 	}
 
-	// Set ExpiresAt if not NULL
-	if expiresAt.Valid {
-		url.ExpiresAt = &expiresAt.Time
-	} else {
-		url.ExpiresAt = nil
-	}
+	return url, nil
+}
 
-	// Set PasswordHash if not NULL
-	if passwordHash.Valid {
-		url.PasswordHash = passwordHash.String
-	} else {
-		url.PasswordHash = ""
+// GetByDomainSlug retrieves a URL by its slug on a custom domain
+func (r *PostgresRepository) GetByDomainSlug(ctx context.Context, domain, slug string) (*models.URL, error) {
+	url, err := scanURL(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE domain = $1 AND slug = $2",
+		domain,
+		slug,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
 	}
 
-	// Check if URL has expired
-	if url.HasExpired() {
-		return nil, ErrNotFound
+	return url, nil
+}
+
+// GetByDomainSlugKey retrieves a URL by the models.SlugKey of its slug on a custom domain
+func (r *PostgresRepository) GetByDomainSlugKey(ctx context.Context, domain, key string) (*models.URL, error) {
+	url, err := scanURL(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE domain = $1 AND slug_key = $2",
+		domain,
+		key,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
 	}
 
-	return &url, nil
+	return url, nil
 }
 
 // Update updates a URL in the repository
@@ -144,16 +219,67 @@ func (r *PostgresRepository) Update(ctx context.Context, url *models.URL) error
 		lastVisitAt = url.LastVisitAt
 	}
 
-	// Update the URL
+	// Encode the targeting rules and destinations as JSON
+	targetingRules, err := json.Marshal(url.TargetingRules)
+	if err != nil {
+		return err
+	}
+	destinations, err := json.Marshal(url.Destinations)
+	if err != nil {
+		return err
+	}
+
+	// Update the URL. Destinations keep their stored click counts, which are
+	// only changed by IncrementDestinationClicks, so an update made with a
+	// stale copy of the URL doesn't undo visits counted in the meantime.
 	result, err := tx.ExecContext(
 		ctx,
-		"UPDATE urls SET original_url = $1, visits = $2, last_visit_at = $3, user_id = $4, expires_at = $5, password_hash = $6 WHERE id = $7",
+		`UPDATE urls SET original_url = $1, visits = $2, last_visit_at = $3, user_id = $4, expires_at = $5, password_hash = $6,
+		                 expiry_action = $7, expiry_redirect_url = $8, expiry_message = $9, targeting_rules = $10,
+		                 destinations = CASE WHEN jsonb_typeof($11::jsonb) <> 'array' THEN $11::jsonb ELSE (
+		                     SELECT COALESCE(jsonb_agg(
+		                                CASE WHEN stored.d IS NULL THEN given.d
+		                                     ELSE jsonb_set(given.d, '{clicks}', COALESCE(stored.d->'clicks', '0'))
+		                                END ORDER BY given.ord), '[]')
+		                       FROM jsonb_array_elements($11::jsonb) WITH ORDINALITY AS given(d, ord)
+		                       LEFT JOIN jsonb_array_elements(
+		                                CASE WHEN jsonb_typeof(urls.destinations) = 'array' THEN urls.destinations ELSE '[]' END
+		                            ) AS stored(d) ON stored.d->>'id' = given.d->>'id'
+		                 ) END,
+		                 sticky_rotation = $12, query_mode = $13, path_passthrough = $14,
+		                 campaign_id = $15, channel = $16, redirect_status = $17, cache_policy = $18,
+		                 canonical_alias = $19, screening_status = $20, screening_reason = $21, screened_at = $22,
+		                 title = $23, preview = $24, social_title = $25, social_description = $26, social_image_url = $27,
+		                 bot_visits = $28
+		 WHERE id = $29`,
 		url.OriginalURL,
 		url.Visits,
 		lastVisitAt,
 		url.UserID,
 		url.ExpiresAt,
 		url.PasswordHash,
+		url.GetExpiryAction(),
+		url.ExpiryRedirectURL,
+		url.ExpiryMessage,
+		targetingRules,
+		destinations,
+		url.StickyRotation,
+		url.GetQueryMode(),
+		url.PathPassthrough,
+		url.CampaignID,
+		url.Channel,
+		url.RedirectStatus,
+		url.CachePolicy,
+		url.CanonicalAlias,
+		url.ScreeningStatus,
+		url.ScreeningReason,
+		url.ScreenedAt,
+		url.Title,
+		url.Preview,
+		url.SocialTitle,
+		url.SocialDescription,
+		url.SocialImageURL,
+		url.BotVisits,
 		url.ID,
 	)
 	if err != nil {
@@ -173,94 +299,253 @@ func (r *PostgresRepository) Update(ctx context.Context, url *models.URL) error
 	return tx.Commit()
 }
 
-// List lists all URLs in the repository
+// List lists all URLs in the repository, including expired ones
 func (r *PostgresRepository) List(ctx context.Context) ([]*models.URL, error) {
-	// Query all URLs that are not expired
-	rows, err := r.db.QueryContext(
+	return r.queryURLs(
+		ctx,
+		"SELECT "+urlColumns+" FROM urls ORDER BY created_at DESC",
+	)
+}
+
+// ListByUserID lists all URLs for a user, including expired ones
+func (r *PostgresRepository) ListByUserID(ctx context.Context, userID int) ([]*models.URL, error) {
+	return r.queryURLs(
+		ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE user_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+}
+
+// ListByUserDestination lists a user's URLs to a destination, newest first.
+// Destinations are matched on their hash first, which is indexed.
+func (r *PostgresRepository) ListByUserDestination(ctx context.Context, userID int, destination string) ([]*models.URL, error) {
+	return r.queryURLs(
 		ctx,
-		`SELECT id, original_url, created_at, visits, last_visit_at, user_id, expires_at, password_hash FROM urls 
-		 WHERE expires_at IS NULL OR expires_at > $1
-		 ORDER BY created_at DESC`,
-		time.Now(),
+		"SELECT "+urlColumns+" FROM urls WHERE user_id = $1 AND md5(original_url) = md5($2) AND original_url = $2 ORDER BY created_at DESC",
+		userID,
+		destination,
 	)
+}
+
+// ListByScreeningStatus lists the URLs with a screening status, newest first
+func (r *PostgresRepository) ListByScreeningStatus(ctx context.Context, status string) ([]*models.URL, error) {
+	return r.queryURLs(
+		ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE screening_status = $1 ORDER BY created_at DESC",
+		status,
+	)
+}
+
+// UpdateScreening records the outcome of screening a URL's destinations
+// without touching its other fields, which may have changed meanwhile
+func (r *PostgresRepository) UpdateScreening(ctx context.Context, id, status, reason string, screenedAt time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE urls SET screening_status = $1, screening_reason = $2, screened_at = $3 WHERE id = $4",
+		status,
+		reason,
+		screenedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListStaleMetadata lists up to limit URLs whose destination metadata is
+// missing, was fetched for another destination, or failed before retryBefore
+func (r *PostgresRepository) ListStaleMetadata(ctx context.Context, retryBefore time.Time, limit int) ([]*models.URL, error) {
+	return r.queryURLs(
+		ctx,
+		`SELECT `+urlColumns+` FROM urls
+		 WHERE metadata IS NULL OR metadata->>'url' IS DISTINCT FROM original_url
+		    OR (metadata->>'error' <> '' AND (metadata->>'fetched_at')::timestamptz < $1)
+		 ORDER BY created_at DESC LIMIT $2`,
+		retryBefore,
+		limitArg(limit),
+	)
+}
+
+// UpdateMetadata records the metadata fetched for a URL's destination
+// without touching its other fields, which may have changed meanwhile
+func (r *PostgresRepository) UpdateMetadata(ctx context.Context, id string, metadata *models.LinkMetadata) error {
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, "UPDATE urls SET metadata = $1 WHERE id = $2", encoded, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDueHealthChecks lists up to limit unexpired, unblocked URLs due a
+// health check, never checked first and then least recently checked
+func (r *PostgresRepository) ListDueHealthChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]*models.URL, error) {
+	return r.queryURLs(
+		ctx,
+		`SELECT `+urlColumns+` FROM urls
+		 WHERE (expires_at IS NULL OR expires_at > NOW()) AND screening_status <> $1
+		   AND (health_checked_at IS NULL OR health_checked_at < $2)
+		 ORDER BY health_checked_at NULLS FIRST, created_at DESC LIMIT $3`,
+		models.ScreeningStatusBlocked,
+		checkedBefore,
+		limitArg(limit),
+	)
+}
+
+// UpdateHealth records the outcome of checking a URL's destinations
+// without touching its other fields, which may have changed meanwhile
+func (r *PostgresRepository) UpdateHealth(ctx context.Context, id, status string, failures int, checkedAt time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE urls SET health_status = $1, health_failures = $2, health_checked_at = $3 WHERE id = $4",
+		status,
+		failures,
+		checkedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// IncrementDestinationClicks counts a visit sent to one of a URL's destination
+// variants. Updating the row locks it, so concurrent visits are all counted.
+func (r *PostgresRepository) IncrementDestinationClicks(ctx context.Context, id, variantID string) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE urls SET destinations = (
+		     SELECT jsonb_agg(CASE WHEN d->>'id' = $2
+		                           THEN jsonb_set(d, '{clicks}', to_jsonb(COALESCE((d->>'clicks')::int, 0) + 1))
+		                           ELSE d END ORDER BY ord)
+		       FROM jsonb_array_elements(destinations) WITH ORDINALITY AS variant(d, ord)
+		 )
+		 WHERE id = $1 AND destinations @> jsonb_build_array(jsonb_build_object('id', $2::text))`,
+		id,
+		variantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeExpired removes up to limit URLs that expired before the given time,
+// copying them into archived_urls first if archive is set
+func (r *PostgresRepository) PurgeExpired(ctx context.Context, before time.Time, archive bool, limit int) ([]*models.URL, error) {
+	// Begin a transaction
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	// Parse the rows
-	urls := []*models.URL{}
+	// Delete a batch of expired URLs, using idx_urls_expires_at to find them
+	rows, err := tx.QueryContext(
+		ctx,
+		`DELETE FROM urls WHERE id IN (
+		     SELECT id FROM urls WHERE expires_at < $1 ORDER BY expires_at LIMIT $2
+		 )
+		 RETURNING `+urlColumns,
+		before,
+		limitArg(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	purged := []*models.URL{}
 	for rows.Next() {
-		var url models.URL
-		var lastVisitAt sql.NullTime
-		var userID sql.NullInt64
-		var expiresAt sql.NullTime
-		var passwordHash sql.NullString
-
-		err := rows.Scan(
-			&url.ID,
-			&url.OriginalURL,
-			&url.CreatedAt,
-			&url.Visits,
-			&lastVisitAt,
-			&userID,
-			&expiresAt,
-			&passwordHash,
-		)
+		url, err := scanURL(rows)
 		if err != nil {
+			rows.Close()
 			return nil, err
 		}
+		purged = append(purged, url)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		// Set LastVisitAt if not NULL
-		if lastVisitAt.Valid {
-			url.LastVisitAt = lastVisitAt.Time
-		} else {
-			url.LastVisitAt = time.Time{}
-		}
-
-		// Set UserID if not NULL
-		if userID.Valid {
-			userId := int(userID.Int64)
-			url.UserID = &userId
-		} else {
-			url.UserID = nil
-		}
-
-		// Set ExpiresAt if not NULL
-		if expiresAt.Valid {
-			url.ExpiresAt = &expiresAt.Time
-		} else {
-			url.ExpiresAt = nil
-		}
-
-		// Set PasswordHash if not NULL
-		if passwordHash.Valid {
-			url.PasswordHash = passwordHash.String
-		} else {
-			url.PasswordHash = ""
+	// Archive the purged URLs
+	if archive {
+		for _, url := range purged {
+			_, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO archived_urls (id, original_url, created_at, visits, user_id, expires_at, archived_at)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				url.ID,
+				url.OriginalURL,
+				url.CreatedAt,
+				url.Visits,
+				url.UserID,
+				url.ExpiresAt,
+				time.Now(),
+			)
+			if err != nil {
+				return nil, err
+			}
 		}
-
-		urls = append(urls, &url)
 	}
 
-	if err := rows.Err(); err != nil {
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	return urls, nil
+	return purged, nil
 }
 
-// ListByUserID lists all URLs for a user
-func (r *PostgresRepository) ListByUserID(ctx context.Context, userID int) ([]*models.URL, error) {
-	// Query all URLs for a user that are not expired
-	rows, err := r.db.QueryContext(
-		ctx,
-		`SELECT id, original_url, created_at, visits, last_visit_at, user_id, expires_at, password_hash FROM urls 
-		 WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > $2)
-		 ORDER BY created_at DESC`,
-		userID,
-		time.Now(),
-	)
+// Close closes the repository
+func (r *PostgresRepository) Close() error {
+	return r.db.Close()
+}
+
+// queryURLs runs a query selecting urlColumns and scans every row
+func (r *PostgresRepository) queryURLs(ctx context.Context, query string, args ...interface{}) ([]*models.URL, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -269,65 +554,137 @@ func (r *PostgresRepository) ListByUserID(ctx context.Context, userID int) ([]*m
 	// Parse the rows
 	urls := []*models.URL{}
 	for rows.Next() {
-		var url models.URL
-		var lastVisitAt sql.NullTime
-		var userIDSql sql.NullInt64
-		var expiresAt sql.NullTime
-		var passwordHash sql.NullString
-
-		err := rows.Scan(
-			&url.ID,
-			&url.OriginalURL,
-			&url.CreatedAt,
-			&url.Visits,
-			&lastVisitAt,
-			&userIDSql,
-			&expiresAt,
-			&passwordHash,
-		)
+		url, err := scanURL(rows)
 		if err != nil {
 			return nil, err
 		}
+		urls = append(urls, url)
+	}
 
-		// Set LastVisitAt if not NULL
-		if lastVisitAt.Valid {
-			url.LastVisitAt = lastVisitAt.Time
-		} else {
-			url.LastVisitAt = time.Time{}
-		}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		// Set UserID if not NULL (it should always be valid in this case, but just to be safe)
-		if userIDSql.Valid {
-			userId := int(userIDSql.Int64)
-			url.UserID = &userId
-		} else {
-			url.UserID = nil
-		}
+	return urls, nil
+}
 
-		// Set ExpiresAt if not NULL
-		if expiresAt.Valid {
-			url.ExpiresAt = &expiresAt.Time
-		} else {
-			url.ExpiresAt = nil
-		}
+// scanURL scans a row selected with urlColumns into a URL
+func scanURL(row rowScanner) (*models.URL, error) {
+	var url models.URL
+	var lastVisitAt sql.NullTime
+	var userID sql.NullInt64
+	var expiresAt sql.NullTime
+	var passwordHash sql.NullString
+	var expiryAction sql.NullString
+	var expiryRedirectURL sql.NullString
+	var expiryMessage sql.NullString
+	var targetingRules []byte
+	var destinations []byte
+	var campaignID sql.NullInt64
+	var channel sql.NullString
+	var screenedAt sql.NullTime
+	var metadata []byte
+	var healthCheckedAt sql.NullTime
+
+	err := row.Scan(
+		&url.ID,
+		&url.OriginalURL,
+		&url.CreatedAt,
+		&url.Visits,
+		&lastVisitAt,
+		&userID,
+		&expiresAt,
+		&passwordHash,
+		&expiryAction,
+		&expiryRedirectURL,
+		&expiryMessage,
+		&targetingRules,
+		&destinations,
+		&url.StickyRotation,
+		&url.QueryMode,
+		&url.PathPassthrough,
+		&campaignID,
+		&channel,
+		&url.RedirectStatus,
+		&url.CachePolicy,
+		&url.Domain,
+		&url.Slug,
+		&url.CanonicalAlias,
+		&url.ScreeningStatus,
+		&url.ScreeningReason,
+		&screenedAt,
+		&url.Title,
+		&url.Preview,
+		&metadata,
+		&url.SocialTitle,
+		&url.SocialDescription,
+		&url.SocialImageURL,
+		&url.HealthStatus,
+		&url.HealthFailures,
+		&healthCheckedAt,
+		&url.BotVisits,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		// Set PasswordHash if not NULL
-		if passwordHash.Valid {
-			url.PasswordHash = passwordHash.String
-		} else {
-			url.PasswordHash = ""
-		}
+	// Set LastVisitAt if not NULL
+	if lastVisitAt.Valid {
+		url.LastVisitAt = lastVisitAt.Time
+	} else {
+		url.LastVisitAt = time.Time{}
+	}
 
-		urls = append(urls, &url)
+	// Set UserID if not NULL
+	if userID.Valid {
+		userId := int(userID.Int64)
+		url.UserID = &userId
 	}
 
-	if err := rows.Err(); err != nil {
+	// Set ExpiresAt if not NULL
+	if expiresAt.Valid {
+		url.ExpiresAt = &expiresAt.Time
+	}
+
+	// Set ScreenedAt if not NULL
+	if screenedAt.Valid {
+		url.ScreenedAt = &screenedAt.Time
+	}
+
+	// Set HealthCheckedAt if not NULL
+	if healthCheckedAt.Valid {
+		url.HealthCheckedAt = &healthCheckedAt.Time
+	}
+
+	// Set CampaignID if not NULL
+	if campaignID.Valid {
+		id := int(campaignID.Int64)
+		url.CampaignID = &id
+	}
+
+	// Handle the remaining nullable fields
+	url.PasswordHash = passwordHash.String
+	url.ExpiryAction = expiryAction.String
+	url.ExpiryRedirectURL = expiryRedirectURL.String
+	url.ExpiryMessage = expiryMessage.String
+	url.Channel = channel.String
+
+	// Decode the destination metadata
+	if err := decodeMetadata(metadata, &url.Metadata); err != nil {
 		return nil, err
 	}
 
-	return urls, nil
+	// Decode the targeting rules and destinations
+	if len(targetingRules) > 0 {
+		if err := json.Unmarshal(targetingRules, &url.TargetingRules); err != nil {
+			return nil, err
+		}
+	}
+	if len(destinations) > 0 {
+		if err := json.Unmarshal(destinations, &url.Destinations); err != nil {
+			return nil, err
+		}
+	}
+
+	return &url, nil
 }
-// Close closes the repository
-func (r *PostgresRepository) Close() error {
-	return r.db.Close()
-}
\ No newline at end of file
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PostgresReservedSlugRepository is a PostgreSQL implementation of the ReservedSlugRepository interface
+type PostgresReservedSlugRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresReservedSlugRepository creates a new PostgreSQL reserved slug repository
+func NewPostgresReservedSlugRepository(db *sql.DB) (*PostgresReservedSlugRepository, error) {
+	return &PostgresReservedSlugRepository{
+		db: db,
+	}, nil
+}
+
+// AddReservedSlug reserves a slug
+func (r *PostgresReservedSlugRepository) AddReservedSlug(ctx context.Context, slug string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO reserved_slugs (slug) VALUES ($1) ON CONFLICT (slug) DO NOTHING",
+		slug,
+	)
+	return err
+}
+
+// DeleteReservedSlug frees a reserved slug
+func (r *PostgresReservedSlugRepository) DeleteReservedSlug(ctx context.Context, slug string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM reserved_slugs WHERE slug = $1", slug)
+	if err != nil {
+		return err
+	}
+
+	// Check if the slug was reserved
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListReservedSlugs lists all reserved slugs
+func (r *PostgresReservedSlugRepository) ListReservedSlugs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT slug FROM reserved_slugs ORDER BY slug")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slugs []string
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		slugs = append(slugs, slug)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slugs, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// PostgresRollupRepository is a PostgreSQL implementation of the RollupRepository interface
+type PostgresRollupRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRollupRepository creates a new PostgreSQL rollup repository
+func NewPostgresRollupRepository(db *sql.DB) (*PostgresRollupRepository, error) {
+	return &PostgresRollupRepository{
+		db: db,
+	}, nil
+}
+
+// GetRollupWatermark returns the ID of the last click rolled up
+func (r *PostgresRollupRepository) GetRollupWatermark(ctx context.Context) (int64, error) {
+	var watermark int64
+	err := r.db.QueryRowContext(ctx, "SELECT last_click_id FROM rollup_state WHERE id = 1").Scan(&watermark)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return watermark, err
+}
+
+// AddRollups adds the counts of rollups and breakdowns and raises the
+// watermark in one transaction, so no click is counted twice
+func (r *PostgresRollupRepository) AddRollups(ctx context.Context, rollups []*models.ClickRollup, breakdowns []*models.ClickBreakdown, lastClickID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rollup := range rollups {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO click_rollups (url_id, bio_page_id, bio_link_id, granularity, bucket, clicks, bot_clicks, visitors)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			 ON CONFLICT (url_id, bio_page_id, bio_link_id, granularity, bucket) DO UPDATE SET
+			 clicks = click_rollups.clicks + EXCLUDED.clicks,
+			 bot_clicks = click_rollups.bot_clicks + EXCLUDED.bot_clicks,
+			 visitors = click_rollups.visitors + EXCLUDED.visitors`,
+			rollup.URLID,
+			rollup.BioPageID,
+			rollup.BioLinkID,
+			rollup.Granularity,
+			rollup.Bucket,
+			rollup.Clicks,
+			rollup.BotClicks,
+			rollup.Visitors,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, breakdown := range breakdowns {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO click_breakdowns (url_id, bio_page_id, bio_link_id, granularity, bucket, dimension, value, clicks)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			 ON CONFLICT (url_id, bio_page_id, bio_link_id, granularity, bucket, dimension, value) DO UPDATE SET
+			 clicks = click_breakdowns.clicks + EXCLUDED.clicks`,
+			breakdown.URLID,
+			breakdown.BioPageID,
+			breakdown.BioLinkID,
+			breakdown.Granularity,
+			breakdown.Bucket,
+			breakdown.Dimension,
+			breakdown.Value,
+			breakdown.Clicks,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO rollup_state (id, last_click_id) VALUES (1, $1)
+		 ON CONFLICT (id) DO UPDATE SET last_click_id = GREATEST(rollup_state.last_click_id, EXCLUDED.last_click_id)`,
+		lastClickID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteRollupsSince deletes the rollups and breakdowns of the buckets starting at or after the given time
+func (r *PostgresRollupRepository) DeleteRollupsSince(ctx context.Context, since time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM click_rollups WHERE bucket >= $1", since); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM click_breakdowns WHERE bucket >= $1", since); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteRollupsByURL deletes every rollup and breakdown of a link
+func (r *PostgresRollupRepository) DeleteRollupsByURL(ctx context.Context, urlID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM click_rollups WHERE url_id = $1", urlID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM click_breakdowns WHERE url_id = $1", urlID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListRollups lists a target's rollups of a granularity since the given time, oldest first
+func (r *PostgresRollupRepository) ListRollups(ctx context.Context, target models.RollupTarget, granularity string, since time.Time) ([]*models.ClickRollup, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT bucket, clicks, bot_clicks, visitors FROM click_rollups
+		 WHERE url_id = $1 AND bio_page_id = $2 AND bio_link_id = $3 AND granularity = $4 AND bucket >= $5
+		 ORDER BY bucket`,
+		target.URLID,
+		target.BioPageID,
+		target.BioLinkID,
+		granularity,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollups := []*models.ClickRollup{}
+	for rows.Next() {
+		rollup := models.ClickRollup{
+			RollupTarget: target,
+			Granularity:  granularity,
+		}
+		if err := rows.Scan(&rollup.Bucket, &rollup.Clicks, &rollup.BotClicks, &rollup.Visitors); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, &rollup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rollups, nil
+}
+
+// SumBreakdowns sums a target's breakdowns of a granularity since the given time by dimension and value
+func (r *PostgresRollupRepository) SumBreakdowns(ctx context.Context, target models.RollupTarget, granularity string, since time.Time) ([]*models.ClickBreakdown, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT dimension, value, SUM(clicks) FROM click_breakdowns
+		 WHERE url_id = $1 AND bio_page_id = $2 AND bio_link_id = $3 AND granularity = $4 AND bucket >= $5
+		 GROUP BY dimension, value ORDER BY SUM(clicks) DESC, value`,
+		target.URLID,
+		target.BioPageID,
+		target.BioLinkID,
+		granularity,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breakdowns := []*models.ClickBreakdown{}
+	for rows.Next() {
+		breakdown := models.ClickBreakdown{
+			RollupTarget: target,
+			Granularity:  granularity,
+		}
+		if err := rows.Scan(&breakdown.Dimension, &breakdown.Value, &breakdown.Clicks); err != nil {
+			return nil, err
+		}
+		breakdowns = append(breakdowns, &breakdown)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return breakdowns, nil
+}
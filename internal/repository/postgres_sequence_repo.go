@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PostgresSequenceRepository is a PostgreSQL implementation of the SequenceRepository interface
+type PostgresSequenceRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresSequenceRepository creates a new PostgreSQL sequence repository
+func NewPostgresSequenceRepository(db *sql.DB) (*PostgresSequenceRepository, error) {
+	return &PostgresSequenceRepository{
+		db: db,
+	}, nil
+}
+
+// ReserveBlock reserves the next size numbers of a sequence. The counter row
+// is created on first use and moved forward in a single statement, so
+// concurrent instances always get separate blocks.
+func (r *PostgresSequenceRepository) ReserveBlock(ctx context.Context, name string, size int) (uint64, error) {
+	var next int64
+	err := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO id_sequences (name, next_value) VALUES ($1, 1 + $2)
+		 ON CONFLICT (name) DO UPDATE SET next_value = id_sequences.next_value + $2
+		 RETURNING next_value`,
+		name,
+		size,
+	).Scan(&next)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(next) - uint64(size), nil
+}
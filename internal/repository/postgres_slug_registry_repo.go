@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/lib/pq"
+)
+
+// PostgresSlugRegistryRepository is a PostgreSQL implementation of the SlugRegistryRepository interface
+type PostgresSlugRegistryRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresSlugRegistryRepository creates a new PostgreSQL slug registry repository
+func NewPostgresSlugRegistryRepository(db *sql.DB) (*PostgresSlugRegistryRepository, error) {
+	return &PostgresSlugRegistryRepository{
+		db: db,
+	}, nil
+}
+
+// ClaimSlug records a slug as used
+func (r *PostgresSlugRegistryRepository) ClaimSlug(ctx context.Context, slug, kind string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO slug_registry (slug, kind, slug_key) VALUES ($1, $2, $3)", slug, kind, models.SlugKey(slug))
+	if err != nil {
+		// Check for unique violation
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrSlugUnavailable
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseSlug frees a slug
+func (r *PostgresSlugRegistryRepository) ReleaseSlug(ctx context.Context, slug, kind string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM slug_registry WHERE slug = $1 AND kind = $2", slug, kind)
+	return err
+}
+
+// GetSlugKind returns the kind of resource that claimed a slug
+func (r *PostgresSlugRegistryRepository) GetSlugKind(ctx context.Context, slug string) (string, error) {
+	var kind string
+	err := r.db.QueryRowContext(ctx, "SELECT kind FROM slug_registry WHERE slug = $1", slug).Scan(&kind)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+
+	return kind, nil
+}
+
+// GetSlugKindByKey returns the kind of resource that claimed the slug with a models.SlugKey
+func (r *PostgresSlugRegistryRepository) GetSlugKindByKey(ctx context.Context, key string) (string, error) {
+	var kind string
+	err := r.db.QueryRowContext(ctx, "SELECT kind FROM slug_registry WHERE slug_key = $1", key).Scan(&kind)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+
+	return kind, nil
+}
@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// webhookColumns is the column list used when selecting webhooks, in scanWebhook order
+const webhookColumns = `id, user_id, url, secret, events, active, created_at`
+
+// deliveryColumns is the column list used when selecting deliveries, in scanDelivery order
+const deliveryColumns = `id, webhook_id, event_id, event, payload, status, attempts, response_status,
+	response_body, error, duration_ms, created_at, last_attempt_at, next_attempt_at`
+
+// PostgresWebhookRepository is a PostgreSQL implementation of the WebhookRepository interface
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresWebhookRepository creates a new PostgreSQL webhook repository
+func NewPostgresWebhookRepository(db *sql.DB) (*PostgresWebhookRepository, error) {
+	return &PostgresWebhookRepository{
+		db: db,
+	}, nil
+}
+
+// CreateWebhook creates a new webhook
+func (r *PostgresWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO webhooks (user_id, url, secret, events, active, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id`,
+		webhook.UserID,
+		webhook.URL,
+		webhook.Secret,
+		events,
+		webhook.Active,
+		webhook.CreatedAt,
+	).Scan(&webhook.ID)
+}
+
+// GetWebhookByID retrieves a webhook by ID
+func (r *PostgresWebhookRepository) GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error) {
+	webhook, err := scanWebhook(r.db.QueryRowContext(
+		ctx,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE id = $1",
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooksByUserID lists all webhooks for a user, oldest first
+func (r *PostgresWebhookRepository) ListWebhooksByUserID(ctx context.Context, userID int) ([]*models.Webhook, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook updates a webhook's URL, events and whether it is active
+func (r *PostgresWebhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE webhooks SET url = $1, events = $2, active = $3 WHERE id = $4`,
+		webhook.URL,
+		events,
+		webhook.Active,
+		webhook.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Check if the webhook was updated
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes a webhook; its deliveries are deleted with it by the foreign key
+func (r *PostgresWebhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	// Check if the webhook was deleted
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery records a delivery
+func (r *PostgresWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload, status, attempts, created_at, next_attempt_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id`,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.Event,
+		string(delivery.Payload),
+		delivery.Status,
+		delivery.Attempts,
+		delivery.CreatedAt,
+		delivery.NextAttemptAt,
+	).Scan(&delivery.ID)
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries SET status = $1, attempts = $2, response_status = $3, response_body = $4,
+		 error = $5, duration_ms = $6, last_attempt_at = $7, next_attempt_at = $8 WHERE id = $9`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.ResponseBody,
+		delivery.Error,
+		delivery.DurationMs,
+		delivery.LastAttemptAt,
+		delivery.NextAttemptAt,
+		delivery.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Check if the delivery was updated
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDueDeliveries lists up to limit pending deliveries due at the given time, the longest due first
+func (r *PostgresWebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	return r.queryDeliveries(
+		ctx,
+		"SELECT "+deliveryColumns+` FROM webhook_deliveries
+		 WHERE status = $1 AND next_attempt_at <= $2 ORDER BY next_attempt_at, id LIMIT $3`,
+		models.WebhookDeliveryPending,
+		now,
+		limitArg(limit),
+	)
+}
+
+// ListDeliveries lists up to limit deliveries to a webhook, newest first
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, webhookID int, limit int) ([]*models.WebhookDelivery, error) {
+	return r.queryDeliveries(
+		ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2",
+		webhookID,
+		limitArg(limit),
+	)
+}
+
+// DeleteDeliveriesBefore deletes the finished deliveries created before the given time
+func (r *PostgresWebhookRepository) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM webhook_deliveries WHERE status <> $1 AND created_at < $2",
+		models.WebhookDeliveryPending,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
+
+// queryDeliveries runs a query selecting deliveryColumns
+func (r *PostgresWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// scanWebhook scans a row selected with webhookColumns into a webhook
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events []byte
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Active,
+		&webhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the events
+	webhook.Events = make([]string, 0)
+	if len(events) > 0 {
+		if err := json.Unmarshal(events, &webhook.Events); err != nil {
+			return nil, err
+		}
+	}
+
+	return &webhook, nil
+}
+
+// scanDelivery scans a row selected with deliveryColumns into a delivery
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload string
+	var lastAttemptAt, nextAttemptAt sql.NullTime
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.Event,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.ResponseBody,
+		&delivery.Error,
+		&delivery.DurationMs,
+		&delivery.CreatedAt,
+		&lastAttemptAt,
+		&nextAttemptAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = []byte(payload)
+	if lastAttemptAt.Valid {
+		delivery.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+
+	return &delivery, nil
+}
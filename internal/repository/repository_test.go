@@ -0,0 +1,482 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/database"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// testDatabaseEnv names the environment variable with the DSN of a PostgreSQL
+// database to test the Postgres repositories against. The tests empty its
+// tables, so never point it at a database you want to keep.
+const testDatabaseEnv = "TEST_DATABASE_DSN"
+
+// openTestDB connects to the test database and migrates it, skipping the test
+// if no test database is set
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	manager, _ := database.NewManager(&config.DatabaseConfig{
+		Type:            "postgres",
+		DSN:             dsn,
+		MaxOpenConns:    5,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 60,
+		MigrationsPath:  "../../migrations",
+	})
+	db, err := manager.Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect to the test database: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	if err := manager.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate the test database: %v", err)
+	}
+	return db
+}
+
+// forEachRepository runs a test against the memory implementation of a
+// repository and, if a test database is set, against the Postgres one after
+// emptying the given tables
+func forEachRepository[R any](t *testing.T, newMemory func() R, newPostgres func(*sql.DB) (R, error), tables []string, test func(t *testing.T, repo R)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newMemory())
+	})
+	t.Run("postgres", func(t *testing.T) {
+		db := openTestDB(t)
+		if _, err := db.Exec(fmt.Sprintf("TRUNCATE %s RESTART IDENTITY CASCADE", strings.Join(tables, ", "))); err != nil {
+			t.Fatalf("Failed to empty tables: %v", err)
+		}
+		repo, err := newPostgres(db)
+		if err != nil {
+			t.Fatalf("Failed to create repository: %v", err)
+		}
+		test(t, repo)
+	})
+}
+
+// createTestUsers adds users with IDs 1 to n to a test database emptied with
+// RESTART IDENTITY, for the tables whose rows must belong to a user
+func createTestUsers(db *sql.DB, n int) error {
+	for i := 1; i <= n; i++ {
+		if _, err := db.Exec(
+			"INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'x')",
+			fmt.Sprintf("user%d", i), fmt.Sprintf("user%d@example.com", i),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachURLRepository runs a test against the memory and Postgres URL
+// repositories, with users 1 and 2 to own the links
+func forEachURLRepository(t *testing.T, test func(t *testing.T, repo Repository)) {
+	forEachRepository(t,
+		func() Repository { return NewMemoryRepository() },
+		func(db *sql.DB) (Repository, error) {
+			if err := createTestUsers(db, 2); err != nil {
+				return nil, err
+			}
+			return NewPostgresRepository(db)
+		},
+		[]string{"users", "urls", "archived_urls"}, test)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		expiredAt := time.Now().Add(-time.Hour)
+		for _, id := range []string{"exp1", "exp2", "exp3"} {
+			if err := repo.Store(ctx, models.NewURL(id, "https://example.com/"+id, nil, &expiredAt)); err != nil {
+				t.Fatalf("Failed to store URL: %v", err)
+			}
+		}
+		if err := repo.Store(ctx, models.NewURL("live", "https://example.com/live", nil, nil)); err != nil {
+			t.Fatalf("Failed to store URL: %v", err)
+		}
+
+		purged, err := repo.PurgeExpired(ctx, time.Now(), false, 1)
+		if err != nil || len(purged) != 1 {
+			t.Fatalf("Expected a batch of 1 URL to be purged, got %d (%v)", len(purged), err)
+		}
+
+		// Without a limit every expired URL is purged at once
+		purged, err = repo.PurgeExpired(ctx, time.Now(), true, 0)
+		if err != nil || len(purged) != 2 {
+			t.Fatalf("Expected the other 2 expired URLs to be purged, got %d (%v)", len(purged), err)
+		}
+		if _, err := repo.GetByID(ctx, "live"); err != nil {
+			t.Errorf("Expected the unexpired URL to be kept, got %v", err)
+		}
+	})
+}
+
+func TestIncrementDestinationClicks(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		url := models.NewURL("split", "https://example.com", nil, nil)
+		url.Destinations = []models.Destination{
+			{ID: "a", URL: "https://example.com/a", Weight: 1},
+			{ID: "b", URL: "https://example.com/b", Weight: 1},
+		}
+		if err := repo.Store(ctx, url); err != nil {
+			t.Fatalf("Failed to store URL: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := repo.IncrementDestinationClicks(ctx, "split", "a"); err != nil {
+					t.Errorf("Failed to count destination click: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if err := repo.IncrementDestinationClicks(ctx, "split", "missing"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for a missing variant, got %v", err)
+		}
+
+		// Updating from a copy loaded before the clicks keeps them
+		stale := *url
+		stale.Destinations = []models.Destination{
+			{ID: "a", URL: "https://example.com/a", Weight: 3},
+			{ID: "b", URL: "https://example.com/b", Weight: 1},
+		}
+		if err := repo.Update(ctx, &stale); err != nil {
+			t.Fatalf("Failed to update URL: %v", err)
+		}
+
+		stored, err := repo.GetByID(ctx, "split")
+		if err != nil {
+			t.Fatalf("Failed to get URL: %v", err)
+		}
+		if a := stored.GetDestination("a"); a == nil || a.Clicks != 20 || a.Weight != 3 {
+			t.Errorf("Expected variant a to keep its 20 clicks with the new weight, got %+v", a)
+		}
+	})
+}
+
+func TestStoreTakenID(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		if err := repo.Store(ctx, models.NewURL("abc123", "https://example.com/first", nil, nil)); err != nil {
+			t.Fatalf("Failed to store URL: %v", err)
+		}
+		if err := repo.Store(ctx, models.NewURL("abc123", "https://example.com/second", nil, nil)); err != ErrSlugUnavailable {
+			t.Errorf("Expected ErrSlugUnavailable for a taken ID, got %v", err)
+		}
+		if stored, err := repo.GetByID(ctx, "abc123"); err != nil || stored.OriginalURL != "https://example.com/first" {
+			t.Errorf("Expected the first link to keep the ID, got %+v (%v)", stored, err)
+		}
+
+		// Only one of several links stored with the same ID at once gets it
+		var wg sync.WaitGroup
+		errs := make(chan error, 5)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repo.Store(ctx, models.NewURL("xyz789", "https://example.com", nil, nil))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		stored := 0
+		for err := range errs {
+			switch err {
+			case nil:
+				stored++
+			case ErrSlugUnavailable:
+			default:
+				t.Errorf("Failed to store URL: %v", err)
+			}
+		}
+		if stored != 1 {
+			t.Errorf("Expected 1 link to be stored, got %d", stored)
+		}
+	})
+}
+
+func TestGetByDomainSlug(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		for _, url := range []*models.URL{
+			{ID: "a1", OriginalURL: "https://example.com/a", Domain: "go.acme.com", Slug: "launch"},
+			{ID: "b1", OriginalURL: "https://example.com/b", Domain: "links.acme.com", Slug: "launch"},
+		} {
+			url.CreatedAt = time.Now()
+			if err := repo.Store(ctx, url); err != nil {
+				t.Fatalf("Failed to store URL: %v", err)
+			}
+		}
+
+		// The same slug may be used on another domain, but not twice on one
+		taken := &models.URL{ID: "c1", OriginalURL: "https://example.com/c", Domain: "go.acme.com", Slug: "launch", CreatedAt: time.Now()}
+		if err := repo.Store(ctx, taken); err != ErrSlugUnavailable {
+			t.Errorf("Expected ErrSlugUnavailable for a slug taken on the domain, got %v", err)
+		}
+		if err := repo.Store(ctx, models.NewURL("a1", "https://example.com", nil, nil)); err != ErrSlugUnavailable {
+			t.Errorf("Expected ErrSlugUnavailable for a taken ID, got %v", err)
+		}
+
+		url, err := repo.GetByDomainSlug(ctx, "links.acme.com", "launch")
+		if err != nil || url.ID != "b1" {
+			t.Errorf("Expected the link on the domain, got %+v (%v)", url, err)
+		}
+		if _, err := repo.GetByDomainSlug(ctx, "go.acme.com", "other"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for a missing slug, got %v", err)
+		}
+	})
+}
+
+func TestSlugKeys(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		link := &models.URL{ID: "SpringSale", OriginalURL: "https://example.com", Domain: "go.acme.com", Slug: "Caf\u00e9", CreatedAt: time.Now()}
+		if err := repo.Store(ctx, link); err != nil {
+			t.Fatalf("Failed to store URL: %v", err)
+		}
+
+		// Short codes differing only in case or normalization can't both be used
+		if err := repo.Store(ctx, models.NewURL("springsale", "https://example.com", nil, nil)); err != ErrSlugUnavailable {
+			t.Errorf("Expected ErrSlugUnavailable for an ID differing only in case, got %v", err)
+		}
+		taken := &models.URL{ID: "other", OriginalURL: "https://example.com", Domain: "go.acme.com", Slug: "cafe\u0301", CreatedAt: time.Now()}
+		if err := repo.Store(ctx, taken); err != ErrSlugUnavailable {
+			t.Errorf("Expected ErrSlugUnavailable for a slug differing only in normalization, got %v", err)
+		}
+
+		if url, err := repo.GetByIDKey(ctx, "springsale"); err != nil || url.ID != "SpringSale" {
+			t.Errorf("Expected the link by the key of its ID, got %+v (%v)", url, err)
+		}
+		if _, err := repo.GetByID(ctx, "springsale"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for the key as an ID, got %v", err)
+		}
+		if url, err := repo.GetByDomainSlugKey(ctx, "go.acme.com", "caf\u00e9"); err != nil || url.ID != "SpringSale" {
+			t.Errorf("Expected the link by the key of its slug, got %+v (%v)", url, err)
+		}
+		if _, err := repo.GetByDomainSlugKey(ctx, "links.acme.com", "caf\u00e9"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for the key on another domain, got %v", err)
+		}
+	})
+}
+
+func TestListByUserDestination(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user, other := 1, 2
+		for i, url := range []*models.URL{
+			models.NewURL("old", "https://example.com/page", &user, nil),
+			models.NewURL("new", "https://example.com/page", &user, nil),
+			models.NewURL("other", "https://example.com/page", &other, nil),
+			models.NewURL("anon", "https://example.com/page", nil, nil),
+			models.NewURL("path", "https://example.com/page/2", &user, nil),
+		} {
+			url.CreatedAt = time.Now().Add(time.Duration(i) * time.Minute)
+			if err := repo.Store(ctx, url); err != nil {
+				t.Fatalf("Failed to store URL: %v", err)
+			}
+		}
+
+		urls, err := repo.ListByUserDestination(ctx, user, "https://example.com/page")
+		if err != nil || len(urls) != 2 || urls[0].ID != "new" || urls[1].ID != "old" {
+			t.Errorf("Expected the user's 2 links to the destination newest first, got %+v (%v)", urls, err)
+		}
+	})
+}
+
+func TestScreeningStatus(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		for i, id := range []string{"older", "newer", "clean"} {
+			url := models.NewURL(id, "https://example.com/"+id, nil, nil)
+			url.CreatedAt = time.Now().Add(time.Duration(i) * time.Minute)
+			if err := repo.Store(ctx, url); err != nil {
+				t.Fatalf("Failed to store URL: %v", err)
+			}
+		}
+		for _, id := range []string{"older", "newer"} {
+			if err := repo.UpdateScreening(ctx, id, models.ScreeningStatusFlagged, "suspicious host", time.Now()); err != nil {
+				t.Fatalf("Failed to update screening: %v", err)
+			}
+		}
+		if err := repo.UpdateScreening(ctx, "missing", models.ScreeningStatusFlagged, "", time.Now()); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for a missing URL, got %v", err)
+		}
+
+		flagged, err := repo.ListByScreeningStatus(ctx, models.ScreeningStatusFlagged)
+		if err != nil || len(flagged) != 2 || flagged[0].ID != "newer" || flagged[1].ID != "older" {
+			t.Fatalf("Expected the 2 flagged links newest first, got %+v (%v)", flagged, err)
+		}
+		if flagged[0].ScreeningReason != "suspicious host" || flagged[0].ScreenedAt == nil {
+			t.Errorf("Expected the screening reason and time to be stored, got %+v", flagged[0])
+		}
+		if clean, _ := repo.ListByScreeningStatus(ctx, models.ScreeningStatusClean); len(clean) != 1 || clean[0].ID != "clean" {
+			t.Errorf("Expected the unscreened link to be clean, got %+v", clean)
+		}
+	})
+}
+
+func TestPreviewSettings(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		url := models.NewURL("preview", "https://example.com", nil, nil)
+		url.Title = "Spring sale"
+		url.Preview = true
+		if err := repo.Store(ctx, url); err != nil {
+			t.Fatalf("Failed to store URL: %v", err)
+		}
+		if stored, err := repo.GetByID(ctx, "preview"); err != nil || stored.Title != "Spring sale" || !stored.Preview {
+			t.Errorf("Expected the stored title and preview, got %+v (%v)", stored, err)
+		}
+
+		updated := *url
+		updated.Title = ""
+		updated.Preview = false
+		if err := repo.Update(ctx, &updated); err != nil {
+			t.Fatalf("Failed to update URL: %v", err)
+		}
+		if stored, err := repo.GetByID(ctx, "preview"); err != nil || stored.Title != "" || stored.Preview {
+			t.Errorf("Expected the title and preview to be cleared, got %+v (%v)", stored, err)
+		}
+	})
+}
+
+func TestListStaleMetadata(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		now := time.Now()
+		metadata := map[string]*models.LinkMetadata{
+			"missing": nil,
+			"fetched": {URL: "https://example.com/fetched", Title: "Fetched", FetchedAt: now},
+			"moved":   {URL: "https://example.com/old", Title: "Old", FetchedAt: now},
+			"failed":  {URL: "https://example.com/failed", Error: "timeout", FetchedAt: now.Add(-2 * time.Hour)},
+			"retried": {URL: "https://example.com/retried", Error: "timeout", FetchedAt: now},
+		}
+		for id, m := range metadata {
+			if err := repo.Store(ctx, models.NewURL(id, "https://example.com/"+id, nil, nil)); err != nil {
+				t.Fatalf("Failed to store URL: %v", err)
+			}
+			if m != nil {
+				if err := repo.UpdateMetadata(ctx, id, m); err != nil {
+					t.Fatalf("Failed to update metadata: %v", err)
+				}
+			}
+		}
+		if err := repo.UpdateMetadata(ctx, "absent", &models.LinkMetadata{}); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for a missing URL, got %v", err)
+		}
+
+		// Metadata that is missing, for an old destination or failed before the
+		// retry time is stale
+		stale, err := repo.ListStaleMetadata(ctx, now.Add(-time.Hour), 0)
+		if err != nil {
+			t.Fatalf("Failed to list stale metadata: %v", err)
+		}
+		ids := make(map[string]bool)
+		for _, url := range stale {
+			ids[url.ID] = true
+		}
+		if len(stale) != 3 || !ids["missing"] || !ids["moved"] || !ids["failed"] {
+			t.Errorf("Expected the missing, moved and failed links, got %v", ids)
+		}
+		if batch, _ := repo.ListStaleMetadata(ctx, now.Add(-time.Hour), 2); len(batch) != 2 {
+			t.Errorf("Expected a batch of 2 links, got %d", len(batch))
+		}
+
+		stored, _ := repo.GetByID(ctx, "fetched")
+		if stored.Metadata == nil || stored.Metadata.Title != "Fetched" {
+			t.Errorf("Expected the stored metadata, got %+v", stored.Metadata)
+		}
+	})
+}
+
+func TestSocialCardSettings(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		url := models.NewURL("card", "https://example.com", nil, nil)
+		url.SocialTitle = "Launch day"
+		url.SocialDescription = "Everything we shipped"
+		url.SocialImageURL = "https://example.com/card.png"
+		if err := repo.Store(ctx, url); err != nil {
+			t.Fatalf("Failed to store URL: %v", err)
+		}
+		stored, err := repo.GetByID(ctx, "card")
+		if err != nil || stored.SocialTitle != "Launch day" || stored.SocialDescription != "Everything we shipped" || stored.SocialImageURL != "https://example.com/card.png" {
+			t.Errorf("Expected the stored social card, got %+v (%v)", stored, err)
+		}
+
+		cleared := *url
+		cleared.SocialTitle, cleared.SocialDescription, cleared.SocialImageURL = "", "", ""
+		if err := repo.Update(ctx, &cleared); err != nil {
+			t.Fatalf("Failed to update URL: %v", err)
+		}
+		if stored, _ := repo.GetByID(ctx, "card"); stored.HasSocialCard() {
+			t.Errorf("Expected the social card to be cleared, got %+v", stored)
+		}
+	})
+}
+
+func TestListDueHealthChecks(t *testing.T) {
+	forEachURLRepository(t, func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		now := time.Now()
+		expiredAt := now.Add(-time.Hour)
+		for _, url := range []*models.URL{
+			models.NewURL("never", "https://example.com/never", nil, nil),
+			models.NewURL("stale", "https://example.com/stale", nil, nil),
+			models.NewURL("staler", "https://example.com/staler", nil, nil),
+			models.NewURL("recent", "https://example.com/recent", nil, nil),
+			models.NewURL("expired", "https://example.com/expired", nil, &expiredAt),
+			models.NewURL("blocked", "https://example.com/blocked", nil, nil),
+		} {
+			if err := repo.Store(ctx, url); err != nil {
+				t.Fatalf("Failed to store URL: %v", err)
+			}
+		}
+		checks := map[string]time.Time{"stale": now.Add(-2 * 24 * time.Hour), "staler": now.Add(-3 * 24 * time.Hour), "recent": now}
+		for id, checkedAt := range checks {
+			if err := repo.UpdateHealth(ctx, id, models.HealthStatusHealthy, 0, checkedAt); err != nil {
+				t.Fatalf("Failed to update health: %v", err)
+			}
+		}
+		if err := repo.UpdateHealth(ctx, "stale", models.HealthStatusBroken, 3, checks["stale"]); err != nil {
+			t.Fatalf("Failed to update health: %v", err)
+		}
+		if err := repo.UpdateScreening(ctx, "blocked", models.ScreeningStatusBlocked, "", now); err != nil {
+			t.Fatalf("Failed to update screening: %v", err)
+		}
+
+		// Never checked links come first, then the least recently checked
+		due, err := repo.ListDueHealthChecks(ctx, now.Add(-24*time.Hour), 0)
+		if err != nil || len(due) != 3 || due[0].ID != "never" || due[1].ID != "staler" || due[2].ID != "stale" {
+			t.Fatalf("Expected the never, staler and stale links, got %+v (%v)", due, err)
+		}
+		if due[2].HealthStatus != models.HealthStatusBroken || due[2].HealthFailures != 3 || due[2].HealthCheckedAt == nil {
+			t.Errorf("Expected the stored health of the link, got %+v", due[2])
+		}
+		if batch, _ := repo.ListDueHealthChecks(ctx, now.Add(-24*time.Hour), 1); len(batch) != 1 || batch[0].ID != "never" {
+			t.Errorf("Expected a batch of the never checked link, got %+v", batch)
+		}
+		if err := repo.UpdateHealth(ctx, "missing", models.HealthStatusHealthy, 0, now); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for a missing URL, got %v", err)
+		}
+	})
+}
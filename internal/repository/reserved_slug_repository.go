@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+)
+
+// ReservedSlugRepository defines the interface for storing the slugs admins have reserved
+type ReservedSlugRepository interface {
+	// AddReservedSlug reserves a lowercase slug; reserving it again does nothing
+	AddReservedSlug(ctx context.Context, slug string) error
+
+	// DeleteReservedSlug frees a reserved slug, failing with ErrNotFound if it isn't reserved
+	DeleteReservedSlug(ctx context.Context, slug string) error
+
+	// ListReservedSlugs lists all reserved slugs in alphabetical order
+	ListReservedSlugs(ctx context.Context) ([]string, error)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestReservedSlugRepository(t *testing.T) {
+	forEachRepository(t,
+		func() ReservedSlugRepository { return NewMemoryReservedSlugRepository() },
+		func(db *sql.DB) (ReservedSlugRepository, error) { return NewPostgresReservedSlugRepository(db) },
+		[]string{"reserved_slugs"},
+		func(t *testing.T, repo ReservedSlugRepository) {
+			ctx := context.Background()
+			for _, slug := range []string{"pricing", "blog", "pricing"} {
+				if err := repo.AddReservedSlug(ctx, slug); err != nil {
+					t.Fatalf("Failed to reserve slug: %v", err)
+				}
+			}
+
+			slugs, err := repo.ListReservedSlugs(ctx)
+			if err != nil || !reflect.DeepEqual(slugs, []string{"blog", "pricing"}) {
+				t.Errorf("Expected each reserved slug once in order, got %v (%v)", slugs, err)
+			}
+
+			if err := repo.DeleteReservedSlug(ctx, "blog"); err != nil {
+				t.Fatalf("Failed to free slug: %v", err)
+			}
+			if err := repo.DeleteReservedSlug(ctx, "blog"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound freeing a slug that isn't reserved, got %v", err)
+			}
+			if slugs, _ := repo.ListReservedSlugs(ctx); !reflect.DeepEqual(slugs, []string{"pricing"}) {
+				t.Errorf("Expected only the remaining slug, got %v", slugs)
+			}
+		})
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// RollupRepository defines the interface for analytics rollup storage
+type RollupRepository interface {
+	// GetRollupWatermark returns the ID of the last click rolled up, zero if
+	// none have been
+	GetRollupWatermark(ctx context.Context) (int64, error)
+
+	// AddRollups adds the counts of rollups and breakdowns to the stored
+	// ones, creating those missing, and raises the watermark to lastClickID,
+	// all at once
+	AddRollups(ctx context.Context, rollups []*models.ClickRollup, breakdowns []*models.ClickBreakdown, lastClickID int64) error
+
+	// DeleteRollupsSince deletes the rollups and breakdowns of the buckets
+	// starting at or after the given time
+	DeleteRollupsSince(ctx context.Context, since time.Time) error
+
+	// DeleteRollupsByURL deletes every rollup and breakdown of a link
+	DeleteRollupsByURL(ctx context.Context, urlID string) error
+
+	// ListRollups lists a target's rollups of a granularity with buckets
+	// starting at or after the given time, oldest first
+	ListRollups(ctx context.Context, target models.RollupTarget, granularity string, since time.Time) ([]*models.ClickRollup, error)
+
+	// SumBreakdowns sums a target's breakdowns of a granularity with buckets
+	// starting at or after the given time by dimension and value, most clicks
+	// first
+	SumBreakdowns(ctx context.Context, target models.RollupTarget, granularity string, since time.Time) ([]*models.ClickBreakdown, error)
+}
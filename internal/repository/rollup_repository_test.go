@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestRollupRepository(t *testing.T) {
+	forEachRepository(t,
+		func() RollupRepository { return NewMemoryRollupRepository() },
+		func(db *sql.DB) (RollupRepository, error) { return NewPostgresRollupRepository(db) },
+		[]string{"click_rollups", "click_breakdowns", "rollup_state"},
+		func(t *testing.T, repo RollupRepository) {
+			ctx := context.Background()
+			if watermark, err := repo.GetRollupWatermark(ctx); err != nil || watermark != 0 {
+				t.Fatalf("Expected no clicks to be rolled up, got %d (%v)", watermark, err)
+			}
+
+			link := models.RollupTarget{URLID: "abc123"}
+			other := models.RollupTarget{URLID: "xyz789"}
+			hour := time.Now().UTC().Truncate(time.Hour)
+			rollup := func(target models.RollupTarget, bucket time.Time, clicks int) *models.ClickRollup {
+				return &models.ClickRollup{RollupTarget: target, Granularity: models.RollupHour, Bucket: bucket, Clicks: clicks, BotClicks: 1, Visitors: clicks}
+			}
+			breakdown := func(bucket time.Time, value string, clicks int) *models.ClickBreakdown {
+				return &models.ClickBreakdown{RollupTarget: link, Granularity: models.RollupHour, Bucket: bucket, Dimension: models.BreakdownCountry, Value: value, Clicks: clicks}
+			}
+
+			if err := repo.AddRollups(ctx,
+				[]*models.ClickRollup{rollup(link, hour.Add(-time.Hour), 2), rollup(link, hour, 1), rollup(other, hour, 5)},
+				[]*models.ClickBreakdown{breakdown(hour.Add(-time.Hour), "GB", 2), breakdown(hour, "US", 1)},
+				10,
+			); err != nil {
+				t.Fatalf("Failed to add rollups: %v", err)
+			}
+			// Counts are added to the stored ones and the watermark never goes down
+			if err := repo.AddRollups(ctx,
+				[]*models.ClickRollup{rollup(link, hour, 2)},
+				[]*models.ClickBreakdown{breakdown(hour, "DE", 2), breakdown(hour, "US", 1)},
+				5,
+			); err != nil {
+				t.Fatalf("Failed to add rollups: %v", err)
+			}
+			if watermark, _ := repo.GetRollupWatermark(ctx); watermark != 10 {
+				t.Errorf("Expected the watermark to stay at 10, got %d", watermark)
+			}
+
+			rollups, err := repo.ListRollups(ctx, link, models.RollupHour, hour.Add(-2*time.Hour))
+			if err != nil || len(rollups) != 2 {
+				t.Fatalf("Expected the link's 2 rollups, got %+v (%v)", rollups, err)
+			}
+			if !rollups[0].Bucket.Equal(hour.Add(-time.Hour)) || rollups[1].Clicks != 3 || rollups[1].BotClicks != 2 || rollups[1].Visitors != 3 {
+				t.Errorf("Expected the rollups oldest first with added counts, got %+v and %+v", rollups[0], rollups[1])
+			}
+			if rollups, _ := repo.ListRollups(ctx, link, models.RollupDay, hour.Add(-2*time.Hour)); len(rollups) != 0 {
+				t.Errorf("Expected no daily rollups, got %+v", rollups)
+			}
+
+			sums, err := repo.SumBreakdowns(ctx, link, models.RollupHour, hour.Add(-2*time.Hour))
+			if err != nil || len(sums) != 3 {
+				t.Fatalf("Expected 3 countries, got %+v (%v)", sums, err)
+			}
+			if sums[0].Value != "DE" || sums[0].Clicks != 2 || sums[1].Value != "GB" || sums[2].Value != "US" || sums[2].Clicks != 2 {
+				t.Errorf("Expected the countries by clicks then value, got %+v %+v %+v", sums[0], sums[1], sums[2])
+			}
+			if sums, _ := repo.SumBreakdowns(ctx, link, models.RollupHour, hour); len(sums) != 2 {
+				t.Errorf("Expected only the countries of the latest hour, got %d", len(sums))
+			}
+
+			if err := repo.DeleteRollupsSince(ctx, hour); err != nil {
+				t.Fatalf("Failed to delete rollups: %v", err)
+			}
+			if rollups, _ := repo.ListRollups(ctx, link, models.RollupHour, time.Time{}); len(rollups) != 1 || !rollups[0].Bucket.Equal(hour.Add(-time.Hour)) {
+				t.Errorf("Expected only the earlier rollup to be kept, got %+v", rollups)
+			}
+			if sums, _ := repo.SumBreakdowns(ctx, link, models.RollupHour, time.Time{}); len(sums) != 1 || sums[0].Value != "GB" {
+				t.Errorf("Expected only the earlier breakdown to be kept, got %+v", sums)
+			}
+
+			if err := repo.AddRollups(ctx, []*models.ClickRollup{rollup(other, hour, 1)}, nil, 11); err != nil {
+				t.Fatalf("Failed to add rollups: %v", err)
+			}
+			if err := repo.DeleteRollupsByURL(ctx, link.URLID); err != nil {
+				t.Fatalf("Failed to delete rollups: %v", err)
+			}
+			if rollups, _ := repo.ListRollups(ctx, link, models.RollupHour, time.Time{}); len(rollups) != 0 {
+				t.Errorf("Expected the link's rollups to be deleted, got %+v", rollups)
+			}
+			if sums, _ := repo.SumBreakdowns(ctx, link, models.RollupHour, time.Time{}); len(sums) != 0 {
+				t.Errorf("Expected the link's breakdowns to be deleted, got %+v", sums)
+			}
+			if rollups, _ := repo.ListRollups(ctx, other, models.RollupHour, time.Time{}); len(rollups) != 1 || rollups[0].Clicks != 1 {
+				t.Errorf("Expected other links' rollups to be kept, got %+v", rollups)
+			}
+		})
+}
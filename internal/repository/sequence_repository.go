@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+)
+
+// SequenceRepository defines the interface for named counters handing out
+// blocks of numbers, so several instances can allocate IDs without clashing
+type SequenceRepository interface {
+	// ReserveBlock reserves the next size numbers of a sequence, starting at
+	// one for a new sequence, and returns the first reserved number
+	ReserveBlock(ctx context.Context, name string, size int) (uint64, error)
+}
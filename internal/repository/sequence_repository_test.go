@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+)
+
+func TestReserveBlock(t *testing.T) {
+	forEachRepository(t,
+		func() SequenceRepository { return NewMemorySequenceRepository() },
+		func(db *sql.DB) (SequenceRepository, error) { return NewPostgresSequenceRepository(db) },
+		[]string{"id_sequences"},
+		func(t *testing.T, repo SequenceRepository) {
+			ctx := context.Background()
+			if start, err := repo.ReserveBlock(ctx, "urls", 10); err != nil || start != 1 {
+				t.Fatalf("Expected a new sequence to start at 1, got %d (%v)", start, err)
+			}
+			if start, _ := repo.ReserveBlock(ctx, "urls", 5); start != 11 {
+				t.Errorf("Expected the next block to start at 11, got %d", start)
+			}
+			if start, _ := repo.ReserveBlock(ctx, "other", 5); start != 1 {
+				t.Errorf("Expected sequences to count apart, got %d", start)
+			}
+
+			// Concurrent reservations never share a number
+			var mutex sync.Mutex
+			starts := make(map[uint64]bool)
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					start, err := repo.ReserveBlock(ctx, "urls", 10)
+					if err != nil {
+						t.Errorf("Failed to reserve block: %v", err)
+						return
+					}
+					mutex.Lock()
+					defer mutex.Unlock()
+					if start < 16 || (start-16)%10 != 0 || starts[start] {
+						t.Errorf("Expected a separate block after the first ones, got %d", start)
+					}
+					starts[start] = true
+				}()
+			}
+			wg.Wait()
+		})
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+)
+
+// SlugRegistryRepository defines the interface for the registry of short
+// codes in use, shared by links and bio pages
+type SlugRegistryRepository interface {
+	// ClaimSlug records a slug as used by a kind of resource, failing with
+	// ErrSlugUnavailable if it or its models.SlugKey is already claimed
+	ClaimSlug(ctx context.Context, slug, kind string) error
+
+	// ReleaseSlug frees a slug claimed by the given kind of resource
+	ReleaseSlug(ctx context.Context, slug, kind string) error
+
+	// GetSlugKind returns the kind of resource that claimed a slug
+	GetSlugKind(ctx context.Context, slug string) (string, error)
+
+	// GetSlugKindByKey returns the kind of resource that claimed the slug with a models.SlugKey
+	GetSlugKindByKey(ctx context.Context, key string) (string, error)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestSlugRegistryRepository(t *testing.T) {
+	forEachRepository(t,
+		func() SlugRegistryRepository { return NewMemorySlugRegistryRepository() },
+		func(db *sql.DB) (SlugRegistryRepository, error) { return NewPostgresSlugRegistryRepository(db) },
+		[]string{"slug_registry"},
+		func(t *testing.T, repo SlugRegistryRepository) {
+			ctx := context.Background()
+			if err := repo.ClaimSlug(ctx, "promo", models.SlugKindLink); err != nil {
+				t.Fatalf("Failed to claim slug: %v", err)
+			}
+			if err := repo.ClaimSlug(ctx, "promo", models.SlugKindBioPage); err != ErrSlugUnavailable {
+				t.Errorf("Expected ErrSlugUnavailable for a claimed slug, got %v", err)
+			}
+			if err := repo.ClaimSlug(ctx, "PROMO", models.SlugKindBioPage); err != ErrSlugUnavailable {
+				t.Errorf("Expected ErrSlugUnavailable for a slug differing only in case, got %v", err)
+			}
+			if kind, err := repo.GetSlugKindByKey(ctx, "promo"); err != nil || kind != models.SlugKindLink {
+				t.Errorf("Expected the kind of the slug by its key, got %q (%v)", kind, err)
+			}
+
+			// Only the kind of resource that claimed a slug can release it
+			if err := repo.ReleaseSlug(ctx, "promo", models.SlugKindBioPage); err != nil {
+				t.Fatalf("Failed to release slug: %v", err)
+			}
+			if kind, err := repo.GetSlugKind(ctx, "promo"); err != nil || kind != models.SlugKindLink {
+				t.Errorf("Expected the slug to stay claimed by a link, got %q (%v)", kind, err)
+			}
+
+			if err := repo.ReleaseSlug(ctx, "promo", models.SlugKindLink); err != nil {
+				t.Fatalf("Failed to release slug: %v", err)
+			}
+			if _, err := repo.GetSlugKind(ctx, "promo"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound for a released slug, got %v", err)
+			}
+			if err := repo.ClaimSlug(ctx, "promo", models.SlugKindBioPage); err != nil {
+				t.Errorf("Expected a released slug to be claimable, got %v", err)
+			}
+		})
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// WebhookRepository defines the interface for webhook and webhook delivery storage
+type WebhookRepository interface {
+	// CreateWebhook creates a new webhook, setting its ID
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+
+	// GetWebhookByID retrieves a webhook by ID
+	GetWebhookByID(ctx context.Context, id int) (*models.Webhook, error)
+
+	// ListWebhooksByUserID lists all webhooks for a user, oldest first
+	ListWebhooksByUserID(ctx context.Context, userID int) ([]*models.Webhook, error)
+
+	// UpdateWebhook updates a webhook's URL, events and whether it is active
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+
+	// DeleteWebhook deletes a webhook and its deliveries
+	DeleteWebhook(ctx context.Context, id int) error
+
+	// CreateDelivery records a delivery, setting its ID
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// UpdateDelivery records the outcome of a delivery attempt
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// ListDueDeliveries lists up to limit pending deliveries, or all of them if
+	// limit is zero or less, whose next attempt is due at the given time, the
+	// longest due first
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+
+	// ListDeliveries lists up to limit deliveries to a webhook, or all of them
+	// if limit is zero or less, newest first
+	ListDeliveries(ctx context.Context, webhookID int, limit int) ([]*models.WebhookDelivery, error)
+
+	// DeleteDeliveriesBefore deletes the finished deliveries created before the
+	// given time and returns how many were deleted
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error)
+}
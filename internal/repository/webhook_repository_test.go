@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	forEachRepository(t,
+		func() WebhookRepository { return NewMemoryWebhookRepository() },
+		func(db *sql.DB) (WebhookRepository, error) {
+			if err := createTestUsers(db, 2); err != nil {
+				return nil, err
+			}
+			return NewPostgresWebhookRepository(db)
+		},
+		[]string{"users", "webhooks", "webhook_deliveries"},
+		func(t *testing.T, repo WebhookRepository) {
+			ctx := context.Background()
+			webhook := models.NewWebhook(1, "https://hooks.example.com/a", "secret", []string{models.WebhookEventLinkCreated})
+			if err := repo.CreateWebhook(ctx, webhook); err != nil || webhook.ID == 0 {
+				t.Fatalf("Failed to create webhook: %v", err)
+			}
+			other := models.NewWebhook(2, "https://hooks.example.com/b", "secret", []string{models.WebhookEventLinkClicked})
+			if err := repo.CreateWebhook(ctx, other); err != nil {
+				t.Fatalf("Failed to create webhook: %v", err)
+			}
+
+			updated := *webhook
+			updated.Events = []string{models.WebhookEventLinkCreated, models.WebhookEventLinkClicked}
+			updated.Active = false
+			if err := repo.UpdateWebhook(ctx, &updated); err != nil {
+				t.Fatalf("Failed to update webhook: %v", err)
+			}
+			webhooks, err := repo.ListWebhooksByUserID(ctx, 1)
+			if err != nil || len(webhooks) != 1 || webhooks[0].Active || !reflect.DeepEqual(webhooks[0].Events, updated.Events) {
+				t.Errorf("Expected the user's updated webhook, got %+v (%v)", webhooks, err)
+			}
+
+			// Deliveries are due once their next attempt time has come
+			now := time.Now()
+			var deliveries []*models.WebhookDelivery
+			for i, offset := range []time.Duration{-time.Minute, -time.Hour, time.Hour} {
+				delivery := models.NewWebhookDelivery(webhook.ID, "evt", models.WebhookEventLinkCreated, []byte(`{"id":"evt"}`))
+				nextAttemptAt := now.Add(offset)
+				delivery.NextAttemptAt = &nextAttemptAt
+				delivery.CreatedAt = now.Add(time.Duration(i-3) * 24 * time.Hour)
+				if err := repo.CreateDelivery(ctx, delivery); err != nil || delivery.ID == 0 {
+					t.Fatalf("Failed to create delivery: %v", err)
+				}
+				deliveries = append(deliveries, delivery)
+			}
+			due, err := repo.ListDueDeliveries(ctx, now, 0)
+			if err != nil || len(due) != 2 || due[0].ID != deliveries[1].ID || due[1].ID != deliveries[0].ID {
+				t.Fatalf("Expected the 2 due deliveries, the longest due first, got %+v (%v)", due, err)
+			}
+			if batch, _ := repo.ListDueDeliveries(ctx, now, 1); len(batch) != 1 || batch[0].ID != deliveries[1].ID {
+				t.Errorf("Expected a batch of the longest due delivery, got %+v", batch)
+			}
+
+			delivered := *deliveries[1]
+			delivered.Status = models.WebhookDeliveryDelivered
+			delivered.Attempts = 1
+			delivered.ResponseStatus = 204
+			delivered.LastAttemptAt = &now
+			delivered.NextAttemptAt = nil
+			if err := repo.UpdateDelivery(ctx, &delivered); err != nil {
+				t.Fatalf("Failed to update delivery: %v", err)
+			}
+			if due, _ := repo.ListDueDeliveries(ctx, now, 0); len(due) != 1 {
+				t.Errorf("Expected the delivered delivery to no longer be due, got %d", len(due))
+			}
+
+			listed, err := repo.ListDeliveries(ctx, webhook.ID, 0)
+			if err != nil || len(listed) != 3 || listed[0].ID != deliveries[2].ID {
+				t.Fatalf("Expected the webhook's 3 deliveries newest first, got %+v (%v)", listed, err)
+			}
+			if listed[1].Status != models.WebhookDeliveryDelivered || listed[1].ResponseStatus != 204 || string(listed[1].Payload) != `{"id":"evt"}` {
+				t.Errorf("Expected the stored outcome and payload, got %+v", listed[1])
+			}
+			if latest, _ := repo.ListDeliveries(ctx, webhook.ID, 1); len(latest) != 1 {
+				t.Errorf("Expected the latest delivery, got %d", len(latest))
+			}
+
+			// Only finished deliveries are pruned
+			if deleted, err := repo.DeleteDeliveriesBefore(ctx, now); err != nil || deleted != 1 {
+				t.Errorf("Expected the delivered delivery to be pruned, got %d (%v)", deleted, err)
+			}
+
+			if err := repo.DeleteWebhook(ctx, webhook.ID); err != nil {
+				t.Fatalf("Failed to delete webhook: %v", err)
+			}
+			if _, err := repo.GetWebhookByID(ctx, webhook.ID); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound after deleting, got %v", err)
+			}
+			if listed, _ := repo.ListDeliveries(ctx, webhook.ID, 0); len(listed) != 0 {
+				t.Errorf("Expected the webhook's deliveries to be deleted with it, got %d", len(listed))
+			}
+		})
+}
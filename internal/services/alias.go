@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// ErrAliasIsID is returned when trying to remove a link's own ID as an alias
+var ErrAliasIsID = errors.New("the link's own ID can't be removed")
+
+// SetAliasRepository sets where link aliases are stored. Without it they are kept in memory.
+func (s *ShortenerService) SetAliasRepository(aliases repository.AliasRepository) {
+	s.aliases = aliases
+}
+
+// AddAlias adds an alias to a link. Aliases share the root namespace with
+// link IDs, so they follow the same rules as custom slugs.
+func (s *ShortenerService) AddAlias(ctx context.Context, id string, userID int, alias string) (*models.Alias, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	alias, err = s.slugPolicy.Normalize(alias)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReservedSlug(ctx, s.reserved, alias); err != nil {
+		return nil, err
+	}
+
+	// Check the alias isn't a link's ID, in any case
+	if _, err := s.repo.GetByIDKey(ctx, models.SlugKey(alias)); err == nil {
+		return nil, ErrSlugUnavailable
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	// Claim the alias in the slug registry, so it can't be a bio page's short code
+	claimed := false
+	if s.registry != nil {
+		claimed, err = s.registry.Claim(ctx, alias, models.SlugKindLink)
+		if errors.Is(err, repository.ErrSlugUnavailable) {
+			return nil, ErrSlugUnavailable
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	a := models.NewAlias(alias, url.ID)
+	if err := s.aliases.CreateAlias(ctx, a); err != nil {
+		if claimed {
+			s.registry.Release(ctx, alias, models.SlugKindLink)
+		}
+		if errors.Is(err, repository.ErrSlugUnavailable) {
+			return nil, ErrSlugUnavailable
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// ListAliases lists a link's aliases, starting with its own ID. Each alias
+// has its own visit count; the ID's count is the visits that came through no
+// alias, and the link's count is the total.
+func (s *ShortenerService) ListAliases(ctx context.Context, id string, userID int) ([]*models.Alias, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.aliases.ListAliasesByURLID(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	own := &models.Alias{
+		Alias:       url.ID,
+		URLID:       url.ID,
+		Visits:      url.Visits,
+		LastVisitAt: url.LastVisitAt,
+		CreatedAt:   url.CreatedAt,
+		Canonical:   url.CanonicalAlias == "",
+	}
+	for _, a := range aliases {
+		own.Visits -= a.Visits
+		a.Canonical = a.Alias == url.CanonicalAlias
+	}
+
+	return append([]*models.Alias{own}, aliases...), nil
+}
+
+// RemoveAlias removes an alias from a link. If it was the canonical alias,
+// the link's short URL goes back to its ID.
+func (s *ShortenerService) RemoveAlias(ctx context.Context, id string, userID int, alias string) error {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if alias == url.ID {
+		return ErrAliasIsID
+	}
+
+	a, err := s.linkAlias(ctx, url, alias)
+	if err != nil {
+		return err
+	}
+	if err := s.aliases.DeleteAlias(ctx, a.Alias); err != nil {
+		return err
+	}
+	if s.registry != nil {
+		if err := s.registry.Release(ctx, a.Alias, models.SlugKindLink); err != nil {
+			return err
+		}
+	}
+
+	if url.CanonicalAlias == a.Alias {
+		url.CanonicalAlias = ""
+		return s.update(ctx, url)
+	}
+
+	return nil
+}
+
+// SetCanonicalAlias chooses the alias used in a link's short URL. The link's
+// own ID makes the short URL use the ID again.
+func (s *ShortenerService) SetCanonicalAlias(ctx context.Context, id string, userID int, alias string) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if alias == url.ID {
+		alias = ""
+	} else {
+		a, err := s.linkAlias(ctx, url, alias)
+		if err != nil {
+			return nil, err
+		}
+		alias = a.Alias
+	}
+
+	url.CanonicalAlias = alias
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
+// GetByAlias retrieves the URL an alias points at, even if it has expired
+func (s *ShortenerService) GetByAlias(ctx context.Context, alias string) (*models.URL, error) {
+	a, err := s.getAlias(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, a.URLID)
+}
+
+// CountAliasVisit counts a visit through an alias. The visit is counted on
+// the link separately, with IncrementVisitCount.
+func (s *ShortenerService) CountAliasVisit(ctx context.Context, alias string) error {
+	a, err := s.getAlias(ctx, alias)
+	if err != nil {
+		return err
+	}
+
+	return s.aliases.IncrementAliasVisits(ctx, a.Alias)
+}
+
+// getAlias retrieves a requested alias as the slug policy compares it
+func (s *ShortenerService) getAlias(ctx context.Context, alias string) (*models.Alias, error) {
+	return lookupSlug(s.slugPolicy, alias,
+		func(alias string) (*models.Alias, error) { return s.aliases.GetAlias(ctx, alias) },
+		func(key string) (*models.Alias, error) { return s.aliases.GetAliasByKey(ctx, key) },
+	)
+}
+
+// linkAlias retrieves one of a link's aliases, or repository.ErrNotFound if
+// the alias belongs to another link
+func (s *ShortenerService) linkAlias(ctx context.Context, url *models.URL, alias string) (*models.Alias, error) {
+	a, err := s.getAlias(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+	if a.URLID != url.ID {
+		return nil, repository.ErrNotFound
+	}
+
+	return a, nil
+}
+
+// deleteAliases deletes the aliases of removed links, freeing them in the slug registry
+func (s *ShortenerService) deleteAliases(ctx context.Context, urls []*models.URL) error {
+	for _, url := range urls {
+		aliases, err := s.aliases.DeleteAliasesByURLID(ctx, url.ID)
+		if err != nil {
+			return err
+		}
+
+		if s.registry == nil {
+			continue
+		}
+		for _, a := range aliases {
+			if err := s.registry.Release(ctx, a.Alias, models.SlugKindLink); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestAliases(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestShortener()
+
+	userID := 1
+	link, err := service.Shorten(ctx, "https://example.com/sale", &userID, "x7Kp2", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+
+	for _, alias := range []string{"spring-sale", "SpringSale"} {
+		if _, err := service.AddAlias(ctx, link.ID, userID, alias); err != nil {
+			t.Fatalf("Failed to add alias %q: %v", alias, err)
+		}
+	}
+	if _, err := service.AddAlias(ctx, link.ID, userID, "spring-sale"); err != ErrSlugUnavailable {
+		t.Errorf("Expected a duplicate alias to be unavailable, got %v", err)
+	}
+	if _, err := service.AddAlias(ctx, link.ID, 2, "other"); err != ErrNotURLOwner {
+		t.Errorf("Expected ErrNotURLOwner, got %v", err)
+	}
+
+	// Aliases share the namespace with link IDs
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "SpringSale", nil, ""); err != ErrSlugUnavailable {
+		t.Errorf("Expected an alias to be unavailable as a custom slug, got %v", err)
+	}
+	other, err := service.Shorten(ctx, "https://example.com", nil, "other-link", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	for _, alias := range []string{other.ID, "Other-Link"} {
+		if _, err := service.AddAlias(ctx, link.ID, userID, alias); err != ErrSlugUnavailable {
+			t.Errorf("Expected link ID %q to be unavailable as an alias, got %v", alias, err)
+		}
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "springsale", nil, ""); err != ErrSlugUnavailable {
+		t.Errorf("Expected an alias differing only in case to be unavailable as a custom slug, got %v", err)
+	}
+
+	// Visits through an alias count on the alias and on the link
+	for _, path := range []string{"spring-sale", "spring-sale", "SpringSale", link.ID} {
+		url, err := service.GetIncludingExpired(ctx, path)
+		if err == repository.ErrNotFound {
+			url, err = service.GetByAlias(ctx, path)
+			if err == nil {
+				err = service.CountAliasVisit(ctx, path)
+			}
+		}
+		if err != nil {
+			t.Fatalf("Failed to resolve %q: %v", path, err)
+		}
+		if url.ID != link.ID {
+			t.Fatalf("Expected %q to resolve to %s, got %s", path, link.ID, url.ID)
+		}
+		if err := service.IncrementVisitCount(ctx, url); err != nil {
+			t.Fatalf("Failed to count visit: %v", err)
+		}
+	}
+
+	aliases, err := service.ListAliases(ctx, link.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to list aliases: %v", err)
+	}
+	visits := map[string]int{}
+	for _, a := range aliases {
+		visits[a.Alias] = a.Visits
+	}
+	expected := map[string]int{link.ID: 1, "spring-sale": 2, "SpringSale": 1}
+	if fmt.Sprint(visits) != fmt.Sprint(expected) {
+		t.Errorf("Expected visits %v, got %v", expected, visits)
+	}
+	if !aliases[0].Canonical || aliases[0].Alias != link.ID {
+		t.Errorf("Expected the link's ID to be listed first as the canonical alias, got %+v", aliases[0])
+	}
+
+	// The canonical alias is used in the short URL until it is removed
+	response, err := service.SetCanonicalAlias(ctx, link.ID, userID, "spring-sale")
+	if err != nil {
+		t.Fatalf("Failed to set canonical alias: %v", err)
+	}
+	if response.ShortURL != "http://localhost:8080/spring-sale" {
+		t.Errorf("Expected the short URL to use the canonical alias, got %s", response.ShortURL)
+	}
+	if _, err := service.SetCanonicalAlias(ctx, link.ID, userID, "unknown"); err != repository.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for an unknown alias, got %v", err)
+	}
+	if err := service.RemoveAlias(ctx, link.ID, userID, link.ID); err != ErrAliasIsID {
+		t.Errorf("Expected ErrAliasIsID, got %v", err)
+	}
+	if err := service.RemoveAlias(ctx, link.ID, userID, "spring-sale"); err != nil {
+		t.Fatalf("Failed to remove alias: %v", err)
+	}
+	url, err := service.GetOwned(ctx, link.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to get URL: %v", err)
+	}
+	if service.ShortURL(url) != "http://localhost:8080/"+link.ID {
+		t.Errorf("Expected the short URL to use the ID again, got %s", service.ShortURL(url))
+	}
+	if _, err := service.GetByAlias(ctx, "spring-sale"); err != repository.ErrNotFound {
+		t.Errorf("Expected the removed alias to stop resolving, got %v", err)
+	}
+}
@@ -0,0 +1,446 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// maxListedClicks is how many recent clicks are listed for a link
+const maxListedClicks = 50
+
+// How many of the latest days visits to links and bio pages are counted by
+const (
+	defaultVisitorDays = 30
+	maxVisitorDays     = 90
+)
+
+// AnalyticsService tags visits as coming from people or bots, identifies
+// their visitors and records them for the analytics of links and bio pages
+type AnalyticsService struct {
+	repo     repository.ClickRepository
+	bots     *BotFilter
+	visitors *VisitorHasher
+	// sketches estimates the total unique visitors of links and bio pages
+	// with HyperLogLog sketches, rather than counting distinct visitor hashes
+	sketches bool
+	// targeting looks up the country of visitors
+	targeting *TargetingService
+	// rollups counts daily visits and unique visitors from the rollups when
+	// they are enabled, and has a purged link's rollups deleted
+	rollups *RollupService
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(repo repository.ClickRepository, bots *BotFilter, sketches bool) *AnalyticsService {
+	return &AnalyticsService{
+		repo:     repo,
+		bots:     bots,
+		visitors: NewVisitorHasher(repo),
+		sketches: sketches,
+	}
+}
+
+// SetTargetingService looks up the country of visitors to record with their clicks
+func (s *AnalyticsService) SetTargetingService(targeting *TargetingService) {
+	s.targeting = targeting
+}
+
+// SetRollupService counts daily visits and unique visitors from the rollups
+// rather than from the recorded clicks and serves analytics reports, if the
+// rollups are enabled
+func (s *AnalyticsService) SetRollupService(rollups *RollupService) {
+	s.rollups = rollups
+}
+
+// rolledUp reports whether visits are counted from the rollups
+func (s *AnalyticsService) rolledUp() bool {
+	return s.rollups != nil && s.rollups.Enabled()
+}
+
+// DeleteURLAnalytics deletes the clicks on a link, its visitor sketch and its
+// rollups, so a new link given the same ID starts without them
+func (s *AnalyticsService) DeleteURLAnalytics(ctx context.Context, urlID string) error {
+	if err := s.repo.DeleteClicksByURL(ctx, urlID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteVisitorSketch(ctx, urlSketch(urlID)); err != nil {
+		return err
+	}
+	if s.rollups != nil {
+		return s.rollups.DeleteURL(ctx, urlID)
+	}
+	return nil
+}
+
+// Classify tags a click as coming from a bot or a person, from its user
+// agent and the request's headers
+func (s *AnalyticsService) Classify(click *models.Click, header http.Header) {
+	click.Bot, click.BotReason = s.bots.Classify(click.UserAgent, header)
+}
+
+// IdentifyVisitor sets the visitor hash, device and country of a click from
+// the visitor's IP address and user agent. The IP address itself is not kept.
+func (s *AnalyticsService) IdentifyVisitor(ctx context.Context, click *models.Click, ip string) error {
+	if s.targeting != nil {
+		info := s.targeting.ClientInfo(click.UserAgent, "", ip)
+		click.Device, click.Country = info.Device, info.Country
+	} else {
+		click.Device, _, _ = ParseUserAgent(click.UserAgent)
+	}
+
+	hash, err := s.visitors.Hash(ctx, ip, click.UserAgent, click.CreatedAt)
+	if err != nil {
+		return err
+	}
+	click.VisitorHash = hash
+	return nil
+}
+
+// RecordClick records a click, adding a person's visit to the visitor sketch
+// of the link or bio page if sketches are kept
+func (s *AnalyticsService) RecordClick(ctx context.Context, click *models.Click) error {
+	if err := s.repo.RecordClick(ctx, click); err != nil {
+		return err
+	}
+	if !s.sketches || click.Bot || click.VisitorHash == "" {
+		return nil
+	}
+
+	var target string
+	switch {
+	case click.URLID != "":
+		target = urlSketch(click.URLID)
+	case click.BioPageID != 0 && click.BioLinkID == 0:
+		target = bioPageSketch(click.BioPageID)
+	default:
+		return nil
+	}
+
+	register, rank, err := sketchPosition(click.VisitorHash)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateVisitorSketch(ctx, target, register, rank)
+}
+
+// ListClicks lists the latest clicks on a link, newest first, leaving out
+// clicks from bots unless includeBots is set
+func (s *AnalyticsService) ListClicks(ctx context.Context, urlID string, includeBots bool) ([]*models.Click, error) {
+	return s.repo.ListClicksByURL(ctx, urlID, includeBots, maxListedClicks)
+}
+
+// URLVisitorStats counts the visits by people to a link and its unique
+// visitors, in total and on each of the latest days
+func (s *AnalyticsService) URLVisitorStats(ctx context.Context, url *models.URL, days int) (*models.VisitorStats, error) {
+	if s.rolledUp() {
+		return s.rolledUpVisitorStats(ctx, url.Visits, days, urlSketch(url.ID), models.RollupTarget{URLID: url.ID})
+	}
+	return s.visitorStats(ctx, url.Visits, days, urlSketch(url.ID),
+		func(since time.Time) ([]*models.DailyVisits, error) {
+			return s.repo.CountDailyVisitsByURL(ctx, url.ID, since)
+		},
+		func() (int, error) {
+			return s.repo.CountVisitorsByURL(ctx, url.ID)
+		},
+	)
+}
+
+// BioPageVisitorStats counts the views by people of a bio page and its unique
+// visitors, in total and on each of the latest days
+func (s *AnalyticsService) BioPageVisitorStats(ctx context.Context, bioPage *models.BioPageResponse, days int) (*models.VisitorStats, error) {
+	if s.rolledUp() {
+		return s.rolledUpVisitorStats(ctx, bioPage.Visits, days, bioPageSketch(bioPage.ID), models.RollupTarget{BioPageID: bioPage.ID})
+	}
+	return s.visitorStats(ctx, bioPage.Visits, days, bioPageSketch(bioPage.ID),
+		func(since time.Time) ([]*models.DailyVisits, error) {
+			return s.repo.CountDailyVisitsByBioPage(ctx, bioPage.ID, since)
+		},
+		func() (int, error) {
+			return s.repo.CountVisitorsByBioPage(ctx, bioPage.ID)
+		},
+	)
+}
+
+// rolledUpVisitorStats counts visits by day and the total unique visitors
+// from the daily rollups of a target, which lag behind the recorded clicks by
+// up to the rollup job's interval
+func (s *AnalyticsService) rolledUpVisitorStats(ctx context.Context, visits, days int, sketch string, target models.RollupTarget) (*models.VisitorStats, error) {
+	return s.visitorStats(ctx, visits, days, sketch,
+		func(since time.Time) ([]*models.DailyVisits, error) {
+			return s.rollups.dailyVisits(ctx, target, since)
+		},
+		func() (int, error) {
+			return s.rollups.totalVisitors(ctx, target)
+		},
+	)
+}
+
+// Report sums the rollups of a link or bio link over the latest days
+func (s *AnalyticsService) Report(ctx context.Context, target models.RollupTarget, granularity string, days int) (*models.AnalyticsReport, error) {
+	if !s.rolledUp() {
+		return nil, ErrRollupsDisabled
+	}
+	return s.rollups.Report(ctx, target, granularity, days)
+}
+
+// visitorStats counts visits by day, filling in days without any, and the
+// total unique visitors from the sketch or the distinct visitor hashes
+func (s *AnalyticsService) visitorStats(ctx context.Context, visits, days int, sketch string,
+	countDaily func(since time.Time) ([]*models.DailyVisits, error), countVisitors func() (int, error)) (*models.VisitorStats, error) {
+	if days <= 0 {
+		days = defaultVisitorDays
+	}
+	if days > maxVisitorDays {
+		days = maxVisitorDays
+	}
+
+	today := visitorDay(time.Now())
+	since := today.AddDate(0, 0, 1-days)
+	counts, err := countDaily(since)
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]*models.DailyVisits, len(counts))
+	for _, count := range counts {
+		byDate[count.Date] = count
+	}
+
+	stats := &models.VisitorStats{
+		Visits: visits,
+		Days:   make([]*models.DailyVisits, 0, days),
+	}
+	for day := today; !day.Before(since); day = day.AddDate(0, 0, -1) {
+		date := day.Format("2006-01-02")
+		count, ok := byDate[date]
+		if !ok {
+			count = &models.DailyVisits{Date: date}
+		}
+		stats.Days = append(stats.Days, count)
+	}
+
+	// Visitor hashes change every day, so both count each visitor once a day
+	if s.sketches {
+		ranks, err := s.repo.GetVisitorSketch(ctx, sketch)
+		if err != nil {
+			return nil, err
+		}
+		stats.Visitors = estimateVisitors(ranks)
+		stats.Estimated = true
+	} else {
+		stats.Visitors, err = countVisitors()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// urlSketch is the target of a link's visitor sketch
+func urlSketch(urlID string) string {
+	return "url:" + urlID
+}
+
+// bioPageSketch is the target of a bio page's visitor sketch
+func bioPageSketch(bioPageID int) string {
+	return "bio:" + strconv.Itoa(bioPageID)
+}
+
+// SetAnalyticsService tags visits to links as coming from people or bots and records them
+func (s *ShortenerService) SetAnalyticsService(analytics *AnalyticsService) {
+	s.analytics = analytics
+}
+
+// ClassifyClick tags a click on a link as coming from a bot or a person.
+// Without an analytics service every click counts as a person's.
+func (s *ShortenerService) ClassifyClick(click *models.Click, header http.Header) {
+	if s.analytics != nil {
+		s.analytics.Classify(click, header)
+	}
+}
+
+// IdentifyVisitor sets the visitor hash of a click on a link from the
+// visitor's IP address and user agent
+func (s *ShortenerService) IdentifyVisitor(ctx context.Context, click *models.Click, ip string) error {
+	if s.analytics == nil {
+		return nil
+	}
+	return s.analytics.IdentifyVisitor(ctx, click, ip)
+}
+
+// CountClick counts a classified click on a URL, records it and tells the
+// owner's webhooks and click streams about it. Clicks from bots are counted
+// apart, leaving the visit counts of the link and its alias alone.
+func (s *ShortenerService) CountClick(ctx context.Context, url *models.URL, click *models.Click) error {
+	var errs []error
+	if click.Bot {
+		url.IncrementBotVisits()
+		if err := s.repo.Update(ctx, url); err != nil {
+			errs = append(errs, err)
+		}
+	} else {
+		if err := s.IncrementVisitCount(ctx, url); err != nil {
+			errs = append(errs, err)
+		}
+		if click.Alias != "" {
+			// Count the visit on the alias as well as on the link
+			s.CountAliasVisit(ctx, click.Alias)
+		}
+	}
+
+	click.URLID = url.ID
+	if s.analytics != nil {
+		if err := s.analytics.RecordClick(ctx, click); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	s.PublishClick(url, click)
+	return errors.Join(errs...)
+}
+
+// ListClicks lists the latest clicks on a URL, leaving out clicks from bots
+// unless includeBots is set
+func (s *ShortenerService) ListClicks(ctx context.Context, id string, userID int, includeBots bool) ([]*models.Click, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.analytics == nil {
+		return []*models.Click{}, nil
+	}
+
+	return s.analytics.ListClicks(ctx, url.ID, includeBots)
+}
+
+// VisitorStats counts the visits by people to a URL and its unique visitors,
+// in total and on each of the latest days
+func (s *ShortenerService) VisitorStats(ctx context.Context, id string, userID int, days int) (*models.VisitorStats, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.analytics == nil {
+		return &models.VisitorStats{Visits: url.Visits, Days: []*models.DailyVisits{}}, nil
+	}
+
+	return s.analytics.URLVisitorStats(ctx, url, days)
+}
+
+// AnalyticsReport sums the rolled up clicks on a URL by hour or day over the
+// latest days
+func (s *ShortenerService) AnalyticsReport(ctx context.Context, id string, userID int, granularity string, days int) (*models.AnalyticsReport, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.analytics == nil {
+		return nil, ErrRollupsDisabled
+	}
+
+	return s.analytics.Report(ctx, models.RollupTarget{URLID: url.ID}, granularity, days)
+}
+
+// SetAnalyticsService tags views of bio pages and clicks on their links as
+// coming from people or bots and records them
+func (s *BioPageService) SetAnalyticsService(analytics *AnalyticsService) {
+	s.analytics = analytics
+}
+
+// ClassifyClick tags a view of a bio page or a click on a bio link as coming
+// from a bot or a person
+func (s *BioPageService) ClassifyClick(click *models.Click, header http.Header) {
+	if s.analytics != nil {
+		s.analytics.Classify(click, header)
+	}
+}
+
+// IdentifyVisitor sets the visitor hash of a view of a bio page or a click on
+// a bio link from the visitor's IP address and user agent
+func (s *BioPageService) IdentifyVisitor(ctx context.Context, click *models.Click, ip string) error {
+	if s.analytics == nil {
+		return nil
+	}
+	return s.analytics.IdentifyVisitor(ctx, click, ip)
+}
+
+// VisitorStats counts the views by people of a bio page and its unique
+// visitors, in total and on each of the latest days
+func (s *BioPageService) VisitorStats(ctx context.Context, bioPage *models.BioPageResponse, days int) (*models.VisitorStats, error) {
+	if s.analytics == nil {
+		return &models.VisitorStats{Visits: bioPage.Visits, Days: []*models.DailyVisits{}}, nil
+	}
+
+	return s.analytics.BioPageVisitorStats(ctx, bioPage, days)
+}
+
+// LinkAnalyticsReport sums the rolled up clicks on a bio link by hour or day
+// over the latest days
+func (s *BioPageService) LinkAnalyticsReport(ctx context.Context, bioPageID, bioLinkID int, granularity string, days int) (*models.AnalyticsReport, error) {
+	if s.analytics == nil {
+		return nil, ErrRollupsDisabled
+	}
+
+	target := models.RollupTarget{BioPageID: bioPageID, BioLinkID: bioLinkID}
+	return s.analytics.Report(ctx, target, granularity, days)
+}
+
+// CountView counts a classified view of a bio page, records it and tells the
+// owner's webhooks and click streams about it. Views from bots are recorded
+// but not counted.
+func (s *BioPageService) CountView(ctx context.Context, bioPage *models.BioPageResponse, click *models.Click) error {
+	var errs []error
+	if !click.Bot {
+		if err := s.IncrementBioPageVisits(ctx, bioPage.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	click.BioPageID = bioPage.ID
+	if s.analytics != nil {
+		if err := s.analytics.RecordClick(ctx, click); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	s.PublishView(bioPage, click)
+	return errors.Join(errs...)
+}
+
+// CountLinkClick counts a classified click on a bio link, records it and
+// tells the owner's click streams about it. Clicks from bots are recorded
+// but not counted.
+func (s *BioPageService) CountLinkClick(ctx context.Context, linkID int, click *models.Click) error {
+	bioLink, err := s.repo.GetBioLinkByID(ctx, linkID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if !click.Bot {
+		bioLink.IncrementVisits()
+		if err := s.repo.UpdateBioLink(ctx, bioLink); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	click.BioPageID = bioLink.BioPageID
+	click.BioLinkID = bioLink.ID
+	click.Destination = bioLink.URL
+	if s.analytics != nil {
+		if err := s.analytics.RecordClick(ctx, click); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := s.publishLinkClick(ctx, bioLink, click); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestPurgeDeletesAnalytics(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestShortener()
+	clickRepo := repository.NewMemoryClickRepository()
+	analytics := NewAnalyticsService(clickRepo, NewBotFilter(true), true)
+	rollups := NewRollupService(clickRepo, repository.NewMemoryRollupRepository(), &config.AnalyticsConfig{Rollups: true})
+	analytics.SetRollupService(rollups)
+	service.SetAnalyticsService(analytics)
+
+	userID := 1
+	if _, err := service.Shorten(ctx, "https://example.com/old", &userID, "recycled", nil, ""); err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	url, _ := repo.GetByID(ctx, "recycled")
+	for _, ip := range []string{"203.0.113.7", "198.51.100.2"} {
+		click := models.NewClick("https://news.example.org/", "Mozilla/5.0 Firefox/130.0")
+		click.CreatedAt = time.Now().Add(-5 * time.Minute)
+		if err := service.IdentifyVisitor(ctx, click, ip); err != nil {
+			t.Fatalf("Failed to identify visitor: %v", err)
+		}
+		if err := service.CountClick(ctx, url, click); err != nil {
+			t.Fatalf("Failed to count click: %v", err)
+		}
+	}
+	if err := rollups.Run(ctx); err != nil {
+		t.Fatalf("Failed to roll up clicks: %v", err)
+	}
+	if report, _ := service.AnalyticsReport(ctx, "recycled", userID, "", 0); report == nil || report.Clicks != 2 {
+		t.Fatalf("Expected 2 rolled up clicks before the purge, got %+v", report)
+	}
+
+	// Purge the link and give its ID to another user's link
+	expiredAt := time.Now().Add(-time.Hour)
+	url.ExpiresAt = &expiredAt
+	if purged, err := service.PurgeExpired(ctx, 0, false, 0); err != nil || purged != 1 {
+		t.Fatalf("Expected the link to be purged, got %d (%v)", purged, err)
+	}
+	newOwner := 2
+	if _, err := service.Shorten(ctx, "https://example.com/new", &newOwner, "recycled", nil, ""); err != nil {
+		t.Fatalf("Failed to reuse the purged ID: %v", err)
+	}
+
+	clicks, err := service.ListClicks(ctx, "recycled", newOwner, true)
+	if err != nil || len(clicks) != 0 {
+		t.Errorf("Expected no clicks on the new link, got %d (%v)", len(clicks), err)
+	}
+	stats, err := service.VisitorStats(ctx, "recycled", newOwner, 7)
+	if err != nil {
+		t.Fatalf("Failed to count visitors: %v", err)
+	}
+	if stats.Visitors != 0 || stats.Days[0].Visits != 0 || stats.Days[0].Visitors != 0 {
+		t.Errorf("Expected no visitors on the new link, got %+v", stats)
+	}
+	report, err := service.AnalyticsReport(ctx, "recycled", newOwner, "", 0)
+	if err != nil {
+		t.Fatalf("Failed to build report: %v", err)
+	}
+	if report.Clicks != 0 || report.Visitors != 0 || len(report.Referrers) != 0 {
+		t.Errorf("Expected an empty report for the new link, got %+v", report)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
@@ -11,8 +12,15 @@ import (
 
 // BioPageService handles bio page operations
 type BioPageService struct {
-	repo    repository.BioPageRepository
-	baseURL string
+	repo      repository.BioPageRepository
+	baseURL   string
+	reserved  *ReservedSlugService
+	registry  *SlugRegistry
+	policy    SlugPolicy
+	metadata  *MetadataService
+	webhooks  *WebhookService
+	clicks    *ClickStream
+	analytics *AnalyticsService
 }
 
 // NewBioPageService creates a new bio page service
@@ -23,22 +31,54 @@ func NewBioPageService(repo repository.BioPageRepository, baseURL string) *BioPa
 	}
 }
 
+// SetReservedSlugService sets the short codes bio pages can't use. Without it only a few default slugs are reserved.
+func (s *BioPageService) SetReservedSlugService(reserved *ReservedSlugService) {
+	s.reserved = reserved
+}
+
+// SetSlugRegistry records the short codes of bio pages in the registry shared
+// with links. In a unified namespace bio pages are also served at the root.
+func (s *BioPageService) SetSlugRegistry(registry *SlugRegistry) {
+	s.registry = registry
+}
+
+// SetSlugPolicy sets which custom short codes are accepted and how short codes are compared
+func (s *BioPageService) SetSlugPolicy(policy SlugPolicy) {
+	s.policy = policy
+}
+
+// SetMetadataService fetches the metadata of bio link URLs in the background
+func (s *BioPageService) SetMetadataService(metadata *MetadataService) {
+	s.metadata = metadata
+}
+
+// SlugPolicy returns the policy for custom short codes
+func (s *BioPageService) SlugPolicy() SlugPolicy {
+	return s.policy
+}
+
 // CreateBioPage creates a new bio page
 func (s *BioPageService) CreateBioPage(ctx context.Context, userID int, shortCode, title, description string) (*models.BioPageResponse, error) {
+	var claimed bool
 	if shortCode == "" {
 		var err error
-		shortCode, err = s.generateUniqueShortCode(ctx)
+		shortCode, claimed, err = s.generateUniqueShortCode(ctx)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		// Validate custom shortCode
-		if err := validateCustomSlug(shortCode); err != nil {
+		// Validate custom shortCode, converting it to the form it is stored in
+		var err error
+		shortCode, err = s.policy.Normalize(shortCode)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkReservedSlug(ctx, s.reserved, shortCode); err != nil {
 			return nil, err
 		}
 
-		// Check if the shortCode is available
-		_, err := s.repo.GetBioPageByShortCode(ctx, shortCode)
+		// Check if the shortCode, or one differing only in case, is available
+		_, err = s.repo.GetBioPageByShortCodeKey(ctx, models.SlugKey(shortCode))
 		if err == nil {
 			// ShortCode already exists
 			return nil, ErrSlugUnavailable
@@ -46,6 +86,14 @@ func (s *BioPageService) CreateBioPage(ctx context.Context, userID int, shortCod
 			// Some other error occurred
 			return nil, err
 		}
+
+		// Check the short code isn't used by a link
+		claimed, err = s.claimShortCode(ctx, shortCode)
+		if errors.Is(err, repository.ErrSlugUnavailable) {
+			return nil, ErrSlugUnavailable
+		} else if err != nil {
+			return nil, err
+		}
 	}
 
 	// Create a new bio page
@@ -54,11 +102,14 @@ func (s *BioPageService) CreateBioPage(ctx context.Context, userID int, shortCod
 
 	// Store the bio page
 	if err := s.repo.CreateBioPage(ctx, bioPage); err != nil {
+		if claimed {
+			s.registry.Release(ctx, shortCode, models.SlugKindBioPage)
+		}
 		return nil, err
 	}
 
 	// Return the response
-	return bioPage.ToBioPageResponse(s.baseURL), nil
+	return s.toResponse(bioPage), nil
 }
 
 // GetBioPage retrieves a bio page by ID
@@ -68,14 +119,17 @@ func (s *BioPageService) GetBioPage(ctx context.Context, id int) (*models.BioPag
 		return nil, err
 	}
 
-	return bioPage.ToBioPageResponse(s.baseURL), nil
+	return s.toResponse(bioPage), nil
 }
 
 // GetBioPageByShortCode retrieves a bio page by short code
 func (s *BioPageService) GetBioPageByShortCode(ctx context.Context, shortCode string) (*models.BioPageResponse, error) {
 	fmt.Printf("Attempting to get bio page with shortCode: %s\n", shortCode)
 
-	bioPage, err := s.repo.GetBioPageByShortCode(ctx, shortCode)
+	bioPage, err := lookupSlug(s.policy, shortCode,
+		func(shortCode string) (*models.BioPage, error) { return s.repo.GetBioPageByShortCode(ctx, shortCode) },
+		func(key string) (*models.BioPage, error) { return s.repo.GetBioPageByShortCodeKey(ctx, key) },
+	)
 	if err != nil {
 		fmt.Printf("Error fetching bio page from repository: %v\n", err)
 		return nil, err
@@ -84,7 +138,7 @@ func (s *BioPageService) GetBioPageByShortCode(ctx context.Context, shortCode st
 	fmt.Printf("Successfully found bio page: ID=%d, Title=%s, Published=%v\n",
 		bioPage.ID, bioPage.Title, bioPage.IsPublished)
 
-	return bioPage.ToBioPageResponse(s.baseURL), nil
+	return s.toResponse(bioPage), nil
 }
 
 // IncrementBioPageVisits increments the visit count for a bio page
@@ -119,7 +173,7 @@ func (s *BioPageService) ListBioPagesByUserID(ctx context.Context, userID int) (
 	// Convert to response format
 	responses := make([]*models.BioPageResponse, 0, len(bioPages))
 	for _, bioPage := range bioPages {
-		responses = append(responses, bioPage.ToBioPageResponse(s.baseURL))
+		responses = append(responses, s.toResponse(bioPage))
 	}
 
 	return responses, nil
@@ -145,12 +199,26 @@ func (s *BioPageService) UpdateBioPage(ctx context.Context, id int, title, descr
 		return nil, err
 	}
 
-	return bioPage.ToBioPageResponse(s.baseURL), nil
+	return s.toResponse(bioPage), nil
 }
 
 // DeleteBioPage deletes a bio page
 func (s *BioPageService) DeleteBioPage(ctx context.Context, id int) error {
-	return s.repo.DeleteBioPage(ctx, id)
+	if s.registry == nil {
+		return s.repo.DeleteBioPage(ctx, id)
+	}
+
+	// Get the short code so it can be freed in the slug registry
+	bioPage, err := s.repo.GetBioPageByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteBioPage(ctx, id); err != nil {
+		return err
+	}
+
+	return s.registry.Release(ctx, bioPage.ShortCode, models.SlugKindBioPage)
 }
 
 // AddBioLink adds a new link to a bio page
@@ -184,6 +252,7 @@ func (s *BioPageService) AddBioLink(ctx context.Context, bioPageID int, title, u
 	if err := s.repo.CreateBioLink(ctx, bioLink); err != nil {
 		return nil, err
 	}
+	s.queueMetadata(bioLink)
 
 	return bioLink.ToBioLinkResponse(), nil
 }
@@ -212,6 +281,7 @@ func (s *BioPageService) UpdateBioLink(ctx context.Context, id int, title, url s
 	}
 
 	// Update the fields
+	urlChanged := bioLink.URL != url
 	bioLink.Title = title
 	bioLink.URL = url
 	bioLink.IsEnabled = isEnabled
@@ -220,6 +290,9 @@ func (s *BioPageService) UpdateBioLink(ctx context.Context, id int, title, url s
 	if err := s.repo.UpdateBioLink(ctx, bioLink); err != nil {
 		return nil, err
 	}
+	if urlChanged {
+		s.queueMetadata(bioLink)
+	}
 
 	return bioLink.ToBioLinkResponse(), nil
 }
@@ -234,27 +307,68 @@ func (s *BioPageService) ReorderBioLinks(ctx context.Context, bioPageID int, lin
 	return s.repo.ReorderBioLinks(ctx, bioPageID, linkIDs)
 }
 
-// generateUniqueShortCode generates a unique short code for a bio page
-func (s *BioPageService) generateUniqueShortCode(ctx context.Context) (string, error) {
+// generateUniqueShortCode generates a unique short code for a bio page and
+// reports whether it was claimed in the slug registry
+func (s *BioPageService) generateUniqueShortCode(ctx context.Context) (string, bool, error) {
 	for attempts := 0; attempts < 5; attempts++ {
 		shortCode, err := generateRandomString(6)
 		if err != nil {
-			return "", err
+			return "", false, err
+		}
+
+		// Skip reserved short codes
+		if err := checkReservedSlug(ctx, s.reserved, shortCode); err == ErrSlugNotAllowed {
+			continue
+		} else if err != nil {
+			return "", false, err
 		}
 
-		// Check if the short code is available
-		_, err = s.repo.GetBioPageByShortCode(ctx, shortCode)
+		// Check if the short code, or one differing only in case, is available
+		_, err = s.repo.GetBioPageByShortCodeKey(ctx, models.SlugKey(shortCode))
 		if err == repository.ErrNotFound {
-			// Short code is available
-			return shortCode, nil
+			// Short code is available, unless a link uses it
+			claimed, err := s.claimShortCode(ctx, shortCode)
+			if errors.Is(err, repository.ErrSlugUnavailable) {
+				continue
+			} else if err != nil {
+				return "", false, err
+			}
+			return shortCode, claimed, nil
 		} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
 			// An error occurred
-			return "", err
+			return "", false, err
 		}
 		// Short code is already in use, try again
 	}
 
-	return "", errors.New("failed to generate a unique short code")
+	return "", false, errors.New("failed to generate a unique short code")
+}
+
+// claimShortCode claims a short code in the slug registry, if there is one,
+// and reports whether it was claimed
+func (s *BioPageService) claimShortCode(ctx context.Context, shortCode string) (bool, error) {
+	if s.registry == nil {
+		return false, nil
+	}
+	return s.registry.Claim(ctx, shortCode, models.SlugKindBioPage)
+}
+
+// toResponse converts a bio page to a response. In a unified namespace its
+// short URL is at the root, like a link's.
+func (s *BioPageService) toResponse(bioPage *models.BioPage) *models.BioPageResponse {
+	response := bioPage.ToBioPageResponse(s.baseURL)
+	response.ShortURL = s.baseURL + "/b/" + url.PathEscape(bioPage.ShortCode)
+	if s.registry != nil && s.registry.Unified() {
+		response.ShortURL = s.baseURL + "/" + url.PathEscape(bioPage.ShortCode)
+	}
+	return response
+}
+
+// queueMetadata asks for the metadata of a bio link's URL to be fetched
+func (s *BioPageService) queueMetadata(bioLink *models.BioLink) {
+	if s.metadata != nil {
+		s.metadata.QueueBioLink(bioLink.ID)
+	}
 }
 
 // GetBioPageIDForLink retrieves the bio page ID for a given link ID
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bufio"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// knownCrawlers are lowercase fragments of the user agents of search engines,
+// SEO tools, AI crawlers and uptime monitors whose names don't give them away
+// as bots. Social media sites and chat apps are in socialCrawlers.
+var knownCrawlers = []string{
+	"slurp",
+	"baiduspider",
+	"exabot",
+	"mediapartners-google",
+	"google-read-aloud",
+	"google-inspectiontool",
+	"googleother",
+	"feedfetcher",
+	"screaming frog",
+	"bytespider",
+	"chatgpt-user",
+	"oai-searchbot",
+	"perplexity",
+	"ccbot",
+	"uptimerobot",
+	"pingdom",
+	"statuscake",
+	"site24x7",
+	"newrelicpinger",
+	"datadog",
+	"better uptime",
+	"hetrixtools",
+	"freshping",
+	"checkly",
+	"nagios",
+	"zabbix",
+	"gtmetrix",
+	"lighthouse",
+	"pagespeed",
+	"ms-office",
+	"office existence discovery",
+	"google-safety",
+	"safebrowsing",
+	"proofpoint",
+	"mimecast",
+	"barracuda",
+}
+
+// automationAgents are lowercase fragments of the user agents of HTTP
+// libraries, command line tools and headless browsers
+var automationAgents = []string{
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"python-httpx",
+	"aiohttp",
+	"go-http-client",
+	"java/",
+	"okhttp",
+	"apache-httpclient",
+	"axios/",
+	"node-fetch",
+	"undici",
+	"libwww-perl",
+	"lwp::",
+	"ruby",
+	"faraday",
+	"guzzlehttp",
+	"postmanruntime",
+	"insomnia",
+	"httpie",
+	"scrapy",
+	"headlesschrome",
+	"phantomjs",
+	"puppeteer",
+	"playwright",
+	"selenium",
+	"rapidurl-",
+}
+
+// botNamePattern matches user agents naming themselves a bot, crawler or
+// spider, like "Googlebot/2.1" or "AhrefsBot 7.0", without matching phone
+// models like "CUBOT X30"
+var botNamePattern = regexp.MustCompile(`(?i)(bot|crawler|spider|scraper)(/|;|\)|\s*\d|\s*$)|\+https?://`)
+
+// BotFilter tells visits from bots, crawlers and prefetchers apart from
+// visits from people, from the request's user agent and headers
+type BotFilter struct {
+	enabled bool
+	// extraAgents are lowercase fragments of user agents added by the bot list
+	extraAgents []string
+}
+
+// NewBotFilter creates a new bot filter. A disabled filter takes every visit
+// to come from a person.
+func NewBotFilter(enabled bool) *BotFilter {
+	return &BotFilter{
+		enabled: enabled,
+	}
+}
+
+// LoadBotList reads a file of extra user agent fragments identifying bots,
+// one per line and matched case-insensitively. Blank lines and lines
+// starting with # are ignored.
+func (f *BotFilter) LoadBotList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var agents []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		agents = append(agents, strings.ToLower(entry))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	f.extraAgents = agents
+	return nil
+}
+
+// Classify checks if a visit came from a bot and returns why it was taken
+// to, as one of the models.BotReason constants
+func (f *BotFilter) Classify(userAgent string, header http.Header) (bool, string) {
+	if f == nil || !f.enabled {
+		return false, ""
+	}
+
+	// Browsers prefetching or prerendering a link say so
+	if isPrefetch(header) {
+		return true, models.BotReasonPrefetch
+	}
+
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return true, models.BotReasonNoUserAgent
+	}
+
+	lower := strings.ToLower(userAgent)
+	if IsSocialCrawler(lower) || containsAny(lower, knownCrawlers) || containsAny(lower, f.extraAgents) {
+		return true, models.BotReasonCrawler
+	}
+	if botNamePattern.MatchString(userAgent) {
+		return true, models.BotReasonCrawler
+	}
+	if containsAny(lower, automationAgents) {
+		return true, models.BotReasonAutomation
+	}
+
+	// Every browser sends its languages when following a link, so scripts
+	// copying a browser's user agent are caught without them
+	if strings.HasPrefix(lower, "mozilla/") && header.Get("Accept-Language") == "" {
+		return true, models.BotReasonHeaders
+	}
+
+	return false, ""
+}
+
+// isPrefetch checks if a request is a browser prefetching or prerendering a
+// link rather than following it
+func isPrefetch(header http.Header) bool {
+	for _, name := range []string{"Sec-Purpose", "Purpose", "X-Purpose", "X-Moz"} {
+		value := strings.ToLower(header.Get(name))
+		if strings.Contains(value, "prefetch") || strings.Contains(value, "prerender") || strings.Contains(value, "preview") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny checks if s contains any of the fragments
+func containsAny(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(s, fragment) {
+			return true
+		}
+	}
+	return false
+}
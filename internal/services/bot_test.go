@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestBotFiltering(t *testing.T) {
+	ctx := context.Background()
+
+	browser := http.Header{"Accept-Language": {"en-GB,en;q=0.9"}}
+	chrome := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	filter := NewBotFilter(true)
+
+	tests := []struct {
+		name      string
+		userAgent string
+		header    http.Header
+		bot       bool
+		reason    string
+	}{
+		{"browser", chrome, browser, false, ""},
+		{"phone model ending in bot", "Mozilla/5.0 (Linux; Android 12; CUBOT X30) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36", browser, false, ""},
+		{"search engine", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", http.Header{}, true, models.BotReasonCrawler},
+		{"social crawler", "facebookexternalhit/1.1", http.Header{}, true, models.BotReasonCrawler},
+		{"uptime monitor", "Mozilla/5.0 (compatible; UptimeRobot/2.0)", browser, true, models.BotReasonCrawler},
+		{"command line", "curl/8.4.0", http.Header{}, true, models.BotReasonAutomation},
+		{"headless browser", "Mozilla/5.0 HeadlessChrome/120.0", browser, true, models.BotReasonAutomation},
+		{"no user agent", "", browser, true, models.BotReasonNoUserAgent},
+		{"prefetch", chrome, http.Header{"Accept-Language": {"en"}, "Sec-Purpose": {"prefetch;prerender"}}, true, models.BotReasonPrefetch},
+		{"browser without languages", chrome, http.Header{}, true, models.BotReasonHeaders},
+	}
+	for _, tt := range tests {
+		bot, reason := filter.Classify(tt.userAgent, tt.header)
+		if bot != tt.bot || reason != tt.reason {
+			t.Errorf("%s: expected (%v, %q), got (%v, %q)", tt.name, tt.bot, tt.reason, bot, reason)
+		}
+	}
+
+	// A disabled filter takes every visit to come from a person
+	if bot, _ := NewBotFilter(false).Classify("curl/8.4.0", http.Header{}); bot {
+		t.Errorf("Expected a disabled filter not to flag bots")
+	}
+
+	// The bot list adds user agents
+	botList := filepath.Join(t.TempDir(), "bots.txt")
+	if err := os.WriteFile(botList, []byte("# In-house\nAcmeLinkChecker\n\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write bot list: %v", err)
+	}
+	if err := filter.LoadBotList(botList); err != nil {
+		t.Fatalf("Failed to load bot list: %v", err)
+	}
+	if bot, reason := filter.Classify("Mozilla/5.0 acmelinkchecker", browser); !bot || reason != models.BotReasonCrawler {
+		t.Errorf("Expected the listed user agent to be a crawler, got (%v, %q)", bot, reason)
+	}
+
+	// Bots are counted apart from people, and both are recorded
+	service, repo := newTestShortener()
+	service.SetAnalyticsService(NewAnalyticsService(repository.NewMemoryClickRepository(), filter, false))
+
+	userID := 1
+	created, err := service.Shorten(ctx, "https://example.com/bots", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	url, _ := repo.GetByID(ctx, created.ID)
+	for _, userAgent := range []string{chrome, "curl/8.4.0", "Slackbot-LinkExpanding 1.0"} {
+		click := models.NewClick("", userAgent)
+		service.ClassifyClick(click, browser)
+		if err := service.CountClick(ctx, url, click); err != nil {
+			t.Fatalf("Failed to count click: %v", err)
+		}
+	}
+	stored, _ := repo.GetByID(ctx, created.ID)
+	if stored.Visits != 1 || stored.BotVisits != 2 {
+		t.Errorf("Expected 1 visit and 2 bot visits, got %d and %d", stored.Visits, stored.BotVisits)
+	}
+
+	humans, err := service.ListClicks(ctx, created.ID, userID, false)
+	if err != nil {
+		t.Fatalf("Failed to list clicks: %v", err)
+	}
+	if len(humans) != 1 || humans[0].Bot || humans[0].URLID != created.ID {
+		t.Errorf("Expected the person's click only, got %+v", humans)
+	}
+	all, _ := service.ListClicks(ctx, created.ID, userID, true)
+	if len(all) != 3 {
+		t.Errorf("Expected 3 clicks including bots, got %d", len(all))
+	}
+	if _, err := service.ListClicks(ctx, created.ID, 2, true); err == nil {
+		t.Errorf("Expected other users not to list the link's clicks")
+	}
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Campaign errors
+var (
+	ErrInvalidCampaign     = errors.New("invalid campaign: it needs a name")
+	ErrInvalidChannel      = errors.New("invalid channel: it needs a name of letters, numbers, hyphens or underscores, a source and a medium")
+	ErrChannelExists       = errors.New("the campaign already has a channel with this name")
+	ErrChannelNotFound     = errors.New("campaign channel not found")
+	ErrNotCampaignOwner    = errors.New("you don't have permission to use this campaign")
+	ErrCampaignUnavailable = errors.New("campaigns are not available")
+)
+
+// channelNamePattern matches valid channel names
+var channelNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// CampaignStats summarizes the links and clicks of a campaign
+type CampaignStats struct {
+	Campaign *models.Campaign `json:"campaign"`
+	Links    int              `json:"links"`
+	Clicks   int              `json:"clicks"`
+	Channels []*ChannelStats  `json:"channels"`
+}
+
+// ChannelStats summarizes the links and clicks of one campaign channel
+type ChannelStats struct {
+	Channel string `json:"channel"`
+	Links   int    `json:"links"`
+	Clicks  int    `json:"clicks"`
+}
+
+// CampaignService handles campaign operations
+type CampaignService struct {
+	repo    repository.CampaignRepository
+	urlRepo repository.Repository
+}
+
+// NewCampaignService creates a new campaign service
+func NewCampaignService(repo repository.CampaignRepository, urlRepo repository.Repository) *CampaignService {
+	return &CampaignService{
+		repo:    repo,
+		urlRepo: urlRepo,
+	}
+}
+
+// CreateCampaign creates a new campaign. The utm_campaign value defaults to the name in lower case.
+func (s *CampaignService) CreateCampaign(ctx context.Context, userID int, name, utmCampaign string) (*models.Campaign, error) {
+	name, utmCampaign, err := validateCampaign(name, utmCampaign)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign := models.NewCampaign(userID, name, utmCampaign)
+	if err := s.repo.CreateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// GetCampaign retrieves a campaign by ID, checking that it belongs to the given user
+func (s *CampaignService) GetCampaign(ctx context.Context, id, userID int) (*models.Campaign, error) {
+	campaign, err := s.repo.GetCampaignByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if campaign.UserID != userID {
+		return nil, ErrNotCampaignOwner
+	}
+
+	return campaign, nil
+}
+
+// ListCampaigns lists all campaigns for a user
+func (s *CampaignService) ListCampaigns(ctx context.Context, userID int) ([]*models.Campaign, error) {
+	return s.repo.ListCampaignsByUserID(ctx, userID)
+}
+
+// UpdateCampaign changes the name and utm_campaign value of a campaign
+func (s *CampaignService) UpdateCampaign(ctx context.Context, id, userID int, name, utmCampaign string) (*models.Campaign, error) {
+	campaign, err := s.GetCampaign(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign.Name, campaign.UTMCampaign, err = validateCampaign(name, utmCampaign)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// DeleteCampaign deletes a campaign. Links already created for it keep their tagged destinations.
+func (s *CampaignService) DeleteCampaign(ctx context.Context, id, userID int) error {
+	if _, err := s.GetCampaign(ctx, id, userID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteCampaign(ctx, id)
+}
+
+// AddChannel adds a channel to a campaign
+func (s *CampaignService) AddChannel(ctx context.Context, id, userID int, channel models.CampaignChannel) (*models.Campaign, error) {
+	campaign, err := s.GetCampaign(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	channel.Name = strings.ToLower(strings.TrimSpace(channel.Name))
+	channel.Source = strings.TrimSpace(channel.Source)
+	channel.Medium = strings.TrimSpace(channel.Medium)
+	channel.Content = strings.TrimSpace(channel.Content)
+	channel.Term = strings.TrimSpace(channel.Term)
+	if !channelNamePattern.MatchString(channel.Name) || channel.Source == "" || channel.Medium == "" {
+		return nil, ErrInvalidChannel
+	}
+	if campaign.GetChannel(channel.Name) != nil {
+		return nil, ErrChannelExists
+	}
+
+	campaign.Channels = append(campaign.Channels, channel)
+	if err := s.repo.UpdateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// RemoveChannel removes a channel from a campaign
+func (s *CampaignService) RemoveChannel(ctx context.Context, id, userID int, name string) (*models.Campaign, error) {
+	campaign, err := s.GetCampaign(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]models.CampaignChannel, 0, len(campaign.Channels))
+	for _, channel := range campaign.Channels {
+		if channel.Name != name {
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == len(campaign.Channels) {
+		return nil, ErrChannelNotFound
+	}
+
+	campaign.Channels = channels
+	if err := s.repo.UpdateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// TagURL adds the UTM parameters of one of the user's campaigns and channels to a URL
+func (s *CampaignService) TagURL(ctx context.Context, campaignID, userID int, channelName, rawURL string) (string, error) {
+	campaign, err := s.GetCampaign(ctx, campaignID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	channel := campaign.GetChannel(channelName)
+	if channel == nil {
+		return "", ErrChannelNotFound
+	}
+
+	return campaign.TagURL(rawURL, channel)
+}
+
+// Stats groups a user's links and clicks by campaign and channel
+func (s *CampaignService) Stats(ctx context.Context, userID int) ([]*CampaignStats, error) {
+	campaigns, err := s.repo.ListCampaignsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	urls, err := s.urlRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start with every channel of every campaign, so unused channels show up too
+	stats := make([]*CampaignStats, 0, len(campaigns))
+	byCampaign := make(map[int]*CampaignStats, len(campaigns))
+	for _, campaign := range campaigns {
+		campaignStats := &CampaignStats{Campaign: campaign, Channels: make([]*ChannelStats, 0, len(campaign.Channels))}
+		for _, channel := range campaign.Channels {
+			campaignStats.Channels = append(campaignStats.Channels, &ChannelStats{Channel: channel.Name})
+		}
+		stats = append(stats, campaignStats)
+		byCampaign[campaign.ID] = campaignStats
+	}
+
+	for _, url := range urls {
+		if url.CampaignID == nil {
+			continue
+		}
+		campaignStats, ok := byCampaign[*url.CampaignID]
+		if !ok {
+			continue
+		}
+
+		campaignStats.Links++
+		campaignStats.Clicks += url.Visits
+
+		// Links for a channel that has since been removed still count towards it
+		channelStats := campaignStats.channel(url.Channel)
+		channelStats.Links++
+		channelStats.Clicks += url.Visits
+	}
+
+	// Sort the channels by clicks, most clicked first
+	for _, campaignStats := range stats {
+		sort.SliceStable(campaignStats.Channels, func(i, j int) bool {
+			return campaignStats.Channels[i].Clicks > campaignStats.Channels[j].Clicks
+		})
+	}
+
+	return stats, nil
+}
+
+// channel returns the stats of a channel, adding them if needed
+func (c *CampaignStats) channel(name string) *ChannelStats {
+	for _, channelStats := range c.Channels {
+		if channelStats.Channel == name {
+			return channelStats
+		}
+	}
+
+	channelStats := &ChannelStats{Channel: name}
+	c.Channels = append(c.Channels, channelStats)
+	return channelStats
+}
+
+// validateCampaign trims a campaign's name and utm_campaign value, defaulting the latter to the name
+func validateCampaign(name, utmCampaign string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", ErrInvalidCampaign
+	}
+
+	utmCampaign = strings.TrimSpace(utmCampaign)
+	if utmCampaign == "" {
+		utmCampaign = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	}
+
+	return name, utmCampaign, nil
+}
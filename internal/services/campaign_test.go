@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestCampaignService(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestShortener()
+	campaigns := NewCampaignService(repository.NewMemoryCampaignRepository(), repo)
+	service.SetCampaignService(campaigns)
+	userID := 1
+
+	campaign, err := campaigns.CreateCampaign(ctx, userID, "Spring Sale", "")
+	if err != nil {
+		t.Fatalf("Failed to create campaign: %v", err)
+	}
+	if campaign.UTMCampaign != "spring_sale" {
+		t.Errorf("Expected utm_campaign spring_sale, got %s", campaign.UTMCampaign)
+	}
+
+	if _, err := campaigns.AddChannel(ctx, campaign.ID, userID, models.CampaignChannel{Name: "Newsletter", Source: "newsletter", Medium: "email"}); err != nil {
+		t.Fatalf("Failed to add channel: %v", err)
+	}
+	if _, err := campaigns.AddChannel(ctx, campaign.ID, userID, models.CampaignChannel{Name: "newsletter", Source: "other", Medium: "email"}); err != ErrChannelExists {
+		t.Errorf("Expected ErrChannelExists, got %v", err)
+	}
+	if _, err := campaigns.AddChannel(ctx, campaign.ID, 2, models.CampaignChannel{Name: "social", Source: "twitter", Medium: "social"}); err != ErrNotCampaignOwner {
+		t.Errorf("Expected ErrNotCampaignOwner, got %v", err)
+	}
+
+	// Links created for a channel are tagged with its UTM parameters
+	resp, err := service.ShortenWithOptions(ctx, "https://example.com/sale?utm_source=old&ref=home", &userID, &ShortenOptions{
+		CampaignID: &campaign.ID,
+		Channel:    "newsletter",
+	})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL for campaign: %v", err)
+	}
+	expected := "https://example.com/sale?ref=home&utm_campaign=spring_sale&utm_medium=email&utm_source=newsletter"
+	if resp.OriginalURL != expected {
+		t.Errorf("Expected %s, got %s", expected, resp.OriginalURL)
+	}
+
+	if _, err := service.ShortenWithOptions(ctx, "https://example.com/sale", &userID, &ShortenOptions{
+		CampaignID: &campaign.ID,
+		Channel:    "print",
+	}); err != ErrChannelNotFound {
+		t.Errorf("Expected ErrChannelNotFound, got %v", err)
+	}
+
+	// Clicks are grouped by campaign and channel
+	url, err := repo.GetByID(ctx, resp.ID)
+	if err != nil {
+		t.Fatalf("Failed to get URL: %v", err)
+	}
+	url.Visits = 3
+	repo.Update(ctx, url)
+
+	stats, err := campaigns.Stats(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to get campaign stats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Links != 1 || stats[0].Clicks != 3 {
+		t.Fatalf("Expected 1 campaign with 1 link and 3 clicks, got %+v", stats)
+	}
+	if len(stats[0].Channels) != 1 || stats[0].Channels[0].Channel != "newsletter" || stats[0].Channels[0].Clicks != 3 {
+		t.Errorf("Expected 3 clicks for the newsletter channel, got %+v", stats[0].Channels)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters added by ad and email platforms to
+// attribute clicks. They don't change the page a destination shows.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"gbraid":  true,
+	"wbraid":  true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"twclid":  true,
+	"ttclid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"_gl":     true,
+}
+
+// defaultPorts maps each accepted scheme to the port it uses when none is given
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// URLNormalization controls how destination URLs are canonicalized before
+// they are stored. Hostnames are always lowercased, default ports dropped and
+// trailing slashes removed from paths; the zero value leaves the query as it
+// is.
+type URLNormalization struct {
+	// SortQuery orders query parameters by name, keeping the order of repeated parameters
+	SortQuery bool
+	// StripTracking removes utm_* parameters and click IDs such as fbclid and gclid
+	StripTracking bool
+}
+
+// Canonicalize validates a destination URL and returns its canonical form,
+// so equivalent URLs are stored the same way
+func (n URLNormalization) Canonicalize(rawURL string) (string, error) {
+	if err := validateURL(rawURL); err != nil {
+		return "", err
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if strings.Contains(host, ":") {
+		// IPv6 addresses keep their brackets
+		host = "[" + host + "]"
+	}
+	if port := parsed.Port(); port != "" && port != defaultPorts[parsed.Scheme] {
+		host += ":" + port
+	}
+	parsed.Host = host
+
+	// https://example.com/ and https://example.com/docs/ show the same pages
+	// as https://example.com and https://example.com/docs
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = strings.TrimRight(parsed.RawPath, "/")
+
+	parsed.RawQuery = n.normalizeQuery(parsed.RawQuery)
+	parsed.ForceQuery = false
+
+	return parsed.String(), nil
+}
+
+// normalizeQuery strips and sorts query parameters as configured. The
+// parameters keep their original encoding, so the destination receives
+// exactly the values it was given.
+func (n URLNormalization) normalizeQuery(rawQuery string) string {
+	if rawQuery == "" || (!n.SortQuery && !n.StripTracking) {
+		return rawQuery
+	}
+
+	params := make([]string, 0, strings.Count(rawQuery, "&")+1)
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		if n.StripTracking && isTrackingParam(queryParamName(param)) {
+			continue
+		}
+		params = append(params, param)
+	}
+
+	if n.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			return queryParamName(params[i]) < queryParamName(params[j])
+		})
+	}
+
+	return strings.Join(params, "&")
+}
+
+// queryParamName returns the unescaped name of a raw query parameter
+func queryParamName(param string) string {
+	name, _, _ := strings.Cut(param, "=")
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+// isTrackingParam reports whether a query parameter only attributes the click
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || trackingParams[name]
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestURLCanonicalization(t *testing.T) {
+	tests := []struct {
+		normalization URLNormalization
+		url           string
+		want          string
+	}{
+		{URLNormalization{}, "https://Example.COM/", "https://example.com"},
+		{URLNormalization{}, "HTTPS://example.com:443/docs/", "https://example.com/docs"},
+		{URLNormalization{}, "http://example.com:80/a?b=1", "http://example.com/a?b=1"},
+		{URLNormalization{}, "http://example.com:8080/", "http://example.com:8080"},
+		{URLNormalization{}, "https://[2001:DB8::1]:443/x", "https://[2001:db8::1]/x"},
+		{URLNormalization{}, "https://example.com/Docs?z=1&a=2#Top", "https://example.com/Docs?z=1&a=2#Top"},
+		{URLNormalization{}, "https://example.com/a%2Fb/", "https://example.com/a%2Fb"},
+		{URLNormalization{SortQuery: true}, "https://example.com/?z=1&a=2&z=0", "https://example.com?a=2&z=1&z=0"},
+		{URLNormalization{StripTracking: true}, "https://example.com/?utm_source=x&id=7&fbclid=abc&UTM_Medium=y", "https://example.com?id=7"},
+		{URLNormalization{StripTracking: true}, "https://example.com/?utm_source=x", "https://example.com"},
+		{URLNormalization{SortQuery: true, StripTracking: true}, "https://example.com/p?q=a%20b&gclid=1&b=%26", "https://example.com/p?b=%26&q=a%20b"},
+	}
+	for _, tt := range tests {
+		got, err := tt.normalization.Canonicalize(tt.url)
+		if err != nil {
+			t.Errorf("Failed to canonicalize %s: %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expected %s to be canonicalized as %s, got %s", tt.url, tt.want, got)
+		}
+	}
+
+	for _, invalid := range []string{"ftp://example.com", "https://", "example.com"} {
+		if _, err := (URLNormalization{}).Canonicalize(invalid); err != ErrInvalidURL {
+			t.Errorf("Expected %s to be invalid, got %v", invalid, err)
+		}
+	}
+}
+
+func TestReuseExistingLink(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestShortener()
+	service.SetURLNormalization(URLNormalization{StripTracking: true})
+
+	userID := 1
+	first, err := service.Shorten(ctx, "https://Example.com/pricing/?utm_source=newsletter", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if first.OriginalURL != "https://example.com/pricing" {
+		t.Errorf("Expected the destination to be stored canonicalized, got %s", first.OriginalURL)
+	}
+
+	// Without the option every request creates a link
+	second, err := service.Shorten(ctx, "https://example.com/pricing", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if second.ID == first.ID || second.Existing {
+		t.Errorf("Expected a new link without ReuseExisting")
+	}
+
+	// With it a link to an equivalent destination is returned
+	reused, err := service.ShortenWithOptions(ctx, "https://EXAMPLE.com:443/pricing/", &userID, &ShortenOptions{ReuseExisting: true})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if (reused.ID != first.ID && reused.ID != second.ID) || !reused.Existing {
+		t.Errorf("Expected an existing link to be reused, got %s (existing %v)", reused.ID, reused.Existing)
+	}
+
+	// Links of other users, custom slugs and password-protected links aren't reused
+	otherUser := 2
+	other, err := service.ShortenWithOptions(ctx, "https://example.com/pricing", &otherUser, &ShortenOptions{ReuseExisting: true})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if other.Existing {
+		t.Errorf("Expected another user's link not to be reused")
+	}
+	custom, err := service.ShortenWithOptions(ctx, "https://example.com/pricing", &userID, &ShortenOptions{ReuseExisting: true, CustomSlug: "pricing"})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if custom.Existing || custom.ID != "pricing" {
+		t.Errorf("Expected a custom slug to create a new link, got %s", custom.ID)
+	}
+	protected, err := service.ShortenWithOptions(ctx, "https://example.com/docs", &userID, &ShortenOptions{Password: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	reused, err = service.ShortenWithOptions(ctx, "https://example.com/docs", &userID, &ShortenOptions{ReuseExisting: true})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if reused.Existing || reused.ID == protected.ID {
+		t.Errorf("Expected a password-protected link not to be reused")
+	}
+
+	// A request with any link setting creates a link with it
+	expiresIn := time.Hour
+	for name, opts := range map[string]*ShortenOptions{
+		"expiry":          {ExpiresIn: &expiresIn},
+		"expiry action":   {ExpiryAction: models.ExpiryActionGone},
+		"query mode":      {QueryMode: models.QueryModeMergeKeep},
+		"passthrough":     {PathPassthrough: true},
+		"redirect status": {RedirectStatus: 301},
+		"cache policy":    {CachePolicy: models.CachePolicyNoStore},
+		"title":           {Title: "Pricing"},
+		"preview":         {Preview: true},
+		"social card":     {SocialTitle: "Our prices"},
+	} {
+		opts.ReuseExisting = true
+		created, err := service.ShortenWithOptions(ctx, "https://example.com/pricing", &userID, opts)
+		if err != nil {
+			t.Fatalf("Failed to shorten URL with %s: %v", name, err)
+		}
+		if created.Existing {
+			t.Errorf("Expected a request with %s to create a link, got existing link %s", name, created.ID)
+		}
+	}
+
+	// Those links aren't reused for requests without settings either
+	plain := map[string]bool{first.ID: true, second.ID: true, custom.ID: true}
+	reused, err = service.ShortenWithOptions(ctx, "https://example.com/pricing", &userID, &ShortenOptions{ReuseExisting: true})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if !reused.Existing || !plain[reused.ID] {
+		t.Errorf("Expected a link without settings to be reused, got %s (existing %v)", reused.ID, reused.Existing)
+	}
+
+	// Nor are links blocked or flagged by screening
+	for id, status := range map[string]string{
+		first.ID:  models.ScreeningStatusBlocked,
+		second.ID: models.ScreeningStatusFlagged,
+		custom.ID: models.ScreeningStatusFlagged,
+	} {
+		if err := repo.UpdateScreening(ctx, id, status, "test", time.Now()); err != nil {
+			t.Fatalf("Failed to update screening: %v", err)
+		}
+	}
+	reused, err = service.ShortenWithOptions(ctx, "https://example.com/pricing", &userID, &ShortenOptions{ReuseExisting: true})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if reused.Existing || plain[reused.ID] {
+		t.Errorf("Expected blocked and flagged links not to be reused, got %s", reused.ID)
+	}
+}
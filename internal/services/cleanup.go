@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+)
+
+// CleanupJobName is the scheduler name of the expired link cleanup job
+const CleanupJobName = "expired-link-cleanup"
+
+// CleanupStatus describes the expired link cleanup job
+type CleanupStatus struct {
+	Enabled     bool          `json:"enabled"`
+	Mode        string        `json:"mode"`
+	Retention   time.Duration `json:"retention"`
+	LastRunAt   time.Time     `json:"last_run_at,omitempty"`
+	LastPurged  int           `json:"last_purged"`
+	LastError   string        `json:"last_error,omitempty"`
+	TotalPurged int           `json:"total_purged"`
+}
+
+// CleanupService purges links that have been expired for longer than the retention period
+type CleanupService struct {
+	shortenerService *ShortenerService
+	config           *config.CleanupConfig
+	status           CleanupStatus
+	mutex            sync.RWMutex
+}
+
+// NewCleanupService creates a new cleanup service
+func NewCleanupService(shortenerService *ShortenerService, config *config.CleanupConfig) *CleanupService {
+	mode := "archive"
+	if !config.Archive {
+		mode = "delete"
+	}
+
+	return &CleanupService{
+		shortenerService: shortenerService,
+		config:           config,
+		status: CleanupStatus{
+			Enabled:   config.Enabled,
+			Mode:      mode,
+			Retention: config.Retention,
+		},
+	}
+}
+
+// Run purges expired links once; it is registered as a scheduler job
+func (s *CleanupService) Run(ctx context.Context) error {
+	purged, err := s.shortenerService.PurgeExpired(ctx, s.config.Retention, s.config.Archive, s.config.BatchSize)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.status.LastRunAt = time.Now()
+	s.status.LastPurged = purged
+	s.status.TotalPurged += purged
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+
+	return err
+}
+
+// Status returns the status of the cleanup job
+func (s *CleanupService) Status() CleanupStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.status
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// Errors returned when subscribing to the click stream
+var (
+	ErrTooManyStreams = errors.New("too many open click streams")
+	ErrStreamClosed   = errors.New("click stream is closed")
+)
+
+// Limits on click stream subscribers
+const (
+	// maxStreamsPerUser is how many click streams a user can have open at once,
+	// across their browser tabs and API clients
+	maxStreamsPerUser = 10
+	// clickStreamBufferSize is how many events can wait for a subscriber; events
+	// for a subscriber that falls further behind are dropped
+	clickStreamBufferSize = 64
+)
+
+// ClickStream fans click events out to the subscribers of the users they
+// belong to, in process. Publishing never blocks the redirect being counted.
+type ClickStream struct {
+	mutex       sync.Mutex
+	subscribers map[int]map[chan *models.ClickEvent]struct{}
+	nextID      int64
+	closed      bool
+}
+
+// NewClickStream creates a new click stream
+func NewClickStream() *ClickStream {
+	return &ClickStream{
+		subscribers: make(map[int]map[chan *models.ClickEvent]struct{}),
+		nextID:      1,
+	}
+}
+
+// Subscribe returns a channel receiving the user's click events and a
+// function ending the subscription. The channel is closed when the
+// subscription ends or the stream is closed.
+func (s *ClickStream) Subscribe(userID int) (<-chan *models.ClickEvent, func(), error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return nil, nil, ErrStreamClosed
+	}
+	if len(s.subscribers[userID]) >= maxStreamsPerUser {
+		return nil, nil, ErrTooManyStreams
+	}
+
+	events := make(chan *models.ClickEvent, clickStreamBufferSize)
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[chan *models.ClickEvent]struct{})
+	}
+	s.subscribers[userID][events] = struct{}{}
+
+	unsubscribe := func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		// The channel is already closed if the stream was
+		if _, ok := s.subscribers[userID][events]; !ok {
+			return
+		}
+		delete(s.subscribers[userID], events)
+		if len(s.subscribers[userID]) == 0 {
+			delete(s.subscribers, userID)
+		}
+		close(events)
+	}
+
+	return events, unsubscribe, nil
+}
+
+// HasSubscribers checks if anyone is subscribed to the stream, so publishers
+// can skip looking up events nobody would receive
+func (s *ClickStream) HasSubscribers() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.subscribers) > 0
+}
+
+// Publish numbers an event and sends it to the subscribers of its user,
+// dropping it for subscribers whose buffer is full
+func (s *ClickStream) Publish(event *models.ClickEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	event.ID = s.nextID
+	s.nextID++
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for events := range s.subscribers[event.UserID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription and refuses new ones. It is called when the
+// server shuts down, so open streams don't hold the shutdown up.
+func (s *ClickStream) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	for userID, subscribers := range s.subscribers {
+		for events := range subscribers {
+			close(events)
+		}
+		delete(s.subscribers, userID)
+	}
+}
+
+// SetClickStream streams visits to the links of logged in users to them
+func (s *ShortenerService) SetClickStream(clicks *ClickStream) {
+	s.clicks = clicks
+}
+
+// PublishClick tells the owner's webhooks and click streams about a click on a URL
+func (s *ShortenerService) PublishClick(url *models.URL, click *models.Click) {
+	s.publishLinkEvent(url, models.WebhookEventLinkClicked, &WebhookVisit{
+		Referrer:  click.Referrer,
+		UserAgent: click.UserAgent,
+		Alias:     click.Alias,
+		Bot:       click.Bot,
+	})
+
+	if s.clicks == nil || url.UserID == nil {
+		return
+	}
+
+	s.clicks.Publish(&models.ClickEvent{
+		Type:        models.ClickEventLink,
+		LinkID:      url.ID,
+		ShortURL:    s.ShortURL(url),
+		Alias:       click.Alias,
+		Title:       url.Title,
+		Destination: click.Destination,
+		Referrer:    click.Referrer,
+		UserAgent:   click.UserAgent,
+		Visits:      url.Visits,
+		Bot:         click.Bot,
+		BotReason:   click.BotReason,
+		UserID:      *url.UserID,
+	})
+}
+
+// SetClickStream streams views of bio pages and their links to the page owners
+func (s *BioPageService) SetClickStream(clicks *ClickStream) {
+	s.clicks = clicks
+}
+
+// PublishView tells the owner's webhooks and click streams about a view of a bio page
+func (s *BioPageService) PublishView(bioPage *models.BioPageResponse, click *models.Click) {
+	if s.webhooks != nil {
+		s.webhooks.Publish(bioPage.UserID, models.WebhookEventBioViewed, &BioPageEventData{
+			BioPage: bioPage,
+			Visit: &WebhookVisit{
+				Referrer:  click.Referrer,
+				UserAgent: click.UserAgent,
+				Bot:       click.Bot,
+			},
+		})
+	}
+
+	if s.clicks == nil {
+		return
+	}
+
+	s.clicks.Publish(&models.ClickEvent{
+		Type:      models.ClickEventBioView,
+		BioPageID: bioPage.ID,
+		ShortURL:  bioPage.ShortURL,
+		Title:     bioPage.Title,
+		Referrer:  click.Referrer,
+		UserAgent: click.UserAgent,
+		Bot:       click.Bot,
+		BotReason: click.BotReason,
+		UserID:    bioPage.UserID,
+	})
+}
+
+// publishLinkClick tells the owner's click streams about a click on a link on their bio page
+func (s *BioPageService) publishLinkClick(ctx context.Context, bioLink *models.BioLink, click *models.Click) error {
+	if s.clicks == nil || !s.clicks.HasSubscribers() {
+		return nil
+	}
+
+	// Look the page up for its owner
+	bioPage, err := s.repo.GetBioPageByID(ctx, bioLink.BioPageID)
+	if err != nil {
+		return err
+	}
+
+	s.clicks.Publish(&models.ClickEvent{
+		Type:        models.ClickEventBioLink,
+		BioPageID:   bioPage.ID,
+		BioLinkID:   bioLink.ID,
+		ShortURL:    bioPage.ToBioPageResponse(s.baseURL).ShortURL,
+		Title:       bioLink.Title,
+		Destination: bioLink.URL,
+		Referrer:    click.Referrer,
+		UserAgent:   click.UserAgent,
+		Visits:      bioLink.Visits,
+		Bot:         click.Bot,
+		BotReason:   click.BotReason,
+		UserID:      bioPage.UserID,
+	})
+	return nil
+}
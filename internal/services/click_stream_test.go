@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestClickStream(t *testing.T) {
+	ctx := context.Background()
+
+	service, repo := newTestShortener()
+	clicks := NewClickStream()
+	service.SetClickStream(clicks)
+
+	userID, otherUserID := 1, 2
+	events, unsubscribe, err := clicks.Subscribe(userID)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	otherEvents, _, err := clicks.Subscribe(otherUserID)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// Clicks are streamed to the link owner only
+	created, err := service.Shorten(ctx, "https://example.com/live", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	url, _ := repo.GetByID(ctx, created.ID)
+	if err := service.IncrementVisitCount(ctx, url); err != nil {
+		t.Fatalf("Failed to count visit: %v", err)
+	}
+	click := models.NewClick("https://news.example.com/", "Mozilla/5.0")
+	click.Destination = "https://example.com/live?utm_source=x"
+	service.PublishClick(url, click)
+	select {
+	case event := <-events:
+		if event.Type != models.ClickEventLink || event.LinkID != url.ID || event.ShortURL != created.ShortURL ||
+			event.Destination != "https://example.com/live?utm_source=x" || event.Visits != 1 || event.ID == 0 || event.Time.IsZero() {
+			t.Errorf("Unexpected click event %+v", event)
+		}
+	default:
+		t.Fatalf("Expected the owner to be streamed the click")
+	}
+	if len(otherEvents) != 0 {
+		t.Errorf("Expected other users not to be streamed the click")
+	}
+
+	// Anonymous links have no one to stream to
+	anonymous, _ := service.Shorten(ctx, "https://example.com/anonymous", nil, "", nil, "")
+	anonymousURL, _ := repo.GetByID(ctx, anonymous.ID)
+	service.PublishClick(anonymousURL, models.NewClick("", ""))
+	if len(events) != 0 || len(otherEvents) != 0 {
+		t.Errorf("Expected clicks on anonymous links not to be streamed")
+	}
+
+	// Bio page views and bio link clicks go to the page owner
+	bioService := NewBioPageService(repository.NewMemoryBioPageRepository(), testBaseURL)
+	bioService.SetClickStream(clicks)
+	bioPage, err := bioService.CreateBioPage(ctx, userID, "", "Me", "")
+	if err != nil {
+		t.Fatalf("Failed to create bio page: %v", err)
+	}
+	bioLink, err := bioService.AddBioLink(ctx, bioPage.ID, "Blog", "https://blog.example.com")
+	if err != nil {
+		t.Fatalf("Failed to add bio link: %v", err)
+	}
+	bioService.PublishView(bioPage, models.NewClick("", "Mozilla/5.0"))
+	if err := bioService.CountLinkClick(ctx, bioLink.ID, models.NewClick("", "Mozilla/5.0")); err != nil {
+		t.Fatalf("Failed to publish bio link click: %v", err)
+	}
+	for _, want := range []string{models.ClickEventBioView, models.ClickEventBioLink} {
+		select {
+		case event := <-events:
+			if event.Type != want || event.BioPageID != bioPage.ID || event.ShortURL != bioPage.ShortURL {
+				t.Errorf("Expected a %s event for the bio page, got %+v", want, event)
+			}
+			if want == models.ClickEventBioLink && (event.BioLinkID != bioLink.ID || event.Destination != bioLink.URL) {
+				t.Errorf("Expected the bio link's ID and destination, got %+v", event)
+			}
+		default:
+			t.Fatalf("Expected a %s event", want)
+		}
+	}
+
+	// A subscriber that falls behind loses events rather than blocking clicks
+	for i := 0; i < clickStreamBufferSize+10; i++ {
+		service.PublishClick(url, models.NewClick("", ""))
+	}
+	if len(events) != clickStreamBufferSize {
+		t.Errorf("Expected %d buffered events, got %d", clickStreamBufferSize, len(events))
+	}
+
+	// Ending a subscription closes its channel
+	unsubscribe()
+	unsubscribe()
+	for range events {
+	}
+
+	// Each user can only have so many streams open
+	for i := 0; i < maxStreamsPerUser; i++ {
+		if _, _, err := clicks.Subscribe(userID); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+	if _, _, err := clicks.Subscribe(userID); err != ErrTooManyStreams {
+		t.Errorf("Expected ErrTooManyStreams, got %v", err)
+	}
+
+	// Closing the stream ends every subscription and refuses new ones
+	clicks.Close()
+	for range otherEvents {
+	}
+	if _, _, err := clicks.Subscribe(otherUserID); err != ErrStreamClosed {
+		t.Errorf("Expected ErrStreamClosed, got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	neturl "net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Domain errors
+var (
+	ErrInvalidDomain            = errors.New("invalid domain: enter a hostname such as go.example.com")
+	ErrDomainNotVerified        = errors.New("the domain has not been verified yet")
+	ErrDomainVerificationFailed = errors.New("the verification TXT record was not found")
+	ErrNotDomainOwner           = errors.New("you don't have permission to use this domain")
+	ErrUnknownDomain            = errors.New("unknown domain: add and verify it on the Domains page first")
+	ErrDomainUnavailable        = errors.New("custom domains are not available")
+)
+
+// hostnameLabelPattern matches one label of a hostname
+var hostnameLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+const (
+	// domainCacheTTL is how long a resolved hostname is reused, bounding how
+	// late other servers notice a domain being verified or deleted
+	domainCacheTTL = time.Minute
+	// domainCacheSize is how many hostnames are cached before starting over,
+	// so requests with made-up Host headers can't grow the cache forever
+	domainCacheSize = 10000
+)
+
+// TXTResolver looks up DNS TXT records. *net.Resolver implements it.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// resolvedDomain is a cached result of resolving a hostname, with a nil
+// domain for hostnames that aren't verified custom domains
+type resolvedDomain struct {
+	domain    *models.Domain
+	expiresAt time.Time
+}
+
+// DomainService handles custom domain operations
+type DomainService struct {
+	repo        repository.DomainRepository
+	resolver    TXTResolver
+	defaultHost string
+
+	cacheMutex sync.Mutex
+	cache      map[string]resolvedDomain
+}
+
+// NewDomainService creates a new domain service. The host of baseURL is the
+// default domain, which can't be registered as a custom domain.
+func NewDomainService(repo repository.DomainRepository, resolver TXTResolver, baseURL string) *DomainService {
+	defaultHost := ""
+	if parsed, err := neturl.Parse(baseURL); err == nil {
+		defaultHost = strings.ToLower(parsed.Hostname())
+	}
+
+	return &DomainService{
+		repo:        repo,
+		resolver:    resolver,
+		defaultHost: defaultHost,
+		cache:       make(map[string]resolvedDomain),
+	}
+}
+
+// AddDomain registers an unverified custom domain for a user. Other users may
+// have added the hostname too; whoever verifies it first gets it.
+func (s *DomainService) AddDomain(ctx context.Context, userID int, hostname string) (*models.Domain, error) {
+	hostname, err := NormalizeHostname(hostname)
+	if err != nil {
+		return nil, err
+	}
+	if hostname == s.defaultHost {
+		return nil, repository.ErrDomainTaken
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	domain := models.NewDomain(userID, hostname, token)
+	if err := s.repo.CreateDomain(ctx, domain); err != nil {
+		return nil, err
+	}
+
+	return domain, nil
+}
+
+// GetDomain retrieves a domain by ID, checking that it belongs to the given user
+func (s *DomainService) GetDomain(ctx context.Context, id, userID int) (*models.Domain, error) {
+	domain, err := s.repo.GetDomainByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if domain.UserID != userID {
+		return nil, ErrNotDomainOwner
+	}
+
+	return domain, nil
+}
+
+// ListDomains lists all domains for a user
+func (s *DomainService) ListDomains(ctx context.Context, userID int) ([]*models.Domain, error) {
+	return s.repo.ListDomainsByUserID(ctx, userID)
+}
+
+// VerifyDomain checks that the domain's verification TXT record contains its token
+func (s *DomainService) VerifyDomain(ctx context.Context, id, userID int) (*models.Domain, error) {
+	domain, err := s.GetDomain(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if domain.IsVerified() {
+		return domain, nil
+	}
+
+	records, err := s.resolver.LookupTXT(ctx, domain.VerificationRecord())
+	if err != nil {
+		// A missing record is the usual reason for a failed lookup
+		return nil, ErrDomainVerificationFailed
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == domain.VerificationToken {
+			// The update fails with ErrDomainTaken if someone else verified
+			// the hostname first, leaving the domain unverified
+			verified := *domain
+			now := time.Now()
+			verified.VerifiedAt = &now
+			if err := s.repo.UpdateDomain(ctx, &verified); err != nil {
+				return nil, err
+			}
+			s.forget(verified.Hostname)
+			return &verified, nil
+		}
+	}
+
+	return nil, ErrDomainVerificationFailed
+}
+
+// DeleteDomain deletes a domain. Its links stop resolving on the domain but
+// stay reachable by ID on the default domain.
+func (s *DomainService) DeleteDomain(ctx context.Context, id, userID int) error {
+	domain, err := s.GetDomain(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteDomain(ctx, id); err != nil {
+		return err
+	}
+	s.forget(domain.Hostname)
+	return nil
+}
+
+// UsableDomain returns the domain with the given hostname if the user may create links on it
+func (s *DomainService) UsableDomain(ctx context.Context, hostname string, userID int) (*models.Domain, error) {
+	hostname, err := NormalizeHostname(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := s.repo.GetVerifiedDomain(ctx, hostname)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The user may have added the domain without verifying it yet
+		domains, err := s.repo.ListDomainsByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, domain := range domains {
+			if domain.Hostname == hostname {
+				return nil, ErrDomainNotVerified
+			}
+		}
+		return nil, ErrUnknownDomain
+	}
+	if err != nil {
+		return nil, err
+	}
+	if domain.UserID != userID {
+		return nil, ErrNotDomainOwner
+	}
+
+	return domain, nil
+}
+
+// Resolve returns the verified custom domain serving a request's Host header,
+// or repository.ErrNotFound for the default domain and unknown hosts. The
+// default domain is recognized without a lookup, and hostnames looked up are
+// cached for a short while.
+func (s *DomainService) Resolve(ctx context.Context, host string) (*models.Domain, error) {
+	hostname, err := NormalizeHostname(host)
+	if err != nil || hostname == s.defaultHost {
+		return nil, repository.ErrNotFound
+	}
+
+	s.cacheMutex.Lock()
+	cached, ok := s.cache[hostname]
+	s.cacheMutex.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		if cached.domain == nil {
+			return nil, repository.ErrNotFound
+		}
+		return cached.domain, nil
+	}
+
+	domain, err := s.repo.GetVerifiedDomain(ctx, hostname)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	s.cacheMutex.Lock()
+	if len(s.cache) >= domainCacheSize {
+		s.cache = make(map[string]resolvedDomain)
+	}
+	s.cache[hostname] = resolvedDomain{domain: domain, expiresAt: time.Now().Add(domainCacheTTL)}
+	s.cacheMutex.Unlock()
+
+	return domain, err
+}
+
+// forget drops a hostname from the cache after its domain was verified or deleted
+func (s *DomainService) forget(hostname string) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	delete(s.cache, hostname)
+}
+
+// NormalizeHostname lowercases a hostname and strips any port and trailing dot.
+// IP addresses and single-label names such as localhost are rejected.
+func NormalizeHostname(host string) (string, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	if len(host) > 253 || net.ParseIP(host) != nil {
+		return "", ErrInvalidDomain
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidDomain
+	}
+	for _, label := range labels {
+		if !hostnameLabelPattern.MatchString(label) {
+			return "", ErrInvalidDomain
+		}
+	}
+
+	return host, nil
+}
+
+// generateVerificationToken generates a random token for a domain's TXT record
+func generateVerificationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
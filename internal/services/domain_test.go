@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// fakeTXTResolver serves TXT records from a map
+type fakeTXTResolver map[string][]string
+
+func (r fakeTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestDomainService(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestShortener()
+	resolver := fakeTXTResolver{}
+	domains := NewDomainService(repository.NewMemoryDomainRepository(repo), resolver, testBaseURL)
+	service.SetDomainService(domains)
+	userID := 1
+
+	domain, err := domains.AddDomain(ctx, userID, "Go.Example.com.")
+	if err != nil {
+		t.Fatalf("Failed to add domain: %v", err)
+	}
+	if domain.Hostname != "go.example.com" {
+		t.Errorf("Expected hostname go.example.com, got %s", domain.Hostname)
+	}
+	if _, err := domains.AddDomain(ctx, userID, "go.example.com"); err != repository.ErrDomainTaken {
+		t.Errorf("Expected ErrDomainTaken, got %v", err)
+	}
+
+	// Unverified domains can't be used
+	_, err = service.ShortenWithOptions(ctx, "https://example.com", &userID, &ShortenOptions{Domain: "go.example.com"})
+	if err != ErrDomainNotVerified {
+		t.Errorf("Expected ErrDomainNotVerified, got %v", err)
+	}
+	if _, err := domains.VerifyDomain(ctx, domain.ID, userID); err != ErrDomainVerificationFailed {
+		t.Errorf("Expected ErrDomainVerificationFailed, got %v", err)
+	}
+
+	resolver[domain.VerificationRecord()] = []string{domain.VerificationToken}
+	if _, err := domains.VerifyDomain(ctx, domain.ID, userID); err != nil {
+		t.Fatalf("Failed to verify domain: %v", err)
+	}
+
+	// Other users can't create links on the domain
+	otherID := 2
+	_, err = service.ShortenWithOptions(ctx, "https://example.com", &otherID, &ShortenOptions{Domain: "go.example.com"})
+	if err != ErrNotDomainOwner {
+		t.Errorf("Expected ErrNotDomainOwner, got %v", err)
+	}
+
+	// The same slug can be used on the default domain and the custom domain
+	if _, err := service.ShortenWithOptions(ctx, "https://example.com/default", &userID, &ShortenOptions{CustomSlug: "launch"}); err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	resp, err := service.ShortenWithOptions(ctx, "https://example.com/branded", &userID, &ShortenOptions{Domain: "go.example.com", CustomSlug: "launch"})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL on domain: %v", err)
+	}
+	if resp.ShortURL != "https://go.example.com/launch" {
+		t.Errorf("Expected short URL https://go.example.com/launch, got %s", resp.ShortURL)
+	}
+	_, err = service.ShortenWithOptions(ctx, "https://example.com", &userID, &ShortenOptions{Domain: "go.example.com", CustomSlug: "launch"})
+	if err != ErrSlugUnavailable {
+		t.Errorf("Expected ErrSlugUnavailable, got %v", err)
+	}
+
+	// Requests for the domain resolve to its own link
+	resolved, err := domains.Resolve(ctx, "GO.example.com:443")
+	if err != nil {
+		t.Fatalf("Failed to resolve domain: %v", err)
+	}
+	url, err := service.GetByDomainSlug(ctx, resolved.Hostname, "launch")
+	if err != nil {
+		t.Fatalf("Failed to get URL by slug: %v", err)
+	}
+	if url.OriginalURL != "https://example.com/branded" {
+		t.Errorf("Expected the branded link, got %s", url.OriginalURL)
+	}
+	if _, err := domains.Resolve(ctx, "localhost:8080"); err != repository.ErrNotFound {
+		t.Errorf("Expected the default domain not to resolve, got %v", err)
+	}
+}
+
+func TestDomainService_Claims(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestShortener()
+	resolver := fakeTXTResolver{}
+	domains := NewDomainService(repository.NewMemoryDomainRepository(repo), resolver, testBaseURL)
+	service.SetDomainService(domains)
+	squatterID, ownerID := 1, 2
+
+	// Claiming a hostname first doesn't keep its owner from adding it
+	claim, err := domains.AddDomain(ctx, squatterID, "go.example.com")
+	if err != nil {
+		t.Fatalf("Failed to add domain: %v", err)
+	}
+	domain, err := domains.AddDomain(ctx, ownerID, "go.example.com")
+	if err != nil {
+		t.Fatalf("Failed to add a domain someone else claimed: %v", err)
+	}
+
+	// Only the owner can publish their token, and whoever verifies first gets the hostname
+	resolver[domain.VerificationRecord()] = []string{domain.VerificationToken}
+	if _, err := domains.VerifyDomain(ctx, claim.ID, squatterID); err != ErrDomainVerificationFailed {
+		t.Errorf("Expected ErrDomainVerificationFailed, got %v", err)
+	}
+	if _, err := domains.VerifyDomain(ctx, domain.ID, ownerID); err != nil {
+		t.Fatalf("Failed to verify domain: %v", err)
+	}
+	resolver[domain.VerificationRecord()] = append(resolver[domain.VerificationRecord()], claim.VerificationToken)
+	if _, err := domains.VerifyDomain(ctx, claim.ID, squatterID); err != repository.ErrDomainTaken {
+		t.Errorf("Expected ErrDomainTaken verifying a verified hostname, got %v", err)
+	}
+
+	resolved, err := domains.Resolve(ctx, "go.example.com")
+	if err != nil || resolved.UserID != ownerID {
+		t.Errorf("Expected the domain to resolve to the owner's, got %+v (%v)", resolved, err)
+	}
+	_, err = service.ShortenWithOptions(ctx, "https://example.com", &squatterID, &ShortenOptions{Domain: "go.example.com"})
+	if err != ErrNotDomainOwner {
+		t.Errorf("Expected ErrNotDomainOwner, got %v", err)
+	}
+	if _, err := domains.AddDomain(ctx, 3, "go.example.com"); err != repository.ErrDomainTaken {
+		t.Errorf("Expected ErrDomainTaken adding a verified hostname, got %v", err)
+	}
+}
+
+func TestDomainService_DeleteAndReclaim(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestShortener()
+	resolver := fakeTXTResolver{}
+	domains := NewDomainService(repository.NewMemoryDomainRepository(repo), resolver, testBaseURL)
+	service.SetDomainService(domains)
+	formerID, newID := 1, 2
+
+	// addVerifiedDomain adds go.example.com for a user and verifies it
+	addVerifiedDomain := func(userID int) *models.Domain {
+		domain, err := domains.AddDomain(ctx, userID, "go.example.com")
+		if err != nil {
+			t.Fatalf("Failed to add domain: %v", err)
+		}
+		resolver[domain.VerificationRecord()] = []string{domain.VerificationToken}
+		if _, err := domains.VerifyDomain(ctx, domain.ID, userID); err != nil {
+			t.Fatalf("Failed to verify domain: %v", err)
+		}
+		return domain
+	}
+
+	domain := addVerifiedDomain(formerID)
+	former, err := service.ShortenWithOptions(ctx, "https://example.com/former", &formerID, &ShortenOptions{Domain: "go.example.com", CustomSlug: "launch"})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL on domain: %v", err)
+	}
+	if err := domains.DeleteDomain(ctx, domain.ID, formerID); err != nil {
+		t.Fatalf("Failed to delete domain: %v", err)
+	}
+
+	// The former owner's links stay reachable on the default domain only
+	if _, err := service.GetByDomainSlug(ctx, "go.example.com", "launch"); err != repository.ErrNotFound {
+		t.Errorf("Expected ErrNotFound on the deleted domain, got %v", err)
+	}
+	if url, err := service.Get(ctx, former.ID); err != nil || url.Domain != "" {
+		t.Errorf("Expected the link on the default domain, got %+v (%v)", url, err)
+	}
+
+	// Whoever reclaims the hostname gets it without the former owner's slugs
+	addVerifiedDomain(newID)
+	if _, err := service.ShortenWithOptions(ctx, "https://example.com/new", &newID, &ShortenOptions{Domain: "go.example.com", CustomSlug: "launch"}); err != nil {
+		t.Fatalf("Failed to shorten URL with a slug of the former owner: %v", err)
+	}
+	url, err := service.GetByDomainSlug(ctx, "go.example.com", "launch")
+	if err != nil || url.OriginalURL != "https://example.com/new" {
+		t.Errorf("Expected the new owner's link, got %+v (%v)", url, err)
+	}
+}
+
+// countingDomainRepository counts the lookups of verified domains
+type countingDomainRepository struct {
+	repository.DomainRepository
+	lookups int
+}
+
+func (r *countingDomainRepository) GetVerifiedDomain(ctx context.Context, hostname string) (*models.Domain, error) {
+	r.lookups++
+	return r.DomainRepository.GetVerifiedDomain(ctx, hostname)
+}
+
+func TestDomainService_ResolveCache(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingDomainRepository{DomainRepository: repository.NewMemoryDomainRepository(nil)}
+	resolver := fakeTXTResolver{}
+	domains := NewDomainService(repo, resolver, "https://sho.rt")
+	userID := 1
+
+	// The default domain is never looked up
+	for _, host := range []string{"sho.rt", "SHO.RT:443", "localhost:8080"} {
+		if _, err := domains.Resolve(ctx, host); err != repository.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for %s, got %v", host, err)
+		}
+	}
+	if repo.lookups != 0 {
+		t.Errorf("Expected no lookups for the default domain, got %d", repo.lookups)
+	}
+
+	// Unknown hostnames are cached until the domain is verified
+	domain, err := domains.AddDomain(ctx, userID, "go.example.com")
+	if err != nil {
+		t.Fatalf("Failed to add domain: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := domains.Resolve(ctx, "go.example.com"); err != repository.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for an unverified domain, got %v", err)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Errorf("Expected 1 lookup, got %d", repo.lookups)
+	}
+
+	resolver[domain.VerificationRecord()] = []string{domain.VerificationToken}
+	if _, err := domains.VerifyDomain(ctx, domain.ID, userID); err != nil {
+		t.Fatalf("Failed to verify domain: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if resolved, err := domains.Resolve(ctx, "go.example.com"); err != nil || resolved.ID != domain.ID {
+			t.Errorf("Expected the verified domain, got %+v (%v)", resolved, err)
+		}
+	}
+	if repo.lookups != 2 {
+		t.Errorf("Expected 2 lookups, got %d", repo.lookups)
+	}
+
+	// Deleting the domain stops it resolving at once
+	if err := domains.DeleteDomain(ctx, domain.ID, userID); err != nil {
+		t.Fatalf("Failed to delete domain: %v", err)
+	}
+	if _, err := domains.Resolve(ctx, "go.example.com"); err != repository.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a deleted domain, got %v", err)
+	}
+}
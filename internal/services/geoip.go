@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GeoIPResolver looks up the country of an IP address
+type GeoIPResolver interface {
+	// Country returns the ISO 3166-1 alpha-2 country code for the IP, or "" if unknown
+	Country(ip net.IP) string
+}
+
+// geoIPRange maps an inclusive range of IP addresses to a country
+type geoIPRange struct {
+	start   net.IP
+	end     net.IP
+	country string
+}
+
+// GeoIPDatabase is an offline GeoIP database loaded from a CSV file of IP ranges.
+// Each row holds the first address, the last address and the country code, as in
+// the DB-IP and IP2Location "lite" country downloads. Addresses may be written as
+// dotted IPs or, for IPv4, as integers.
+type GeoIPDatabase struct {
+	ranges []geoIPRange
+}
+
+// LoadGeoIPDatabase loads a GeoIP database from a CSV file
+func LoadGeoIPDatabase(path string) (*GeoIPDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer file.Close()
+
+	return ParseGeoIPDatabase(file)
+}
+
+// ParseGeoIPDatabase parses a GeoIP database from CSV
+func ParseGeoIPDatabase(r io.Reader) (*GeoIPDatabase, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	db := &GeoIPDatabase{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("invalid GeoIP database line %d: expected at least 3 fields", line)
+		}
+
+		start, startErr := parseGeoIPAddress(record[0])
+		end, endErr := parseGeoIPAddress(record[1])
+		if startErr != nil || endErr != nil {
+			// Skip a header row
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("invalid GeoIP database line %d: bad address", line)
+		}
+
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if country == "" || country == "-" {
+			continue
+		}
+
+		db.ranges = append(db.ranges, geoIPRange{start: start, end: end, country: country})
+	}
+
+	// Sort by start address so lookups can binary search
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+
+	return db, nil
+}
+
+// Country returns the country code for the IP, or "" if it is not in the database
+func (db *GeoIPDatabase) Country(ip net.IP) string {
+	ip = ip.To16()
+	if ip == nil || db == nil {
+		return ""
+	}
+
+	// Find the last range starting at or before the IP
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip) > 0
+	}) - 1
+	if i < 0 {
+		return ""
+	}
+
+	if bytes.Compare(ip, db.ranges[i].end) <= 0 {
+		return db.ranges[i].country
+	}
+	return ""
+}
+
+// Len returns the number of ranges in the database
+func (db *GeoIPDatabase) Len() int {
+	return len(db.ranges)
+}
+
+// parseGeoIPAddress parses a dotted IP address or an IPv4 address written as an integer
+func parseGeoIPAddress(s string) (net.IP, error) {
+	s = strings.TrimSpace(s)
+
+	if ip := net.ParseIP(s); ip != nil {
+		return ip.To16(), nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return nil, errors.New("invalid IP address")
+	}
+
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, uint32(n))
+	return ip.To16(), nil
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// ErrHealthChecksDisabled is returned when checking a link while health checks are turned off
+var ErrHealthChecksDisabled = errors.New("link health checks are disabled")
+
+// HealthJobName is the scheduler name of the job checking link destinations
+const HealthJobName = "link-health"
+
+const (
+	// healthUserAgent identifies the checker to destination sites
+	healthUserAgent = "Mozilla/5.0 (compatible; RapidURL-LinkChecker/1.0)"
+	// healthHistoryLimit is how many checks are listed for a link
+	healthHistoryLimit = 50
+	// maxHealthBodyBytes is how much of a response body is read before the connection is closed
+	maxHealthBodyBytes = 64 << 10
+)
+
+// HealthService checks that link destinations still respond, records the
+// results and marks links broken after repeated failures, notifying their owners
+type HealthService struct {
+	shortenerService *ShortenerService
+	checks           repository.HealthCheckRepository
+	config           *config.HealthConfig
+	client           HTTPClient
+	notifier         Notifier
+	limiter          *hostLimiter
+}
+
+// NewHealthService creates a new health service. Its default client refuses
+// to connect to private networks, like the metadata fetcher's.
+func NewHealthService(shortenerService *ShortenerService, checks repository.HealthCheckRepository, config *config.HealthConfig) *HealthService {
+	return &HealthService{
+		shortenerService: shortenerService,
+		checks:           checks,
+		config:           config,
+		client:           newPublicClient(config.Timeout),
+		notifier:         LogNotifier{},
+		limiter:          newHostLimiter(config.HostInterval),
+	}
+}
+
+// SetHTTPClient replaces the client used to request destinations
+func (s *HealthService) SetHTTPClient(client HTTPClient) {
+	s.client = client
+}
+
+// SetNotifier replaces how owners are told about broken links, which are only logged by default
+func (s *HealthService) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// Run checks the links due a check, several at a time, and prunes old
+// results; it is registered as a scheduler job
+func (s *HealthService) Run(ctx context.Context) error {
+	if s.config.HistoryRetention > 0 {
+		if _, err := s.checks.DeleteHealthChecksBefore(ctx, time.Now().Add(-s.config.HistoryRetention)); err != nil {
+			return err
+		}
+	}
+
+	urls, err := s.shortenerService.repo.ListDueHealthChecks(ctx, time.Now().Add(-s.config.RecheckAfter), s.config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	workers := s.config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var firstErr error
+	queue := make(chan *models.URL)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range queue {
+				if err := s.CheckURL(ctx, url); err != nil && ctx.Err() == nil {
+					mutex.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, url := range urls {
+		select {
+		case queue <- url:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// CheckURL requests each of a link's destinations, records the results and
+// updates the link's health. A link is broken once FailureThreshold checks in
+// a row have failed, and healthy again after one succeeds; its owner is
+// notified of both.
+func (s *HealthService) CheckURL(ctx context.Context, url *models.URL) error {
+	var failed *models.HealthCheck
+	seen := make(map[string]bool)
+	for _, destination := range url.DestinationURLs() {
+		if seen[destination] {
+			continue
+		}
+		seen[destination] = true
+
+		check := s.checkDestination(ctx, url.ID, destination)
+		if err := ctx.Err(); err != nil {
+			// An abandoned check isn't a failure of the destination
+			return err
+		}
+		if err := s.checks.AddHealthCheck(ctx, check); err != nil {
+			return err
+		}
+		if !check.Healthy() && failed == nil {
+			failed = check
+		}
+	}
+
+	wasBroken := url.IsBroken()
+	status, failures := models.HealthStatusHealthy, 0
+	if failed != nil {
+		failures = url.HealthFailures + 1
+		if failures >= s.config.FailureThreshold {
+			status = models.HealthStatusBroken
+		}
+	}
+
+	checkedAt := time.Now()
+	if err := s.shortenerService.repo.UpdateHealth(ctx, url.ID, status, failures, checkedAt); err != nil {
+		return err
+	}
+	url.HealthStatus = status
+	url.HealthFailures = failures
+	url.HealthCheckedAt = &checkedAt
+
+	switch {
+	case url.IsBroken() && !wasBroken:
+		s.notify(ctx, url, "is broken", fmt.Sprintf(
+			"%s failed %d checks in a row. The latest check of %s failed with: %s.",
+			s.shortenerService.ShortURL(url), failures, failed.Destination, failed.Error))
+	case wasBroken && !url.IsBroken():
+		s.notify(ctx, url, "works again", fmt.Sprintf(
+			"%s passed its latest check, so it is no longer marked broken.",
+			s.shortenerService.ShortURL(url)))
+	}
+
+	return nil
+}
+
+// ListHealthChecks lists the latest checks of a link's destinations, newest first
+func (s *HealthService) ListHealthChecks(ctx context.Context, urlID string) ([]*models.HealthCheck, error) {
+	return s.checks.ListHealthChecks(ctx, urlID, healthHistoryLimit)
+}
+
+// checkDestination requests a destination with HEAD, falling back to GET for
+// servers that don't answer HEAD properly. Destinations refusing the checker
+// with 401, 403 or 429 are up, so they count as healthy.
+func (s *HealthService) checkDestination(ctx context.Context, urlID, destination string) *models.HealthCheck {
+	start := time.Now()
+	check := &models.HealthCheck{
+		URLID:       urlID,
+		Destination: destination,
+		CheckedAt:   start,
+	}
+
+	host := destination
+	if parsed, err := neturl.Parse(destination); err == nil {
+		host = strings.ToLower(parsed.Hostname())
+	}
+
+	status, err := s.request(ctx, http.MethodHead, host, destination)
+	if err != nil || !healthyStatus(status) {
+		status, err = s.request(ctx, http.MethodGet, host, destination)
+	}
+
+	check.StatusCode = status
+	check.DurationMs = int(time.Since(start) / time.Millisecond)
+	switch {
+	case err != nil:
+		check.Error = err.Error()
+	case !healthyStatus(status):
+		check.Error = fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return check
+}
+
+// request sends one request to a destination once its host's rate limit allows
+func (s *HealthService) request(ctx context.Context, method, host, destination string) (int, error) {
+	if err := s.limiter.wait(ctx, host); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, destination, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", healthUserAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Read a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxHealthBodyBytes))
+
+	return resp.StatusCode, nil
+}
+
+// notify tells a link's owner about a change of its health, logging failures
+func (s *HealthService) notify(ctx context.Context, url *models.URL, change, message string) {
+	if url.UserID == nil {
+		return
+	}
+
+	err := s.notifier.Notify(ctx, Notification{
+		UserID:  *url.UserID,
+		Subject: "Your link " + s.shortenerService.ShortURL(url) + " " + change,
+		Message: message + "\n\nManage the link at " + s.shortenerService.baseURL + "/dashboard/links/" + url.ID,
+	})
+	if err != nil {
+		log.Printf("Failed to notify user %d about link %s: %v", *url.UserID, url.ID, err)
+	}
+}
+
+// healthyStatus reports whether a response status shows the destination is up
+func healthyStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 200 && status < 400
+}
+
+// hostLimiter spaces out requests to each host
+type hostLimiter struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	next     map[string]time.Time
+}
+
+// newHostLimiter creates a limiter allowing one request per interval to each host
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a request to host is allowed, reserving its slot
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+
+	// Forget hosts whose slots have passed, so the map doesn't keep growing
+	for h, next := range l.next {
+		if next.Before(now) {
+			delete(l.next, h)
+		}
+	}
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetHealthService lets link owners see and run destination health checks
+func (s *ShortenerService) SetHealthService(health *HealthService) {
+	s.health = health
+}
+
+// ListHealthChecks lists the latest checks of a URL's destinations, newest first
+func (s *ShortenerService) ListHealthChecks(ctx context.Context, id string, userID int) ([]*models.HealthCheck, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.health == nil {
+		return []*models.HealthCheck{}, nil
+	}
+
+	return s.health.ListHealthChecks(ctx, url.ID)
+}
+
+// CheckHealth checks a URL's destinations now, so owners can confirm a fix
+func (s *ShortenerService) CheckHealth(ctx context.Context, id string, userID int) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.health == nil {
+		return nil, ErrHealthChecksDisabled
+	}
+
+	if err := s.health.CheckURL(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
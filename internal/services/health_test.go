@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// recordingNotifier keeps the notifications sent to it
+type recordingNotifier struct {
+	notifications []Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestLinkHealth(t *testing.T) {
+	ctx := context.Background()
+
+	gone := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		if gone {
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/members", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	service, repo := newTestShortener()
+	checks := repository.NewMemoryHealthCheckRepository()
+	healthService := NewHealthService(service, checks, &config.HealthConfig{
+		Concurrency:      2,
+		FailureThreshold: 2,
+		BatchSize:        10,
+		HistoryRetention: time.Hour,
+	})
+	healthService.SetHTTPClient(server.Client())
+	notifier := &recordingNotifier{}
+	healthService.SetNotifier(notifier)
+	service.SetHealthService(healthService)
+
+	userID := 1
+	links := make(map[string]*models.URL)
+	for _, path := range []string{"/ok", "/gone", "/no-head", "/members"} {
+		created, err := service.Shorten(ctx, server.URL+path, &userID, "", nil, "")
+		if err != nil {
+			t.Fatalf("Failed to shorten URL: %v", err)
+		}
+		links[path], _ = repo.GetByID(ctx, created.ID)
+	}
+
+	// A failed check doesn't mark the link broken until it happens again
+	if err := healthService.Run(ctx); err != nil {
+		t.Fatalf("Failed to run health checks: %v", err)
+	}
+	for _, path := range []string{"/ok", "/no-head", "/members"} {
+		if links[path].HealthStatus != models.HealthStatusHealthy {
+			t.Errorf("Expected %s to be healthy, got %q", path, links[path].HealthStatus)
+		}
+	}
+	if links["/gone"].HealthStatus != models.HealthStatusHealthy || links["/gone"].HealthFailures != 1 {
+		t.Errorf("Expected one failure without being broken, got %q with %d failures", links["/gone"].HealthStatus, links["/gone"].HealthFailures)
+	}
+	if len(notifier.notifications) != 0 {
+		t.Errorf("Expected no notifications yet, got %v", notifier.notifications)
+	}
+
+	if err := healthService.Run(ctx); err != nil {
+		t.Fatalf("Failed to run health checks: %v", err)
+	}
+	if !links["/gone"].IsBroken() {
+		t.Errorf("Expected the link to be broken after two failures")
+	}
+	if len(notifier.notifications) != 1 || notifier.notifications[0].UserID != userID || !strings.Contains(notifier.notifications[0].Message, "404 Not Found") {
+		t.Errorf("Expected the owner to be notified about the broken link, got %v", notifier.notifications)
+	}
+
+	history, err := service.ListHealthChecks(ctx, links["/gone"].ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to list health checks: %v", err)
+	}
+	if len(history) != 2 || history[0].StatusCode != http.StatusNotFound || history[0].Healthy() {
+		t.Errorf("Expected two failed checks, got %+v", history)
+	}
+	if _, err := service.ListHealthChecks(ctx, links["/gone"].ID, 2); err != ErrNotURLOwner {
+		t.Errorf("Expected ErrNotURLOwner, got %v", err)
+	}
+
+	// Checking on demand after a fix clears the broken status
+	gone = false
+	response, err := service.CheckHealth(ctx, links["/gone"].ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to check link: %v", err)
+	}
+	if response.HealthStatus != models.HealthStatusHealthy || response.HealthFailures != 0 {
+		t.Errorf("Expected the link to be healthy again, got %q", response.HealthStatus)
+	}
+	if len(notifier.notifications) != 2 || !strings.Contains(notifier.notifications[1].Subject, "works again") {
+		t.Errorf("Expected the owner to be told the link works again, got %v", notifier.notifications)
+	}
+
+	// Links checked recently aren't due again, and blocked links are skipped
+	links["/ok"].SetScreening(models.ScreeningStatusBlocked, "blocked by an admin")
+	due, err := repo.ListDueHealthChecks(ctx, time.Now().Add(-time.Hour), 10)
+	if err != nil || len(due) != 0 {
+		t.Errorf("Expected no links due a check, got %d, %v", len(due), err)
+	}
+	due, _ = repo.ListDueHealthChecks(ctx, time.Now(), 10)
+	if len(due) != 3 {
+		t.Errorf("Expected the three unblocked links to be due, got %d", len(due))
+	}
+
+	// Requests to the same host are spaced out
+	limiter := newHostLimiter(30 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.wait(ctx, "example.com")
+	}
+	limiter.wait(ctx, "example.org")
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("Expected three requests to one host to take about 60ms, took %v", elapsed)
+	}
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// MetadataJobName is the scheduler name of the job fetching missing destination metadata
+const MetadataJobName = "link-metadata"
+
+// ErrPrivateDestination is returned when fetching a destination would connect to a private network
+var ErrPrivateDestination = errors.New("destination is on a private network")
+
+// Limits on the pages fetched for metadata
+const (
+	// maxMetadataBodyBytes is how much of a page is read looking for its metadata
+	maxMetadataBodyBytes = 512 << 10
+	// maxFetchRedirects is how many redirects are followed to reach a destination
+	maxFetchRedirects = 5
+	// maxMetadataTitleLength and maxMetadataDescriptionLength truncate long page texts
+	maxMetadataTitleLength       = 300
+	maxMetadataDescriptionLength = 500
+	// metadataRetryAfter is how long a failed fetch waits before it is retried
+	metadataRetryAfter = 24 * time.Hour
+	// metadataQueueSize is how many new links can wait for their metadata; the
+	// job catches up with links dropped from a full queue
+	metadataQueueSize = 256
+	// metadataUserAgent identifies the fetcher to destination sites
+	metadataUserAgent = "Mozilla/5.0 (compatible; RapidURL-LinkPreview/1.0)"
+)
+
+// Patterns finding metadata in a page's head
+var (
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	headEndPattern   = regexp.MustCompile(`(?i)</head>`)
+	tagPattern       = regexp.MustCompile(`(?is)<(meta|link)\b([^>]*)>`)
+	attributePattern = regexp.MustCompile(`(?s)([a-zA-Z_:.-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	spacePattern     = regexp.MustCompile(`\s+`)
+)
+
+// HTTPClient sends HTTP requests. It is implemented by *http.Client.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// metadataTask is a link or bio link waiting for its metadata
+type metadataTask struct {
+	urlID     string
+	bioLinkID int
+}
+
+// MetadataService fetches the title, description, favicon and Open Graph
+// image of link destinations in the background
+type MetadataService struct {
+	repo      repository.Repository
+	bioRepo   repository.BioPageRepository
+	client    HTTPClient
+	batchSize int
+	queue     chan metadataTask
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewMetadataService creates a new metadata service. Its default client
+// refuses to connect to private networks, so link destinations can't be used
+// to reach internal services.
+func NewMetadataService(repo repository.Repository, bioRepo repository.BioPageRepository, timeout time.Duration, batchSize int) *MetadataService {
+	return &MetadataService{
+		repo:      repo,
+		bioRepo:   bioRepo,
+		client:    newPublicClient(timeout),
+		batchSize: batchSize,
+		queue:     make(chan metadataTask, metadataQueueSize),
+	}
+}
+
+// SetHTTPClient replaces the client used to fetch destinations
+func (s *MetadataService) SetHTTPClient(client HTTPClient) {
+	s.client = client
+}
+
+// QueueURL asks for the metadata of a link's destination to be fetched in the background
+func (s *MetadataService) QueueURL(id string) {
+	s.enqueue(metadataTask{urlID: id})
+}
+
+// QueueBioLink asks for the metadata of a bio link's URL to be fetched in the background
+func (s *MetadataService) QueueBioLink(id int) {
+	s.enqueue(metadataTask{bioLinkID: id})
+}
+
+// enqueue adds a task to the queue, dropping it if the queue is full
+func (s *MetadataService) enqueue(task metadataTask) {
+	select {
+	case s.queue <- task:
+	default:
+	}
+}
+
+// Start starts fetching queued metadata in the background
+func (s *MetadataService) Start() {
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case task := <-s.queue:
+				if err := s.process(ctx, task); err != nil && ctx.Err() == nil {
+					log.Printf("Failed to fetch link metadata: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops fetching queued metadata, abandoning the fetch in progress
+func (s *MetadataService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.cancel = nil
+	s.wg.Wait()
+}
+
+// process fetches the metadata of a queued link or bio link
+func (s *MetadataService) process(ctx context.Context, task metadataTask) error {
+	if task.urlID != "" {
+		url, err := s.repo.GetByID(ctx, task.urlID)
+		if err != nil {
+			return err
+		}
+		return s.RefreshURL(ctx, url)
+	}
+
+	bioLink, err := s.bioRepo.GetBioLinkByID(ctx, task.bioLinkID)
+	if err != nil {
+		return err
+	}
+	return s.RefreshBioLink(ctx, bioLink)
+}
+
+// Run fetches the metadata missing from links and bio links, retrying
+// failed fetches once a day; it is registered as a scheduler job
+func (s *MetadataService) Run(ctx context.Context) error {
+	retryBefore := time.Now().Add(-metadataRetryAfter)
+
+	urls, err := s.repo.ListStaleMetadata(ctx, retryBefore, s.batchSize)
+	if err != nil {
+		return err
+	}
+	for _, url := range urls {
+		if err := s.RefreshURL(ctx, url); err != nil {
+			return err
+		}
+	}
+
+	bioLinks, err := s.bioRepo.ListBioLinksWithStaleMetadata(ctx, retryBefore, s.batchSize)
+	if err != nil {
+		return err
+	}
+	for _, bioLink := range bioLinks {
+		if err := s.RefreshBioLink(ctx, bioLink); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RefreshURL fetches and stores the metadata of a link's destination. A
+// failed fetch is stored too, so it isn't retried until metadataRetryAfter.
+// The destinations of blocked links aren't visited.
+func (s *MetadataService) RefreshURL(ctx context.Context, url *models.URL) error {
+	var metadata *models.LinkMetadata
+	if url.IsBlocked() {
+		metadata = &models.LinkMetadata{URL: url.OriginalURL, FetchedAt: time.Now(), Error: "link is blocked"}
+	} else {
+		metadata = s.fetchOrRecord(ctx, url.OriginalURL)
+	}
+	if err := ctx.Err(); err != nil {
+		// An abandoned fetch isn't a failure of the destination
+		return err
+	}
+	if err := s.repo.UpdateMetadata(ctx, url.ID, metadata); err != nil {
+		return err
+	}
+
+	url.Metadata = metadata
+	return nil
+}
+
+// RefreshBioLink fetches and stores the metadata of a bio link's URL
+func (s *MetadataService) RefreshBioLink(ctx context.Context, bioLink *models.BioLink) error {
+	metadata := s.fetchOrRecord(ctx, bioLink.URL)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.bioRepo.UpdateBioLinkMetadata(ctx, bioLink.ID, metadata); err != nil {
+		return err
+	}
+
+	bioLink.Metadata = metadata
+	return nil
+}
+
+// fetchOrRecord fetches a destination's metadata, recording the error if the fetch fails
+func (s *MetadataService) fetchOrRecord(ctx context.Context, rawURL string) *models.LinkMetadata {
+	metadata, err := s.Fetch(ctx, rawURL)
+	if err != nil {
+		return &models.LinkMetadata{URL: rawURL, FetchedAt: time.Now(), Error: err.Error()}
+	}
+	return metadata
+}
+
+// Fetch retrieves a destination page and reads its title, description,
+// favicon and Open Graph image. Destinations that aren't HTML pages only get
+// the site's default favicon.
+func (s *MetadataService) Fetch(ctx context.Context, rawURL string) (*models.LinkMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", metadataUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("destination responded with %s", resp.Status)
+	}
+
+	// Relative URLs in the page are resolved against the page, after redirects
+	base := req.URL
+	if resp.Request != nil {
+		base = resp.Request.URL
+	}
+
+	metadata := &models.LinkMetadata{
+		URL:        rawURL,
+		FaviconURL: resolveMetadataURL(base, "/favicon.ico"),
+		FetchedAt:  time.Now(),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return metadata, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	parsePageMetadata(strings.ToValidUTF8(string(body), ""), base, metadata)
+
+	return metadata, nil
+}
+
+// parsePageMetadata reads the metadata from the head of an HTML page
+func parsePageMetadata(page string, base *neturl.URL, metadata *models.LinkMetadata) {
+	if end := headEndPattern.FindStringIndex(page); end != nil {
+		page = page[:end[0]]
+	}
+
+	// Meta tags by name or property, keeping the first of each
+	meta := make(map[string]string)
+	var icon, touchIcon string
+	for _, tag := range tagPattern.FindAllStringSubmatch(page, -1) {
+		attrs := parseAttributes(tag[2])
+		switch strings.ToLower(tag[1]) {
+		case "meta":
+			key := strings.ToLower(attrs["property"])
+			if key == "" {
+				key = strings.ToLower(attrs["name"])
+			}
+			if _, ok := meta[key]; key != "" && !ok && attrs["content"] != "" {
+				meta[key] = attrs["content"]
+			}
+		case "link":
+			rels := strings.Fields(strings.ToLower(attrs["rel"]))
+			if icon == "" && slices.Contains(rels, "icon") {
+				icon = attrs["href"]
+			}
+			if touchIcon == "" && slices.Contains(rels, "apple-touch-icon") {
+				touchIcon = attrs["href"]
+			}
+		}
+	}
+
+	var title string
+	if match := titlePattern.FindStringSubmatch(page); match != nil {
+		title = match[1]
+	}
+	metadata.Title = cleanMetadataText(firstNonEmpty(title, meta["og:title"], meta["twitter:title"]), maxMetadataTitleLength)
+	metadata.Description = cleanMetadataText(firstNonEmpty(meta["description"], meta["og:description"], meta["twitter:description"]), maxMetadataDescriptionLength)
+
+	if image := firstNonEmpty(meta["og:image"], meta["og:image:url"], meta["twitter:image"]); image != "" {
+		metadata.ImageURL = resolveMetadataURL(base, html.UnescapeString(image))
+	}
+	if favicon := firstNonEmpty(icon, touchIcon); favicon != "" {
+		if resolved := resolveMetadataURL(base, html.UnescapeString(favicon)); resolved != "" {
+			metadata.FaviconURL = resolved
+		}
+	}
+}
+
+// parseAttributes returns the attributes of an HTML tag by lowercase name
+func parseAttributes(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range attributePattern.FindAllStringSubmatch(tag, -1) {
+		name := strings.ToLower(match[1])
+		if _, ok := attrs[name]; !ok {
+			attrs[name] = match[2] + match[3] + match[4]
+		}
+	}
+	return attrs
+}
+
+// cleanMetadataText unescapes page text, collapses its whitespace and truncates it
+func cleanMetadataText(text string, maxLength int) string {
+	text = strings.TrimSpace(spacePattern.ReplaceAllString(html.UnescapeString(text), " "))
+	if utf8.RuneCountInString(text) > maxLength {
+		text = strings.TrimSpace(string([]rune(text)[:maxLength-1])) + "…"
+	}
+	return text
+}
+
+// resolveMetadataURL resolves a URL found in a page, returning "" unless it is an http or https URL
+func resolveMetadataURL(base *neturl.URL, ref string) string {
+	resolved, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") || resolved.Host == "" {
+		return ""
+	}
+	return resolved.String()
+}
+
+// firstNonEmpty returns the first of the values that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// newPublicClient creates an HTTP client that only connects to public
+// addresses, checked after DNS resolution so hostnames can't point it at
+// private networks
+func newPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return ErrPrivateDestination
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// SetMetadataService fetches the metadata of new link destinations in the background
+func (s *ShortenerService) SetMetadataService(metadata *MetadataService) {
+	s.metadata = metadata
+}
+
+// queueMetadata asks for the metadata of a link's destination to be fetched
+func (s *ShortenerService) queueMetadata(url *models.URL) {
+	if s.metadata != nil {
+		s.metadata.QueueURL(url.ID)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestLinkMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!DOCTYPE html><html><head>
+			<title>
+				Launch  notes &amp; more
+			</title>
+			<meta name="description" content="Everything new this spring">
+			<meta property="og:image" content="/images/cover.png">
+			<link rel="shortcut icon" href="https://cdn.example.com/icon.png">
+			</head><body><title>Not this one</title></body></html>`)
+	})
+	mux.HandleFunc("/og-only", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<head><meta property='og:title' content='Open Graph title'><meta property="og:description" content="From Open Graph"></head>`)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusFound)
+	})
+	mux.HandleFunc("/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		fmt.Fprint(w, "%PDF-1.7")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	service, repo := newTestShortener()
+	bioRepo := repository.NewMemoryBioPageRepository()
+	metadataService := NewMetadataService(repo, bioRepo, time.Second, 10)
+
+	// The default client refuses to fetch from private networks, such as the stub server
+	if _, err := metadataService.Fetch(ctx, server.URL+"/article"); !errors.Is(err, ErrPrivateDestination) {
+		t.Errorf("Expected ErrPrivateDestination, got %v", err)
+	}
+	metadataService.SetHTTPClient(server.Client())
+
+	metadata, err := metadataService.Fetch(ctx, server.URL+"/moved")
+	if err != nil {
+		t.Fatalf("Failed to fetch metadata: %v", err)
+	}
+	if metadata.Title != "Launch notes & more" || metadata.Description != "Everything new this spring" {
+		t.Errorf("Unexpected title and description: %q, %q", metadata.Title, metadata.Description)
+	}
+	if metadata.ImageURL != server.URL+"/images/cover.png" || metadata.FaviconURL != "https://cdn.example.com/icon.png" {
+		t.Errorf("Unexpected image and favicon: %q, %q", metadata.ImageURL, metadata.FaviconURL)
+	}
+	if metadata.URL != server.URL+"/moved" {
+		t.Errorf("Expected the metadata to be recorded for the requested URL, got %s", metadata.URL)
+	}
+
+	metadata, err = metadataService.Fetch(ctx, server.URL+"/og-only")
+	if err != nil {
+		t.Fatalf("Failed to fetch metadata: %v", err)
+	}
+	if metadata.Title != "Open Graph title" || metadata.Description != "From Open Graph" || metadata.FaviconURL != server.URL+"/favicon.ico" {
+		t.Errorf("Expected the Open Graph texts and the default favicon, got %+v", metadata)
+	}
+
+	metadata, err = metadataService.Fetch(ctx, server.URL+"/report.pdf")
+	if err != nil || metadata.Title != "" {
+		t.Errorf("Expected no title for a PDF, got %+v, %v", metadata, err)
+	}
+
+	// New links are queued and fetched in the background
+	service.SetMetadataService(metadataService)
+	metadataService.Start()
+	created, err := service.Shorten(ctx, server.URL+"/article", nil, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	metadataService.Stop()
+	url, err := service.GetIncludingExpired(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get URL: %v", err)
+	}
+	if url.Metadata == nil {
+		// The worker may have stopped before taking the link off the queue
+		if err := metadataService.Run(ctx); err != nil {
+			t.Fatalf("Failed to run metadata job: %v", err)
+		}
+	}
+	if !url.Metadata.Usable(url.OriginalURL) || url.Metadata.Title != "Launch notes & more" {
+		t.Errorf("Expected the link's metadata to be fetched, got %+v", url.Metadata)
+	}
+
+	// The job catches up with links and bio links missing metadata, recording failures
+	missing := models.NewURL("missing", server.URL+"/gone", nil, nil)
+	repo.Store(ctx, missing)
+	page := &models.BioPage{UserID: 1, ShortCode: "me", Title: "Me"}
+	bioRepo.CreateBioPage(ctx, page)
+	bioLink := models.NewBioLink(page.ID, "Notes", server.URL+"/og-only", 0)
+	bioRepo.CreateBioLink(ctx, bioLink)
+
+	if err := metadataService.Run(ctx); err != nil {
+		t.Fatalf("Failed to run metadata job: %v", err)
+	}
+	if missing.Metadata == nil || !strings.Contains(missing.Metadata.Error, "404") {
+		t.Errorf("Expected the failed fetch to be recorded, got %+v", missing.Metadata)
+	}
+	if missing.Metadata.NeedsFetch(missing.OriginalURL, time.Now().Add(-time.Hour)) {
+		t.Errorf("Expected a recent failure not to be retried yet")
+	}
+	if bioLink.Metadata == nil || bioLink.Metadata.Title != "Open Graph title" {
+		t.Errorf("Expected the bio link's metadata to be fetched, got %+v", bioLink.Metadata)
+	}
+
+	// Changing the destination makes the metadata stale
+	if !url.Metadata.NeedsFetch(server.URL+"/og-only", time.Now()) {
+		t.Errorf("Expected metadata for another destination to need a fetch")
+	}
+}
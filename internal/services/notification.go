@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Notification is a message to a user about one of their links
+type Notification struct {
+	// UserID is the user being notified
+	UserID int
+	// Subject summarizes the notification
+	Subject string
+	// Message is the body of the notification
+	Message string
+}
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// LogNotifier writes notifications to the log, for servers without a mail server
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	log.Printf("Notification for user %d: %s: %s", notification.UserID, notification.Subject, notification.Message)
+	return nil
+}
+
+// EmailNotifier emails notifications to the address of the user's account
+type EmailNotifier struct {
+	users    repository.UserRepository
+	addr     string
+	auth     smtp.Auth
+	from     string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates a notifier sending mail through the SMTP server at
+// host and port, authenticating if a username is given
+func NewEmailNotifier(users repository.UserRepository, host string, port int, username, password, from string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailNotifier{
+		users:    users,
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:     auth,
+		from:     from,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Notify emails the notification to the user
+func (n *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	user, err := n.users.GetByID(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return fmt.Errorf("user %d has no email address", user.ID)
+	}
+
+	// Header values can't contain line breaks, or they would add headers
+	subject := strings.Join(strings.Fields(notification.Subject), " ")
+	message := strings.Join([]string{
+		"From: " + n.from,
+		"To: " + user.Email,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		strings.ReplaceAll(notification.Message, "\n", "\r\n"),
+	}, "\r\n")
+
+	return n.sendMail(n.addr, n.auth, n.from, []string{user.Email}, []byte(message))
+}
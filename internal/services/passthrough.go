@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	neturl "net/url"
+	"path"
+	"strings"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// ErrInvalidQueryMode is returned when a query mode is not one of models.QueryModes
+var ErrInvalidQueryMode = errors.New("invalid query mode")
+
+// internalQueryParams are query parameters used by the shortener itself, which
+// are never passed on to the destination
+var internalQueryParams = []string{"verified"}
+
+// UpdateForwardingSettings changes how a URL passes query parameters and trailing path segments to its destination
+func (s *ShortenerService) UpdateForwardingSettings(ctx context.Context, id string, userID int, queryMode string, pathPassthrough bool) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	queryMode, err = validateQueryMode(queryMode)
+	if err != nil {
+		return nil, err
+	}
+
+	url.QueryMode = queryMode
+	url.PathPassthrough = pathPassthrough
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
+// ForwardRequest applies a URL's forwarding settings to the destination of a
+// visit: the trailing path after the short link is appended to the destination
+// path, and the incoming query parameters are merged according to the query mode
+func ForwardRequest(url *models.URL, destination, extraPath string, query neturl.Values) (string, error) {
+	queryMode := url.GetQueryMode()
+	forwardPath := url.PathPassthrough && extraPath != ""
+	if queryMode == models.QueryModeNone && !forwardPath {
+		return destination, nil
+	}
+
+	target, err := neturl.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	if forwardPath {
+		// Clean the path so ".." segments can't climb above the destination path
+		extraPath = strings.TrimPrefix(path.Clean("/"+extraPath), "/")
+		if extraPath != "" {
+			target.Path = strings.TrimSuffix(target.Path, "/") + "/" + extraPath
+			target.RawPath = ""
+		}
+	}
+
+	if queryMode != models.QueryModeNone && len(query) > 0 {
+		target.RawQuery = mergeQuery(target.Query(), query, queryMode).Encode()
+	}
+
+	return target.String(), nil
+}
+
+// mergeQuery merges the incoming query parameters into the destination's
+// parameters, resolving conflicts according to the query mode
+func mergeQuery(destination, incoming neturl.Values, queryMode string) neturl.Values {
+	for key, values := range incoming {
+		if isInternalQueryParam(key) {
+			continue
+		}
+
+		_, conflict := destination[key]
+		switch {
+		case !conflict:
+			destination[key] = values
+		case queryMode == models.QueryModeMergeOverride:
+			destination[key] = values
+		case queryMode == models.QueryModeMergeAppend:
+			destination[key] = append(destination[key], values...)
+		}
+	}
+	return destination
+}
+
+// isInternalQueryParam checks if a query parameter is used by the shortener itself
+func isInternalQueryParam(key string) bool {
+	for _, param := range internalQueryParams {
+		if key == param {
+			return true
+		}
+	}
+	return false
+}
+
+// validateQueryMode checks a query mode, defaulting an empty one to none
+func validateQueryMode(queryMode string) (string, error) {
+	if queryMode == "" {
+		return models.QueryModeNone, nil
+	}
+	if !containsString(models.QueryModes, queryMode) {
+		return "", ErrInvalidQueryMode
+	}
+	return queryMode, nil
+}
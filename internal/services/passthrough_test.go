@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestForwardRequest(t *testing.T) {
+	query := map[string][]string{"utm_source": {"newsletter"}, "ref": {"short"}, "verified": {"true"}}
+
+	tests := []struct {
+		name            string
+		queryMode       string
+		pathPassthrough bool
+		extraPath       string
+		expected        string
+	}{
+		{"drop", models.QueryModeNone, false, "", "https://docs.example.com/v2?ref=docs"},
+		{"merge keeping destination", models.QueryModeMergeKeep, false, "", "https://docs.example.com/v2?ref=docs&utm_source=newsletter"},
+		{"merge overriding destination", models.QueryModeMergeOverride, false, "", "https://docs.example.com/v2?ref=short&utm_source=newsletter"},
+		{"merge appending", models.QueryModeMergeAppend, false, "", "https://docs.example.com/v2?ref=docs&ref=short&utm_source=newsletter"},
+		{"path", models.QueryModeNone, true, "guide/install", "https://docs.example.com/v2/guide/install?ref=docs"},
+		{"path cannot climb", models.QueryModeNone, true, "../../admin", "https://docs.example.com/v2/admin?ref=docs"},
+	}
+
+	for _, tt := range tests {
+		url := models.NewURL("docs", "https://docs.example.com/v2?ref=docs", nil, nil)
+		url.QueryMode = tt.queryMode
+		url.PathPassthrough = tt.pathPassthrough
+
+		destination, err := ForwardRequest(url, url.OriginalURL, tt.extraPath, query)
+		if err != nil {
+			t.Fatalf("%s: failed to forward request: %v", tt.name, err)
+		}
+		if destination != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, destination)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	neturl "net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// ErrTitleTooLong is returned for link titles longer than maxTitleLength
+var ErrTitleTooLong = errors.New("title must be at most 200 characters")
+
+// maxTitleLength is the most characters a link title may have
+const maxTitleLength = 200
+
+// Preview verdicts summarize what screening found about a link's destinations
+const (
+	// PreviewVerdictSafe means the destinations were screened and nothing matched
+	PreviewVerdictSafe = "safe"
+	// PreviewVerdictReviewed means an admin reviewed the link
+	PreviewVerdictReviewed = "reviewed"
+	// PreviewVerdictCaution means a screening check matched the destinations
+	PreviewVerdictCaution = "caution"
+	// PreviewVerdictUnchecked means the destinations haven't been screened
+	PreviewVerdictUnchecked = "unchecked"
+)
+
+// LinkPreview describes where a link goes, for visitors to check before they follow it
+type LinkPreview struct {
+	// ID is the ID of the link
+	ID string
+	// ShortURL is the link's short URL
+	ShortURL string
+	// Title is the title given by the link's creator
+	Title string
+	// PageTitle, Description, FaviconURL and ImageURL describe the destination
+	// page, once its metadata has been fetched
+	PageTitle   string
+	Description string
+	FaviconURL  string
+	ImageURL    string
+	// Destination is where the link sends visitors, with the visit's path and query forwarded
+	Destination string
+	// Domain is the hostname of the destination
+	Domain string
+	// OtherDestinations lists the weighted destinations and targeting rule destinations some visitors are sent to instead
+	OtherDestinations []string
+	// Verdict is one of the PreviewVerdict constants
+	Verdict string
+	// Reason explains a caution verdict
+	Reason string
+}
+
+// UpdatePreviewSettings changes a URL's title and whether visitors see a preview page instead of being redirected
+func (s *ShortenerService) UpdatePreviewSettings(ctx context.Context, id string, userID int, title string, preview bool) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	title, err = validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	url.Title = title
+	url.Preview = preview
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
+// PreviewLink describes where a visit to a URL goes without choosing a
+// destination for it, so previewing doesn't count as a visit
+func (s *ShortenerService) PreviewLink(url *models.URL, extraPath string, query neturl.Values) (*LinkPreview, error) {
+	// Weighted destinations replace the original URL, and targeting rules
+	// send some visitors elsewhere
+	destinations := []string{url.OriginalURL}
+	if url.IsRotating() {
+		destinations = destinations[:0]
+		for _, d := range url.Destinations {
+			destinations = append(destinations, d.URL)
+		}
+	}
+	for _, rule := range url.TargetingRules {
+		destinations = append(destinations, rule.Destination)
+	}
+
+	destination, err := ForwardRequest(url, destinations[0], extraPath, query)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &LinkPreview{
+		ID:          url.ID,
+		ShortURL:    s.ShortURL(url),
+		Title:       url.Title,
+		Destination: destination,
+	}
+	if parsed, err := neturl.Parse(destination); err == nil {
+		preview.Domain = parsed.Hostname()
+	}
+	if url.Metadata.Usable(destinations[0]) {
+		preview.PageTitle = url.Metadata.Title
+		preview.Description = url.Metadata.Description
+		preview.FaviconURL = url.Metadata.FaviconURL
+		preview.ImageURL = url.Metadata.ImageURL
+	}
+
+	seen := map[string]bool{destinations[0]: true}
+	for _, other := range destinations[1:] {
+		if !seen[other] {
+			seen[other] = true
+			preview.OtherDestinations = append(preview.OtherDestinations, other)
+		}
+	}
+
+	switch {
+	case url.ShowsWarning():
+		preview.Verdict = PreviewVerdictCaution
+		preview.Reason = url.ScreeningReason
+	case url.ScreeningStatus == models.ScreeningStatusApproved:
+		preview.Verdict = PreviewVerdictReviewed
+	case url.ScreenedAt != nil:
+		preview.Verdict = PreviewVerdictSafe
+	default:
+		preview.Verdict = PreviewVerdictUnchecked
+	}
+
+	return preview, nil
+}
+
+// validateTitle trims a link title and checks its length
+func validateTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", ErrTitleTooLong
+	}
+	return title, nil
+}
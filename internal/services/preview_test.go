@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestLinkPreview(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestShortener()
+
+	userID := 1
+	created, err := service.ShortenWithOptions(ctx, "https://example.com/docs", &userID, &ShortenOptions{
+		Title:           "  Product docs ",
+		Preview:         true,
+		PathPassthrough: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if created.Title != "Product docs" || !created.Preview {
+		t.Errorf("Expected the trimmed title and preview mode, got %q and %v", created.Title, created.Preview)
+	}
+
+	if _, err := service.UpdatePreviewSettings(ctx, created.ID, userID, strings.Repeat("a", 201), false); err != ErrTitleTooLong {
+		t.Errorf("Expected ErrTitleTooLong, got %v", err)
+	}
+	if _, err := service.UpdatePreviewSettings(ctx, created.ID, 2, "", false); err != ErrNotURLOwner {
+		t.Errorf("Expected ErrNotURLOwner, got %v", err)
+	}
+
+	url, err := service.GetIncludingExpired(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get URL: %v", err)
+	}
+	url.TargetingRules = []models.TargetingRule{
+		{OS: "ios", Destination: "https://apps.apple.com/app"},
+		{OS: "android", Destination: "https://example.com/docs"},
+	}
+
+	// The preview forwards the visit's path but doesn't count it
+	preview, err := service.PreviewLink(url, "guide", nil)
+	if err != nil {
+		t.Fatalf("Failed to preview link: %v", err)
+	}
+	if preview.Destination != "https://example.com/docs/guide" || preview.Domain != "example.com" {
+		t.Errorf("Expected the forwarded destination on example.com, got %s on %s", preview.Destination, preview.Domain)
+	}
+	if len(preview.OtherDestinations) != 1 || preview.OtherDestinations[0] != "https://apps.apple.com/app" {
+		t.Errorf("Expected the other rule destination, got %v", preview.OtherDestinations)
+	}
+	if preview.Verdict != PreviewVerdictUnchecked {
+		t.Errorf("Expected an unchecked verdict without screening, got %s", preview.Verdict)
+	}
+	if url.Visits != 0 {
+		t.Errorf("Expected previewing not to count a visit, got %d", url.Visits)
+	}
+
+	// Weighted destinations replace the original URL
+	url.TargetingRules = nil
+	url.Destinations = []models.Destination{{ID: "a", URL: "https://a.example.com", Weight: 1}, {ID: "b", URL: "https://b.example.com", Weight: 1}}
+	preview, _ = service.PreviewLink(url, "", nil)
+	if preview.Domain != "a.example.com" || len(preview.OtherDestinations) != 1 {
+		t.Errorf("Expected the weighted destinations, got %s and %v", preview.Destination, preview.OtherDestinations)
+	}
+
+	verdicts := map[string]string{
+		models.ScreeningStatusClean:    PreviewVerdictSafe,
+		models.ScreeningStatusWarn:     PreviewVerdictCaution,
+		models.ScreeningStatusFlagged:  PreviewVerdictCaution,
+		models.ScreeningStatusApproved: PreviewVerdictReviewed,
+	}
+	for status, want := range verdicts {
+		url.SetScreening(status, "bit.ly is a URL shortener")
+		preview, _ := service.PreviewLink(url, "", nil)
+		if preview.Verdict != want {
+			t.Errorf("Expected status %q to give verdict %s, got %s", status, want, preview.Verdict)
+		}
+	}
+}
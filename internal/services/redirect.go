@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// Redirect setting errors
+var (
+	ErrInvalidRedirectStatus = errors.New("invalid redirect status: use 301, 302, 307 or 308")
+	ErrInvalidCachePolicy    = errors.New("invalid cache policy")
+)
+
+// RedirectPolicy decides the status code and caching headers of a redirect
+type RedirectPolicy struct {
+	// Status is the redirect status code
+	Status int
+	// CachePolicy is one of the models.CachePolicy constants other than the default
+	CachePolicy string
+	// CacheMaxAge is how long a cacheable redirect may be reused
+	CacheMaxAge time.Duration
+}
+
+// Validate checks that the policy can be used as the server-wide default
+func (p RedirectPolicy) Validate() error {
+	if p.Status == 0 || validateRedirectStatus(p.Status) != nil {
+		return ErrInvalidRedirectStatus
+	}
+	if p.CachePolicy == models.CachePolicyDefault || validateCachePolicy(p.CachePolicy) != nil {
+		return ErrInvalidCachePolicy
+	}
+	if p.CacheMaxAge < 0 {
+		return fmt.Errorf("invalid cache max age: %s", p.CacheMaxAge)
+	}
+	return nil
+}
+
+// ForURL applies a URL's own redirect settings on top of the policy
+func (p RedirectPolicy) ForURL(url *models.URL) RedirectPolicy {
+	if url.RedirectStatus != 0 {
+		p.Status = url.RedirectStatus
+	}
+	if url.CachePolicy != models.CachePolicyDefault {
+		p.CachePolicy = url.CachePolicy
+	}
+	return p
+}
+
+// IsPermanent reports whether the policy's status code is a permanent redirect
+func (p RedirectPolicy) IsPermanent() bool {
+	return p.Status == http.StatusMovedPermanently || p.Status == http.StatusPermanentRedirect
+}
+
+// CacheControl returns the Cache-Control header for a redirect. A shared
+// redirect is the same for every visitor; when it isn't, the response is
+// never cached publicly. Cached redirects expire no later than expiresAt.
+func (p RedirectPolicy) CacheControl(shared bool, expiresAt *time.Time) string {
+	policy := p.CachePolicy
+	if policy == models.CachePolicyAuto {
+		policy = models.CachePolicyNoStore
+		if p.IsPermanent() {
+			policy = models.CachePolicyPublic
+		}
+	}
+	if policy == models.CachePolicyPublic && !shared {
+		policy = models.CachePolicyPrivate
+	}
+	if policy == models.CachePolicyNoStore {
+		return "no-store"
+	}
+
+	maxAge := p.CacheMaxAge
+	if expiresAt != nil {
+		if untilExpiry := time.Until(*expiresAt); untilExpiry < maxAge {
+			maxAge = untilExpiry
+		}
+	}
+	if maxAge <= 0 {
+		return "no-store"
+	}
+
+	return fmt.Sprintf("%s, max-age=%d", policy, int(maxAge.Seconds()))
+}
+
+// UpdateRedirectSettings changes the status code and cache policy a URL redirects with.
+// A zero status or empty cache policy falls back to the server default.
+func (s *ShortenerService) UpdateRedirectSettings(ctx context.Context, id string, userID int, status int, cachePolicy string) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRedirectStatus(status); err != nil {
+		return nil, err
+	}
+	if err := validateCachePolicy(cachePolicy); err != nil {
+		return nil, err
+	}
+
+	url.RedirectStatus = status
+	url.CachePolicy = cachePolicy
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
+// validateRedirectStatus checks that a status is zero or one of models.RedirectStatuses
+func validateRedirectStatus(status int) error {
+	if status == 0 {
+		return nil
+	}
+	for _, valid := range models.RedirectStatuses {
+		if status == valid {
+			return nil
+		}
+	}
+	return ErrInvalidRedirectStatus
+}
+
+// validateCachePolicy checks that a cache policy is one of models.CachePolicies
+func validateCachePolicy(policy string) error {
+	if !containsString(models.CachePolicies, policy) {
+		return ErrInvalidCachePolicy
+	}
+	return nil
+}
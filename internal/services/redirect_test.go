@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	defaults := RedirectPolicy{Status: 302, CachePolicy: models.CachePolicyAuto, CacheMaxAge: time.Hour}
+	if err := defaults.Validate(); err != nil {
+		t.Fatalf("Expected valid default policy, got %v", err)
+	}
+	if err := (RedirectPolicy{Status: 303, CachePolicy: models.CachePolicyAuto}).Validate(); err != ErrInvalidRedirectStatus {
+		t.Errorf("Expected ErrInvalidRedirectStatus, got %v", err)
+	}
+
+	soon := time.Now().Add(10 * time.Minute)
+	tests := []struct {
+		name        string
+		status      int
+		cachePolicy string
+		shared      bool
+		expiresAt   *time.Time
+		expected    string
+	}{
+		{"temporary", 0, "", true, nil, "no-store"},
+		{"permanent", 301, "", true, nil, "public, max-age=3600"},
+		{"permanent per visitor", 308, "", false, nil, "private, max-age=3600"},
+		{"permanent but tracked", 301, models.CachePolicyNoStore, true, nil, "no-store"},
+		{"temporary but cached", 307, models.CachePolicyPublic, true, nil, "public, max-age=3600"},
+		{"expiring soon", 301, "", true, &soon, "public, max-age=599"},
+	}
+
+	for _, tt := range tests {
+		url := models.NewURL("abc", "https://example.com", nil, nil)
+		url.RedirectStatus = tt.status
+		url.CachePolicy = tt.cachePolicy
+
+		policy := defaults.ForURL(url)
+		if tt.status != 0 && policy.Status != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, policy.Status)
+		}
+		if cacheControl := policy.CacheControl(tt.shared, tt.expiresAt); cacheControl != tt.expected {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tt.name, tt.expected, cacheControl)
+		}
+	}
+}
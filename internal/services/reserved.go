@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Reserved slug errors
+var (
+	ErrSlugNotAllowed = errors.New("this custom slug is reserved")
+	ErrRouteSlug      = errors.New("this slug is used by the site's own pages and can't be freed")
+)
+
+// defaultReservedSlugs are reserved even before the routes are known
+var defaultReservedSlugs = []string{"admin", "login", "signup", "api"}
+
+// reservedRefreshInterval is how long the admin-reserved slugs are cached, so
+// changes made on another instance are picked up
+const reservedRefreshInterval = time.Minute
+
+// ReservedSlugs lists the reserved slugs by where they come from
+type ReservedSlugs struct {
+	// Routes are the first path segments of the site's own routes
+	Routes []string `json:"routes"`
+	// Custom are the slugs reserved by admins
+	Custom []string `json:"custom"`
+	// Blocklist is the number of words in the blocklist file
+	Blocklist int `json:"blocklist"`
+}
+
+// ReservedSlugService decides which slugs can't be used for links and bio
+// pages: those that would be shadowed by the site's own routes, those admins
+// have reserved, and those containing a word from the blocklist file.
+// Slugs are compared case-insensitively.
+type ReservedSlugService struct {
+	repo repository.ReservedSlugRepository
+
+	mu        sync.RWMutex
+	routes    map[string]bool
+	blocklist map[string]bool
+	custom    map[string]bool
+	loadedAt  time.Time
+}
+
+// NewReservedSlugService creates a new reserved slug service
+func NewReservedSlugService(repo repository.ReservedSlugRepository) *ReservedSlugService {
+	s := &ReservedSlugService{
+		repo:      repo,
+		routes:    make(map[string]bool),
+		blocklist: make(map[string]bool),
+	}
+	s.SetRouteSlugs(nil)
+	return s
+}
+
+// SetRouteSlugs reserves the first path segments of the site's routes, in
+// addition to the default reserved slugs
+func (s *ReservedSlugService) SetRouteSlugs(slugs []string) {
+	routes := make(map[string]bool, len(slugs)+len(defaultReservedSlugs))
+	for _, slug := range append(slugs, defaultReservedSlugs...) {
+		routes[strings.ToLower(slug)] = true
+	}
+
+	s.mu.Lock()
+	s.routes = routes
+	s.mu.Unlock()
+}
+
+// LoadBlocklist reads the blocklist file, one word per line. Blank lines and
+// lines starting with # are ignored.
+func (s *ReservedSlugService) LoadBlocklist(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	blocklist := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		blocklist[word] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.blocklist = blocklist
+	s.mu.Unlock()
+	return nil
+}
+
+// Check returns ErrSlugNotAllowed if a slug is reserved. A slug is blocked if
+// it is a blocklisted word or has one between its hyphens and underscores.
+func (s *ReservedSlugService) Check(ctx context.Context, slug string) error {
+	custom, err := s.customSlugs(ctx)
+	if err != nil {
+		return err
+	}
+
+	slug = strings.ToLower(slug)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.routes[slug] || custom[slug] || s.blocklist[slug] {
+		return ErrSlugNotAllowed
+	}
+	for _, word := range strings.FieldsFunc(slug, isSlugSeparator) {
+		if s.blocklist[word] {
+			return ErrSlugNotAllowed
+		}
+	}
+
+	return nil
+}
+
+// List lists the reserved slugs
+func (s *ReservedSlugService) List(ctx context.Context) (*ReservedSlugs, error) {
+	custom, err := s.repo.ListReservedSlugs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	routes := make([]string, 0, len(s.routes))
+	for slug := range s.routes {
+		routes = append(routes, slug)
+	}
+	sort.Strings(routes)
+
+	return &ReservedSlugs{
+		Routes:    routes,
+		Custom:    custom,
+		Blocklist: len(s.blocklist),
+	}, nil
+}
+
+// Add reserves a slug
+func (s *ReservedSlugService) Add(ctx context.Context, slug string) error {
+	if err := validateCustomSlug(slug); err != nil {
+		return err
+	}
+
+	if err := s.repo.AddReservedSlug(ctx, strings.ToLower(slug)); err != nil {
+		return err
+	}
+	return s.reload(ctx)
+}
+
+// Remove frees a slug reserved by an admin. Route slugs can't be freed.
+func (s *ReservedSlugService) Remove(ctx context.Context, slug string) error {
+	slug = strings.ToLower(slug)
+
+	s.mu.RLock()
+	isRoute := s.routes[slug]
+	s.mu.RUnlock()
+	if isRoute {
+		return ErrRouteSlug
+	}
+
+	if err := s.repo.DeleteReservedSlug(ctx, slug); err != nil {
+		return err
+	}
+	return s.reload(ctx)
+}
+
+// customSlugs returns the admin-reserved slugs, reloading them once they are stale
+func (s *ReservedSlugService) customSlugs(ctx context.Context) (map[string]bool, error) {
+	s.mu.RLock()
+	custom, loadedAt := s.custom, s.loadedAt
+	s.mu.RUnlock()
+
+	if custom != nil && time.Since(loadedAt) < reservedRefreshInterval {
+		return custom, nil
+	}
+
+	if err := s.reload(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.custom, nil
+}
+
+// reload loads the admin-reserved slugs from the repository
+func (s *ReservedSlugService) reload(ctx context.Context) error {
+	slugs, err := s.repo.ListReservedSlugs(ctx)
+	if err != nil {
+		return err
+	}
+
+	custom := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		custom[slug] = true
+	}
+
+	s.mu.Lock()
+	s.custom = custom
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// isSlugSeparator reports whether a character separates the words of a slug
+func isSlugSeparator(r rune) bool {
+	return r == '-' || r == '_'
+}
+
+// checkReservedSlug checks a slug against the reserved slugs, or only the
+// default ones when no reserved slug service is set
+func checkReservedSlug(ctx context.Context, reserved *ReservedSlugService, slug string) error {
+	if reserved == nil {
+		if containsString(defaultReservedSlugs, strings.ToLower(slug)) {
+			return ErrSlugNotAllowed
+		}
+		return nil
+	}
+
+	return reserved.Check(ctx, slug)
+}
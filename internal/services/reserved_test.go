@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestReservedSlugs(t *testing.T) {
+	ctx := context.Background()
+	reserved := NewReservedSlugService(repository.NewMemoryReservedSlugRepository())
+	reserved.SetRouteSlugs([]string{"dashboard", "b"})
+
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(blocklist, []byte("# Brand names\nacme\n\nrival\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+	if err := reserved.LoadBlocklist(blocklist); err != nil {
+		t.Fatalf("Failed to load blocklist: %v", err)
+	}
+
+	service, _ := newTestShortener()
+	service.SetReservedSlugService(reserved)
+	bioPages := NewBioPageService(repository.NewMemoryBioPageRepository(), "http://localhost:8080")
+	bioPages.SetReservedSlugService(reserved)
+
+	for _, slug := range []string{"dashboard", "Dashboard", "API", "rival", "Rival_Deals", "summer-acme-sale"} {
+		if _, err := service.Shorten(ctx, "https://example.com", nil, slug, nil, ""); err != ErrSlugNotAllowed {
+			t.Errorf("Expected %q to be reserved, got %v", slug, err)
+		}
+	}
+	for _, slug := range []string{"dashboards", "acmesale"} {
+		if _, err := service.Shorten(ctx, "https://example.com", nil, slug, nil, ""); err != nil {
+			t.Errorf("Expected %q to be allowed, got %v", slug, err)
+		}
+	}
+	if _, err := bioPages.CreateBioPage(ctx, 1, "DASHBOARD", "Me", ""); err != ErrSlugNotAllowed {
+		t.Errorf("Expected the bio page short code to be reserved, got %v", err)
+	}
+
+	// Admins can reserve and free slugs, but not those of routes
+	if err := reserved.Add(ctx, "Launch"); err != nil {
+		t.Fatalf("Failed to reserve slug: %v", err)
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "launch", nil, ""); err != ErrSlugNotAllowed {
+		t.Errorf("Expected launch to be reserved, got %v", err)
+	}
+	if err := reserved.Remove(ctx, "launch"); err != nil {
+		t.Fatalf("Failed to free slug: %v", err)
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "launch", nil, ""); err != nil {
+		t.Errorf("Expected launch to be allowed again, got %v", err)
+	}
+	if err := reserved.Remove(ctx, "dashboard"); err != ErrRouteSlug {
+		t.Errorf("Expected ErrRouteSlug, got %v", err)
+	}
+}
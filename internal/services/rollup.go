@@ -0,0 +1,520 @@
+package services
+
+import (
+	"context"
+	"errors"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// RollupJobName is the scheduler name of the analytics rollup job
+const RollupJobName = "analytics-rollup"
+
+const (
+	// rollupSettleDelay is how old a click must be before it is rolled up, so
+	// clicks still being recorded with lower IDs aren't skipped
+	rollupSettleDelay = time.Minute
+	// minRawRetention is the shortest time recorded clicks are kept, so the
+	// visitors of the current day can still be told apart
+	minRawRetention = 2 * 24 * time.Hour
+	// maxReferrerLength is the longest referrer host kept in breakdowns
+	maxReferrerLength = 255
+	// maxBreakdownEntries is how many values of each dimension a report lists
+	maxBreakdownEntries = 10
+)
+
+// Report periods in days, by granularity
+const (
+	defaultDailyReportDays  = 30
+	maxDailyReportDays      = 90
+	defaultHourlyReportDays = 2
+	maxHourlyReportDays     = 7
+)
+
+var (
+	ErrInvalidGranularity = errors.New("granularity must be hour or day")
+	ErrRollupsDisabled    = errors.New("analytics reports are turned off")
+)
+
+// rollupGranularities are the granularities every click is rolled up by
+var rollupGranularities = []string{models.RollupHour, models.RollupDay}
+
+// RollupStatus describes the analytics rollup job
+type RollupStatus struct {
+	Enabled       bool          `json:"enabled"`
+	LastClickID   int64         `json:"last_click_id"`
+	RawRetention  time.Duration `json:"raw_retention"`
+	LastRunAt     time.Time     `json:"last_run_at,omitempty"`
+	LastRolledUp  int           `json:"last_rolled_up"`
+	LastPurged    int           `json:"last_purged"`
+	TotalRolledUp int           `json:"total_rolled_up"`
+	LastError     string        `json:"last_error,omitempty"`
+}
+
+// RollupService counts recorded clicks by hour and day into rollups, so
+// reports don't have to scan the clicks, and deletes the clicks once they
+// are older than the raw retention period
+type RollupService struct {
+	clicks repository.ClickRepository
+	repo   repository.RollupRepository
+	config *config.AnalyticsConfig
+	// running keeps runs and backfills from rolling up the same clicks at once
+	running sync.Mutex
+	status  RollupStatus
+	mutex   sync.RWMutex
+}
+
+// NewRollupService creates a new rollup service
+func NewRollupService(clicks repository.ClickRepository, repo repository.RollupRepository, config *config.AnalyticsConfig) *RollupService {
+	s := &RollupService{
+		clicks: clicks,
+		repo:   repo,
+		config: config,
+	}
+	s.status = RollupStatus{
+		Enabled:      config.Rollups,
+		RawRetention: s.rawRetention(),
+	}
+	return s
+}
+
+// Run rolls up the clicks recorded since the last run and deletes the rolled
+// up clicks past the retention period; it is registered as a scheduler job
+func (s *RollupService) Run(ctx context.Context) error {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	rolledUp, err := s.rollUpNew(ctx)
+	purged := 0
+	if err == nil {
+		purged, err = s.purgeRaw(ctx)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.status.LastRunAt = time.Now()
+	s.status.LastRolledUp = rolledUp
+	s.status.LastPurged = purged
+	s.status.TotalRolledUp += rolledUp
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+
+	return err
+}
+
+// Backfill rebuilds the rollups of the days since the given time from the
+// recorded clicks, for example for clicks recorded before rollups were
+// turned on. Days whose clicks may already have been deleted are left alone.
+// It returns how many clicks were rolled up.
+func (s *RollupService) Backfill(ctx context.Context, since time.Time) (int, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	since = visitorDay(since)
+	if retention := s.rawRetention(); retention > 0 {
+		earliest := visitorDay(time.Now().Add(-retention)).AddDate(0, 0, 1)
+		if since.Before(earliest) {
+			since = earliest
+		}
+	}
+
+	watermark, err := s.repo.GetRollupWatermark(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.DeleteRollupsSince(ctx, since); err != nil {
+		return 0, err
+	}
+
+	// Clicks after the watermark are left for the next run
+	batchSize := s.batchSize()
+	total := 0
+	afterID := int64(0)
+	for {
+		clicks, err := s.clicks.ListClicksAfter(ctx, afterID, since, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		n := 0
+		for n < len(clicks) && clicks[n].ID <= watermark {
+			n++
+		}
+		if n == 0 {
+			return total, nil
+		}
+		if err := s.rollUp(ctx, clicks[:n], watermark); err != nil {
+			return total, err
+		}
+		total += n
+
+		if n < len(clicks) || len(clicks) < batchSize {
+			return total, nil
+		}
+		afterID = clicks[n-1].ID
+	}
+}
+
+// Enabled reports whether clicks are rolled up and reports served from the rollups
+func (s *RollupService) Enabled() bool {
+	return s.config.Rollups
+}
+
+// DeleteURL deletes a link's rollups once its clicks are deleted. It waits
+// for a run in progress, which may have rolled up the clicks before they
+// were deleted.
+func (s *RollupService) DeleteURL(ctx context.Context, urlID string) error {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	return s.repo.DeleteRollupsByURL(ctx, urlID)
+}
+
+// Report sums a target's rollups of a granularity over the latest days
+func (s *RollupService) Report(ctx context.Context, target models.RollupTarget, granularity string, days int) (*models.AnalyticsReport, error) {
+	if granularity == "" {
+		granularity = models.RollupDay
+	}
+
+	defaultDays, maxDays := defaultDailyReportDays, maxDailyReportDays
+	switch granularity {
+	case models.RollupDay:
+	case models.RollupHour:
+		defaultDays, maxDays = defaultHourlyReportDays, maxHourlyReportDays
+	default:
+		return nil, ErrInvalidGranularity
+	}
+	if days <= 0 {
+		days = defaultDays
+	}
+	if days > maxDays {
+		days = maxDays
+	}
+
+	now := time.Now()
+	since := visitorDay(now).AddDate(0, 0, 1-days)
+
+	rollups, err := s.repo.ListRollups(ctx, target, granularity, since)
+	if err != nil {
+		return nil, err
+	}
+	byBucket := make(map[int64]*models.ClickRollup, len(rollups))
+	for _, rollup := range rollups {
+		byBucket[rollup.Bucket.Unix()] = rollup
+	}
+
+	report := &models.AnalyticsReport{
+		Granularity: granularity,
+		Since:       since,
+		Buckets:     []*models.AnalyticsBucket{},
+		Referrers:   []*models.BreakdownEntry{},
+		Countries:   []*models.BreakdownEntry{},
+		Devices:     []*models.BreakdownEntry{},
+	}
+
+	// Buckets without clicks have no rollup, so they are filled in with zeros
+	for bucket := rollupBucket(now, granularity); !bucket.Before(since); bucket = previousBucket(bucket, granularity) {
+		entry := &models.AnalyticsBucket{Start: bucket}
+		if rollup, ok := byBucket[bucket.Unix()]; ok {
+			entry.Clicks = rollup.Clicks
+			entry.BotClicks = rollup.BotClicks
+			entry.Visitors = rollup.Visitors
+		}
+		report.Clicks += entry.Clicks
+		report.BotClicks += entry.BotClicks
+		report.Visitors += entry.Visitors
+		report.Buckets = append(report.Buckets, entry)
+	}
+
+	breakdowns, err := s.repo.SumBreakdowns(ctx, target, granularity, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, breakdown := range breakdowns {
+		var entries *[]*models.BreakdownEntry
+		switch breakdown.Dimension {
+		case models.BreakdownReferrer:
+			entries = &report.Referrers
+		case models.BreakdownCountry:
+			entries = &report.Countries
+		case models.BreakdownDevice:
+			entries = &report.Devices
+		default:
+			continue
+		}
+		if len(*entries) < maxBreakdownEntries {
+			*entries = append(*entries, &models.BreakdownEntry{Value: breakdown.Value, Clicks: breakdown.Clicks})
+		}
+	}
+
+	return report, nil
+}
+
+// Status returns the status of the rollup job
+func (s *RollupService) Status() RollupStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.status
+}
+
+// dailyVisits lists a target's visits and unique visitors of each day since
+// the given time from its daily rollups, oldest first
+func (s *RollupService) dailyVisits(ctx context.Context, target models.RollupTarget, since time.Time) ([]*models.DailyVisits, error) {
+	rollups, err := s.repo.ListRollups(ctx, target, models.RollupDay, since)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]*models.DailyVisits, 0, len(rollups))
+	for _, rollup := range rollups {
+		days = append(days, &models.DailyVisits{
+			Date:     rollup.Bucket.Format("2006-01-02"),
+			Visits:   rollup.Clicks,
+			Visitors: rollup.Visitors,
+		})
+	}
+	return days, nil
+}
+
+// totalVisitors sums the unique visitors of all of a target's daily rollups
+func (s *RollupService) totalVisitors(ctx context.Context, target models.RollupTarget) (int, error) {
+	rollups, err := s.repo.ListRollups(ctx, target, models.RollupDay, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, rollup := range rollups {
+		total += rollup.Visitors
+	}
+	return total, nil
+}
+
+// rollUpNew rolls up the settled clicks recorded after the watermark, one
+// batch at a time, and returns how many were rolled up
+func (s *RollupService) rollUpNew(ctx context.Context) (int, error) {
+	watermark, err := s.repo.GetRollupWatermark(ctx)
+	if err != nil {
+		return 0, err
+	}
+	s.setLastClickID(watermark)
+
+	settled := time.Now().Add(-rollupSettleDelay)
+	batchSize := s.batchSize()
+	total := 0
+	for {
+		clicks, err := s.clicks.ListClicksAfter(ctx, watermark, time.Time{}, batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		n := 0
+		for n < len(clicks) && clicks[n].CreatedAt.Before(settled) {
+			n++
+		}
+		if n == 0 {
+			return total, nil
+		}
+		watermark = clicks[n-1].ID
+		if err := s.rollUp(ctx, clicks[:n], watermark); err != nil {
+			return total, err
+		}
+		total += n
+		s.setLastClickID(watermark)
+
+		if n < len(clicks) || len(clicks) < batchSize {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// purgeRaw deletes the rolled up clicks older than the raw retention period
+func (s *RollupService) purgeRaw(ctx context.Context) (int, error) {
+	retention := s.rawRetention()
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	watermark, err := s.repo.GetRollupWatermark(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.clicks.DeleteClicksBefore(ctx, time.Now().Add(-retention), watermark)
+}
+
+// rollUp counts a batch of clicks, in ID order, into hourly and daily
+// rollups and breakdowns, and raises the watermark to lastClickID. A
+// visitor counts once in each bucket of a target, so clicks earlier in the
+// bucket are checked for the batch's visitors.
+func (s *RollupService) rollUp(ctx context.Context, clicks []*models.Click, lastClickID int64) error {
+	type bucketKey struct {
+		target      models.RollupTarget
+		granularity string
+		bucket      int64
+	}
+	type visitorKey struct {
+		bucketKey
+		hash string
+	}
+	type breakdownKey struct {
+		bucketKey
+		dimension string
+		value     string
+	}
+
+	earliest := clicks[0].CreatedAt
+	hashes := make(map[string]bool)
+	for _, click := range clicks {
+		if click.CreatedAt.Before(earliest) {
+			earliest = click.CreatedAt
+		}
+		if !click.Bot && click.VisitorHash != "" {
+			hashes[click.VisitorHash] = true
+		}
+	}
+
+	seen := make(map[visitorKey]bool)
+	if len(hashes) > 0 {
+		visitorHashes := make([]string, 0, len(hashes))
+		for hash := range hashes {
+			visitorHashes = append(visitorHashes, hash)
+		}
+
+		earlier, err := s.clicks.ListVisitorClicks(ctx, visitorHashes, visitorDay(earliest), clicks[0].ID)
+		if err != nil {
+			return err
+		}
+		for _, click := range earlier {
+			for _, granularity := range rollupGranularities {
+				key := bucketKey{click.Target(), granularity, rollupBucket(click.CreatedAt, granularity).Unix()}
+				seen[visitorKey{key, click.VisitorHash}] = true
+			}
+		}
+	}
+
+	rollups := make(map[bucketKey]*models.ClickRollup)
+	breakdowns := make(map[breakdownKey]*models.ClickBreakdown)
+	for _, click := range clicks {
+		target := click.Target()
+		for _, granularity := range rollupGranularities {
+			bucket := rollupBucket(click.CreatedAt, granularity)
+			key := bucketKey{target, granularity, bucket.Unix()}
+
+			rollup, ok := rollups[key]
+			if !ok {
+				rollup = &models.ClickRollup{RollupTarget: target, Granularity: granularity, Bucket: bucket}
+				rollups[key] = rollup
+			}
+			if click.Bot {
+				rollup.BotClicks++
+				continue
+			}
+
+			rollup.Clicks++
+			if visitor := (visitorKey{key, click.VisitorHash}); click.VisitorHash != "" && !seen[visitor] {
+				seen[visitor] = true
+				rollup.Visitors++
+			}
+
+			for _, dimension := range []struct{ name, value string }{
+				{models.BreakdownReferrer, referrerHost(click.Referrer)},
+				{models.BreakdownCountry, click.Country},
+				{models.BreakdownDevice, click.Device},
+			} {
+				bkey := breakdownKey{key, dimension.name, dimension.value}
+				breakdown, ok := breakdowns[bkey]
+				if !ok {
+					breakdown = &models.ClickBreakdown{
+						RollupTarget: target,
+						Granularity:  granularity,
+						Bucket:       bucket,
+						Dimension:    dimension.name,
+						Value:        dimension.value,
+					}
+					breakdowns[bkey] = breakdown
+				}
+				breakdown.Clicks++
+			}
+		}
+	}
+
+	rollupList := make([]*models.ClickRollup, 0, len(rollups))
+	for _, rollup := range rollups {
+		rollupList = append(rollupList, rollup)
+	}
+	breakdownList := make([]*models.ClickBreakdown, 0, len(breakdowns))
+	for _, breakdown := range breakdowns {
+		breakdownList = append(breakdownList, breakdown)
+	}
+	return s.repo.AddRollups(ctx, rollupList, breakdownList, lastClickID)
+}
+
+// setLastClickID records the watermark in the job status
+func (s *RollupService) setLastClickID(id int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status.LastClickID = id
+}
+
+// rawRetention returns how long rolled up clicks are kept, zero for forever
+func (s *RollupService) rawRetention() time.Duration {
+	retention := s.config.RawRetention
+	if retention > 0 && retention < minRawRetention {
+		retention = minRawRetention
+	}
+	return retention
+}
+
+// batchSize returns how many clicks are rolled up at a time
+func (s *RollupService) batchSize() int {
+	if s.config.RollupBatchSize <= 0 {
+		return 5000
+	}
+	return s.config.RollupBatchSize
+}
+
+// rollupBucket returns the start of the hour or day of the given time, in UTC
+func rollupBucket(at time.Time, granularity string) time.Time {
+	if granularity == models.RollupHour {
+		return at.UTC().Truncate(time.Hour)
+	}
+	return visitorDay(at)
+}
+
+// previousBucket returns the start of the hour or day before a bucket
+func previousBucket(bucket time.Time, granularity string) time.Time {
+	if granularity == models.RollupHour {
+		return bucket.Add(-time.Hour)
+	}
+	return bucket.AddDate(0, 0, -1)
+}
+
+// referrerHost returns the host of a referrer without "www.", empty for
+// direct visits and referrers that aren't URLs
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	parsed, err := neturl.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	if len(host) > maxReferrerLength {
+		host = host[:maxReferrerLength]
+	}
+	return host
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestAnalyticsRollups(t *testing.T) {
+	ctx := context.Background()
+	clickRepo := repository.NewMemoryClickRepository()
+	cfg := &config.AnalyticsConfig{Rollups: true, RollupBatchSize: 2, RawRetention: 3 * 24 * time.Hour}
+	rollups := NewRollupService(clickRepo, repository.NewMemoryRollupRepository(), cfg)
+	analytics := NewAnalyticsService(clickRepo, NewBotFilter(true), false)
+	analytics.SetRollupService(rollups)
+
+	// Visitors are tagged with their device even without geolocation
+	identified := models.NewClick("", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)")
+	if err := analytics.IdentifyVisitor(ctx, identified, "203.0.113.7"); err != nil {
+		t.Fatalf("Failed to identify visitor: %v", err)
+	}
+	if device, _, _ := ParseUserAgent(identified.UserAgent); identified.Device != device || device == "" {
+		t.Errorf("Expected the click's device to be %q, got %q", device, identified.Device)
+	}
+
+	yesterday := visitorDay(time.Now()).AddDate(0, 0, -1)
+	noon := yesterday.Add(12 * time.Hour)
+	for _, click := range []*models.Click{
+		{URLID: "r", VisitorHash: "a", Referrer: "https://www.Example.com/post", Country: "US", Device: "desktop", CreatedAt: noon},
+		{URLID: "r", VisitorHash: "a", CreatedAt: noon.Add(10 * time.Minute)},
+		{URLID: "r", VisitorHash: "b", CreatedAt: noon.Add(90 * time.Minute)},
+		{URLID: "r", VisitorHash: "c", Bot: true, CreatedAt: noon},
+		{URLID: "r", VisitorHash: "a", CreatedAt: noon.Add(2 * time.Hour)},
+		{URLID: "r", VisitorHash: "d", CreatedAt: yesterday.AddDate(0, 0, -4)},
+		// Too recent to roll up yet
+		{URLID: "r", VisitorHash: "e", CreatedAt: time.Now()},
+	} {
+		if err := analytics.RecordClick(ctx, click); err != nil {
+			t.Fatalf("Failed to record click: %v", err)
+		}
+	}
+
+	// Batches of two make visitors repeat across batches
+	if err := rollups.Run(ctx); err != nil {
+		t.Fatalf("Failed to roll up clicks: %v", err)
+	}
+	status := rollups.Status()
+	if status.LastRolledUp != 6 || status.LastClickID != 6 || status.LastPurged != 1 || status.LastError != "" {
+		t.Errorf("Expected 6 clicks rolled up and 1 purged, got %+v", status)
+	}
+	if err := rollups.Run(ctx); err != nil || rollups.Status().LastRolledUp != 0 {
+		t.Errorf("Expected nothing left to roll up, got %+v (%v)", rollups.Status(), err)
+	}
+
+	checkReport := func(when string) {
+		t.Helper()
+		report, err := analytics.Report(ctx, models.RollupTarget{URLID: "r"}, "", 7)
+		if err != nil {
+			t.Fatalf("Failed to build report: %v", err)
+		}
+		if len(report.Buckets) != 7 || !report.Buckets[0].Start.Equal(visitorDay(time.Now())) {
+			t.Fatalf("Expected 7 days starting today %s, got %+v", when, report.Buckets)
+		}
+		if day := report.Buckets[1]; day.Clicks != 4 || day.BotClicks != 1 || day.Visitors != 2 {
+			t.Errorf("Expected yesterday's 4 clicks from 2 visitors and 1 bot %s, got %+v", when, day)
+		}
+		if old := report.Buckets[5]; old.Clicks != 1 {
+			t.Errorf("Expected the purged day's rollup to be kept %s, got %+v", when, old)
+		}
+		if report.Clicks != 5 || report.Buckets[0].Clicks != 0 {
+			t.Errorf("Expected 5 clicks in total and none today %s, got %+v", when, report)
+		}
+		if len(report.Referrers) != 2 || report.Referrers[0].Value != "" || report.Referrers[0].Clicks != 4 ||
+			report.Referrers[1].Value != "example.com" || report.Referrers[1].Clicks != 1 {
+			t.Errorf("Expected 4 direct clicks and 1 from example.com %s, got %+v", when, report.Referrers)
+		}
+		if len(report.Countries) != 2 || len(report.Devices) != 2 {
+			t.Errorf("Expected countries and devices to be broken down %s, got %+v and %+v", when, report.Countries, report.Devices)
+		}
+	}
+	checkReport("after rolling up")
+
+	// Hourly rollups count a visitor again in a later hour
+	hourly, err := rollups.Report(ctx, models.RollupTarget{URLID: "r"}, models.RollupHour, 2)
+	if err != nil {
+		t.Fatalf("Failed to build hourly report: %v", err)
+	}
+	visitorsByHour := make(map[int]int)
+	for _, bucket := range hourly.Buckets {
+		if bucket.Start.Before(noon) || bucket.Start.After(noon.Add(2*time.Hour)) {
+			continue
+		}
+		visitorsByHour[bucket.Start.Hour()] = bucket.Visitors
+	}
+	if visitorsByHour[12] != 1 || visitorsByHour[13] != 1 || visitorsByHour[14] != 1 {
+		t.Errorf("Expected one visitor in each hour, got %v", visitorsByHour)
+	}
+	if _, err := rollups.Report(ctx, models.RollupTarget{URLID: "r"}, "week", 0); !errors.Is(err, ErrInvalidGranularity) {
+		t.Errorf("Expected an invalid granularity error, got %v", err)
+	}
+
+	// Visitor stats are read from the daily rollups
+	stats, err := analytics.URLVisitorStats(ctx, &models.URL{ID: "r", Visits: 6}, 7)
+	if err != nil {
+		t.Fatalf("Failed to count visitors: %v", err)
+	}
+	if stats.Visitors != 3 || stats.Days[1].Visits != 4 || stats.Days[1].Visitors != 2 {
+		t.Errorf("Expected yesterday's 4 visits from 2 visitors and 3 in total, got %+v", stats)
+	}
+
+	// Backfilling rebuilds the same counts, leaving the purged day alone
+	rolledUp, err := rollups.Backfill(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to backfill rollups: %v", err)
+	}
+	if rolledUp != 5 {
+		t.Errorf("Expected 5 clicks rolled up again, got %d", rolledUp)
+	}
+	checkReport("after backfilling")
+
+	if referrerHost("https://WWW.news.example.org:8443/a?b") != "news.example.org" || referrerHost("") != "" {
+		t.Errorf("Expected referrers to be reduced to their host")
+	}
+	if _, err := NewAnalyticsService(clickRepo, nil, false).Report(ctx, models.RollupTarget{URLID: "r"}, "", 0); !errors.Is(err, ErrRollupsDisabled) {
+		t.Errorf("Expected reports to need rollups, got %v", err)
+	}
+}
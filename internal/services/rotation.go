@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Errors related to weighted destinations
+var (
+	ErrInvalidDestinations = errors.New("invalid destinations: a rotation needs at least two valid URLs with a positive total weight")
+	ErrVariantNotFound     = errors.New("destination variant not found")
+)
+
+// variantIDLength is the length of generated destination variant IDs
+const variantIDLength = 4
+
+// SetDestinations replaces the weighted destinations of a URL. Click counts are
+// kept for destinations whose URL is unchanged. An empty list ends the rotation.
+func (s *ShortenerService) SetDestinations(ctx context.Context, id string, userID int, destinations []models.Destination, sticky bool) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err = prepareDestinations(destinations, url.Destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.changeDestinations(ctx, url, func(url *models.URL) {
+		url.Destinations = destinations
+		url.StickyRotation = sticky && len(destinations) > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
+// PromoteDestination ends a URL's rotation by making one of its variants the sole destination
+func (s *ShortenerService) PromoteDestination(ctx context.Context, id string, userID int, variantID string) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	winner := url.GetDestination(variantID)
+	if winner == nil {
+		return nil, ErrVariantNotFound
+	}
+
+	url.OriginalURL = winner.URL
+	url.Destinations = nil
+	url.StickyRotation = false
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+	s.queueMetadata(url)
+
+	return s.ToResponse(url), nil
+}
+
+// CountDestinationClick counts a visit sent to one of a URL's destination
+// variants. The count is incremented in the repository, leaving the URL
+// itself untouched, so concurrent visits don't race on it.
+func (s *ShortenerService) CountDestinationClick(ctx context.Context, url *models.URL, variantID string) error {
+	err := s.repo.IncrementDestinationClicks(ctx, url.ID, variantID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrVariantNotFound
+	}
+	return err
+}
+
+// ChooseDestination picks the destination variant for a visit. A sticky URL
+// keeps the visitor on their previous variant while it still exists; otherwise
+// a variant is chosen at random in proportion to the weights.
+func ChooseDestination(url *models.URL, previousVariantID string) *models.Destination {
+	if !url.IsRotating() {
+		return nil
+	}
+
+	if url.StickyRotation && previousVariantID != "" {
+		if previous := url.GetDestination(previousVariantID); previous != nil && previous.Weight > 0 {
+			return previous
+		}
+	}
+
+	total := url.TotalWeight()
+	if total <= 0 {
+		return &url.Destinations[0]
+	}
+
+	n := rand.Intn(total)
+	for i := range url.Destinations {
+		n -= url.Destinations[i].Weight
+		if n < 0 {
+			return &url.Destinations[i]
+		}
+	}
+	return &url.Destinations[len(url.Destinations)-1]
+}
+
+// prepareDestinations validates new destinations, assigning variant IDs and
+// carrying over the click counts of unchanged destinations
+func prepareDestinations(destinations, existing []models.Destination) ([]models.Destination, error) {
+	if len(destinations) == 0 {
+		return nil, nil
+	}
+	if len(destinations) < 2 {
+		return nil, ErrInvalidDestinations
+	}
+
+	previous := make(map[string]models.Destination, len(existing))
+	for _, d := range existing {
+		previous[d.URL] = d
+	}
+
+	total := 0
+	prepared := make([]models.Destination, 0, len(destinations))
+	seen := make(map[string]bool, len(destinations))
+	for _, d := range destinations {
+		d.URL = strings.TrimSpace(d.URL)
+		if validateURL(d.URL) != nil || d.Weight < 0 {
+			return nil, ErrInvalidDestinations
+		}
+		total += d.Weight
+
+		// Keep the variant ID and clicks of an unchanged destination
+		if old, ok := previous[d.URL]; ok {
+			d.ID = old.ID
+			d.Clicks = old.Clicks
+		} else {
+			d.Clicks = 0
+			d.ID = ""
+		}
+
+		// Generate a variant ID unique within the URL
+		for d.ID == "" || seen[d.ID] {
+			variantID, err := generateRandomString(variantIDLength)
+			if err != nil {
+				return nil, err
+			}
+			d.ID = variantID
+		}
+		seen[d.ID] = true
+
+		prepared = append(prepared, d)
+	}
+
+	if total <= 0 {
+		return nil, ErrInvalidDestinations
+	}
+
+	return prepared, nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestShortenerService_Destinations(t *testing.T) {
+	service, repo := newTestShortener()
+	ctx := context.Background()
+
+	userID := 1
+	if _, err := service.Shorten(ctx, "https://example.com", &userID, "landing", nil, ""); err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+
+	// A rotation needs at least two destinations
+	_, err := service.SetDestinations(ctx, "landing", userID, []models.Destination{{URL: "https://example.com/a", Weight: 1}}, false)
+	if err != ErrInvalidDestinations {
+		t.Errorf("Expected ErrInvalidDestinations, got %v", err)
+	}
+
+	resp, err := service.SetDestinations(ctx, "landing", userID, []models.Destination{
+		{URL: "https://example.com/a", Weight: 3},
+		{URL: "https://example.com/b", Weight: 0},
+	}, true)
+	if err != nil {
+		t.Fatalf("Failed to set destinations: %v", err)
+	}
+	if len(resp.Destinations) != 2 || resp.Destinations[0].ID == "" || resp.Destinations[0].ID == resp.Destinations[1].ID {
+		t.Fatalf("Expected two destinations with distinct variant IDs, got %+v", resp.Destinations)
+	}
+
+	// A variant with zero weight is never chosen, and sticky visitors keep their variant
+	url, _ := repo.GetByID(ctx, "landing")
+	variantA := url.Destinations[0].ID
+	for i := 0; i < 20; i++ {
+		if chosen := ChooseDestination(url, ""); chosen.ID != variantA {
+			t.Fatalf("Expected variant %s, got %s", variantA, chosen.ID)
+		}
+	}
+	url.Destinations[1].Weight = 1
+	if chosen := ChooseDestination(url, url.Destinations[1].ID); chosen.ID != url.Destinations[1].ID {
+		t.Errorf("Expected sticky variant %s, got %s", url.Destinations[1].ID, chosen.ID)
+	}
+
+	// Click counts survive edits that keep the destination URL
+	url.Destinations[0].Clicks = 5
+	resp, err = service.SetDestinations(ctx, "landing", userID, []models.Destination{
+		{URL: "https://example.com/a", Weight: 1},
+		{URL: "https://example.com/c", Weight: 1},
+	}, false)
+	if err != nil {
+		t.Fatalf("Failed to set destinations: %v", err)
+	}
+	if resp.Destinations[0].ID != variantA || resp.Destinations[0].Clicks != 5 {
+		t.Errorf("Expected variant %s to keep 5 clicks, got %+v", variantA, resp.Destinations[0])
+	}
+
+	// Concurrent visits are all counted against their variant
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.CountDestinationClick(ctx, url, variantA); err != nil {
+				t.Errorf("Failed to count destination click: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if clicks := url.GetDestination(variantA).Clicks; clicks != 55 {
+		t.Errorf("Expected variant %s to have 55 clicks, got %d", variantA, clicks)
+	}
+	if err := service.CountDestinationClick(ctx, url, "missing"); !errors.Is(err, ErrVariantNotFound) {
+		t.Errorf("Expected ErrVariantNotFound, got %v", err)
+	}
+
+	// Promoting a variant ends the rotation
+	resp, err = service.PromoteDestination(ctx, "landing", userID, variantA)
+	if err != nil {
+		t.Fatalf("Failed to promote destination: %v", err)
+	}
+	if resp.OriginalURL != "https://example.com/a" || len(resp.Destinations) != 0 {
+		t.Errorf("Expected https://example.com/a as the sole destination, got %s with %d destinations", resp.OriginalURL, len(resp.Destinations))
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownJob is returned when a job name is not registered
+var ErrUnknownJob = errors.New("unknown job")
+
+// JobFunc is the work done by a scheduled job
+type JobFunc func(ctx context.Context) error
+
+// JobStatus describes a scheduled job and its last run
+type JobStatus struct {
+	Name         string        `json:"name"`
+	Interval     time.Duration `json:"interval"`
+	Running      bool          `json:"running"`
+	Runs         int           `json:"runs"`
+	LastRunAt    time.Time     `json:"last_run_at,omitempty"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+}
+
+// job is a job registered with the scheduler
+type job struct {
+	fn     JobFunc
+	status JobStatus
+	mutex  sync.Mutex // held while the job is running
+}
+
+// Scheduler runs background jobs at fixed intervals
+type Scheduler struct {
+	jobs    map[string]*job
+	mutex   sync.RWMutex
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewScheduler creates a new scheduler
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		jobs: make(map[string]*job),
+	}
+}
+
+// Register adds a job that runs every interval once the scheduler is started
+func (s *Scheduler) Register(name string, interval time.Duration, fn JobFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.jobs[name] = &job{
+		fn: fn,
+		status: JobStatus{
+			Name:     name,
+			Interval: interval,
+		},
+	}
+}
+
+// Start starts running the registered jobs in the background
+func (s *Scheduler) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for name, j := range s.jobs {
+		if j.status.Interval <= 0 {
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(name string, interval time.Duration) {
+			defer s.wg.Done()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := s.RunNow(ctx, name); err != nil {
+						log.Printf("Job %s failed: %v", name, err)
+					}
+				}
+			}
+		}(name, j.status.Interval)
+	}
+}
+
+// Stop stops the scheduler and waits for running jobs to finish
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	if !s.started {
+		s.mutex.Unlock()
+		return
+	}
+	s.started = false
+	s.cancel()
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+}
+
+// RunNow runs a job immediately, waiting for a run already in progress to finish first
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	s.mutex.RLock()
+	j, ok := s.jobs[name]
+	s.mutex.RUnlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	s.setStatus(j, func(status *JobStatus) {
+		status.Running = true
+	})
+
+	start := time.Now()
+	err := j.fn(ctx)
+
+	s.setStatus(j, func(status *JobStatus) {
+		status.Running = false
+		status.Runs++
+		status.LastRunAt = start
+		status.LastDuration = time.Since(start)
+		status.LastError = ""
+		if err != nil {
+			status.LastError = err.Error()
+		}
+	})
+
+	return err
+}
+
+// Statuses returns the status of every registered job, sorted by name
+func (s *Scheduler) Statuses() []JobStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, j.status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// setStatus updates a job's status under the scheduler lock
+func (s *Scheduler) setStatus(j *job, update func(status *JobStatus)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	update(&j.status)
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Screening errors
+var (
+	ErrDestinationBlocked     = errors.New("destination was rejected by screening")
+	ErrInvalidScreeningAction = errors.New("invalid screening action")
+	ErrInvalidScreeningStatus = errors.New("invalid screening status")
+)
+
+// ScreeningJobName is the scheduler name of the job rescreening existing links
+const ScreeningJobName = "destination-screening"
+
+// screeningLookupTimeout bounds the DNS lookup made to find destinations on private networks
+const screeningLookupTimeout = 2 * time.Second
+
+// maxHostnameLabels is the most labels a hostname has before it looks like
+// it is hiding the real domain, as in paypal.com.account.verify.example.net
+const maxHostnameLabels = 5
+
+// knownShorteners are public URL shorteners. Links to them hide the real
+// destination behind a second redirect.
+var knownShorteners = []string{
+	"bit.ly", "bitly.com", "buff.ly", "cutt.ly", "goo.gl", "is.gd", "ow.ly",
+	"rb.gy", "rebrand.ly", "shorturl.at", "t.co", "t.ly", "tiny.cc",
+	"tinyurl.com", "v.gd",
+}
+
+// privateDomains are domains whose hostnames only resolve on the local network
+var privateDomains = []string{"localhost", "local", "internal", "lan", "home.arpa"}
+
+// IPResolver looks up the addresses of a hostname. *net.Resolver implements it.
+type IPResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// ScreeningPolicy sets the action taken when a destination matches each
+// screening check. Empty actions allow the match.
+type ScreeningPolicy struct {
+	// Blocklist matches destinations on the blocklist file
+	Blocklist string
+	// PrivateNetwork matches loopback, private and link-local addresses and local hostnames
+	PrivateNetwork string
+	// IPAddress matches public IP addresses used instead of a hostname
+	IPAddress string
+	// Shortener matches links to other URL shorteners, and to this one
+	Shortener string
+	// Suspicious matches credentials in the URL, punycode hostnames and long chains of subdomains
+	Suspicious string
+}
+
+// Validate checks that each action is a valid screening action
+func (p ScreeningPolicy) Validate() error {
+	for _, action := range []string{p.Blocklist, p.PrivateNetwork, p.IPAddress, p.Shortener, p.Suspicious} {
+		if action != "" && screeningSeverity(action) < 0 {
+			return ErrInvalidScreeningAction
+		}
+	}
+	return nil
+}
+
+// ScreeningService checks link destinations against the blocklist and for
+// patterns used by phishing links, and records the outcome on the links
+type ScreeningService struct {
+	repo     repository.Repository
+	policy   ScreeningPolicy
+	resolver IPResolver
+	ownHost  string
+
+	mu             sync.RWMutex
+	blocklistPath  string
+	blockedDomains map[string]bool
+	blockedURLs    []string
+}
+
+// NewScreeningService creates a new screening service. Links to the host of
+// baseURL count as links to a shortener.
+func NewScreeningService(repo repository.Repository, policy ScreeningPolicy, baseURL string) *ScreeningService {
+	ownHost := ""
+	if parsed, err := neturl.Parse(baseURL); err == nil {
+		ownHost = strings.ToLower(parsed.Hostname())
+	}
+
+	return &ScreeningService{
+		repo:           repo,
+		policy:         policy,
+		ownHost:        ownHost,
+		blockedDomains: make(map[string]bool),
+	}
+}
+
+// SetResolver enables looking up hostnames, so hostnames resolving to
+// private addresses are caught as well as private IP addresses
+func (s *ScreeningService) SetResolver(resolver IPResolver) {
+	s.resolver = resolver
+}
+
+// LoadBlocklist reads the blocklist file, one domain or URL per line. A
+// domain blocks its subdomains too, and a URL blocks the URLs it prefixes.
+// Blank lines and lines starting with # are ignored. The file is read again
+// each time existing links are rescreened.
+func (s *ScreeningService) LoadBlocklist(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	domains := make(map[string]bool)
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		if strings.Contains(entry, "://") {
+			canonical, err := (URLNormalization{}).Canonicalize(entry)
+			if err != nil {
+				continue
+			}
+			urls = append(urls, canonical)
+			continue
+		}
+		domains[strings.TrimSuffix(strings.ToLower(entry), ".")] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.blocklistPath = path
+	s.blockedDomains = domains
+	s.blockedURLs = urls
+	s.mu.Unlock()
+	return nil
+}
+
+// Screen checks a destination URL and returns the most severe action of the
+// checks it matched
+func (s *ScreeningService) Screen(ctx context.Context, rawURL string) *models.ScreeningResult {
+	result := &models.ScreeningResult{Action: models.ScreeningActionAllow}
+	match := func(action, reason string) {
+		if action == "" || action == models.ScreeningActionAllow {
+			return
+		}
+		result.Reasons = append(result.Reasons, reason)
+		if screeningSeverity(action) > screeningSeverity(result.Action) {
+			result.Action = action
+		}
+	}
+
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		match(s.policy.Suspicious, "the URL can't be parsed")
+		return result
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+
+	if s.isBlocklisted(rawURL, host) {
+		match(s.policy.Blocklist, host+" is on the blocklist")
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if isPrivateIP(ip) {
+			match(s.policy.PrivateNetwork, host+" is a private network address")
+		} else {
+			match(s.policy.IPAddress, "the URL uses the IP address "+host+" instead of a hostname")
+		}
+	} else if matchesDomain(host, privateDomains) {
+		match(s.policy.PrivateNetwork, host+" is a local network hostname")
+	} else if s.resolvesToPrivateIP(ctx, host) {
+		match(s.policy.PrivateNetwork, host+" resolves to a private network address")
+	}
+
+	if host == s.ownHost || matchesDomain(host, knownShorteners) {
+		match(s.policy.Shortener, host+" is a URL shortener, which hides the real destination")
+	}
+
+	if parsed.User != nil {
+		match(s.policy.Suspicious, "the URL contains a username, which can disguise the real hostname")
+	}
+	if looksLikeNumericIP(host) {
+		match(s.policy.Suspicious, host+" is an IP address written as a number")
+	}
+	labels := strings.Split(host, ".")
+	for _, label := range labels {
+		if strings.HasPrefix(label, "xn--") {
+			match(s.policy.Suspicious, host+" uses an internationalized hostname, which can imitate another")
+			break
+		}
+	}
+	if len(labels) > maxHostnameLabels {
+		match(s.policy.Suspicious, host+" has an unusual number of subdomains")
+	}
+
+	return result
+}
+
+// ScreenURL checks every destination of a link and returns the most severe
+// action of the checks they matched
+func (s *ScreeningService) ScreenURL(ctx context.Context, url *models.URL) *models.ScreeningResult {
+	result := &models.ScreeningResult{Action: models.ScreeningActionAllow}
+	seen := make(map[string]bool)
+	for _, destination := range url.DestinationURLs() {
+		if destination == "" || seen[destination] {
+			continue
+		}
+		seen[destination] = true
+
+		destinationResult := s.Screen(ctx, destination)
+		result.Reasons = append(result.Reasons, destinationResult.Reasons...)
+		if screeningSeverity(destinationResult.Action) > screeningSeverity(result.Action) {
+			result.Action = destinationResult.Action
+		}
+	}
+	return result
+}
+
+// Run rescreens existing links against the current blocklist and checks; it
+// is registered as a scheduler job. Warnings follow the checks, so they are
+// added and cleared, while flagged links stay flagged until an admin reviews
+// them. Links an admin approved or blocked are left alone.
+func (s *ScreeningService) Run(ctx context.Context) error {
+	s.mu.RLock()
+	path := s.blocklistPath
+	s.mu.RUnlock()
+	if path != "" {
+		if err := s.LoadBlocklist(path); err != nil {
+			return err
+		}
+	}
+
+	urls, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		if url.ScreeningStatus == models.ScreeningStatusApproved || url.IsBlocked() {
+			continue
+		}
+
+		result := s.ScreenURL(ctx, url)
+		status := screeningStatusFor(result.Action)
+		if url.ScreeningStatus == models.ScreeningStatusFlagged && status != models.ScreeningStatusBlocked {
+			continue
+		}
+		reason := strings.Join(result.Reasons, "; ")
+		if status == url.ScreeningStatus && reason == url.ScreeningReason {
+			continue
+		}
+
+		if err := s.repo.UpdateScreening(ctx, url.ID, status, reason, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List lists the links with a screening status, such as the flagged links
+// waiting for review
+func (s *ScreeningService) List(ctx context.Context, status string) ([]*models.URL, error) {
+	if status == models.ScreeningStatusClean || !containsString(models.ScreeningStatuses, status) {
+		return nil, ErrInvalidScreeningStatus
+	}
+	return s.repo.ListByScreeningStatus(ctx, status)
+}
+
+// Approve marks a link as reviewed, so it redirects without a warning until
+// its destinations are edited
+func (s *ScreeningService) Approve(ctx context.Context, id string) error {
+	return s.repo.UpdateScreening(ctx, id, models.ScreeningStatusApproved, "", time.Now())
+}
+
+// Block stops a link from redirecting
+func (s *ScreeningService) Block(ctx context.Context, id, reason string) error {
+	if reason == "" {
+		reason = "blocked by an admin"
+	}
+	return s.repo.UpdateScreening(ctx, id, models.ScreeningStatusBlocked, reason, time.Now())
+}
+
+// isBlocklisted reports whether a destination's host or one of its parent
+// domains is blocklisted, or the URL starts with a blocklisted URL
+func (s *ScreeningService) isBlocklisted(rawURL, host string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for domain := host; domain != ""; {
+		if s.blockedDomains[domain] {
+			return true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+
+	if len(s.blockedURLs) == 0 {
+		return false
+	}
+	canonical, err := (URLNormalization{}).Canonicalize(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range s.blockedURLs {
+		if strings.HasPrefix(canonical, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvesToPrivateIP reports whether a hostname resolves to a private
+// address. Lookup failures aren't treated as matches.
+func (s *ScreeningService) resolvesToPrivateIP(ctx context.Context, host string) bool {
+	if s.resolver == nil || host == "" {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, screeningLookupTimeout)
+	defer cancel()
+
+	addrs, err := s.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetScreeningService enables screening link destinations when links are
+// created and their destinations are edited
+func (s *ShortenerService) SetScreeningService(screening *ScreeningService) {
+	s.screening = screening
+}
+
+// screen checks a link's destinations, recording the outcome on the link, or
+// returns ErrDestinationBlocked if one must be rejected. Blocked links stay
+// blocked when they are edited.
+func (s *ShortenerService) screen(ctx context.Context, url *models.URL) error {
+	if s.screening == nil {
+		return nil
+	}
+
+	result := s.screening.ScreenURL(ctx, url)
+	reason := strings.Join(result.Reasons, "; ")
+	if result.Action == models.ScreeningActionReject {
+		return fmt.Errorf("%w: %s", ErrDestinationBlocked, reason)
+	}
+
+	if !url.IsBlocked() {
+		url.SetScreening(screeningStatusFor(result.Action), reason)
+	}
+	return nil
+}
+
+// changeDestinations applies a change to a link's destinations if the
+// changed destinations pass screening, leaving the link untouched otherwise.
+// A change keeping the same destinations keeps the screening status, so an
+// admin's approval lasts until the destinations are edited.
+func (s *ShortenerService) changeDestinations(ctx context.Context, url *models.URL, change func(url *models.URL)) error {
+	changed := *url
+	change(&changed)
+	if !slices.Equal(changed.DestinationURLs(), url.DestinationURLs()) {
+		if err := s.screen(ctx, &changed); err != nil {
+			return err
+		}
+	}
+
+	*url = changed
+	return nil
+}
+
+// screeningSeverity ranks screening actions, returning -1 for invalid ones
+func screeningSeverity(action string) int {
+	for i, a := range models.ScreeningActions {
+		if a == action {
+			return i
+		}
+	}
+	return -1
+}
+
+// screeningStatusFor returns the screening status of a link whose
+// destinations matched checks with an action
+func screeningStatusFor(action string) string {
+	switch action {
+	case models.ScreeningActionWarn:
+		return models.ScreeningStatusWarn
+	case models.ScreeningActionFlag:
+		return models.ScreeningStatusFlagged
+	case models.ScreeningActionReject:
+		return models.ScreeningStatusBlocked
+	default:
+		return models.ScreeningStatusClean
+	}
+}
+
+// isPrivateIP reports whether an address is on a loopback, private,
+// link-local or unspecified network
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// matchesDomain reports whether a host is one of the domains or a subdomain of one
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeNumericIP reports whether a host is an IPv4 address written in a
+// form browsers accept but people don't recognize, such as 3232235521 or
+// 0x7f.1
+func looksLikeNumericIP(host string) bool {
+	if host == "" || net.ParseIP(host) != nil {
+		return false
+	}
+	for _, part := range strings.Split(host, ".") {
+		if part == "" {
+			return false
+		}
+		hex := strings.HasPrefix(part, "0x")
+		for _, r := range strings.TrimPrefix(part, "0x") {
+			isDigit := r >= '0' && r <= '9'
+			isHex := hex && ((r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F'))
+			if !isDigit && !isHex {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// fakeIPResolver serves host addresses from a map
+type fakeIPResolver map[string]string
+
+func (r fakeIPResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addr, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []net.IPAddr{{IP: net.ParseIP(addr)}}, nil
+}
+
+func TestScreening(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestShortener()
+
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(blocklist, []byte("# Phishing\nevil.example\nhttps://docs.example.com/shared/\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+
+	screening := NewScreeningService(repo, ScreeningPolicy{
+		Blocklist:      models.ScreeningActionReject,
+		PrivateNetwork: models.ScreeningActionReject,
+		IPAddress:      models.ScreeningActionWarn,
+		Shortener:      models.ScreeningActionWarn,
+		Suspicious:     models.ScreeningActionFlag,
+	}, "https://sho.rt")
+	screening.SetResolver(fakeIPResolver{"wiki.corp.example.com": "10.1.2.3", "example.com": "93.184.215.14"})
+	if err := screening.LoadBlocklist(blocklist); err != nil {
+		t.Fatalf("Failed to load blocklist: %v", err)
+	}
+
+	tests := map[string]string{
+		"https://example.com/page":                   models.ScreeningActionAllow,
+		"https://login.evil.example/account":         models.ScreeningActionReject,
+		"https://docs.example.com/shared/phish":      models.ScreeningActionReject,
+		"https://docs.example.com/public":            models.ScreeningActionAllow,
+		"http://127.0.0.1:8080/admin":                models.ScreeningActionReject,
+		"http://[::1]/":                              models.ScreeningActionReject,
+		"http://192.168.1.1":                         models.ScreeningActionReject,
+		"http://printer.local":                       models.ScreeningActionReject,
+		"https://wiki.corp.example.com":              models.ScreeningActionReject,
+		"http://93.184.215.14/":                      models.ScreeningActionWarn,
+		"https://bit.ly/3xYz":                        models.ScreeningActionWarn,
+		"https://sho.rt/abc":                         models.ScreeningActionWarn,
+		"https://example.com@203.0.113.9/":           models.ScreeningActionFlag,
+		"https://xn--pypal-4ve.com":                  models.ScreeningActionFlag,
+		"http://3232235777/":                         models.ScreeningActionFlag,
+		"https://paypal.com.secure.login.example.io": models.ScreeningActionFlag,
+	}
+	for rawURL, want := range tests {
+		if result := screening.Screen(ctx, rawURL); result.Action != want {
+			t.Errorf("Expected %s to be screened as %s, got %s (%v)", rawURL, want, result.Action, result.Reasons)
+		}
+	}
+
+	if err := (ScreeningPolicy{Shortener: "block"}).Validate(); err != ErrInvalidScreeningAction {
+		t.Errorf("Expected ErrInvalidScreeningAction, got %v", err)
+	}
+
+	// Rejected destinations can't be shortened, warned ones are marked
+	service.SetScreeningService(screening)
+
+	userID := 1
+	if _, err := service.Shorten(ctx, "https://login.evil.example", &userID, "", nil, ""); !errors.Is(err, ErrDestinationBlocked) {
+		t.Errorf("Expected ErrDestinationBlocked, got %v", err)
+	}
+	warned, err := service.Shorten(ctx, "https://bit.ly/3xYz", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if warned.ScreeningStatus != models.ScreeningStatusWarn {
+		t.Errorf("Expected a link to a shortener to show a warning, got %q", warned.ScreeningStatus)
+	}
+
+	// Edits are screened too, and a rejected edit leaves the link unchanged
+	link, err := service.Shorten(ctx, "https://example.com/page", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	rules := []models.TargetingRule{{OS: "ios", Destination: "http://10.0.0.1/app"}}
+	if _, err := service.SetTargetingRules(ctx, link.ID, userID, rules); !errors.Is(err, ErrDestinationBlocked) {
+		t.Errorf("Expected a private rule destination to be rejected, got %v", err)
+	}
+	if url, _ := service.GetIncludingExpired(ctx, link.ID); len(url.TargetingRules) != 0 {
+		t.Errorf("Expected the rejected rules not to be saved")
+	}
+	rules = []models.TargetingRule{{OS: "ios", Destination: "https://user@example.com/app"}}
+	updated, err := service.SetTargetingRules(ctx, link.ID, userID, rules)
+	if err != nil {
+		t.Fatalf("Failed to set targeting rules: %v", err)
+	}
+	if updated.ScreeningStatus != models.ScreeningStatusFlagged {
+		t.Errorf("Expected the link to be flagged, got %q", updated.ScreeningStatus)
+	}
+
+	flagged, err := screening.List(ctx, models.ScreeningStatusFlagged)
+	if err != nil {
+		t.Fatalf("Failed to list flagged links: %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].ID != link.ID {
+		t.Errorf("Expected the link to wait for review, got %d links", len(flagged))
+	}
+
+	// An approval lasts until the destinations change
+	if err := screening.Approve(ctx, link.ID); err != nil {
+		t.Fatalf("Failed to approve link: %v", err)
+	}
+	if _, err := service.UpdateExpirySettings(ctx, link.ID, userID, models.ExpiryActionPage, "", "Gone"); err != nil {
+		t.Fatalf("Failed to update expiry settings: %v", err)
+	}
+	if url, _ := service.GetIncludingExpired(ctx, link.ID); url.ScreeningStatus != models.ScreeningStatusApproved {
+		t.Errorf("Expected the approval to survive an edit keeping the destinations, got %q", url.ScreeningStatus)
+	}
+
+	// Rescreening picks up new blocklist entries, blocking existing links
+	if err := os.WriteFile(blocklist, []byte("evil.example\nbit.ly\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+	if err := screening.Run(ctx); err != nil {
+		t.Fatalf("Failed to rescreen links: %v", err)
+	}
+	url, err := service.GetIncludingExpired(ctx, warned.ID)
+	if err != nil {
+		t.Fatalf("Failed to get URL: %v", err)
+	}
+	if !url.IsBlocked() {
+		t.Errorf("Expected the rescreened link to be blocked, got %q", url.ScreeningStatus)
+	}
+	if url, _ := service.GetIncludingExpired(ctx, link.ID); url.ScreeningStatus != models.ScreeningStatusApproved {
+		t.Errorf("Expected rescreening to skip approved links, got %q", url.ScreeningStatus)
+	}
+}
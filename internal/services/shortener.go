@@ -7,7 +7,6 @@ import (
 	"math/big"
 	"net/url"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
@@ -22,105 +21,402 @@ const (
 
 // Common errors
 var (
-	ErrInvalidURL      = errors.New("invalid URL")
-	ErrInvalidSlug     = errors.New("invalid custom slug: must contain only letters, numbers, hyphens, and underscores")
-	ErrSlugUnavailable = errors.New("custom slug is already in use")
-	ErrURLExpired      = errors.New("URL has expired")
-	ErrInvalidPassword = errors.New("invalid password")
+	ErrInvalidURL       = errors.New("invalid URL")
+	ErrInvalidSlug      = errors.New("invalid custom slug: must contain only letters, numbers, hyphens, and underscores")
+	ErrSlugUnavailable  = errors.New("custom slug is already in use")
+	ErrURLExpired       = errors.New("URL has expired")
+	ErrInvalidPassword  = errors.New("invalid password")
+	ErrNotURLOwner      = errors.New("you don't have permission to modify this URL")
+	ErrInvalidExpiry    = errors.New("invalid expiry action: redirect needs a valid fallback URL")
+	ErrIDSpaceExhausted = errors.New("no free ID found: use a longer slug length or the counter strategy")
 )
 
+// maxIDAttempts is how many generated IDs are tried before giving up. Counter
+// IDs only clash with custom slugs, but random IDs clash more often as the
+// keyspace fills up.
+const maxIDAttempts = 10
+
+// ShortenOptions holds the optional settings for a new short URL
+type ShortenOptions struct {
+	// CustomSlug is used as the ID instead of a generated one
+	CustomSlug string
+	// ExpiresIn is the lifetime of the URL (nil or zero for never)
+	ExpiresIn *time.Duration
+	// Password protects the URL when set
+	Password string
+	// ExpiryAction controls what happens once the URL has expired
+	ExpiryAction string
+	// ExpiryRedirectURL is the fallback destination for the redirect action
+	ExpiryRedirectURL string
+	// ExpiryMessage is shown on the branded expired page
+	ExpiryMessage string
+	// Destinations splits the traffic across weighted destinations instead of the original URL
+	Destinations []models.Destination
+	// StickyRotation keeps returning visitors on the same destination
+	StickyRotation bool
+	// QueryMode controls how incoming query parameters are passed to the destination
+	QueryMode string
+	// PathPassthrough forwards trailing path segments to the destination
+	PathPassthrough bool
+	// CampaignID tags the destination with the UTM parameters of one of the user's campaigns
+	CampaignID *int
+	// Channel is the campaign channel providing utm_source and utm_medium
+	Channel string
+	// RedirectStatus is the redirect status code (zero for the server default)
+	RedirectStatus int
+	// CachePolicy controls how long the redirect may be cached (empty for the server default)
+	CachePolicy string
+	// Domain is the hostname of one of the user's verified custom domains (empty for the default domain)
+	Domain string
+	// SlugStrategy chooses how the ID is generated when there's no custom slug (empty for the server default)
+	SlugStrategy string
+	// ReuseExisting returns the user's existing link to the same destination instead of creating one
+	ReuseExisting bool
+	// Title is shown on the preview page
+	Title string
+	// Preview shows visitors a preview page instead of redirecting them
+	Preview bool
+	// SocialTitle, SocialDescription and SocialImageURL override what social
+	// media sites and chat apps show when the link is shared
+	SocialTitle       string
+	SocialDescription string
+	SocialImageURL    string
+}
+
 // ShortenerService is responsible for shortening URLs
 type ShortenerService struct {
 	repo      repository.Repository
 	baseURL   string
-	keyLength int
+	campaigns *CampaignService
+	domains   *DomainService
+	reserved  *ReservedSlugService
+	registry  *SlugRegistry
+	aliases   repository.AliasRepository
+	screening *ScreeningService
+	metadata  *MetadataService
+	health    *HealthService
+	webhooks  *WebhookService
+	clicks    *ClickStream
+	analytics *AnalyticsService
+
+	// slugPolicy decides which custom slugs are accepted and how short codes are compared
+	slugPolicy SlugPolicy
+
+	// normalization decides how destination URLs are canonicalized
+	normalization URLNormalization
+
+	// slugGenerators holds a generator per slug strategy
+	slugGenerators map[string]SlugGenerator
+	slugStrategy   string
 }
 
-// NewShortenerService creates a new shortener service
+// NewShortenerService creates a new shortener service generating random base62
+// IDs of keyLength characters. Counter IDs come from an in-memory sequence.
 func NewShortenerService(repo repository.Repository, baseURL string, keyLength int) *ShortenerService {
-	return &ShortenerService{
-		repo:      repo,
-		baseURL:   baseURL,
-		keyLength: keyLength,
+	s := &ShortenerService{
+		repo:    repo,
+		baseURL: baseURL,
+		aliases: repository.NewMemoryAliasRepository(),
 	}
+
+	// A keyLength below one leaves no generators, and shortening fails with ErrInvalidSlugStrategy
+	allocator, _ := NewIDAllocator(repository.NewMemorySequenceRepository(), URLSequence, 1)
+	s.SetSlugSettings(SlugSettings{
+		Strategy:  SlugStrategyRandom,
+		Length:    keyLength,
+		Alphabet:  Base62Alphabet,
+		Words:     3,
+		Allocator: allocator,
+	})
+
+	return s
+}
+
+// SetSlugSettings replaces the slug generators and sets the default slug strategy
+func (s *ShortenerService) SetSlugSettings(settings SlugSettings) error {
+	generators := make(map[string]SlugGenerator, len(SlugStrategies))
+	for _, strategy := range SlugStrategies {
+		generator, err := NewSlugGenerator(strategy, settings)
+		if err != nil {
+			return err
+		}
+		generators[strategy] = generator
+	}
+
+	if _, ok := generators[settings.Strategy]; !ok {
+		return ErrInvalidSlugStrategy
+	}
+
+	s.slugGenerators = generators
+	s.slugStrategy = settings.Strategy
+	return nil
+}
+
+// SetCampaignService enables creating links for campaigns
+func (s *ShortenerService) SetCampaignService(campaigns *CampaignService) {
+	s.campaigns = campaigns
+}
+
+// SetReservedSlugService sets the slugs links can't use. Without it only a few default slugs are reserved.
+func (s *ShortenerService) SetReservedSlugService(reserved *ReservedSlugService) {
+	s.reserved = reserved
+}
+
+// SetSlugRegistry records the IDs of links in the registry shared with bio pages
+func (s *ShortenerService) SetSlugRegistry(registry *SlugRegistry) {
+	s.registry = registry
+}
+
+// SetSlugPolicy sets which custom slugs are accepted and how short codes are compared
+func (s *ShortenerService) SetSlugPolicy(policy SlugPolicy) {
+	s.slugPolicy = policy
+}
+
+// SlugPolicy returns the policy for custom slugs
+func (s *ShortenerService) SlugPolicy() SlugPolicy {
+	return s.slugPolicy
+}
+
+// SetURLNormalization sets how destination URLs are canonicalized
+func (s *ShortenerService) SetURLNormalization(normalization URLNormalization) {
+	s.normalization = normalization
+}
+
+// SetDomainService enables creating links on custom domains
+func (s *ShortenerService) SetDomainService(domains *DomainService) {
+	s.domains = domains
 }
 
 // Shorten shortens a URL, optionally with a custom slug, expiration time, and password protection
 func (s *ShortenerService) Shorten(ctx context.Context, originalURL string, userID *int, customSlug string, expiresIn *time.Duration, password string) (*models.URLResponse, error) {
-	// Validate URL
-	if err := validateURL(originalURL); err != nil {
+	return s.ShortenWithOptions(ctx, originalURL, userID, &ShortenOptions{
+		CustomSlug: customSlug,
+		ExpiresIn:  expiresIn,
+		Password:   password,
+	})
+}
+
+// ShortenWithOptions shortens a URL using the given options
+func (s *ShortenerService) ShortenWithOptions(ctx context.Context, originalURL string, userID *int, opts *ShortenOptions) (*models.URLResponse, error) {
+	if opts == nil {
+		opts = &ShortenOptions{}
+	}
+
+	// Validate URL, converting it to its canonical form
+	originalURL, err := s.normalization.Canonicalize(originalURL)
+	if err != nil {
 		return nil, err
 	}
 
-	var id string
-	var err error
+	// Validate the expiry settings
+	expiryAction, err := validateExpirySettings(opts.ExpiryAction, opts.ExpiryRedirectURL)
+	if err != nil {
+		return nil, err
+	}
 
-	// Use custom slug if provided, otherwise generate a random ID
-	if customSlug != "" {
-		// Validate custom slug
-		if err := validateCustomSlug(customSlug); err != nil {
+	// Tag the URL with the campaign's UTM parameters
+	if opts.CampaignID != nil {
+		if s.campaigns == nil {
+			return nil, ErrCampaignUnavailable
+		}
+		if userID == nil {
+			return nil, ErrNotCampaignOwner
+		}
+
+		originalURL, err = s.campaigns.TagURL(ctx, *opts.CampaignID, *userID, opts.Channel, originalURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate the query mode
+	queryMode, err := validateQueryMode(opts.QueryMode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the redirect settings
+	if err := validateRedirectStatus(opts.RedirectStatus); err != nil {
+		return nil, err
+	}
+	if err := validateCachePolicy(opts.CachePolicy); err != nil {
+		return nil, err
+	}
+
+	// Validate the title
+	title, err := validateTitle(opts.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the social card overrides
+	socialTitle, socialDescription, socialImageURL, err := validateSocialCard(opts.SocialTitle, opts.SocialDescription, opts.SocialImageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the weighted destinations
+	destinations, err := prepareDestinations(opts.Destinations, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pick the slug generator
+	generator, err := s.slugGenerator(opts.SlugStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	var domain string
+	if opts.Domain != "" {
+		// Check the user may create links on the custom domain
+		if s.domains == nil {
+			return nil, ErrDomainUnavailable
+		}
+		if userID == nil {
+			return nil, ErrNotDomainOwner
+		}
+		d, err := s.domains.UsableDomain(ctx, opts.Domain, *userID)
+		if err != nil {
 			return nil, err
 		}
+		domain = d.Hostname
+	}
 
-		// Check if the slug is available
-		_, err := s.repo.GetByID(ctx, customSlug)
-		if err == nil {
-			// Slug already exists
-			return nil, ErrSlugUnavailable
-		} else if err != repository.ErrNotFound {
-			// Some other error occurred
+	// Validate custom slug, converting it to the form it is stored in
+	customSlug := opts.CustomSlug
+	if customSlug != "" {
+		customSlug, err = s.slugPolicy.Normalize(customSlug)
+		if err != nil {
 			return nil, err
 		}
+		if err := checkReservedSlug(ctx, s.reserved, customSlug); err != nil {
+			return nil, err
+		}
+	}
 
-		// Slug is available
-		id = customSlug
-	} else {
-		// Generate a random ID
-		id, err = s.generateUniqueID(ctx)
+	// Return the user's existing link to the destination if asked to. Only
+	// requests without link settings are answered with an existing link, so
+	// the link returned never behaves differently from the one asked for.
+	if opts.ReuseExisting && userID != nil && customSlug == "" && !opts.hasLinkSettings() {
+		existing, err := s.existingLink(ctx, *userID, originalURL, domain)
 		if err != nil {
 			return nil, err
 		}
+		if existing != nil {
+			response := s.ToResponse(existing)
+			response.Existing = true
+			return response, nil
+		}
 	}
 
 	// Calculate expiration time if provided
 	var expiresAt *time.Time
-	if expiresIn != nil && *expiresIn > 0 {
-		t := time.Now().Add(*expiresIn)
+	if opts.ExpiresIn != nil && *opts.ExpiresIn > 0 {
+		t := time.Now().Add(*opts.ExpiresIn)
 		expiresAt = &t
 	}
 
-	// Create a new URL
-	shortenedURL := models.NewURL(id, originalURL, userID, expiresAt)
+	// Create a new URL, which gets its ID when it is stored
+	shortenedURL := models.NewURL("", originalURL, userID, expiresAt)
+	shortenedURL.ExpiryAction = expiryAction
+	shortenedURL.ExpiryRedirectURL = opts.ExpiryRedirectURL
+	shortenedURL.ExpiryMessage = opts.ExpiryMessage
+	shortenedURL.Destinations = destinations
+	shortenedURL.StickyRotation = opts.StickyRotation && len(destinations) > 0
+	shortenedURL.QueryMode = queryMode
+	shortenedURL.PathPassthrough = opts.PathPassthrough
+	shortenedURL.RedirectStatus = opts.RedirectStatus
+	shortenedURL.CachePolicy = opts.CachePolicy
+	shortenedURL.Domain = domain
+	shortenedURL.Title = title
+	shortenedURL.Preview = opts.Preview
+	shortenedURL.SocialTitle = socialTitle
+	shortenedURL.SocialDescription = socialDescription
+	shortenedURL.SocialImageURL = socialImageURL
+	if opts.CampaignID != nil {
+		shortenedURL.CampaignID = opts.CampaignID
+		shortenedURL.Channel = opts.Channel
+	}
 
 	// Hash the password if provided
-	if password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if opts.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(opts.Password), bcrypt.DefaultCost)
 		if err != nil {
 			return nil, err
 		}
 		shortenedURL.PasswordHash = string(hashedPassword)
 	}
 
+	// Screen the destinations, which may reject the URL
+	if err := s.screen(ctx, shortenedURL); err != nil {
+		return nil, err
+	}
+
 	// Store the URL
-	if err := s.repo.Store(ctx, shortenedURL); err != nil {
+	if err := s.store(ctx, shortenedURL, generator, customSlug); err != nil {
 		return nil, err
 	}
+	s.queueMetadata(shortenedURL)
+	s.publishLinkEvent(shortenedURL, models.WebhookEventLinkCreated, nil)
 
 	// Return the response
-	return &models.URLResponse{
-		ShortURL:           s.baseURL + "/" + id,
-		OriginalURL:        originalURL,
-		CreatedAt:          shortenedURL.CreatedAt,
-		Visits:             shortenedURL.Visits,
-		UserID:             userID,
-		ExpiresAt:          expiresAt,
-		IsPasswordProtected: shortenedURL.PasswordHash != "",
-	}, nil
+	return s.ToResponse(shortenedURL), nil
+}
+
+// existingLink finds the user's newest link to a destination that can be
+// shared in place of a new one without settings: on the same domain, with
+// default settings, and neither blocked nor flagged by screening. It returns
+// nil if there's none.
+func (s *ShortenerService) existingLink(ctx context.Context, userID int, destination, domain string) (*models.URL, error) {
+	urls, err := s.repo.ListByUserDestination(ctx, userID, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, url := range urls {
+		if url.Domain != domain || !hasDefaultSettings(url) {
+			continue
+		}
+		if url.IsBlocked() || url.ScreeningStatus == models.ScreeningStatusFlagged {
+			continue
+		}
+		return url, nil
+	}
+
+	return nil, nil
+}
+
+// hasLinkSettings reports whether a request to shorten a URL sets anything
+// beyond the destination, its domain and how its ID is generated
+func (o *ShortenOptions) hasLinkSettings() bool {
+	return (o.ExpiresIn != nil && *o.ExpiresIn > 0) || o.Password != "" ||
+		(o.ExpiryAction != "" && o.ExpiryAction != models.ExpiryActionNotFound) ||
+		o.ExpiryRedirectURL != "" || o.ExpiryMessage != "" ||
+		len(o.Destinations) > 0 || o.StickyRotation ||
+		(o.QueryMode != "" && o.QueryMode != models.QueryModeNone) || o.PathPassthrough ||
+		o.CampaignID != nil || o.Channel != "" ||
+		o.RedirectStatus != 0 || o.CachePolicy != "" ||
+		o.Title != "" || o.Preview ||
+		o.SocialTitle != "" || o.SocialDescription != "" || o.SocialImageURL != ""
+}
+
+// hasDefaultSettings reports whether a link behaves as one created without
+// any settings would
+func hasDefaultSettings(url *models.URL) bool {
+	return url.ExpiresAt == nil && !url.IsPasswordProtected() &&
+		url.GetExpiryAction() == models.ExpiryActionNotFound &&
+		url.ExpiryRedirectURL == "" && url.ExpiryMessage == "" &&
+		len(url.TargetingRules) == 0 && !url.IsRotating() && !url.StickyRotation &&
+		url.GetQueryMode() == models.QueryModeNone && !url.PathPassthrough &&
+		url.CampaignID == nil && url.Channel == "" &&
+		url.RedirectStatus == 0 && url.CachePolicy == "" &&
+		url.Title == "" && !url.Preview && !url.HasSocialCard()
 }
 
 // VerifyPassword checks if the provided password is correct for the URL
 func (s *ShortenerService) VerifyPassword(ctx context.Context, id string, password string) (bool, error) {
-	url, err := s.repo.GetByID(ctx, id)
+	url, err := s.lookup(ctx, id)
 	if err != nil {
 		return false, err
 	}
@@ -139,16 +435,15 @@ func (s *ShortenerService) VerifyPassword(ctx context.Context, id string, passwo
 	return true, nil
 }
 
-// Get retrieves a URL by its ID
+// Get retrieves a URL by its ID and counts the visit
 func (s *ShortenerService) Get(ctx context.Context, id string) (*models.URL, error) {
-	url, err := s.repo.GetByID(ctx, id)
+	url, err := s.GetWithoutPassword(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if URL has expired (should be redundant as repository now checks this)
-	if url.HasExpired() {
-		return nil, ErrURLExpired
+	if err := s.IncrementVisitCount(ctx, url); err != nil {
+		return nil, err
 	}
 
 	return url, nil
@@ -156,12 +451,12 @@ func (s *ShortenerService) Get(ctx context.Context, id string) (*models.URL, err
 
 // GetWithoutPassword retrieves a URL by its ID, but doesn't increment the counter or reveal if it's password-protected
 func (s *ShortenerService) GetWithoutPassword(ctx context.Context, id string) (*models.URL, error) {
-	url, err := s.repo.GetByID(ctx, id)
+	url, err := s.lookup(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if URL has expired (should be redundant as repository now checks this)
+	// The repository returns expired URLs, so check the expiry here
 	if url.HasExpired() {
 		return nil, ErrURLExpired
 	}
@@ -169,6 +464,71 @@ func (s *ShortenerService) GetWithoutPassword(ctx context.Context, id string) (*
 	return url, nil
 }
 
+// GetIncludingExpired retrieves a URL by its ID even if it has expired, so the
+// caller can apply the URL's expiry action
+func (s *ShortenerService) GetIncludingExpired(ctx context.Context, id string) (*models.URL, error) {
+	return s.lookup(ctx, id)
+}
+
+// GetByDomainSlug retrieves a URL by its slug on a custom domain, even if it has expired
+func (s *ShortenerService) GetByDomainSlug(ctx context.Context, domain, slug string) (*models.URL, error) {
+	return lookupSlug(s.slugPolicy, slug,
+		func(slug string) (*models.URL, error) { return s.repo.GetByDomainSlug(ctx, domain, slug) },
+		func(key string) (*models.URL, error) { return s.repo.GetByDomainSlugKey(ctx, domain, key) },
+	)
+}
+
+// lookup retrieves a requested URL by its ID as the slug policy compares it
+func (s *ShortenerService) lookup(ctx context.Context, id string) (*models.URL, error) {
+	return lookupSlug(s.slugPolicy, id,
+		func(id string) (*models.URL, error) { return s.repo.GetByID(ctx, id) },
+		func(key string) (*models.URL, error) { return s.repo.GetByIDKey(ctx, key) },
+	)
+}
+
+// GetOwned retrieves a URL by its ID as the slug policy compares it, checking
+// that it belongs to the given user
+func (s *ShortenerService) GetOwned(ctx context.Context, id string, userID int) (*models.URL, error) {
+	url, err := s.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !url.IsOwnedBy(userID) {
+		return nil, ErrNotURLOwner
+	}
+
+	return url, nil
+}
+
+// UpdateExpirySettings changes what happens when a URL is visited after it has expired
+func (s *ShortenerService) UpdateExpirySettings(ctx context.Context, id string, userID int, action, redirectURL, message string) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err = validateExpirySettings(action, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.changeDestinations(ctx, url, func(url *models.URL) {
+		url.ExpiryAction = action
+		url.ExpiryRedirectURL = redirectURL
+		url.ExpiryMessage = message
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
 // IncrementVisitCount increments the visit counter for a URL
 func (s *ShortenerService) IncrementVisitCount(ctx context.Context, url *models.URL) error {
 	url.IncrementVisits()
@@ -182,6 +542,55 @@ func (s *ShortenerService) IncrementVisitCount(ctx context.Context, url *models.
 	return nil
 }
 
+// PurgeExpired removes URLs that expired more than retention ago, archiving
+// them first if archive is set, and returns the number of URLs removed.
+// Their slugs become available again.
+func (s *ShortenerService) PurgeExpired(ctx context.Context, retention time.Duration, archive bool, batchSize int) (int, error) {
+	before := time.Now().Add(-retention)
+
+	total := 0
+	for {
+		purged, err := s.repo.PurgeExpired(ctx, before, archive, batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += len(purged)
+
+		// Delete the purged links' aliases
+		if err := s.deleteAliases(ctx, purged); err != nil {
+			return total, err
+		}
+
+		// Delete the purged links' clicks and analytics before their IDs can be reused
+		if s.analytics != nil {
+			for _, url := range purged {
+				if err := s.analytics.DeleteURLAnalytics(ctx, url.ID); err != nil {
+					return total, err
+				}
+			}
+		}
+
+		// Tell the owners' webhooks about the purged links
+		for _, url := range purged {
+			s.publishLinkEvent(url, models.WebhookEventLinkDeleted, nil)
+		}
+
+		// Free the purged IDs in the slug registry
+		if s.registry != nil {
+			for _, url := range purged {
+				if err := s.registry.Release(ctx, url.ID, models.SlugKindLink); err != nil {
+					return total, err
+				}
+			}
+		}
+
+		// A short batch means there is nothing left to purge
+		if batchSize <= 0 || len(purged) < batchSize {
+			return total, nil
+		}
+	}
+}
+
 // List lists all URLs
 func (s *ShortenerService) List(ctx context.Context) ([]*models.URLResponse, error) {
 	urls, err := s.repo.List(ctx)
@@ -191,15 +600,7 @@ func (s *ShortenerService) List(ctx context.Context) ([]*models.URLResponse, err
 
 	responses := make([]*models.URLResponse, 0, len(urls))
 	for _, u := range urls {
-		responses = append(responses, &models.URLResponse{
-			ShortURL:           s.baseURL + "/" + u.ID,
-			OriginalURL:        u.OriginalURL,
-			CreatedAt:          u.CreatedAt,
-			Visits:             u.Visits,
-			UserID:             u.UserID,
-			ExpiresAt:          u.ExpiresAt,
-			IsPasswordProtected: u.PasswordHash != "",
-		})
+		responses = append(responses, s.ToResponse(u))
 	}
 
 	return responses, nil
@@ -214,40 +615,167 @@ func (s *ShortenerService) ListByUserID(ctx context.Context, userID int) ([]*mod
 
 	responses := make([]*models.URLResponse, 0, len(urls))
 	for _, u := range urls {
-		responses = append(responses, &models.URLResponse{
-			ShortURL:           s.baseURL + "/" + u.ID,
-			OriginalURL:        u.OriginalURL,
-			CreatedAt:          u.CreatedAt,
-			Visits:             u.Visits,
-			UserID:             u.UserID,
-			ExpiresAt:          u.ExpiresAt,
-			IsPasswordProtected: u.PasswordHash != "",
-		})
+		responses = append(responses, s.ToResponse(u))
 	}
 
 	return responses, nil
 }
 
-// generateUniqueID generates a unique ID for a URL
-func (s *ShortenerService) generateUniqueID(ctx context.Context) (string, error) {
-	for {
-		id, err := generateRandomString(s.keyLength)
-		if err != nil {
-			return "", err
+// ShortURL returns the full short link of a URL, on its custom domain if it has one
+func (s *ShortenerService) ShortURL(u *models.URL) string {
+	if u.Domain != "" {
+		return "https://" + u.Domain + "/" + url.PathEscape(u.ShortPath())
+	}
+	return s.baseURL + "/" + url.PathEscape(u.ShortPath())
+}
+
+// PasswordURL returns the password form of a password-protected URL
+func (s *ShortenerService) PasswordURL(u *models.URL) string {
+	if u.Domain != "" {
+		return s.baseURL + "/password/" + url.PathEscape(u.ID)
+	}
+	return "/password/" + url.PathEscape(u.ID)
+}
+
+// ToResponse converts a URL to its response format
+func (s *ShortenerService) ToResponse(u *models.URL) *models.URLResponse {
+	return &models.URLResponse{
+		ID:                  u.ID,
+		ShortURL:            s.ShortURL(u),
+		OriginalURL:         u.OriginalURL,
+		CreatedAt:           u.CreatedAt,
+		Visits:              u.Visits,
+		BotVisits:           u.BotVisits,
+		UserID:              u.UserID,
+		ExpiresAt:           u.ExpiresAt,
+		IsPasswordProtected: u.PasswordHash != "",
+		ExpiryAction:        u.GetExpiryAction(),
+		ExpiryRedirectURL:   u.ExpiryRedirectURL,
+		ExpiryMessage:       u.ExpiryMessage,
+		TargetingRules:      u.TargetingRules,
+		Destinations:        u.Destinations,
+		StickyRotation:      u.StickyRotation,
+		QueryMode:           u.GetQueryMode(),
+		PathPassthrough:     u.PathPassthrough,
+		CampaignID:          u.CampaignID,
+		Channel:             u.Channel,
+		RedirectStatus:      u.RedirectStatus,
+		CachePolicy:         u.CachePolicy,
+		Domain:              u.Domain,
+		Slug:                u.Slug,
+		CanonicalAlias:      u.CanonicalAlias,
+		ScreeningStatus:     u.ScreeningStatus,
+		ScreeningReason:     u.ScreeningReason,
+		Title:               u.Title,
+		Preview:             u.Preview,
+		Metadata:            u.Metadata,
+		SocialTitle:         u.SocialTitle,
+		SocialDescription:   u.SocialDescription,
+		SocialImageURL:      u.SocialImageURL,
+		HealthStatus:        u.HealthStatus,
+		HealthFailures:      u.HealthFailures,
+		HealthCheckedAt:     u.HealthCheckedAt,
+	}
+}
+
+// slugGenerator returns the generator for a slug strategy, or the default one for an empty strategy
+func (s *ShortenerService) slugGenerator(strategy string) (SlugGenerator, error) {
+	if strategy == "" {
+		strategy = s.slugStrategy
+	}
+
+	generator, ok := s.slugGenerators[strategy]
+	if !ok {
+		return nil, ErrInvalidSlugStrategy
+	}
+	return generator, nil
+}
+
+// store assigns a new URL its ID and slug and stores it. Nothing is looked up
+// beforehand: the repository rejects IDs and slugs already in use, and a
+// generated ID that clashes with a custom slug is replaced by the next one.
+// Links on a custom domain always get a generated ID, with the custom slug,
+// or else the ID, as their slug on the domain.
+func (s *ShortenerService) store(ctx context.Context, url *models.URL, generator SlugGenerator, customSlug string) error {
+	for attempt := 1; ; attempt++ {
+		if url.Domain == "" && customSlug != "" {
+			url.ID = customSlug
+		} else {
+			id, err := generator.Generate(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Skip generated IDs that are reserved, counting them as a clash
+			if err := checkReservedSlug(ctx, s.reserved, id); err != nil {
+				if err != ErrSlugNotAllowed {
+					return err
+				}
+				if attempt == maxIDAttempts {
+					return ErrIDSpaceExhausted
+				}
+				continue
+			}
+			url.ID = id
+			url.Slug = ""
+			if url.Domain != "" {
+				url.Slug = id
+				if customSlug != "" {
+					url.Slug = customSlug
+				}
+			}
 		}
 
-		// Check if the ID already exists
-		_, err = s.repo.GetByID(ctx, id)
-		if err == repository.ErrNotFound {
-			// ID is unique
-			return id, nil
-		} else if err != nil {
-			// An error occurred
-			return "", err
+		err := s.claimAndStore(ctx, url)
+		if !errors.Is(err, repository.ErrSlugUnavailable) {
+			return err
+		}
+
+		// The custom slug itself is taken
+		if customSlug != "" && url.Domain == "" {
+			return ErrSlugUnavailable
+		}
+		if customSlug != "" {
+			if _, err := s.repo.GetByDomainSlug(ctx, url.Domain, customSlug); err == nil {
+				return ErrSlugUnavailable
+			} else if err != repository.ErrNotFound {
+				return err
+			}
+		}
+
+		// The generated ID is taken, so try another
+		if attempt == maxIDAttempts {
+			return ErrIDSpaceExhausted
 		}
+	}
+}
+
+// claimAndStore stores a URL, first claiming its ID in the slug registry if there is one
+func (s *ShortenerService) claimAndStore(ctx context.Context, url *models.URL) error {
+	// IDs share the root namespace with aliases, and can't differ from one only in case
+	if _, err := s.aliases.GetAliasByKey(ctx, models.SlugKey(url.ID)); err == nil {
+		return repository.ErrSlugUnavailable
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
 
-		// ID already exists, try again
+	if s.registry == nil {
+		return s.repo.Store(ctx, url)
 	}
+
+	claimed, err := s.registry.Claim(ctx, url.ID, models.SlugKindLink)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Store(ctx, url); err != nil {
+		if claimed {
+			s.registry.Release(ctx, url.ID, models.SlugKindLink)
+		}
+		return err
+	}
+
+	return nil
 }
 
 // generateRandomString generates a random string of the given length
@@ -281,6 +809,33 @@ func validateURL(u string) error {
 	return nil
 }
 
+// validateExpirySettings validates an expiry action and returns it, defaulting to not found
+func validateExpirySettings(action, redirectURL string) (string, error) {
+	if action == "" {
+		action = models.ExpiryActionNotFound
+	}
+
+	valid := false
+	for _, a := range models.ExpiryActions {
+		if a == action {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "", ErrInvalidExpiry
+	}
+
+	// The redirect action needs somewhere to send visitors
+	if action == models.ExpiryActionRedirect {
+		if err := validateURL(redirectURL); err != nil {
+			return "", ErrInvalidExpiry
+		}
+	}
+
+	return action, nil
+}
+
 // validateCustomSlug validates a custom slug
 func validateCustomSlug(slug string) error {
 	// Check if slug contains only allowed characters: letters, numbers, hyphens, and underscores
@@ -293,16 +848,5 @@ func validateCustomSlug(slug string) error {
 		return ErrInvalidSlug
 	}
 
-	// Check for offensive words or other restrictions
-	// This is a simplified check - you might want to expand this
-	offensiveWords := []string{"admin", "login", "signup", "api"}
-	slugLower := strings.ToLower(slug)
-	
-	for _, word := range offensiveWords {
-		if slugLower == word {
-			return errors.New("this custom slug is not allowed")
-		}
-	}
-
 	return nil
 }
\ No newline at end of file
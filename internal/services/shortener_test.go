@@ -3,10 +3,22 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
 	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
 )
 
+// testBaseURL is the base URL of the shortener services created by newTestShortener
+const testBaseURL = "http://localhost:8080"
+
+// newTestShortener creates a shortener service with 6-character IDs backed by
+// a new memory repository, which is returned for tests to inspect and change
+func newTestShortener() (*ShortenerService, *repository.MemoryRepository) {
+	repo := repository.NewMemoryRepository()
+	return NewShortenerService(repo, testBaseURL, 6), repo
+}
+
 func TestShortenerService_Shorten(t *testing.T) {
 	// Create a repository
 	repo := repository.NewMemoryRepository()
@@ -67,4 +79,74 @@ func TestShortenerService_Get(t *testing.T) {
 	if url.Visits != 1 {
 		t.Errorf("Expected visit count to be 1, got %d", url.Visits)
 	}
-}
\ No newline at end of file
+}
+
+func TestShortenerService_ExpiredURL(t *testing.T) {
+	service, repo := newTestShortener()
+	ctx := context.Background()
+
+	// Shorten a URL, then make it expire a minute ago
+	resp, err := service.ShortenWithOptions(ctx, "https://example.com", nil, &ShortenOptions{
+		CustomSlug:        "expired",
+		ExpiryAction:      models.ExpiryActionRedirect,
+		ExpiryRedirectURL: "https://example.com/fallback",
+	})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	url, _ := repo.GetByID(ctx, resp.ID)
+	expiredAt := time.Now().Add(-time.Minute)
+	url.ExpiresAt = &expiredAt
+
+	// Regular lookups treat the URL as expired
+	if _, err := service.GetWithoutPassword(ctx, "expired"); err != ErrURLExpired {
+		t.Errorf("Expected ErrURLExpired, got %v", err)
+	}
+
+	// The expired URL is still queryable with its expiry action
+	url, err = service.GetIncludingExpired(ctx, "expired")
+	if err != nil {
+		t.Fatalf("Failed to get expired URL: %v", err)
+	}
+	if url.GetExpiryAction() != models.ExpiryActionRedirect || url.ExpiryRedirectURL != "https://example.com/fallback" {
+		t.Errorf("Expected redirect expiry action to be kept, got %s %s", url.GetExpiryAction(), url.ExpiryRedirectURL)
+	}
+
+	// The redirect action requires a fallback URL
+	_, err = service.ShortenWithOptions(ctx, "https://example.com", nil, &ShortenOptions{ExpiryAction: models.ExpiryActionRedirect})
+	if err != ErrInvalidExpiry {
+		t.Errorf("Expected ErrInvalidExpiry, got %v", err)
+	}
+}
+
+func TestShortenerService_PurgeExpired(t *testing.T) {
+	service, repo := newTestShortener()
+	ctx := context.Background()
+
+	// One URL expired two days ago, the other an hour ago
+	for slug, age := range map[string]time.Duration{"old": 48 * time.Hour, "recent": time.Hour} {
+		if _, err := service.Shorten(ctx, "https://example.com", nil, slug, nil, ""); err != nil {
+			t.Fatalf("Failed to shorten URL: %v", err)
+		}
+		url, _ := repo.GetByID(ctx, slug)
+		expiredAt := time.Now().Add(-age)
+		url.ExpiresAt = &expiredAt
+	}
+
+	// Only the URL past the one day retention is purged
+	purged, err := service.PurgeExpired(ctx, 24*time.Hour, true, 1)
+	if err != nil {
+		t.Fatalf("Failed to purge URLs: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged URL, got %d", purged)
+	}
+	if _, err := repo.GetByID(ctx, "recent"); err != nil {
+		t.Errorf("Expected recently expired URL to be kept, got %v", err)
+	}
+
+	// The purged slug can be reused
+	if _, err := service.Shorten(ctx, "https://example.org", nil, "old", nil, ""); err != nil {
+		t.Errorf("Expected purged slug to be available, got %v", err)
+	}
+}
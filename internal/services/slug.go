@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"math/bits"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Slug generation strategies
+const (
+	// SlugStrategyRandom picks random characters from the configured alphabet
+	SlugStrategyRandom = "random"
+	// SlugStrategyCounter encodes an increasing counter, shuffled so consecutive links don't look consecutive
+	SlugStrategyCounter = "counter"
+	// SlugStrategyWords joins random words, such as brave-green-otter
+	SlugStrategyWords = "words"
+	// SlugStrategyUnambiguous picks random lowercase characters that can't be confused with each other
+	SlugStrategyUnambiguous = "unambiguous"
+)
+
+// SlugStrategies lists the available slug generation strategies
+var SlugStrategies = []string{
+	SlugStrategyRandom,
+	SlugStrategyCounter,
+	SlugStrategyWords,
+	SlugStrategyUnambiguous,
+}
+
+// Alphabets for generated slugs
+const (
+	// Base62Alphabet is the default alphabet of random and counter slugs
+	Base62Alphabet = charset
+	// UnambiguousAlphabet leaves out characters that are easily misread, such as l, 1, o and 0
+	UnambiguousAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Slug generation errors
+var (
+	ErrInvalidSlugStrategy = errors.New("invalid slug strategy: use random, counter, words or unambiguous")
+	ErrInvalidSlugSettings = errors.New("invalid slug settings")
+)
+
+// slugAlphabetPattern matches the characters allowed in generated slugs
+var slugAlphabetPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// counterMultiplier shuffles counter values. It is prime and larger than any
+// alphabet, so it shares no factor with the size of the keyspace.
+const counterMultiplier = 2654435761
+
+// slugAdjectives and slugNouns are the words of word slugs
+var (
+	slugAdjectives = []string{
+		"amber", "ancient", "autumn", "bold", "brave", "bright", "calm", "clever",
+		"cool", "cosmic", "crimson", "crisp", "curious", "daring", "dawn", "eager",
+		"early", "electric", "fancy", "fast", "fierce", "gentle", "giant", "golden",
+		"grand", "green", "happy", "hidden", "honest", "humble", "icy", "jolly",
+		"keen", "kind", "lively", "lucky", "mellow", "merry", "mighty", "misty",
+		"noble", "polar", "proud", "quick", "quiet", "rapid", "royal", "rustic",
+		"shiny", "silent", "silver", "sleepy", "smooth", "snowy", "solar", "steady",
+		"sunny", "swift", "tidy", "urban", "vivid", "warm", "wild", "witty",
+	}
+	slugNouns = []string{
+		"anchor", "badger", "beacon", "bison", "breeze", "brook", "canyon", "cedar",
+		"comet", "coral", "crane", "dolphin", "eagle", "ember", "falcon", "fern",
+		"finch", "forest", "fox", "galaxy", "garden", "glacier", "harbor", "hawk",
+		"heron", "island", "jaguar", "koala", "lagoon", "lantern", "lemur", "lily",
+		"lotus", "maple", "meadow", "meteor", "moose", "nebula", "ocean", "orchid",
+		"otter", "panda", "pebble", "pine", "planet", "prairie", "quartz", "raven",
+		"reef", "river", "robin", "rocket", "sparrow", "summit", "thunder", "tiger",
+		"tulip", "valley", "walrus", "willow", "wolf", "yak", "zebra", "zephyr",
+	}
+)
+
+// SlugGenerator generates candidate slugs for new links. Candidates aren't
+// guaranteed to be free: random slugs can repeat, and any slug can clash with
+// a custom slug, so the caller retries when storing the link fails.
+type SlugGenerator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// SlugSettings configures the slug generators
+type SlugSettings struct {
+	// Strategy is the default strategy, used when a request doesn't choose one
+	Strategy string
+	// Length is the length of random, counter and unambiguous slugs
+	Length int
+	// Alphabet is the alphabet of random and counter slugs
+	Alphabet string
+	// Words is the number of words in word slugs
+	Words int
+	// CounterSalt offsets the counter shuffle, so each deployment gets its own sequence
+	CounterSalt uint64
+	// Allocator hands out the numbers of counter slugs
+	Allocator *IDAllocator
+}
+
+// NewSlugGenerator creates the generator for a strategy
+func NewSlugGenerator(strategy string, settings SlugSettings) (SlugGenerator, error) {
+	switch strategy {
+	case SlugStrategyRandom:
+		return NewRandomSlugGenerator(settings.Alphabet, settings.Length)
+	case SlugStrategyCounter:
+		if settings.Allocator == nil {
+			return nil, ErrInvalidSlugSettings
+		}
+		return NewCounterSlugGenerator(settings.Allocator, settings.Alphabet, settings.Length, settings.CounterSalt)
+	case SlugStrategyWords:
+		return NewWordSlugGenerator(settings.Words)
+	case SlugStrategyUnambiguous:
+		return NewRandomSlugGenerator(UnambiguousAlphabet, settings.Length)
+	default:
+		return nil, ErrInvalidSlugStrategy
+	}
+}
+
+// validateAlphabet checks that an alphabet has at least two distinct slug characters
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 || !slugAlphabetPattern.MatchString(alphabet) {
+		return ErrInvalidSlugSettings
+	}
+	for i := 0; i < len(alphabet); i++ {
+		if strings.IndexByte(alphabet[i+1:], alphabet[i]) >= 0 {
+			return ErrInvalidSlugSettings
+		}
+	}
+	return nil
+}
+
+// RandomSlugGenerator generates slugs of random characters
+type RandomSlugGenerator struct {
+	alphabet string
+	length   int
+}
+
+// NewRandomSlugGenerator creates a generator of random slugs of the given alphabet and length
+func NewRandomSlugGenerator(alphabet string, length int) (*RandomSlugGenerator, error) {
+	if err := validateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	if length < 1 {
+		return nil, ErrInvalidSlugSettings
+	}
+
+	return &RandomSlugGenerator{
+		alphabet: alphabet,
+		length:   length,
+	}, nil
+}
+
+// Generate generates a random slug
+func (g *RandomSlugGenerator) Generate(ctx context.Context) (string, error) {
+	result := make([]byte, g.length)
+	max := big.NewInt(int64(len(g.alphabet)))
+	for i := range result {
+		num, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		result[i] = g.alphabet[num.Int64()]
+	}
+	return string(result), nil
+}
+
+// URLSequence is the name of the sequence numbering counter IDs of links
+const URLSequence = "urls"
+
+// IDAllocator hands out unique numbers from a sequence. It reserves them a
+// block at a time, so most numbers cost no database round trip, and each
+// instance sharing the sequence gets its own blocks.
+type IDAllocator struct {
+	repo      repository.SequenceRepository
+	name      string
+	blockSize int
+
+	mu   sync.Mutex
+	next uint64
+	end  uint64
+}
+
+// NewIDAllocator creates an allocator for the named sequence, reserving blockSize numbers at a time
+func NewIDAllocator(repo repository.SequenceRepository, name string, blockSize int) (*IDAllocator, error) {
+	if blockSize < 1 {
+		return nil, ErrInvalidSlugSettings
+	}
+
+	return &IDAllocator{
+		repo:      repo,
+		name:      name,
+		blockSize: blockSize,
+	}, nil
+}
+
+// Next returns the next unused number, reserving a new block when the current one runs out.
+// Numbers left in the block when the instance stops are never handed out.
+func (a *IDAllocator) Next(ctx context.Context) (uint64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.next == a.end {
+		start, err := a.repo.ReserveBlock(ctx, a.name, a.blockSize)
+		if err != nil {
+			return 0, err
+		}
+		a.next = start
+		a.end = start + uint64(a.blockSize)
+	}
+
+	n := a.next
+	a.next++
+	return n, nil
+}
+
+// CounterSlugGenerator generates slugs from numbers handed out by an
+// IDAllocator. Each number is shuffled within the keyspace of its length by a
+// reversible multiplication, so slugs never repeat but don't reveal how many
+// links exist. Once a length is used up, slugs grow by one character.
+type CounterSlugGenerator struct {
+	alphabet  string
+	length    int
+	salt      uint64
+	allocator *IDAllocator
+}
+
+// NewCounterSlugGenerator creates a generator of counter slugs of at least the given length
+func NewCounterSlugGenerator(allocator *IDAllocator, alphabet string, length int, salt uint64) (*CounterSlugGenerator, error) {
+	if err := validateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	if length < 1 || keyspace(len(alphabet), length) == 0 {
+		return nil, ErrInvalidSlugSettings
+	}
+
+	return &CounterSlugGenerator{
+		alphabet:  alphabet,
+		length:    length,
+		salt:      salt,
+		allocator: allocator,
+	}, nil
+}
+
+// Generate generates the slug for the next number of the sequence
+func (g *CounterSlugGenerator) Generate(ctx context.Context) (string, error) {
+	n, err := g.allocator.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return g.encode(n)
+}
+
+// encode shuffles a counter value and writes it in the generator's alphabet
+func (g *CounterSlugGenerator) encode(n uint64) (string, error) {
+	base := len(g.alphabet)
+
+	// Find the shortest length whose keyspace holds n
+	length := g.length
+	space := keyspace(base, length)
+	for n >= space {
+		length++
+		space = keyspace(base, length)
+		if space == 0 {
+			return "", ErrInvalidSlugSettings
+		}
+	}
+
+	// n*multiplier + salt modulo the keyspace is a bijection on the keyspace
+	hi, lo := bits.Mul64(n, counterMultiplier)
+	shuffled := bits.Rem64(hi, lo, space)
+	lo, carry := bits.Add64(shuffled, g.salt%space, 0)
+	shuffled = bits.Rem64(carry, lo, space)
+
+	result := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		result[i] = g.alphabet[shuffled%uint64(base)]
+		shuffled /= uint64(base)
+	}
+	return string(result), nil
+}
+
+// keyspace returns base^length, or zero if it doesn't fit in a uint64
+func keyspace(base, length int) uint64 {
+	space := uint64(1)
+	for i := 0; i < length; i++ {
+		if space > math.MaxUint64/uint64(base) {
+			return 0
+		}
+		space *= uint64(base)
+	}
+	return space
+}
+
+// WordSlugGenerator generates slugs of random words joined by hyphens,
+// adjectives followed by a noun
+type WordSlugGenerator struct {
+	words int
+}
+
+// NewWordSlugGenerator creates a generator of slugs with the given number of words
+func NewWordSlugGenerator(words int) (*WordSlugGenerator, error) {
+	if words < 2 {
+		return nil, ErrInvalidSlugSettings
+	}
+
+	return &WordSlugGenerator{
+		words: words,
+	}, nil
+}
+
+// Generate generates a word slug
+func (g *WordSlugGenerator) Generate(ctx context.Context) (string, error) {
+	words := make([]string, g.words)
+	for i := range words {
+		list := slugAdjectives
+		if i == len(words)-1 {
+			list = slugNouns
+		}
+
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(list))))
+		if err != nil {
+			return "", err
+		}
+		words[i] = list[num.Int64()]
+	}
+	return strings.Join(words, "-"), nil
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	neturl "net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrConfusableSlug is returned for Unicode slugs that could be mistaken for another slug
+var ErrConfusableSlug = errors.New("custom slug mixes alphabets or uses look-alike characters")
+
+// maxSlugLength is the most characters a Unicode slug may have, matching the ID columns
+const maxSlugLength = 255
+
+// Invisible characters allowed inside emoji sequences
+const (
+	zeroWidthJoiner  = '\u200d'
+	variationEmoji   = '\ufe0f'
+	keycapCombining  = '\u20e3'
+	emojiModifierMin = '\U0001f3fb'
+	emojiModifierMax = '\U0001f3ff'
+)
+
+// allowedScriptMixes lists the scripts that may appear together in one slug,
+// following the rules browsers use to display internationalized domain
+// names. Any single script may be used on its own.
+var allowedScriptMixes = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// latinLookalikes are the Cyrillic and Greek letters that look like Latin
+// ones. A slug written only with them, such as Cyrillic "рау", would pass for
+// a Latin slug.
+const latinLookalikes = "авеһіјкмнорԛѕтуԝхүԁАВЕНІЈКМНОРЅТХҮ" +
+	"αβεικνορτυχΑΒΕΖΗΙΚΜΝΟΡΤΥΧ"
+
+// SlugPolicy controls which custom slugs are accepted and how short codes
+// are compared. The zero value accepts ASCII letters, digits, hyphens and
+// underscores and compares short codes exactly.
+type SlugPolicy struct {
+	// CaseInsensitive stores custom slugs in lower case and lowercases
+	// requested short codes, so /SpringSale and /springsale are the same link
+	CaseInsensitive bool
+	// Unicode accepts letters of any script, digits and emoji, normalized to NFC
+	Unicode bool
+}
+
+// Normalize validates a custom slug and returns the form it is stored in
+func (p SlugPolicy) Normalize(slug string) (string, error) {
+	if !p.Unicode {
+		if err := validateCustomSlug(slug); err != nil {
+			return "", err
+		}
+		return p.fold(slug), nil
+	}
+
+	// A slug copied from a short URL may still be percent-encoded
+	if strings.Contains(slug, "%") {
+		unescaped, err := neturl.PathUnescape(slug)
+		if err != nil {
+			return "", ErrInvalidSlug
+		}
+		slug = unescaped
+	}
+
+	slug = norm.NFC.String(slug)
+	if err := validateUnicodeSlug(slug); err != nil {
+		return "", err
+	}
+
+	return p.fold(slug), nil
+}
+
+// Key returns the stored form of a requested short code: NFC-normalized, as
+// some systems send decomposed characters, and lowercased if slugs are
+// case-insensitive
+func (p SlugPolicy) Key(slug string) string {
+	if p.Unicode {
+		slug = norm.NFC.String(slug)
+	}
+	return p.fold(slug)
+}
+
+// FoldAlphabet returns the alphabet of generated slugs. Case-insensitive
+// slugs only use lowercase letters, so two generated slugs never differ in
+// case only.
+func (p SlugPolicy) FoldAlphabet(alphabet string) string {
+	if !p.CaseInsensitive {
+		return alphabet
+	}
+
+	var folded strings.Builder
+	for _, r := range strings.ToLower(alphabet) {
+		if !strings.ContainsRune(folded.String(), r) {
+			folded.WriteRune(r)
+		}
+	}
+	return folded.String()
+}
+
+// fold lowercases a slug if slugs are case-insensitive
+func (p SlugPolicy) fold(slug string) string {
+	if !p.CaseInsensitive {
+		return slug
+	}
+
+	slug = strings.ToLower(slug)
+	if p.Unicode {
+		slug = norm.NFC.String(slug)
+	}
+	return slug
+}
+
+// validateUnicodeSlug checks that an NFC-normalized slug only has letters,
+// digits, marks, emoji, hyphens and underscores, and can't be confused with
+// another slug
+func validateUnicodeSlug(slug string) error {
+	if slug == "" || utf8.RuneCountInString(slug) > maxSlugLength {
+		return ErrInvalidSlug
+	}
+
+	// Compatibility characters, such as fullwidth letters and ligatures, look
+	// like the plain characters they normalize to
+	if !norm.NFKC.IsNormalString(slug) {
+		return ErrConfusableSlug
+	}
+
+	prev := rune(0)
+	for _, r := range slug {
+		switch {
+		case r == '-' || r == '_':
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsMark(r):
+		case isEmoji(r):
+		case r == zeroWidthJoiner || r == variationEmoji:
+			// Only allowed inside emoji sequences, where they aren't invisible
+			if !isEmoji(prev) && prev != variationEmoji {
+				return ErrConfusableSlug
+			}
+		default:
+			return ErrInvalidSlug
+		}
+		prev = r
+	}
+
+	return checkScripts(slug)
+}
+
+// isEmoji reports whether a character is an emoji or a symbol used in one
+func isEmoji(r rune) bool {
+	return unicode.Is(unicode.So, r) || r == keycapCombining ||
+		(r >= emojiModifierMin && r <= emojiModifierMax)
+}
+
+// checkScripts rejects slugs mixing scripts that browsers wouldn't show
+// together, and slugs written only with letters that look like Latin ones
+func checkScripts(slug string) error {
+	scripts := make(map[string]bool)
+	lookalikes := true
+	for _, r := range slug {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		if script := scriptOf(r); script != "" {
+			scripts[script] = true
+		}
+		if unicode.IsLetter(r) && !strings.ContainsRune(latinLookalikes, r) {
+			lookalikes = false
+		}
+	}
+
+	if len(scripts) == 1 {
+		if (scripts["Cyrillic"] || scripts["Greek"]) && lookalikes {
+			return ErrConfusableSlug
+		}
+		return nil
+	}
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	for _, mix := range allowedScriptMixes {
+		allowed := true
+		for script := range scripts {
+			if !containsString(mix, script) {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	return ErrConfusableSlug
+}
+
+// scriptOf returns the name of a character's script, or "" for characters
+// shared by all scripts, such as ASCII digits
+func scriptOf(r rune) string {
+	for name, table := range unicode.Scripts {
+		if name == "Common" || name == "Inherited" {
+			continue
+		}
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
+
+// lookupSlug looks a requested short code up once, with byCode for its stored
+// form if slugs are case-sensitive or with byKey for its models.SlugKey if
+// not, so short codes stored before slugs became case-insensitive match
+// regardless of case as well
+func lookupSlug[T any](p SlugPolicy, slug string, byCode, byKey func(string) (T, error)) (T, error) {
+	if p.CaseInsensitive {
+		return byKey(models.SlugKey(slug))
+	}
+	return byCode(p.Key(slug))
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	neturl "net/url"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestSlugPolicy(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestShortener()
+	service.SetSlugPolicy(SlugPolicy{CaseInsensitive: true, Unicode: true})
+
+	// Custom slugs are stored in lower case and match in any case
+	link, err := service.Shorten(ctx, "https://example.com/sale", nil, "SpringSale", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if link.ID != "springsale" {
+		t.Errorf("Expected the slug to be stored as springsale, got %s", link.ID)
+	}
+	for _, path := range []string{"springsale", "SPRINGSALE", "SpringSale"} {
+		url, err := service.GetIncludingExpired(ctx, path)
+		if err != nil {
+			t.Fatalf("Failed to resolve %q: %v", path, err)
+		}
+		if url.ID != link.ID {
+			t.Errorf("Expected %q to resolve to %s, got %s", path, link.ID, url.ID)
+		}
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "springSALE", nil, ""); err != ErrSlugUnavailable {
+		t.Errorf("Expected a slug differing only in case to be unavailable, got %v", err)
+	}
+
+	// Links are managed by the short code as it is matched, too
+	userID := 1
+	owned, err := service.Shorten(ctx, "https://example.com/edit", &userID, "MyLink", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	for _, id := range []string{"MyLink", owned.ID} {
+		if _, err := service.UpdatePreviewSettings(ctx, id, userID, "Edited", false); err != nil {
+			t.Errorf("Failed to edit the link as %q: %v", id, err)
+		}
+	}
+
+	// Unicode and emoji slugs are stored in NFC and percent-encoded in short URLs
+	for slug, stored := range map[string]string{
+		"cafe\u0301":                "caf\u00e9",
+		"\u65e5\u672c":              "\u65e5\u672c",
+		"\u6771\u4eacsale":          "\u6771\u4eacsale",
+		"\U0001f355":                "\U0001f355",
+		"\U0001f44d\U0001f3fd-deal": "\U0001f44d\U0001f3fd-deal",
+	} {
+		link, err := service.Shorten(ctx, "https://example.com", nil, slug, nil, "")
+		if err != nil {
+			t.Fatalf("Failed to shorten URL with slug %q: %v", slug, err)
+		}
+		if link.ID != stored {
+			t.Errorf("Expected slug %q to be stored as %q, got %q", slug, stored, link.ID)
+		}
+		if link.ShortURL != "http://localhost:8080/"+neturl.PathEscape(stored) {
+			t.Errorf("Expected the short URL of %q to be percent-encoded, got %s", stored, link.ShortURL)
+		}
+		if _, err := service.GetIncludingExpired(ctx, slug); err != nil {
+			t.Errorf("Failed to resolve %q: %v", slug, err)
+		}
+	}
+
+	// Slugs that could pass for another slug are rejected
+	for _, slug := range []string{
+		"p\u0430ypal",        // Latin with a Cyrillic a
+		"\u0440\u0430\u0443", // Cyrillic letters that look like "pay"
+		"\uff50\uff41\uff59", // fullwidth "pay"
+		"pay\u200dpal",       // zero width joiner outside an emoji
+	} {
+		if _, err := service.Shorten(ctx, "https://example.com", nil, slug, nil, ""); err != ErrConfusableSlug {
+			t.Errorf("Expected slug %q to be rejected as confusable, got %v", slug, err)
+		}
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "\u043c\u043e\u0441\u043a\u0432\u0430", nil, ""); err != nil {
+		t.Errorf("Expected a Cyrillic word to be accepted, got %v", err)
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "a b", nil, ""); err != ErrInvalidSlug {
+		t.Errorf("Expected a slug with a space to be invalid, got %v", err)
+	}
+
+	// The default policy still only accepts ASCII slugs, compared exactly
+	ascii, _ := newTestShortener()
+	if _, err := ascii.Shorten(ctx, "https://example.com", nil, "caf\u00e9", nil, ""); err != ErrInvalidSlug {
+		t.Errorf("Expected a Unicode slug to be invalid by default, got %v", err)
+	}
+	if _, err := ascii.Shorten(ctx, "https://example.com", nil, "SpringSale", nil, ""); err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if _, err := ascii.GetIncludingExpired(ctx, "springsale"); err != repository.ErrNotFound {
+		t.Errorf("Expected slugs to be case-sensitive by default, got %v", err)
+	}
+
+	// Short codes differing only in case can't both be used, so once matching
+	// ignores case, those created before match in any case too
+	if _, err := ascii.Shorten(ctx, "https://example.com", nil, "springSALE", nil, ""); err != ErrSlugUnavailable {
+		t.Errorf("Expected a slug differing only in case to be unavailable, got %v", err)
+	}
+	ascii.SetSlugPolicy(SlugPolicy{CaseInsensitive: true})
+	for _, path := range []string{"SpringSale", "springsale"} {
+		if url, err := ascii.GetIncludingExpired(ctx, path); err != nil || url.ID != "SpringSale" {
+			t.Errorf("Expected %q to resolve to SpringSale, got %+v (%v)", path, url, err)
+		}
+	}
+
+	// Generated slugs only use lowercase letters when matching ignores case
+	policy := SlugPolicy{CaseInsensitive: true}
+	if alphabet := policy.FoldAlphabet("abcABC123"); alphabet != "abc123" {
+		t.Errorf("Expected the folded alphabet abc123, got %s", alphabet)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// SlugRegistry keeps track of which short codes links and bio pages use, so
+// a root-level short code names exactly one of them. When the namespace is
+// unified, a short code can't be used by both a link and a bio page and bio
+// pages are served at the root as well as under /b/. Otherwise the registry
+// is only kept up to date, so the namespace can be unified later.
+type SlugRegistry struct {
+	repo    repository.SlugRegistryRepository
+	unified bool
+	policy  SlugPolicy
+}
+
+// NewSlugRegistry creates a new slug registry
+func NewSlugRegistry(repo repository.SlugRegistryRepository, unified bool) *SlugRegistry {
+	return &SlugRegistry{
+		repo:    repo,
+		unified: unified,
+	}
+}
+
+// Unified reports whether links and bio pages share one namespace
+func (r *SlugRegistry) Unified() bool {
+	return r.unified
+}
+
+// SetSlugPolicy sets how requested short codes are compared
+func (r *SlugRegistry) SetSlugPolicy(policy SlugPolicy) {
+	r.policy = policy
+}
+
+// Claim records a short code as used by a kind of resource and reports
+// whether it was recorded. In a unified namespace it fails with
+// repository.ErrSlugUnavailable if any resource has the code; otherwise each
+// kind keeps its own namespace and the first one to use a code keeps it in
+// the registry.
+func (r *SlugRegistry) Claim(ctx context.Context, slug, kind string) (bool, error) {
+	err := r.repo.ClaimSlug(ctx, slug, kind)
+	if errors.Is(err, repository.ErrSlugUnavailable) && !r.unified {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release frees a short code used by a kind of resource
+func (r *SlugRegistry) Release(ctx context.Context, slug, kind string) error {
+	return r.repo.ReleaseSlug(ctx, slug, kind)
+}
+
+// Resolve returns the kind of resource a short code names, or repository.ErrNotFound
+func (r *SlugRegistry) Resolve(ctx context.Context, slug string) (string, error) {
+	return lookupSlug(r.policy, slug,
+		func(slug string) (string, error) { return r.repo.GetSlugKind(ctx, slug) },
+		func(key string) (string, error) { return r.repo.GetSlugKindByKey(ctx, key) },
+	)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestSlugRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	newServices := func(unified bool) (*ShortenerService, *BioPageService, *SlugRegistry) {
+		registry := NewSlugRegistry(repository.NewMemorySlugRegistryRepository(), unified)
+		service, _ := newTestShortener()
+		service.SetSlugRegistry(registry)
+		bioPages := NewBioPageService(repository.NewMemoryBioPageRepository(), "http://localhost:8080")
+		bioPages.SetSlugRegistry(registry)
+		return service, bioPages, registry
+	}
+
+	// In a unified namespace a short code names either a link or a bio page
+	service, bioPages, registry := newServices(true)
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "promo", nil, ""); err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if _, err := bioPages.CreateBioPage(ctx, 1, "promo", "Me", ""); err != ErrSlugUnavailable {
+		t.Errorf("Expected a link's short code to be unavailable to bio pages, got %v", err)
+	}
+	page, err := bioPages.CreateBioPage(ctx, 1, "me", "Me", "")
+	if err != nil {
+		t.Fatalf("Failed to create bio page: %v", err)
+	}
+	if page.ShortURL != "http://localhost:8080/me" {
+		t.Errorf("Expected the bio page at the root, got %s", page.ShortURL)
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "me", nil, ""); err != ErrSlugUnavailable {
+		t.Errorf("Expected a bio page's short code to be unavailable to links, got %v", err)
+	}
+	if kind, err := registry.Resolve(ctx, "me"); err != nil || kind != models.SlugKindBioPage {
+		t.Errorf("Expected me to resolve to a bio page, got %q, %v", kind, err)
+	}
+	if kind, err := registry.Resolve(ctx, "promo"); err != nil || kind != models.SlugKindLink {
+		t.Errorf("Expected promo to resolve to a link, got %q, %v", kind, err)
+	}
+
+	// Deleting the bio page frees its short code
+	if err := bioPages.DeleteBioPage(ctx, page.ID); err != nil {
+		t.Fatalf("Failed to delete bio page: %v", err)
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "me", nil, ""); err != nil {
+		t.Errorf("Expected the freed short code to be available, got %v", err)
+	}
+
+	// Otherwise links and bio pages can share short codes
+	service, bioPages, _ = newServices(false)
+	if _, err := service.Shorten(ctx, "https://example.com", nil, "promo", nil, ""); err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	page, err = bioPages.CreateBioPage(ctx, 1, "promo", "Me", "")
+	if err != nil {
+		t.Errorf("Expected links and bio pages to share short codes, got %v", err)
+	} else if page.ShortURL != "http://localhost:8080/b/promo" {
+		t.Errorf("Expected the bio page under /b/, got %s", page.ShortURL)
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestSlugGenerators(t *testing.T) {
+	settings := SlugSettings{Strategy: SlugStrategyRandom, Length: 4, Alphabet: "ab", Words: 3, CounterSalt: 7}
+
+	random, err := NewSlugGenerator(SlugStrategyRandom, settings)
+	if err != nil {
+		t.Fatalf("Failed to create random generator: %v", err)
+	}
+	slug, _ := random.Generate(context.Background())
+	if len(slug) != 4 || strings.Trim(slug, "ab") != "" {
+		t.Errorf("Expected 4 characters of a and b, got %q", slug)
+	}
+
+	unambiguous, _ := NewSlugGenerator(SlugStrategyUnambiguous, settings)
+	slug, _ = unambiguous.Generate(context.Background())
+	if len(slug) != 4 || strings.ContainsAny(slug, "il1o0ABC") {
+		t.Errorf("Expected 4 unambiguous lowercase characters, got %q", slug)
+	}
+
+	words, _ := NewSlugGenerator(SlugStrategyWords, settings)
+	slug, _ = words.Generate(context.Background())
+	if parts := strings.Split(slug, "-"); len(parts) != 3 {
+		t.Errorf("Expected 3 hyphenated words, got %q", slug)
+	}
+
+	// Counter slugs never repeat, growing once a length is used up
+	allocator, _ := NewIDAllocator(repository.NewMemorySequenceRepository(), URLSequence, 1)
+	counter, err := NewCounterSlugGenerator(allocator, "ab", 2, 7)
+	if err != nil {
+		t.Fatalf("Failed to create counter generator: %v", err)
+	}
+	seen := make(map[string]bool)
+	for n := uint64(0); n < 12; n++ {
+		slug, err := counter.encode(n)
+		if err != nil {
+			t.Fatalf("Failed to encode %d: %v", n, err)
+		}
+		if seen[slug] {
+			t.Fatalf("Counter value %d repeated slug %q", n, slug)
+		}
+		seen[slug] = true
+		expected := 2
+		if n >= 8 {
+			expected = 4
+		} else if n >= 4 {
+			expected = 3
+		}
+		if len(slug) != expected {
+			t.Errorf("Expected length %d for counter value %d, got %q", expected, n, slug)
+		}
+	}
+
+	if _, err := NewSlugGenerator("sequential", settings); err != ErrInvalidSlugStrategy {
+		t.Errorf("Expected ErrInvalidSlugStrategy, got %v", err)
+	}
+	if _, err := NewRandomSlugGenerator("aa", 4); err != ErrInvalidSlugSettings {
+		t.Errorf("Expected ErrInvalidSlugSettings for a repeated character, got %v", err)
+	}
+
+	// Requests can choose a strategy other than the server default
+	service, _ := newTestShortener()
+	resp, err := service.ShortenWithOptions(context.Background(), "https://example.com", nil, &ShortenOptions{SlugStrategy: SlugStrategyWords})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if strings.Count(resp.ID, "-") != 2 {
+		t.Errorf("Expected a word ID, got %q", resp.ID)
+	}
+	_, err = service.ShortenWithOptions(context.Background(), "https://example.com", nil, &ShortenOptions{SlugStrategy: "sequential"})
+	if err != ErrInvalidSlugStrategy {
+		t.Errorf("Expected ErrInvalidSlugStrategy, got %v", err)
+	}
+}
+
+func TestIDAllocation(t *testing.T) {
+	ctx := context.Background()
+
+	// Allocators sharing a sequence get separate blocks
+	sequences := repository.NewMemorySequenceRepository()
+	first, _ := NewIDAllocator(sequences, URLSequence, 10)
+	second, _ := NewIDAllocator(sequences, URLSequence, 10)
+	seen := make(map[uint64]bool)
+	for i := 0; i < 25; i++ {
+		for _, allocator := range []*IDAllocator{first, second} {
+			n, err := allocator.Next(ctx)
+			if err != nil {
+				t.Fatalf("Failed to allocate: %v", err)
+			}
+			if seen[n] {
+				t.Fatalf("Number %d was allocated twice", n)
+			}
+			seen[n] = true
+		}
+	}
+
+	// A generated ID that clashes with a custom slug is skipped
+	service, _ := newTestShortener()
+	allocator, _ := NewIDAllocator(repository.NewMemorySequenceRepository(), URLSequence, 1)
+	twin, _ := NewCounterSlugGenerator(allocator, Base62Alphabet, 6, 0)
+	nextID, _ := twin.Generate(ctx)
+
+	if _, err := service.Shorten(ctx, "https://example.com/custom", nil, nextID, nil, ""); err != nil {
+		t.Fatalf("Failed to shorten URL with custom slug: %v", err)
+	}
+	resp, err := service.Shorten(ctx, "https://example.com/generated", nil, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if resp.ID == nextID {
+		t.Errorf("Expected a generated ID other than the custom slug %q", nextID)
+	}
+	if _, err := service.Shorten(ctx, "https://example.com", nil, nextID, nil, ""); err != ErrSlugUnavailable {
+		t.Errorf("Expected ErrSlugUnavailable, got %v", err)
+	}
+}
+
+// BenchmarkShortenAtOccupancy measures creating a link once part of a
+// three-character keyspace is taken. Counter IDs cost the same at any
+// occupancy, while random IDs need more attempts as the keyspace fills up.
+func BenchmarkShortenAtOccupancy(b *testing.B) {
+	ctx := context.Background()
+	space := int(keyspace(len(Base62Alphabet), 3))
+
+	for _, strategy := range []string{SlugStrategyCounter, SlugStrategyRandom} {
+		for _, occupancy := range []int{0, 50, 90} {
+			b.Run(fmt.Sprintf("%s/%d%%", strategy, occupancy), func(b *testing.B) {
+				service := NewShortenerService(repository.NewMemoryRepository(), "http://localhost:8080", 3)
+				allocator, _ := NewIDAllocator(repository.NewMemorySequenceRepository(), URLSequence, 100)
+				service.SetSlugSettings(SlugSettings{Strategy: strategy, Length: 3, Alphabet: Base62Alphabet, Words: 3, Allocator: allocator})
+
+				// Fill the keyspace with counter IDs, which never clash
+				opts := &ShortenOptions{SlugStrategy: SlugStrategyCounter}
+				for i := 0; i < space*occupancy/100; i++ {
+					if _, err := service.ShortenWithOptions(ctx, "https://example.com", nil, opts); err != nil {
+						b.Fatalf("Failed to fill keyspace: %v", err)
+					}
+				}
+
+				failed := 0
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := service.Shorten(ctx, "https://example.com", nil, "", nil, ""); err != nil {
+						failed++
+					}
+				}
+				b.ReportMetric(float64(failed)/float64(b.N), "failed/op")
+			})
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	neturl "net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// Errors returned for invalid social card overrides
+var (
+	ErrSocialTitleTooLong       = errors.New("social title must be at most 200 characters")
+	ErrSocialDescriptionTooLong = errors.New("social description must be at most 500 characters")
+	ErrInvalidSocialImage       = errors.New("social image must be an http or https URL")
+)
+
+const (
+	// maxSocialTitleLength is the most characters a social title may have
+	maxSocialTitleLength = 200
+	// maxSocialDescriptionLength is the most characters a social description may have
+	maxSocialDescriptionLength = 500
+)
+
+// socialCrawlers are lowercase fragments of the user agents social media
+// sites and chat apps use to fetch the pages of shared links
+var socialCrawlers = []string{
+	"facebookexternalhit",
+	"facebookcatalog",
+	"facebot",
+	"twitterbot",
+	"linkedinbot",
+	"slackbot",
+	"slack-imgproxy",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"skypeuripreview",
+	"pinterest",
+	"redditbot",
+	"applebot",
+	"embedly",
+	"iframely",
+	"vkshare",
+	"mastodon",
+	"cardyb",
+	"viber",
+	"snapchat",
+}
+
+// SocialCard is what social media sites and chat apps show for a shared link
+type SocialCard struct {
+	// URL is the link's short URL
+	URL string
+	// Title, Description and ImageURL are the link's overrides, falling back
+	// to its title and the destination page's metadata
+	Title       string
+	Description string
+	ImageURL    string
+	// Destination is where the link sends visitors
+	Destination string
+	// Domain is the hostname of the destination
+	Domain string
+}
+
+// IsSocialCrawler checks if a user agent belongs to a social media site or
+// chat app fetching a shared link to show a preview of it
+func IsSocialCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, crawler := range socialCrawlers {
+		if strings.Contains(userAgent, crawler) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateSocialCard changes the title, description and image social media sites
+// and chat apps show when a URL is shared. Clearing all three lets crawlers
+// follow the redirect and use the destination's own tags.
+func (s *ShortenerService) UpdateSocialCard(ctx context.Context, id string, userID int, title, description, imageURL string) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	title, description, imageURL, err = validateSocialCard(title, description, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	url.SocialTitle = title
+	url.SocialDescription = description
+	url.SocialImageURL = imageURL
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
+// SocialCard builds the card crawlers are shown for a URL with social card overrides
+func (s *ShortenerService) SocialCard(url *models.URL, extraPath string, query neturl.Values) (*SocialCard, error) {
+	preview, err := s.PreviewLink(url, extraPath, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SocialCard{
+		URL:         preview.ShortURL,
+		Title:       firstNonEmpty(url.SocialTitle, url.Title, preview.PageTitle, preview.ShortURL),
+		Description: firstNonEmpty(url.SocialDescription, preview.Description),
+		ImageURL:    firstNonEmpty(url.SocialImageURL, preview.ImageURL),
+		Destination: preview.Destination,
+		Domain:      preview.Domain,
+	}, nil
+}
+
+// validateSocialCard trims social card overrides and checks them
+func validateSocialCard(title, description, imageURL string) (string, string, string, error) {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > maxSocialTitleLength {
+		return "", "", "", ErrSocialTitleTooLong
+	}
+
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxSocialDescriptionLength {
+		return "", "", "", ErrSocialDescriptionTooLong
+	}
+
+	imageURL = strings.TrimSpace(imageURL)
+	if imageURL != "" && validateURL(imageURL) != nil {
+		return "", "", "", ErrInvalidSocialImage
+	}
+
+	return title, description, imageURL, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestSocialCard(t *testing.T) {
+	crawlers := map[string]bool{
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)": true,
+		"Twitterbot/1.0": true,
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)":        true,
+		"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)": true,
+		"WhatsApp/2.23.20.0 A": true,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148":   false,
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0.0.0 Safari/537.36": false,
+		"": false,
+	}
+	for userAgent, want := range crawlers {
+		if got := IsSocialCrawler(userAgent); got != want {
+			t.Errorf("IsSocialCrawler(%q) = %v, want %v", userAgent, got, want)
+		}
+	}
+
+	ctx := context.Background()
+	service, _ := newTestShortener()
+
+	userID := 1
+	created, err := service.ShortenWithOptions(ctx, "https://example.com/launch", &userID, &ShortenOptions{
+		SocialTitle: " Launch day ",
+	})
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	if created.SocialTitle != "Launch day" {
+		t.Errorf("Expected the trimmed social title, got %q", created.SocialTitle)
+	}
+
+	if _, err := service.UpdateSocialCard(ctx, created.ID, userID, "", strings.Repeat("a", 501), ""); err != ErrSocialDescriptionTooLong {
+		t.Errorf("Expected ErrSocialDescriptionTooLong, got %v", err)
+	}
+	if _, err := service.UpdateSocialCard(ctx, created.ID, userID, "", "", "javascript:alert(1)"); err != ErrInvalidSocialImage {
+		t.Errorf("Expected ErrInvalidSocialImage, got %v", err)
+	}
+	if _, err := service.UpdateSocialCard(ctx, created.ID, 2, "", "", ""); err != ErrNotURLOwner {
+		t.Errorf("Expected ErrNotURLOwner, got %v", err)
+	}
+
+	// Overrides that are left empty fall back to the destination's metadata
+	url, err := service.GetIncludingExpired(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get URL: %v", err)
+	}
+	url.Metadata = &models.LinkMetadata{
+		URL:         url.OriginalURL,
+		Title:       "Example launch",
+		Description: "All the details",
+		ImageURL:    "https://example.com/og.png",
+		FetchedAt:   time.Now(),
+	}
+	card, err := service.SocialCard(url, "", nil)
+	if err != nil {
+		t.Fatalf("Failed to build social card: %v", err)
+	}
+	if card.Title != "Launch day" || card.Description != "All the details" || card.ImageURL != "https://example.com/og.png" {
+		t.Errorf("Unexpected social card: %+v", card)
+	}
+	if card.URL != testBaseURL+"/"+url.ID || card.Destination != "https://example.com/launch" {
+		t.Errorf("Expected the short URL and destination, got %s and %s", card.URL, card.Destination)
+	}
+
+	// Clearing every override lets crawlers follow the redirect again
+	response, err := service.UpdateSocialCard(ctx, created.ID, userID, "", "", "")
+	if err != nil {
+		t.Fatalf("Failed to update social card: %v", err)
+	}
+	if response.SocialTitle != "" || url.HasSocialCard() {
+		t.Errorf("Expected the social card to be cleared")
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+// ErrInvalidTargetingRule is returned when a targeting rule is invalid
+var ErrInvalidTargetingRule = errors.New("invalid targeting rule: it needs at least one valid condition and a valid destination")
+
+// ClientInfo describes the visitor of a short link
+type ClientInfo struct {
+	Device    string
+	OS        string
+	Browser   string
+	Languages []string // Primary language subtags in order of preference
+	Country   string
+}
+
+// TargetingService picks a link's destination based on the visitor
+type TargetingService struct {
+	geoIP GeoIPResolver
+}
+
+// NewTargetingService creates a new targeting service. geoIP may be nil, in
+// which case rules with a country condition never match.
+func NewTargetingService(geoIP GeoIPResolver) *TargetingService {
+	return &TargetingService{
+		geoIP: geoIP,
+	}
+}
+
+// ClientInfo builds the client info for a request from its headers and IP address
+func (s *TargetingService) ClientInfo(userAgent, acceptLanguage, ip string) ClientInfo {
+	device, os, browser := ParseUserAgent(userAgent)
+
+	info := ClientInfo{
+		Device:    device,
+		OS:        os,
+		Browser:   browser,
+		Languages: ParseAcceptLanguage(acceptLanguage),
+	}
+
+	if s.geoIP != nil {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			info.Country = s.geoIP.Country(parsed)
+		}
+	}
+
+	return info
+}
+
+// MatchingRule returns the first targeting rule matching the client, or nil
+func (s *TargetingService) MatchingRule(url *models.URL, info ClientInfo) *models.TargetingRule {
+	for i := range url.TargetingRules {
+		if MatchesTargetingRule(url.TargetingRules[i], info) {
+			return &url.TargetingRules[i]
+		}
+	}
+	return nil
+}
+
+// MatchesTargetingRule checks if the client matches every condition of the rule
+func MatchesTargetingRule(rule models.TargetingRule, info ClientInfo) bool {
+	if !rule.HasConditions() {
+		return false
+	}
+	if rule.Device != "" && rule.Device != info.Device {
+		return false
+	}
+	if rule.OS != "" && rule.OS != info.OS {
+		return false
+	}
+	if rule.Browser != "" && rule.Browser != info.Browser {
+		return false
+	}
+	if rule.Country != "" && rule.Country != info.Country {
+		return false
+	}
+	if rule.Language != "" {
+		// Only the visitor's most preferred language is considered
+		if len(info.Languages) == 0 || info.Languages[0] != rule.Language {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseUserAgent extracts the device type, operating system and browser from a User-Agent header
+func ParseUserAgent(ua string) (device, os, browser string) {
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		os = models.OSIOS
+	case strings.Contains(ua, "Android"):
+		os = models.OSAndroid
+	case strings.Contains(ua, "Windows"):
+		os = models.OSWindows
+	case strings.Contains(ua, "CrOS"):
+		os = models.OSChromeOS
+	case strings.Contains(ua, "Macintosh"), strings.Contains(ua, "Mac OS X"):
+		os = models.OSMacOS
+	case strings.Contains(ua, "Linux"):
+		os = models.OSLinux
+	}
+
+	switch {
+	case strings.Contains(ua, "iPad"), strings.Contains(ua, "Tablet"),
+		os == models.OSAndroid && !strings.Contains(ua, "Mobile"):
+		device = models.DeviceTablet
+	case strings.Contains(ua, "Mobi"), strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"):
+		device = models.DeviceMobile
+	case ua != "":
+		device = models.DeviceDesktop
+	}
+
+	// Order matters: most browsers include "Chrome" and "Safari" in their user agent
+	switch {
+	case strings.Contains(ua, "Edg/"), strings.Contains(ua, "EdgA/"), strings.Contains(ua, "EdgiOS/"):
+		browser = models.BrowserEdge
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		browser = models.BrowserOpera
+	case strings.Contains(ua, "SamsungBrowser/"):
+		browser = models.BrowserSamsung
+	case strings.Contains(ua, "Firefox/"), strings.Contains(ua, "FxiOS/"):
+		browser = models.BrowserFirefox
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		browser = models.BrowserChrome
+	case strings.Contains(ua, "Safari/"):
+		browser = models.BrowserSafari
+	}
+
+	return device, os, browser
+}
+
+// ParseAcceptLanguage returns the primary language subtags of an Accept-Language
+// header, most preferred first
+func ParseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	languages := make([]language, 0)
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		// Keep only the primary subtag, e.g. "en" for "en-US"
+		if i := strings.IndexAny(tag, "-_"); i > 0 {
+			tag = tag[:i]
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality <= 0 || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		languages = append(languages, language{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	tags := make([]string, 0, len(languages))
+	for _, l := range languages {
+		tags = append(tags, l.tag)
+	}
+	return tags
+}
+
+// SetTargetingRules replaces the targeting rules of a URL
+func (s *ShortenerService) SetTargetingRules(ctx context.Context, id string, userID int, rules []models.TargetingRule) (*models.URLResponse, error) {
+	url, err := s.GetOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]models.TargetingRule, 0, len(rules))
+	for _, rule := range rules {
+		rule, err := normalizeTargetingRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, rule)
+	}
+
+	err = s.changeDestinations(ctx, url, func(url *models.URL) {
+		url.TargetingRules = normalized
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.update(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return s.ToResponse(url), nil
+}
+
+// normalizeTargetingRule validates a targeting rule and normalizes the case of its conditions
+func normalizeTargetingRule(rule models.TargetingRule) (models.TargetingRule, error) {
+	rule.Device = strings.ToLower(strings.TrimSpace(rule.Device))
+	rule.OS = strings.ToLower(strings.TrimSpace(rule.OS))
+	rule.Browser = strings.ToLower(strings.TrimSpace(rule.Browser))
+	rule.Language = strings.ToLower(strings.TrimSpace(rule.Language))
+	rule.Country = strings.ToUpper(strings.TrimSpace(rule.Country))
+	rule.Destination = strings.TrimSpace(rule.Destination)
+
+	if !rule.HasConditions() || validateURL(rule.Destination) != nil {
+		return rule, ErrInvalidTargetingRule
+	}
+	if rule.Device != "" && !containsString(models.TargetingDevices, rule.Device) {
+		return rule, ErrInvalidTargetingRule
+	}
+	if rule.OS != "" && !containsString(models.TargetingOSes, rule.OS) {
+		return rule, ErrInvalidTargetingRule
+	}
+	if rule.Browser != "" && !containsString(models.TargetingBrowsers, rule.Browser) {
+		return rule, ErrInvalidTargetingRule
+	}
+	if rule.Language != "" && (len(rule.Language) < 2 || len(rule.Language) > 3) {
+		return rule, ErrInvalidTargetingRule
+	}
+	if rule.Country != "" && len(rule.Country) != 2 {
+		return rule, ErrInvalidTargetingRule
+	}
+
+	return rule, nil
+}
+
+// containsString checks if a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+)
+
+func TestShortenerService_TargetingRules(t *testing.T) {
+	service, repo := newTestShortener()
+	ctx := context.Background()
+
+	userID := 1
+	if _, err := service.Shorten(ctx, "https://example.com", &userID, "app", nil, ""); err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+
+	// Rules need a condition and a valid destination
+	_, err := service.SetTargetingRules(ctx, "app", userID, []models.TargetingRule{{Destination: "https://example.com/ios"}})
+	if err != ErrInvalidTargetingRule {
+		t.Errorf("Expected ErrInvalidTargetingRule, got %v", err)
+	}
+
+	response, err := service.SetTargetingRules(ctx, "app", userID, []models.TargetingRule{
+		{OS: "iOS", Destination: "https://apps.apple.com/app/example"},
+		{OS: models.OSAndroid, Destination: "https://play.google.com/store/apps/details?id=example"},
+		{Country: "de", Destination: "https://example.de"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set targeting rules: %v", err)
+	}
+	if len(response.TargetingRules) != 3 {
+		t.Errorf("Expected 3 targeting rules, got %d", len(response.TargetingRules))
+	}
+	if stored, _ := repo.GetByID(ctx, "app"); len(stored.TargetingRules) != 3 {
+		t.Errorf("Expected the targeting rules to be stored, got %+v", stored.TargetingRules)
+	}
+
+	geoIP, err := ParseGeoIPDatabase(strings.NewReader("ip_start,ip_end,country\n10.0.0.0,10.0.0.255,DE\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse GeoIP database: %v", err)
+	}
+	if country := geoIP.Country(net.ParseIP("10.0.0.7")); country != "DE" {
+		t.Errorf("Expected country DE, got %q", country)
+	}
+}
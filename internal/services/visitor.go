@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"math/bits"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// visitorSaltSize is the size in bytes of the random salt visitors are hashed with each day
+const visitorSaltSize = 32
+
+// Size of the HyperLogLog sketches estimating unique visitors. 2^12 registers
+// estimate counts to within about 1.6%.
+const (
+	sketchPrecision = 12
+	sketchRegisters = 1 << sketchPrecision
+)
+
+// VisitorHasher identifies visitors by a hash of their IP address and user
+// agent with a random salt that changes every day. Visitors can be counted
+// once a day without their IP address being stored, and as past salts are
+// deleted, a hash can't be traced back to an IP address or matched with the
+// same visitor's hashes on other days.
+type VisitorHasher struct {
+	repo  repository.ClickRepository
+	mutex sync.Mutex
+	day   time.Time
+	salt  []byte
+}
+
+// NewVisitorHasher creates a new visitor hasher, sharing each day's salt with
+// other instances through the repository
+func NewVisitorHasher(repo repository.ClickRepository) *VisitorHasher {
+	return &VisitorHasher{
+		repo: repo,
+	}
+}
+
+// Hash returns the hash identifying a visitor on the day of the given time
+func (h *VisitorHasher) Hash(ctx context.Context, ip, userAgent string, at time.Time) (string, error) {
+	salt, err := h.daySalt(ctx, visitorDay(at))
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// daySalt returns the salt of a day, creating it on the first visit of the day
+// and deleting the salts of the days before
+func (h *VisitorHasher) daySalt(ctx context.Context, day time.Time) ([]byte, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// A visit finishing just after midnight keeps the new day's salt
+	if h.salt != nil && !day.After(h.day) {
+		return h.salt, nil
+	}
+
+	candidate := make([]byte, visitorSaltSize)
+	if _, err := rand.Read(candidate); err != nil {
+		return nil, err
+	}
+	salt, err := h.repo.GetVisitorSalt(ctx, day, candidate)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.repo.DeleteVisitorSaltsBefore(ctx, day); err != nil {
+		return nil, err
+	}
+
+	h.day = day
+	h.salt = salt
+	return salt, nil
+}
+
+// visitorDay returns the start of the day of the given time, in UTC
+func visitorDay(at time.Time) time.Time {
+	year, month, day := at.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// sketchPosition returns the register of a visitor sketch a visitor hash falls
+// into, and the rank it raises the register to: one more than the number of
+// leading zeros in the rest of the hash
+func sketchPosition(visitorHash string) (int, int, error) {
+	if len(visitorHash) < 16 {
+		return 0, 0, strconv.ErrSyntax
+	}
+	value, err := strconv.ParseUint(visitorHash[:16], 16, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	register := int(value >> (64 - sketchPrecision))
+	// Mark the end of the rest of the hash, so an all-zero rest has the highest rank
+	rest := value<<sketchPrecision | 1<<(sketchPrecision-1)
+	return register, bits.LeadingZeros64(rest) + 1, nil
+}
+
+// estimateVisitors estimates how many distinct visitors were added to a
+// sketch from the ranks of its registers
+func estimateVisitors(ranks map[int]int) int {
+	registers := float64(sketchRegisters)
+
+	sum := 0.0
+	empty := 0
+	for register := 0; register < sketchRegisters; register++ {
+		rank := ranks[register]
+		if rank == 0 {
+			empty++
+		}
+		sum += math.Ldexp(1, -rank)
+	}
+	estimate := 0.7213 / (1 + 1.079/registers) * registers * registers / sum
+
+	// Small counts are estimated better from the number of empty registers
+	if estimate <= 2.5*registers && empty > 0 {
+		estimate = registers * math.Log(registers/float64(empty))
+	}
+	return int(math.Round(estimate))
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestUniqueVisitors(t *testing.T) {
+	ctx := context.Background()
+	clickRepo := repository.NewMemoryClickRepository()
+
+	// Visitors hash the same within a day and differently across days
+	hasher := NewVisitorHasher(clickRepo)
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	first, err := hasher.Hash(ctx, "203.0.113.7", "Mozilla/5.0", monday)
+	if err != nil {
+		t.Fatalf("Failed to hash visitor: %v", err)
+	}
+	again, _ := hasher.Hash(ctx, "203.0.113.7", "Mozilla/5.0", monday.Add(8*time.Hour))
+	other, _ := hasher.Hash(ctx, "203.0.113.8", "Mozilla/5.0", monday)
+	if first != again || first == other || strings.Contains(first, "203.0.113.7") {
+		t.Errorf("Expected one hash per visitor per day, got %q, %q and %q", first, again, other)
+	}
+	tuesday, _ := hasher.Hash(ctx, "203.0.113.7", "Mozilla/5.0", monday.AddDate(0, 0, 1))
+	if tuesday == first {
+		t.Errorf("Expected the hash to change the next day")
+	}
+	// The past day's salt is gone, so its hashes can't be recomputed
+	if salt, _ := clickRepo.GetVisitorSalt(ctx, visitorDay(monday), []byte("new")); string(salt) != "new" {
+		t.Errorf("Expected the previous day's salt to be deleted")
+	}
+
+	// Visits and unique visitors are counted by day, leaving bots out
+	service, repo := newTestShortener()
+	service.SetAnalyticsService(NewAnalyticsService(clickRepo, NewBotFilter(true), false))
+
+	userID := 1
+	created, err := service.Shorten(ctx, "https://example.com/uniques", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	url, _ := repo.GetByID(ctx, created.ID)
+	browser := http.Header{"Accept-Language": {"en"}}
+	for _, visit := range []struct{ ip, userAgent string }{
+		{"203.0.113.7", "Mozilla/5.0 Firefox/130.0"},
+		{"203.0.113.7", "Mozilla/5.0 Firefox/130.0"},
+		{"198.51.100.2", "Mozilla/5.0 Firefox/130.0"},
+		{"198.51.100.3", "curl/8.4.0"},
+	} {
+		click := models.NewClick("", visit.userAgent)
+		service.ClassifyClick(click, browser)
+		if err := service.IdentifyVisitor(ctx, click, visit.ip); err != nil {
+			t.Fatalf("Failed to identify visitor: %v", err)
+		}
+		if err := service.CountClick(ctx, url, click); err != nil {
+			t.Fatalf("Failed to count click: %v", err)
+		}
+	}
+
+	stats, err := service.VisitorStats(ctx, created.ID, userID, 0)
+	if err != nil {
+		t.Fatalf("Failed to count visitors: %v", err)
+	}
+	if stats.Visits != 3 || stats.Visitors != 2 || stats.Estimated {
+		t.Errorf("Expected 3 visits from 2 visitors, got %+v", stats)
+	}
+	if len(stats.Days) != defaultVisitorDays {
+		t.Fatalf("Expected %d days, got %d", defaultVisitorDays, len(stats.Days))
+	}
+	today := stats.Days[0]
+	if today.Date != time.Now().UTC().Format("2006-01-02") || today.Visits != 3 || today.Visitors != 2 {
+		t.Errorf("Expected today's 3 visits from 2 visitors first, got %+v", today)
+	}
+	if yesterday := stats.Days[1]; yesterday.Visits != 0 || yesterday.Visitors != 0 {
+		t.Errorf("Expected no visits yesterday, got %+v", yesterday)
+	}
+	if week, _ := service.VisitorStats(ctx, created.ID, userID, 7); len(week.Days) != 7 {
+		t.Errorf("Expected 7 days, got %d", len(week.Days))
+	}
+	if _, err := service.VisitorStats(ctx, created.ID, 2, 0); err == nil {
+		t.Errorf("Expected other users not to see the link's visitors")
+	}
+
+	// Bio page views are counted the same way
+	bioService := NewBioPageService(repository.NewMemoryBioPageRepository(), testBaseURL)
+	bioService.SetAnalyticsService(NewAnalyticsService(clickRepo, NewBotFilter(true), false))
+	bioPage, err := bioService.CreateBioPage(ctx, userID, "", "Me", "")
+	if err != nil {
+		t.Fatalf("Failed to create bio page: %v", err)
+	}
+	for _, ip := range []string{"203.0.113.7", "203.0.113.7", "198.51.100.2"} {
+		click := models.NewClick("", "Mozilla/5.0 Firefox/130.0")
+		bioService.IdentifyVisitor(ctx, click, ip)
+		if err := bioService.CountView(ctx, bioPage, click); err != nil {
+			t.Fatalf("Failed to count view: %v", err)
+		}
+	}
+	bioStats, err := bioService.VisitorStats(ctx, bioPage, 7)
+	if err != nil {
+		t.Fatalf("Failed to count bio page visitors: %v", err)
+	}
+	if bioStats.Visitors != 2 || bioStats.Days[0].Visits != 3 || bioStats.Days[0].Visitors != 2 {
+		t.Errorf("Expected 3 views from 2 visitors, got %+v", bioStats)
+	}
+
+	// Sketches estimate large totals closely
+	sketchRepo := repository.NewMemoryClickRepository()
+	analytics := NewAnalyticsService(sketchRepo, NewBotFilter(true), true)
+	for i := 0; i < 20000; i++ {
+		click := models.NewClick("", "Mozilla/5.0")
+		click.URLID = "big"
+		if err := analytics.IdentifyVisitor(ctx, click, fmt.Sprintf("10.0.%d.%d", i/256, i%256)); err != nil {
+			t.Fatalf("Failed to identify visitor: %v", err)
+		}
+		if err := analytics.RecordClick(ctx, click); err != nil {
+			t.Fatalf("Failed to record click: %v", err)
+		}
+	}
+	estimate, err := analytics.URLVisitorStats(ctx, &models.URL{ID: "big"}, 1)
+	if err != nil {
+		t.Fatalf("Failed to estimate visitors: %v", err)
+	}
+	if !estimate.Estimated || estimate.Visitors < 19000 || estimate.Visitors > 21000 {
+		t.Errorf("Expected about 20000 visitors, got %+v", estimate)
+	}
+	if estimateVisitors(map[int]int{}) != 0 {
+		t.Errorf("Expected an empty sketch to estimate no visitors")
+	}
+}
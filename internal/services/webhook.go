@@ -0,0 +1,547 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+// Webhook errors
+var (
+	ErrInvalidWebhookURL   = errors.New("webhook URL must be an http or https URL")
+	ErrInvalidWebhookEvent = errors.New("unknown webhook event: use " + strings.Join(models.WebhookEvents, ", "))
+	ErrNoWebhookEvents     = errors.New("choose at least one event to send to the webhook")
+	ErrNotWebhookOwner     = errors.New("you don't have permission to manage this webhook")
+	ErrTooManyWebhooks     = fmt.Errorf("you can register at most %d webhooks", maxWebhooksPerUser)
+)
+
+// WebhookJobName is the scheduler name of the job retrying webhook deliveries
+const WebhookJobName = "webhook-delivery"
+
+// Headers sent with webhook deliveries
+const (
+	// WebhookSignatureHeader carries the timestamp and HMAC-SHA256 signature of a delivery, see SignWebhookPayload
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookEventHeader carries the event type
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookIDHeader carries the event ID, the same for every attempt of a delivery
+	WebhookIDHeader = "X-Webhook-ID"
+)
+
+const (
+	// maxWebhooksPerUser is how many webhooks each user can register
+	maxWebhooksPerUser = 10
+	// maxWebhookBackoff caps the wait between retries of a delivery
+	maxWebhookBackoff = 12 * time.Hour
+	// maxWebhookResponseBytes is how much of an endpoint's response is kept in the delivery log
+	maxWebhookResponseBytes = 1024
+	// webhookDeliveryLimit is how many deliveries are listed for a webhook
+	webhookDeliveryLimit = 50
+	// webhookQueueSize is how many events can wait to be delivered; events
+	// published while the queue is full are dropped
+	webhookQueueSize = 1024
+	// webhookUserAgent identifies deliveries to webhook endpoints
+	webhookUserAgent = "RapidURL-Webhooks/1.0"
+)
+
+// LinkEventData is the data of the link events
+type LinkEventData struct {
+	Link *models.URLResponse `json:"link"`
+	// Visit is set for link.clicked events
+	Visit *WebhookVisit `json:"visit,omitempty"`
+}
+
+// BioPageEventData is the data of the bio page events
+type BioPageEventData struct {
+	BioPage *models.BioPageResponse `json:"bio_page"`
+	Visit   *WebhookVisit           `json:"visit,omitempty"`
+}
+
+// WebhookVisit describes the visit behind a click or view event
+type WebhookVisit struct {
+	Referrer  string `json:"referrer,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	// Alias is the alias the link was visited through, if any
+	Alias string `json:"alias,omitempty"`
+	// Bot is set for visits from bots, crawlers and prefetchers, which the visit counts leave out
+	Bot bool `json:"bot"`
+}
+
+// WebhookService sends users' link events to the webhooks they register. Events
+// are delivered in the background and failed deliveries are retried by a
+// scheduler job, waiting twice as long before each retry.
+type WebhookService struct {
+	repo   repository.WebhookRepository
+	config *config.WebhookConfig
+	client HTTPClient
+	queue  chan *models.WebhookEvent
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookService creates a new webhook service. Its default client refuses
+// to connect to private networks and doesn't follow redirects.
+func NewWebhookService(repo repository.WebhookRepository, config *config.WebhookConfig) *WebhookService {
+	client := newPublicClient(config.Timeout)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &WebhookService{
+		repo:   repo,
+		config: config,
+		client: client,
+		queue:  make(chan *models.WebhookEvent, webhookQueueSize),
+	}
+}
+
+// SetHTTPClient replaces the client used to send deliveries
+func (s *WebhookService) SetHTTPClient(client HTTPClient) {
+	s.client = client
+}
+
+// CreateWebhook registers a webhook for a user, generating its signing secret
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID int, url string, events []string) (*models.Webhook, error) {
+	url, events, err := validateWebhook(url, events)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.repo.ListWebhooksByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks) >= maxWebhooksPerUser {
+		return nil, ErrTooManyWebhooks
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := models.NewWebhook(userID, url, "whsec_"+secret, events)
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetWebhook retrieves a webhook by ID, checking that it belongs to the given user
+func (s *WebhookService) GetWebhook(ctx context.Context, id, userID int) (*models.Webhook, error) {
+	webhook, err := s.repo.GetWebhookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.UserID != userID {
+		return nil, ErrNotWebhookOwner
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks lists all webhooks for a user
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID int) ([]*models.Webhook, error) {
+	return s.repo.ListWebhooksByUserID(ctx, userID)
+}
+
+// UpdateWebhook changes a webhook's URL and events, and pauses or resumes it.
+// Deliveries waiting for a retry are sent to the new URL.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id, userID int, url string, events []string, active bool) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	url, events, err = validateWebhook(url, events)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *webhook
+	updated.URL = url
+	updated.Events = events
+	updated.Active = active
+	if err := s.repo.UpdateWebhook(ctx, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteWebhook deletes a webhook with its delivery log
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id, userID int) error {
+	if _, err := s.GetWebhook(ctx, id, userID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// ListDeliveries lists the latest deliveries to a webhook, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, id, userID int) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListDeliveries(ctx, id, webhookDeliveryLimit)
+}
+
+// SendTestEvent sends a webhook.test event to a webhook right away, even if
+// it is paused, and returns the delivery. Test events aren't retried.
+func (s *WebhookService) SendTestEvent(ctx context.Context, id, userID int) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := newWebhookEvent(userID, models.WebhookEventTest, map[string]interface{}{
+		"webhook_id": webhook.ID,
+		"message":    "This is a test event. Your webhook is set up correctly if it answered with a 2xx status.",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.deliver(ctx, webhook, event, false)
+}
+
+// Publish queues an event for the user's webhooks subscribing to it. It
+// doesn't block: events published while the queue is full are dropped.
+func (s *WebhookService) Publish(userID int, eventType string, data interface{}) {
+	event, err := newWebhookEvent(userID, eventType, data)
+	if err != nil {
+		log.Printf("Failed to create webhook event %s: %v", eventType, err)
+		return
+	}
+
+	select {
+	case s.queue <- event:
+	default:
+		log.Printf("Webhook queue is full, dropping %s event for user %d", eventType, userID)
+	}
+}
+
+// Start starts delivering queued events in the background
+func (s *WebhookService) Start() {
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-s.queue:
+				if err := s.process(ctx, event); err != nil && ctx.Err() == nil {
+					log.Printf("Failed to deliver webhook event %s: %v", event.ID, err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops delivering queued events. A delivery abandoned mid-attempt is
+// retried by the job.
+func (s *WebhookService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.cancel = nil
+	s.wg.Wait()
+}
+
+// process delivers an event to each of the user's active webhooks subscribing to it
+func (s *WebhookService) process(ctx context.Context, event *models.WebhookEvent) error {
+	webhooks, err := s.repo.ListWebhooksByUserID(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || !webhook.Subscribes(event.Type) {
+			continue
+		}
+		if _, err := s.deliver(ctx, webhook, event, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run retries the deliveries that are due and prunes old deliveries from the
+// logs; it is registered as a scheduler job
+func (s *WebhookService) Run(ctx context.Context) error {
+	if s.config.Retention > 0 {
+		if _, err := s.repo.DeleteDeliveriesBefore(ctx, time.Now().Add(-s.config.Retention)); err != nil {
+			return err
+		}
+	}
+
+	deliveries, err := s.repo.ListDueDeliveries(ctx, time.Now(), s.config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		webhook, err := s.repo.GetWebhookByID(ctx, delivery.WebhookID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// Paused webhooks keep their deliveries until they are resumed. They
+		// are put off, so they don't fill every batch.
+		if !webhook.Active {
+			next := time.Now().Add(s.backoff(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+			if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.attempt(ctx, webhook, delivery, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliver records a delivery of an event to a webhook and makes its first attempt
+func (s *WebhookService) deliver(ctx context.Context, webhook *models.Webhook, event *models.WebhookEvent, retry bool) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	// The job retries the delivery as if its first attempt failed, in case
+	// the attempt below is abandoned
+	delivery := models.NewWebhookDelivery(webhook.ID, event.ID, event.Type, payload)
+	if retry {
+		next := delivery.CreatedAt.Add(s.backoff(1))
+		delivery.NextAttemptAt = &next
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	if err := s.attempt(ctx, webhook, delivery, retry); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// attempt sends a delivery and records the outcome. A failed delivery is
+// retried after a backoff until it runs out of attempts, unless retry is off.
+// Only errors storing the outcome are returned.
+func (s *WebhookService) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, retry bool) error {
+	start := time.Now()
+	status, body, err := s.send(ctx, webhook, delivery)
+	if ctx.Err() != nil {
+		// An abandoned attempt isn't a failure of the endpoint
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	delivery.DurationMs = int(now.Sub(start) / time.Millisecond)
+	delivery.LastAttemptAt = &now
+	delivery.NextAttemptAt = nil
+
+	switch {
+	case err != nil:
+		delivery.Error = err.Error()
+	case status < 200 || status >= 300:
+		delivery.Error = fmt.Sprintf("%d %s", status, http.StatusText(status))
+	default:
+		delivery.Error = ""
+	}
+
+	switch {
+	case delivery.Error == "":
+		delivery.Status = models.WebhookDeliveryDelivered
+	case retry && delivery.Attempts < s.config.MaxAttempts:
+		next := now.Add(s.backoff(delivery.Attempts))
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+	default:
+		delivery.Status = models.WebhookDeliveryFailed
+	}
+
+	return s.repo.UpdateDelivery(ctx, delivery)
+}
+
+// backoff returns the wait before retrying a delivery that has failed attempts times
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	wait := s.config.RetryBackoff
+	for i := 1; i < attempts && wait < maxWebhookBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxWebhookBackoff {
+		wait = maxWebhookBackoff
+	}
+	return wait
+}
+
+// send posts a delivery's signed payload to its webhook, returning the
+// response status and the start of the response body
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookIDHeader, delivery.EventID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, time.Now().Unix(), delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBytes))
+	if !utf8.Valid(body) {
+		body = []byte(strings.ToValidUTF8(string(body), ""))
+	}
+
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhookPayload returns the signature header of a payload sent at the
+// given Unix time: "t=<timestamp>,v1=<signature>", where the signature is the
+// hex HMAC-SHA256 of "<timestamp>.<payload>" keyed with the webhook's secret.
+// Receivers recompute it to check a delivery came from this server, and can
+// reject old timestamps to stop replays.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookEvent creates an event with a random ID
+func newWebhookEvent(userID int, eventType string, data interface{}) (*models.WebhookEvent, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookEvent{
+		ID:        "evt_" + id,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+		UserID:    userID,
+	}, nil
+}
+
+// validateWebhook trims a webhook's URL and checks it and its events,
+// returning the events without duplicates
+func validateWebhook(url string, events []string) (string, []string, error) {
+	url = strings.TrimSpace(url)
+	if validateURL(url) != nil {
+		return "", nil, ErrInvalidWebhookURL
+	}
+
+	var valid []string
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !slices.Contains(models.WebhookEvents, event) {
+			return "", nil, ErrInvalidWebhookEvent
+		}
+		if !slices.Contains(valid, event) {
+			valid = append(valid, event)
+		}
+	}
+	if len(valid) == 0 {
+		return "", nil, ErrNoWebhookEvents
+	}
+
+	return url, valid, nil
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SetWebhookService sends link events to the webhooks of link owners
+func (s *ShortenerService) SetWebhookService(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
+
+// update stores a change an owner made to a URL and tells their webhooks about it
+func (s *ShortenerService) update(ctx context.Context, url *models.URL) error {
+	if err := s.repo.Update(ctx, url); err != nil {
+		return err
+	}
+
+	s.publishLinkEvent(url, models.WebhookEventLinkUpdated, nil)
+	return nil
+}
+
+// publishLinkEvent tells the owner's webhooks about an event of a URL. Anonymous URLs have no webhooks.
+func (s *ShortenerService) publishLinkEvent(url *models.URL, eventType string, visit *WebhookVisit) {
+	if s.webhooks == nil || url.UserID == nil {
+		return
+	}
+
+	s.webhooks.Publish(*url.UserID, eventType, &LinkEventData{
+		Link:  s.ToResponse(url),
+		Visit: visit,
+	})
+}
+
+// SetWebhookService sends bio page views to the webhooks of page owners
+func (s *BioPageService) SetWebhookService(webhooks *WebhookService) {
+	s.webhooks = webhooks
+}
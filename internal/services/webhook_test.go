@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/config"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/models"
+	"github.com/GnanaPrakashNarayana/url-shortener/internal/repository"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+
+	var mutex sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if failing {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	service, repo := newTestShortener()
+	webhookRepo := repository.NewMemoryWebhookRepository()
+	webhookService := NewWebhookService(webhookRepo, &config.WebhookConfig{
+		MaxAttempts:  2,
+		RetryBackoff: time.Millisecond,
+		BatchSize:    10,
+		Retention:    time.Hour,
+	})
+	webhookService.SetHTTPClient(server.Client())
+	service.SetWebhookService(webhookService)
+
+	// processQueued delivers the events waiting in the queue
+	processQueued := func() {
+		for {
+			select {
+			case event := <-webhookService.queue:
+				if err := webhookService.process(ctx, event); err != nil {
+					t.Fatalf("Failed to process webhook event: %v", err)
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	userID := 1
+	for _, tc := range []struct {
+		url    string
+		events []string
+		err    error
+	}{
+		{"ftp://example.com/hook", []string{models.WebhookEventLinkCreated}, ErrInvalidWebhookURL},
+		{server.URL, []string{"link.exploded"}, ErrInvalidWebhookEvent},
+		{server.URL, nil, ErrNoWebhookEvents},
+	} {
+		if _, err := webhookService.CreateWebhook(ctx, userID, tc.url, tc.events); err != tc.err {
+			t.Errorf("Expected %v for %s %v, got %v", tc.err, tc.url, tc.events, err)
+		}
+	}
+
+	webhook, err := webhookService.CreateWebhook(ctx, userID, server.URL+"/hooks",
+		[]string{models.WebhookEventLinkCreated, models.WebhookEventLinkClicked, models.WebhookEventLinkCreated})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if !strings.HasPrefix(webhook.Secret, "whsec_") || len(webhook.Events) != 2 {
+		t.Errorf("Expected a secret and two events, got %+v", webhook)
+	}
+	otherUserID := 2
+	if _, err := webhookService.CreateWebhook(ctx, otherUserID, server.URL+"/other", []string{models.WebhookEventLinkCreated}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	// A new link is posted to its owner's webhook only, signed with its secret
+	created, err := service.Shorten(ctx, "https://example.com/launch", &userID, "", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to shorten URL: %v", err)
+	}
+	processQueued()
+	if len(received) != 1 || received[0].URL.Path != "/hooks" {
+		t.Fatalf("Expected one delivery to the owner's webhook, got %d", len(received))
+	}
+	if received[0].Header.Get(WebhookEventHeader) != models.WebhookEventLinkCreated {
+		t.Errorf("Expected a link.created event, got %q", received[0].Header.Get(WebhookEventHeader))
+	}
+	var timestamp int64
+	fmt.Sscanf(received[0].Header.Get(WebhookSignatureHeader), "t=%d,", &timestamp)
+	if signature := SignWebhookPayload(webhook.Secret, timestamp, bodies[0]); received[0].Header.Get(WebhookSignatureHeader) != signature {
+		t.Errorf("Expected signature %q, got %q", signature, received[0].Header.Get(WebhookSignatureHeader))
+	}
+	var payload struct {
+		ID   string        `json:"id"`
+		Type string        `json:"type"`
+		Data LinkEventData `json:"data"`
+	}
+	if err := json.Unmarshal(bodies[0], &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if payload.ID != received[0].Header.Get(WebhookIDHeader) || payload.Data.Link.ID != created.ID {
+		t.Errorf("Expected the event to describe the new link, got %s", bodies[0])
+	}
+
+	// Events the webhook doesn't subscribe to aren't sent
+	if _, err := service.UpdatePreviewSettings(ctx, created.ID, userID, "Launch", false); err != nil {
+		t.Fatalf("Failed to update preview settings: %v", err)
+	}
+	processQueued()
+	if len(received) != 1 {
+		t.Errorf("Expected link.updated not to be sent, got %d deliveries", len(received))
+	}
+
+	// A failed delivery is retried by the job until it succeeds
+	url, _ := repo.GetByID(ctx, created.ID)
+	failing = true
+	service.PublishClick(url, models.NewClick("https://news.example.com/", "Mozilla/5.0"))
+	processQueued()
+	deliveries, err := webhookService.ListDeliveries(ctx, webhook.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to list deliveries: %v", err)
+	}
+	clicked := deliveries[0]
+	if clicked.Event != models.WebhookEventLinkClicked || clicked.Status != models.WebhookDeliveryPending ||
+		clicked.ResponseStatus != http.StatusServiceUnavailable || clicked.NextAttemptAt == nil {
+		t.Errorf("Expected a pending click delivery waiting for a retry, got %+v", clicked)
+	}
+	if !strings.Contains(string(clicked.Payload), `"referrer":"https://news.example.com/"`) {
+		t.Errorf("Expected the click's referrer in the payload, got %s", clicked.Payload)
+	}
+
+	failing = false
+	time.Sleep(5 * time.Millisecond)
+	if err := webhookService.Run(ctx); err != nil {
+		t.Fatalf("Failed to run webhook deliveries: %v", err)
+	}
+	deliveries, _ = webhookService.ListDeliveries(ctx, webhook.ID, userID)
+	if deliveries[0].Status != models.WebhookDeliveryDelivered || deliveries[0].Attempts != 2 || deliveries[0].NextAttemptAt != nil {
+		t.Errorf("Expected the retry to be delivered, got %+v", deliveries[0])
+	}
+	if len(received) != 3 || received[1].Header.Get(WebhookIDHeader) != received[2].Header.Get(WebhookIDHeader) {
+		t.Errorf("Expected the retry to reuse the event ID, got %d deliveries", len(received))
+	}
+
+	// Deliveries fail once they run out of attempts
+	failing = true
+	service.PublishClick(url, models.NewClick("", ""))
+	processQueued()
+	time.Sleep(5 * time.Millisecond)
+	webhookService.Run(ctx)
+	deliveries, _ = webhookService.ListDeliveries(ctx, webhook.ID, userID)
+	if deliveries[0].Status != models.WebhookDeliveryFailed || deliveries[0].Attempts != 2 {
+		t.Errorf("Expected the delivery to fail after two attempts, got %+v", deliveries[0])
+	}
+
+	// The wait doubles with each retry, up to a cap
+	if webhookService.backoff(1) != time.Millisecond || webhookService.backoff(4) != 8*time.Millisecond || webhookService.backoff(100) != maxWebhookBackoff {
+		t.Errorf("Expected exponential backoff, got %v, %v, %v", webhookService.backoff(1), webhookService.backoff(4), webhookService.backoff(100))
+	}
+
+	// Paused webhooks aren't sent events, but test events are sent right away
+	failing = false
+	if _, err := webhookService.UpdateWebhook(ctx, webhook.ID, userID, webhook.URL, webhook.Events, false); err != nil {
+		t.Fatalf("Failed to pause webhook: %v", err)
+	}
+	before := len(received)
+	service.PublishClick(url, models.NewClick("", ""))
+	processQueued()
+	if len(received) != before {
+		t.Errorf("Expected a paused webhook not to be sent events")
+	}
+	delivery, err := webhookService.SendTestEvent(ctx, webhook.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to send test event: %v", err)
+	}
+	if delivery.Status != models.WebhookDeliveryDelivered || delivery.Event != models.WebhookEventTest {
+		t.Errorf("Expected the test event to be delivered, got %+v", delivery)
+	}
+	if _, err := webhookService.SendTestEvent(ctx, webhook.ID, otherUserID); err != ErrNotWebhookOwner {
+		t.Errorf("Expected ErrNotWebhookOwner, got %v", err)
+	}
+
+	// Bio page views go to the page owner's webhooks
+	bioService := NewBioPageService(repository.NewMemoryBioPageRepository(), testBaseURL)
+	bioService.SetWebhookService(webhookService)
+	if _, err := webhookService.UpdateWebhook(ctx, webhook.ID, userID, webhook.URL, []string{models.WebhookEventBioViewed}, true); err != nil {
+		t.Fatalf("Failed to update webhook: %v", err)
+	}
+	bioService.PublishView(&models.BioPageResponse{ID: 7, UserID: userID, ShortCode: "me"}, models.NewClick("", "Mozilla/5.0"))
+	processQueued()
+	last := received[len(received)-1]
+	if last.Header.Get(WebhookEventHeader) != models.WebhookEventBioViewed {
+		t.Errorf("Expected a bio.viewed event, got %q", last.Header.Get(WebhookEventHeader))
+	}
+
+	// Deleting a webhook deletes its deliveries
+	if err := webhookService.DeleteWebhook(ctx, webhook.ID, userID); err != nil {
+		t.Fatalf("Failed to delete webhook: %v", err)
+	}
+	if deliveries, _ := webhookRepo.ListDeliveries(ctx, webhook.ID, 0); len(deliveries) != 0 {
+		t.Errorf("Expected the deliveries to be deleted, got %d", len(deliveries))
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS expiry_message;
+ALTER TABLE urls DROP COLUMN IF EXISTS expiry_redirect_url;
+ALTER TABLE urls DROP COLUMN IF EXISTS expiry_action;
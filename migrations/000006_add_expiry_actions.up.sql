@@ -0,0 +1,4 @@
+-- What happens when an expired link is visited: not_found, redirect, page or gone
+ALTER TABLE urls ADD COLUMN expiry_action VARCHAR(20) NOT NULL DEFAULT 'not_found';
+ALTER TABLE urls ADD COLUMN expiry_redirect_url TEXT NULL;
+ALTER TABLE urls ADD COLUMN expiry_message TEXT NULL;
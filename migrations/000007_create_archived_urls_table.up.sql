@@ -0,0 +1,14 @@
+-- Expired links moved out of urls by the cleanup job, freeing their slugs
+CREATE TABLE IF NOT EXISTS archived_urls (
+    archive_id SERIAL PRIMARY KEY,
+    id VARCHAR(255) NOT NULL,
+    original_url TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    visits INT NOT NULL DEFAULT 0,
+    user_id INT NULL REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_archived_urls_id ON archived_urls(id);
+CREATE INDEX idx_archived_urls_user_id ON archived_urls(user_id);
@@ -0,0 +1 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS targeting_rules;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Ordered list of device, OS, browser, language and country rules; the first match picks the destination
+ALTER TABLE urls ADD COLUMN targeting_rules JSONB NOT NULL DEFAULT '[]';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS sticky_rotation;
+ALTER TABLE urls DROP COLUMN IF EXISTS destinations;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Weighted destinations splitting a link's traffic, with per-variant click counts
+ALTER TABLE urls ADD COLUMN destinations JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE urls ADD COLUMN sticky_rotation BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
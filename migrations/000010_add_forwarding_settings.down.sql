@@ -0,0 +1,2 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS path_passthrough;
+ALTER TABLE urls DROP COLUMN IF EXISTS query_mode;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- How a link passes incoming query parameters and trailing path segments to its destination
+ALTER TABLE urls ADD COLUMN query_mode VARCHAR(20) NOT NULL DEFAULT 'none';
+ALTER TABLE urls ADD COLUMN path_passthrough BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    utm_campaign VARCHAR(255) NOT NULL,
+    channels JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_campaigns_user_id ON campaigns(user_id);
+
+-- Links created for a campaign keep working if the campaign is deleted
+ALTER TABLE urls ADD COLUMN campaign_id INT NULL REFERENCES campaigns(id) ON DELETE SET NULL;
+ALTER TABLE urls ADD COLUMN channel VARCHAR(100) NULL;
+
+CREATE INDEX idx_urls_campaign_id ON urls(campaign_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS cache_policy;
+ALTER TABLE urls DROP COLUMN IF EXISTS redirect_status;
\ No newline at end of file
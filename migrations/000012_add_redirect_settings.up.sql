@@ -0,0 +1,3 @@
+-- Per-link redirect status code and Cache-Control policy; 0 and '' use the server defaults
+ALTER TABLE urls ADD COLUMN redirect_status INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN cache_policy VARCHAR(20) NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_urls_domain_slug;
+ALTER TABLE urls DROP COLUMN IF EXISTS slug;
+ALTER TABLE urls DROP COLUMN IF EXISTS domain;
+DROP TABLE IF EXISTS domains;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS domains (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hostname VARCHAR(253) NOT NULL UNIQUE,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_domains_user_id ON domains(user_id);
+
+-- Links on a custom domain are looked up by their slug on that domain; links
+-- on the default domain keep an empty domain and are looked up by ID
+ALTER TABLE urls ADD COLUMN domain VARCHAR(253) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN slug VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX idx_urls_domain_slug ON urls(domain, slug) WHERE domain <> '';
\ No newline at end of file
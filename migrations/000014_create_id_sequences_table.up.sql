@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS id_sequences (
+    name VARCHAR(64) PRIMARY KEY,
+    next_value BIGINT NOT NULL
+);
\ No newline at end of file
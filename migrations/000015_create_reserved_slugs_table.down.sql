@@ -0,0 +1 @@
+DROP TABLE IF EXISTS reserved_slugs;
\ No newline at end of file
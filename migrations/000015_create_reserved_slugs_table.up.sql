@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS reserved_slugs (
+    slug VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
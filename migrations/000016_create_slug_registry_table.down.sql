@@ -0,0 +1 @@
+DROP TABLE IF EXISTS slug_registry;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS slug_registry (
+    slug VARCHAR(255) PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Register the existing short codes. Where a link and a bio page share one,
+-- the link keeps it, as it already owns the root-level path.
+INSERT INTO slug_registry (slug, kind, created_at)
+SELECT id, 'link', created_at FROM urls
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO slug_registry (slug, kind, created_at)
+SELECT short_code, 'bio_page', created_at FROM bio_pages
+ON CONFLICT (slug) DO NOTHING;
\ No newline at end of file
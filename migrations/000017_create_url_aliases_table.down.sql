@@ -0,0 +1,3 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS canonical_alias;
+
+DROP TABLE IF EXISTS url_aliases;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS url_aliases (
+    alias VARCHAR(255) PRIMARY KEY,
+    url_id VARCHAR(255) NOT NULL,
+    visits INT NOT NULL DEFAULT 0,
+    last_visit_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- urls.id has no unique constraint to reference, so aliases are deleted along
+-- with their link by the application
+CREATE INDEX idx_url_aliases_url_id ON url_aliases(url_id);
+
+-- The alias used in a link's short URL; empty uses the link's ID
+ALTER TABLE urls ADD COLUMN canonical_alias VARCHAR(255) NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_urls_user_destination;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Finds a user's existing links to a destination. Destinations are indexed
+-- by their hash, as long URLs exceed the size a btree index entry can hold.
+CREATE INDEX idx_urls_user_destination ON urls(user_id, md5(original_url));
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_urls_screening_status;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS screened_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS screening_reason;
+ALTER TABLE urls DROP COLUMN IF EXISTS screening_status;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- The outcome of screening a link's destinations; empty when nothing matched
+ALTER TABLE urls ADD COLUMN screening_status VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN screening_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN screened_at TIMESTAMP NULL;
+
+-- Finds the links waiting for review; most links have no status
+CREATE INDEX idx_urls_screening_status ON urls(screening_status) WHERE screening_status <> '';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS preview;
+ALTER TABLE urls DROP COLUMN IF EXISTS title;
\ No newline at end of file
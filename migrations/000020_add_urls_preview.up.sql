@@ -0,0 +1,3 @@
+-- A title shown on the link's preview page, and whether visitors always see the preview
+ALTER TABLE urls ADD COLUMN title TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN preview BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
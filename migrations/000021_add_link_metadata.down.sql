@@ -0,0 +1,2 @@
+ALTER TABLE bio_links DROP COLUMN IF EXISTS metadata;
+ALTER TABLE urls DROP COLUMN IF EXISTS metadata;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Title, description and images of the destination page, fetched in the background
+ALTER TABLE urls ADD COLUMN metadata JSONB NULL;
+ALTER TABLE bio_links ADD COLUMN metadata JSONB NULL;
\ No newline at end of file
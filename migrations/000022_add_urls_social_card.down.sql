@@ -0,0 +1,3 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS social_image_url;
+ALTER TABLE urls DROP COLUMN IF EXISTS social_description;
+ALTER TABLE urls DROP COLUMN IF EXISTS social_title;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Open Graph and Twitter card overrides served to social media and chat app crawlers
+ALTER TABLE urls ADD COLUMN social_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN social_description TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN social_image_url TEXT NOT NULL DEFAULT '';
\ No newline at end of file
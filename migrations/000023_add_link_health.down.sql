@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS link_health_checks;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS health_checked_at;
+ALTER TABLE urls DROP COLUMN IF EXISTS health_failures;
+ALTER TABLE urls DROP COLUMN IF EXISTS health_status;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- The outcome of the latest destination health checks of each link
+ALTER TABLE urls ADD COLUMN health_status VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN health_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN health_checked_at TIMESTAMP NULL;
+
+-- The history of destination health checks, pruned by the health check job
+CREATE TABLE IF NOT EXISTS link_health_checks (
+    id SERIAL PRIMARY KEY,
+    url_id VARCHAR(255) NOT NULL,
+    destination TEXT NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INT NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_link_health_checks_url_id ON link_health_checks(url_id, checked_at);
+CREATE INDEX idx_link_health_checks_checked_at ON link_health_checks(checked_at);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS clicks;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS bot_visits;
\ No newline at end of file
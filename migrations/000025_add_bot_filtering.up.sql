@@ -0,0 +1,21 @@
+-- Visits from bots, crawlers and prefetchers, counted apart from the visits of people
+ALTER TABLE urls ADD COLUMN bot_visits INT NOT NULL DEFAULT 0;
+
+-- Every recorded visit to a link, bio page or bio link, tagged as coming from a person or a bot
+CREATE TABLE IF NOT EXISTS clicks (
+    id BIGSERIAL PRIMARY KEY,
+    url_id VARCHAR(255) NOT NULL DEFAULT '',
+    alias VARCHAR(255) NOT NULL DEFAULT '',
+    bio_page_id INT NOT NULL DEFAULT 0,
+    bio_link_id INT NOT NULL DEFAULT 0,
+    destination TEXT NOT NULL DEFAULT '',
+    referrer TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    bot BOOLEAN NOT NULL DEFAULT FALSE,
+    bot_reason VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_clicks_url_id ON clicks(url_id, created_at) WHERE url_id <> '';
+CREATE INDEX idx_clicks_bio_page_id ON clicks(bio_page_id, created_at) WHERE bio_page_id <> 0;
+CREATE INDEX idx_clicks_created_at ON clicks(created_at);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS visitor_sketches;
+
+DROP TABLE IF EXISTS visitor_salts;
+
+ALTER TABLE clicks DROP COLUMN IF EXISTS visitor_hash;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- A hash of the visitor's IP address and user agent with the day's salt, so
+-- unique visitors can be counted without storing IP addresses
+ALTER TABLE clicks ADD COLUMN visitor_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+-- The random salt visitors are hashed with each day. Past salts are deleted,
+-- so their hashes can't be recomputed from an IP address.
+CREATE TABLE IF NOT EXISTS visitor_salts (
+    day DATE PRIMARY KEY,
+    salt BYTEA NOT NULL
+);
+
+-- The registers of the HyperLogLog sketches estimating the unique visitors of
+-- links and bio pages, keyed by target such as url:abc123 or bio:42
+CREATE TABLE IF NOT EXISTS visitor_sketches (
+    target VARCHAR(255) NOT NULL,
+    register SMALLINT NOT NULL,
+    rank SMALLINT NOT NULL,
+    PRIMARY KEY (target, register)
+);
\ No newline at end of file
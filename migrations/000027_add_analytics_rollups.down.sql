@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS rollup_state;
+
+DROP TABLE IF EXISTS click_breakdowns;
+
+DROP TABLE IF EXISTS click_rollups;
+
+DROP INDEX IF EXISTS idx_clicks_visitor_hash;
+
+ALTER TABLE clicks DROP COLUMN IF EXISTS device;
+ALTER TABLE clicks DROP COLUMN IF EXISTS country;
\ No newline at end of file
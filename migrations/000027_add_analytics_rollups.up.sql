@@ -0,0 +1,44 @@
+-- Where visitors were and what device they used, for the rollups' breakdowns
+ALTER TABLE clicks ADD COLUMN country VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN device VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Finding a visitor's earlier clicks when counting unique visitors
+CREATE INDEX idx_clicks_visitor_hash ON clicks(visitor_hash) WHERE visitor_hash <> '';
+
+-- The clicks on links, bio page views and bio link clicks counted by hour and day
+CREATE TABLE IF NOT EXISTS click_rollups (
+    url_id VARCHAR(255) NOT NULL DEFAULT '',
+    bio_page_id INT NOT NULL DEFAULT 0,
+    bio_link_id INT NOT NULL DEFAULT 0,
+    granularity VARCHAR(10) NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    clicks INT NOT NULL DEFAULT 0,
+    bot_clicks INT NOT NULL DEFAULT 0,
+    visitors INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (url_id, bio_page_id, bio_link_id, granularity, bucket)
+);
+
+CREATE INDEX idx_click_rollups_bucket ON click_rollups(bucket);
+
+-- The clicks by people in each rollup broken down by referrer, country and device
+CREATE TABLE IF NOT EXISTS click_breakdowns (
+    url_id VARCHAR(255) NOT NULL DEFAULT '',
+    bio_page_id INT NOT NULL DEFAULT 0,
+    bio_link_id INT NOT NULL DEFAULT 0,
+    granularity VARCHAR(10) NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    dimension VARCHAR(20) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    clicks INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (url_id, bio_page_id, bio_link_id, granularity, bucket, dimension, value)
+);
+
+CREATE INDEX idx_click_breakdowns_bucket ON click_breakdowns(bucket);
+
+-- The last click rolled up, so each run picks up where the last one stopped
+CREATE TABLE IF NOT EXISTS rollup_state (
+    id INT PRIMARY KEY,
+    last_click_id BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO rollup_state (id, last_click_id) VALUES (1, 0) ON CONFLICT (id) DO NOTHING;
\ No newline at end of file
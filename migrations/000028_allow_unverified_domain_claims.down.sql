@@ -0,0 +1,11 @@
+-- Keep the verified claim of each hostname, or else the oldest one
+DELETE FROM domains d WHERE EXISTS (
+    SELECT 1 FROM domains o
+    WHERE o.hostname = d.hostname AND o.id <> d.id
+      AND (o.verified_at IS NOT NULL OR (d.verified_at IS NULL AND o.id < d.id))
+);
+
+DROP INDEX IF EXISTS idx_domains_user_hostname;
+DROP INDEX IF EXISTS idx_domains_verified_hostname;
+
+ALTER TABLE domains ADD CONSTRAINT domains_hostname_key UNIQUE (hostname);
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Several users may claim a hostname until one of them verifies it, so no one
+-- can keep the owner of a hostname from adding it by claiming it first
+ALTER TABLE domains DROP CONSTRAINT IF EXISTS domains_hostname_key;
+
+CREATE UNIQUE INDEX idx_domains_verified_hostname ON domains(hostname) WHERE verified_at IS NOT NULL;
+CREATE UNIQUE INDEX idx_domains_user_hostname ON domains(user_id, hostname);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_urls_id;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- Storing a link relies on a unique violation to tell a taken ID apart, so
+-- concurrent inserts of the same ID can't both succeed. Any duplicates stored
+-- before keep the oldest link on the ID and move the others to a new one.
+UPDATE urls SET id = urls.id || '-' || duplicates.n
+FROM (
+    SELECT ctid, ROW_NUMBER() OVER (PARTITION BY id ORDER BY created_at) - 1 AS n FROM urls
+) duplicates
+WHERE urls.ctid = duplicates.ctid AND duplicates.n > 0;
+
+CREATE UNIQUE INDEX idx_urls_id ON urls(id);
\ No newline at end of file
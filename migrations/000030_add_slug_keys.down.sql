@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_slug_registry_slug_key;
+DROP INDEX IF EXISTS idx_bio_pages_short_code_key;
+DROP INDEX IF EXISTS idx_url_aliases_alias_key;
+DROP INDEX IF EXISTS idx_urls_domain_slug_key;
+DROP INDEX IF EXISTS idx_urls_id_key;
+
+ALTER TABLE slug_registry DROP COLUMN IF EXISTS slug_key;
+ALTER TABLE bio_pages DROP COLUMN IF EXISTS short_code_key;
+ALTER TABLE url_aliases DROP COLUMN IF EXISTS alias_key;
+ALTER TABLE urls DROP COLUMN IF EXISTS slug_key;
+ALTER TABLE urls DROP COLUMN IF EXISTS id_key;
\ No newline at end of file
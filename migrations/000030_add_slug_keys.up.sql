@@ -0,0 +1,59 @@
+-- Short codes that only differ in case or Unicode normalization name the same
+-- link when slugs are case-insensitive, so each short code is also stored
+-- under its key, lowercased and NFC-normalized (normalize() needs PostgreSQL
+-- 13), and keys must be unique. The application computes the keys of new
+-- short codes.
+ALTER TABLE urls ADD COLUMN id_key VARCHAR(255);
+ALTER TABLE urls ADD COLUMN slug_key VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE url_aliases ADD COLUMN alias_key VARCHAR(255);
+ALTER TABLE bio_pages ADD COLUMN short_code_key VARCHAR(255);
+ALTER TABLE slug_registry ADD COLUMN slug_key VARCHAR(255);
+
+-- Where short codes stored before share a key, the one already in that form,
+-- or else the oldest, keeps it. The others use their own form as their key,
+-- so they still resolve when slugs are case-sensitive.
+UPDATE urls SET id_key = CASE WHEN keys.n = 1 THEN keys.key ELSE urls.id END
+FROM (
+    SELECT ctid, key, ROW_NUMBER() OVER (PARTITION BY key ORDER BY id = key DESC, created_at, id) AS n
+    FROM (SELECT ctid, id, created_at, lower(normalize(id, NFC)) AS key FROM urls) folded
+) keys
+WHERE urls.ctid = keys.ctid;
+
+UPDATE urls SET slug_key = CASE WHEN keys.n = 1 THEN keys.key ELSE urls.slug END
+FROM (
+    SELECT ctid, key, ROW_NUMBER() OVER (PARTITION BY domain, key ORDER BY slug = key DESC, created_at, slug) AS n
+    FROM (SELECT ctid, domain, slug, created_at, lower(normalize(slug, NFC)) AS key FROM urls WHERE domain <> '') folded
+) keys
+WHERE urls.ctid = keys.ctid;
+
+UPDATE url_aliases SET alias_key = CASE WHEN keys.n = 1 THEN keys.key ELSE url_aliases.alias END
+FROM (
+    SELECT alias, key, ROW_NUMBER() OVER (PARTITION BY key ORDER BY alias = key DESC, created_at, alias) AS n
+    FROM (SELECT alias, created_at, lower(normalize(alias, NFC)) AS key FROM url_aliases) folded
+) keys
+WHERE url_aliases.alias = keys.alias;
+
+UPDATE bio_pages SET short_code_key = CASE WHEN keys.n = 1 THEN keys.key ELSE bio_pages.short_code END
+FROM (
+    SELECT id, key, ROW_NUMBER() OVER (PARTITION BY key ORDER BY short_code = key DESC, created_at, id) AS n
+    FROM (SELECT id, short_code, created_at, lower(normalize(short_code, NFC)) AS key FROM bio_pages) folded
+) keys
+WHERE bio_pages.id = keys.id;
+
+UPDATE slug_registry SET slug_key = CASE WHEN keys.n = 1 THEN keys.key ELSE slug_registry.slug END
+FROM (
+    SELECT slug, key, ROW_NUMBER() OVER (PARTITION BY key ORDER BY slug = key DESC, created_at, slug) AS n
+    FROM (SELECT slug, created_at, lower(normalize(slug, NFC)) AS key FROM slug_registry) folded
+) keys
+WHERE slug_registry.slug = keys.slug;
+
+ALTER TABLE urls ALTER COLUMN id_key SET NOT NULL;
+ALTER TABLE url_aliases ALTER COLUMN alias_key SET NOT NULL;
+ALTER TABLE bio_pages ALTER COLUMN short_code_key SET NOT NULL;
+ALTER TABLE slug_registry ALTER COLUMN slug_key SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_urls_id_key ON urls(id_key);
+CREATE UNIQUE INDEX idx_urls_domain_slug_key ON urls(domain, slug_key) WHERE domain <> '';
+CREATE UNIQUE INDEX idx_url_aliases_alias_key ON url_aliases(alias_key);
+CREATE UNIQUE INDEX idx_bio_pages_short_code_key ON bio_pages(short_code_key);
+CREATE UNIQUE INDEX idx_slug_registry_slug_key ON slug_registry(slug_key);
\ No newline at end of file